@@ -0,0 +1,5 @@
+// Package typingbirdv1 holds the generated client and server for the
+// typing-bird gRPC control API described in typingbird.proto.
+package typingbirdv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative typingbird/v1/typingbird.proto
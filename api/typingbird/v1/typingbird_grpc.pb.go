@@ -0,0 +1,382 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: typingbird/v1/typingbird.proto
+
+// Control and observation API for a running typing-bird, served when the
+// bird is started with --grpc-listen.
+
+package typingbirdv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TypingBird_GetStatus_FullMethodName      = "/typingbird.v1.TypingBird/GetStatus"
+	TypingBird_ListBirds_FullMethodName      = "/typingbird.v1.TypingBird/ListBirds"
+	TypingBird_SendNow_FullMethodName        = "/typingbird.v1.TypingBird/SendNow"
+	TypingBird_Pause_FullMethodName          = "/typingbird.v1.TypingBird/Pause"
+	TypingBird_Resume_FullMethodName         = "/typingbird.v1.TypingBird/Resume"
+	TypingBird_UpdateMessages_FullMethodName = "/typingbird.v1.TypingBird/UpdateMessages"
+	TypingBird_Watch_FullMethodName          = "/typingbird.v1.TypingBird/Watch"
+)
+
+// TypingBirdClient is the client API for TypingBird service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Requests carry the name of the bird they are for. A standalone bird
+// answers to an empty name; in fleet mode (typing-bird fleet) the name is
+// required.
+type TypingBirdClient interface {
+	// GetStatus reports the bird's target, settings and progress.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error)
+	// ListBirds reports every bird served by this process.
+	ListBirds(ctx context.Context, in *ListBirdsRequest, opts ...grpc.CallOption) (*ListBirdsResponse, error)
+	// SendNow sends without waiting for the pane to go idle, even while
+	// paused.
+	SendNow(ctx context.Context, in *SendNowRequest, opts ...grpc.CallOption) (*SendNowResponse, error)
+	// Pause stops sends until Resume is called.
+	Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*PauseResponse, error)
+	Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*ResumeResponse, error)
+	// UpdateMessages replaces the message list, keeping the rotation
+	// position. A later reload re-reads messages from their original source.
+	UpdateMessages(ctx context.Context, in *UpdateMessagesRequest, opts ...grpc.CallOption) (*UpdateMessagesResponse, error)
+	// Watch streams events from every bird until the client cancels or the
+	// process exits.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+}
+
+type typingBirdClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTypingBirdClient(cc grpc.ClientConnInterface) TypingBirdClient {
+	return &typingBirdClient{cc}
+}
+
+func (c *typingBirdClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Status)
+	err := c.cc.Invoke(ctx, TypingBird_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *typingBirdClient) ListBirds(ctx context.Context, in *ListBirdsRequest, opts ...grpc.CallOption) (*ListBirdsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBirdsResponse)
+	err := c.cc.Invoke(ctx, TypingBird_ListBirds_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *typingBirdClient) SendNow(ctx context.Context, in *SendNowRequest, opts ...grpc.CallOption) (*SendNowResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendNowResponse)
+	err := c.cc.Invoke(ctx, TypingBird_SendNow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *typingBirdClient) Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*PauseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PauseResponse)
+	err := c.cc.Invoke(ctx, TypingBird_Pause_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *typingBirdClient) Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*ResumeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResumeResponse)
+	err := c.cc.Invoke(ctx, TypingBird_Resume_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *typingBirdClient) UpdateMessages(ctx context.Context, in *UpdateMessagesRequest, opts ...grpc.CallOption) (*UpdateMessagesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateMessagesResponse)
+	err := c.cc.Invoke(ctx, TypingBird_UpdateMessages_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *typingBirdClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TypingBird_ServiceDesc.Streams[0], TypingBird_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TypingBird_WatchClient = grpc.ServerStreamingClient[Event]
+
+// TypingBirdServer is the server API for TypingBird service.
+// All implementations must embed UnimplementedTypingBirdServer
+// for forward compatibility.
+//
+// Requests carry the name of the bird they are for. A standalone bird
+// answers to an empty name; in fleet mode (typing-bird fleet) the name is
+// required.
+type TypingBirdServer interface {
+	// GetStatus reports the bird's target, settings and progress.
+	GetStatus(context.Context, *GetStatusRequest) (*Status, error)
+	// ListBirds reports every bird served by this process.
+	ListBirds(context.Context, *ListBirdsRequest) (*ListBirdsResponse, error)
+	// SendNow sends without waiting for the pane to go idle, even while
+	// paused.
+	SendNow(context.Context, *SendNowRequest) (*SendNowResponse, error)
+	// Pause stops sends until Resume is called.
+	Pause(context.Context, *PauseRequest) (*PauseResponse, error)
+	Resume(context.Context, *ResumeRequest) (*ResumeResponse, error)
+	// UpdateMessages replaces the message list, keeping the rotation
+	// position. A later reload re-reads messages from their original source.
+	UpdateMessages(context.Context, *UpdateMessagesRequest) (*UpdateMessagesResponse, error)
+	// Watch streams events from every bird until the client cancels or the
+	// process exits.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error
+	mustEmbedUnimplementedTypingBirdServer()
+}
+
+// UnimplementedTypingBirdServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTypingBirdServer struct{}
+
+func (UnimplementedTypingBirdServer) GetStatus(context.Context, *GetStatusRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedTypingBirdServer) ListBirds(context.Context, *ListBirdsRequest) (*ListBirdsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBirds not implemented")
+}
+func (UnimplementedTypingBirdServer) SendNow(context.Context, *SendNowRequest) (*SendNowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendNow not implemented")
+}
+func (UnimplementedTypingBirdServer) Pause(context.Context, *PauseRequest) (*PauseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pause not implemented")
+}
+func (UnimplementedTypingBirdServer) Resume(context.Context, *ResumeRequest) (*ResumeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Resume not implemented")
+}
+func (UnimplementedTypingBirdServer) UpdateMessages(context.Context, *UpdateMessagesRequest) (*UpdateMessagesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateMessages not implemented")
+}
+func (UnimplementedTypingBirdServer) Watch(*WatchRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedTypingBirdServer) mustEmbedUnimplementedTypingBirdServer() {}
+func (UnimplementedTypingBirdServer) testEmbeddedByValue()                    {}
+
+// UnsafeTypingBirdServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TypingBirdServer will
+// result in compilation errors.
+type UnsafeTypingBirdServer interface {
+	mustEmbedUnimplementedTypingBirdServer()
+}
+
+func RegisterTypingBirdServer(s grpc.ServiceRegistrar, srv TypingBirdServer) {
+	// If the following call pancis, it indicates UnimplementedTypingBirdServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TypingBird_ServiceDesc, srv)
+}
+
+func _TypingBird_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TypingBirdServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TypingBird_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TypingBirdServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TypingBird_ListBirds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBirdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TypingBirdServer).ListBirds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TypingBird_ListBirds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TypingBirdServer).ListBirds(ctx, req.(*ListBirdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TypingBird_SendNow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendNowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TypingBirdServer).SendNow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TypingBird_SendNow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TypingBirdServer).SendNow(ctx, req.(*SendNowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TypingBird_Pause_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TypingBirdServer).Pause(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TypingBird_Pause_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TypingBirdServer).Pause(ctx, req.(*PauseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TypingBird_Resume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TypingBirdServer).Resume(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TypingBird_Resume_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TypingBirdServer).Resume(ctx, req.(*ResumeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TypingBird_UpdateMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TypingBirdServer).UpdateMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TypingBird_UpdateMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TypingBirdServer).UpdateMessages(ctx, req.(*UpdateMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TypingBird_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TypingBirdServer).Watch(m, &grpc.GenericServerStream[WatchRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TypingBird_WatchServer = grpc.ServerStreamingServer[Event]
+
+// TypingBird_ServiceDesc is the grpc.ServiceDesc for TypingBird service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TypingBird_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "typingbird.v1.TypingBird",
+	HandlerType: (*TypingBirdServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _TypingBird_GetStatus_Handler,
+		},
+		{
+			MethodName: "ListBirds",
+			Handler:    _TypingBird_ListBirds_Handler,
+		},
+		{
+			MethodName: "SendNow",
+			Handler:    _TypingBird_SendNow_Handler,
+		},
+		{
+			MethodName: "Pause",
+			Handler:    _TypingBird_Pause_Handler,
+		},
+		{
+			MethodName: "Resume",
+			Handler:    _TypingBird_Resume_Handler,
+		},
+		{
+			MethodName: "UpdateMessages",
+			Handler:    _TypingBird_UpdateMessages_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _TypingBird_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "typingbird/v1/typingbird.proto",
+}
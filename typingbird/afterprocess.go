@@ -0,0 +1,110 @@
+package typingbird
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// processPoll is how often --after-pid and --after-command look for the
+// process.
+const processPoll = time.Second
+
+// watchProcess waits for the process named by --after-pid, or every process
+// matching --after-command, to exit and then sends --after-message (the
+// next message in rotation when unset) without waiting for the pane to go
+// idle. It fires once.
+func (b *bird) watchProcess(ctx context.Context) {
+	opts := b.options()
+	var err error
+	if opts.afterPID != 0 {
+		err = waitPIDExit(ctx, opts.afterPID)
+	} else {
+		err = b.waitCommandExit(ctx, opts.afterCommand)
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			b.logf("WARNING: not watching for the process to exit: %v", err)
+		}
+		return
+	}
+	if opts.afterPID != 0 {
+		b.logf("process %d exited; sending now", opts.afterPID)
+	} else {
+		b.logf("no process matches %q any more; sending now", opts.afterCommand)
+	}
+	b.sendNow(opts.afterMessage)
+}
+
+func waitPIDExit(ctx context.Context, pid int) error {
+	for {
+		alive, err := processAlive(pid)
+		if err != nil {
+			return err
+		}
+		if !alive {
+			return nil
+		}
+		if err := sleepWithContext(ctx, processPoll); err != nil {
+			return err
+		}
+	}
+}
+
+// waitCommandExit waits for a process matching pattern to show up, if none
+// is running yet, and then for every match to be gone.
+func (b *bird) waitCommandExit(ctx context.Context, pattern string) error {
+	seen := false
+	for {
+		pids, err := pgrep(pattern)
+		if err != nil {
+			return err
+		}
+		switch {
+		case len(pids) > 0 && !seen:
+			seen = true
+			b.debugf("waiting for %d process(es) matching %q to exit", len(pids), pattern)
+		case len(pids) == 0 && seen:
+			return nil
+		case len(pids) == 0 && !seen:
+			b.debugf("no process matches %q yet", pattern)
+		}
+		if err := sleepWithContext(ctx, processPoll); err != nil {
+			return err
+		}
+	}
+}
+
+// pgrep lists the processes whose command line matches pattern, leaving out
+// this one and its parent (a wrapper such as timeout or nohup), whose
+// arguments usually contain the pattern too.
+func pgrep(pattern string) ([]int, error) {
+	out, err := exec.Command("pgrep", "-f", "--", pattern).Output()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 1 {
+		// pgrep exits 1 when nothing matches.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pgrep: %w", err)
+	}
+	return parsePIDs(string(out), os.Getpid(), os.Getppid()), nil
+}
+
+func parsePIDs(out string, skip ...int) []int {
+	var pids []int
+	for _, field := range strings.Fields(out) {
+		pid, err := strconv.Atoi(field)
+		if err != nil || slices.Contains(skip, pid) {
+			continue
+		}
+		pids = append(pids, pid)
+	}
+	return pids
+}
@@ -0,0 +1,45 @@
+package typingbird
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestParsePIDs(t *testing.T) {
+	cases := []struct {
+		out  string
+		skip []int
+		want []int
+	}{
+		{"", []int{1}, nil},
+		{"12\n34\n", []int{1}, []int{12, 34}},
+		{"12\n34\n56\n", []int{34}, []int{12, 56}},
+		{"12\n34\n56\n", []int{34, 12}, []int{56}},
+		{"12\nbogus\n", nil, []int{12}},
+	}
+	for _, tc := range cases {
+		if got := parsePIDs(tc.out, tc.skip...); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("parsePIDs(%q, %v) = %v; want %v", tc.out, tc.skip, got, tc.want)
+		}
+	}
+}
+
+func TestWaitPIDExit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a unix platform")
+	}
+	cmd := exec.Command("sleep", "0.2")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	go cmd.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := waitPIDExit(ctx, cmd.Process.Pid); err != nil {
+		t.Fatalf("waitPIDExit(%d) = %v; want nil", cmd.Process.Pid, err)
+	}
+}
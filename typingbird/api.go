@@ -0,0 +1,246 @@
+package typingbird
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxAPIBody bounds request bodies; messages are small.
+const maxAPIBody = 1 << 20
+
+// newAPIHandler serves the REST API. Responses use the same JSON shape as
+// the control socket. In a fleet, requests name their bird with ?bird=;
+// GET /status and POST /reload without one cover every bird. A non-empty
+// token requires "Authorization: Bearer <token>" on every request.
+// Requests a browser sends for a page on another site are refused either
+// way.
+func newAPIHandler(f *flock, token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		st, err := f.status(r.URL.Query().Get("bird"))
+		if err != nil {
+			writeAPIResponse(w, http.StatusNotFound, controlError(err))
+			return
+		}
+		writeAPIResponse(w, http.StatusOK, controlOK(st))
+	})
+	mux.HandleFunc("POST /reload", func(w http.ResponseWriter, r *http.Request) {
+		if err := f.reload(r.URL.Query().Get("bird")); err != nil {
+			writeAPIResponse(w, http.StatusInternalServerError, controlError(err))
+			return
+		}
+		writeAPIResponse(w, http.StatusOK, controlOK(nil))
+	})
+	// withBird resolves the ?bird= parameter for single-bird endpoints.
+	withBird := func(handle func(http.ResponseWriter, *http.Request, *bird)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			b, err := f.lookup(r.URL.Query().Get("bird"))
+			if err != nil {
+				writeAPIResponse(w, http.StatusNotFound, controlError(err))
+				return
+			}
+			handle(w, r, b)
+		}
+	}
+	mux.HandleFunc("POST /pause", withBird(func(w http.ResponseWriter, r *http.Request, b *bird) {
+		b.pause()
+		writeAPIResponse(w, http.StatusOK, controlOK(nil))
+	}))
+	mux.HandleFunc("POST /resume", withBird(func(w http.ResponseWriter, r *http.Request, b *bird) {
+		b.resume()
+		writeAPIResponse(w, http.StatusOK, controlOK(nil))
+	}))
+	mux.HandleFunc("POST /send", withBird(func(w http.ResponseWriter, r *http.Request, b *bird) {
+		var req struct {
+			Message string `json:"message"`
+		}
+		if err := decodeAPIBody(r, &req, true); err != nil {
+			writeAPIResponse(w, http.StatusBadRequest, controlError(err))
+			return
+		}
+		b.sendNow(req.Message)
+		writeAPIResponse(w, http.StatusAccepted, controlOK(nil))
+	}))
+	mux.HandleFunc("GET /dead-letters", withBird(func(w http.ResponseWriter, r *http.Request, b *bird) {
+		writeAPIResponse(w, http.StatusOK, controlOK(b.deadLetters()))
+	}))
+	mux.HandleFunc("PUT /messages", withBird(func(w http.ResponseWriter, r *http.Request, b *bird) {
+		var req struct {
+			Messages []string `json:"messages"`
+		}
+		if err := decodeAPIBody(r, &req, false); err != nil {
+			writeAPIResponse(w, http.StatusBadRequest, controlError(err))
+			return
+		}
+		if err := b.setMessages(req.Messages); err != nil {
+			writeAPIResponse(w, http.StatusBadRequest, controlError(err))
+			return
+		}
+		writeAPIResponse(w, http.StatusOK, controlOK(nil))
+	}))
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if crossSite(r) {
+			writeAPIResponse(w, http.StatusForbidden, controlError(errors.New("cross-site request refused")))
+			return
+		}
+		got := []byte(r.Header.Get("Authorization"))
+		if token != "" && subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="typing-bird"`)
+			writeAPIResponse(w, http.StatusUnauthorized, controlError(errors.New("unauthorized")))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// crossSite reports whether r was sent by a browser for a page on another
+// site, a form post or a no-cors fetch, which any page may send to a local
+// server without asking. Clients outside a browser send neither header.
+func crossSite(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err != nil || u.Host != r.Host
+}
+
+// loopbackHost reports whether host, with or without a port, names the
+// loopback interface.
+func loopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// decodeAPIBody decodes a JSON request body, which must be sent as
+// application/json: a browser only sends that type cross-site after asking
+// first. An empty body without a type is accepted when optional is set.
+func decodeAPIBody(r *http.Request, v any, optional bool) error {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" && optional && r.ContentLength == 0 {
+		return nil
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/json" {
+		return fmt.Errorf("request body must be application/json, not %q", contentType)
+	}
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxAPIBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if optional && errors.Is(err, io.EOF) {
+			return nil
+		}
+		return fmt.Errorf("invalid request body: %v", err)
+	}
+	return nil
+}
+
+func writeAPIResponse(w http.ResponseWriter, code int, resp controlResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// apiServer serves the REST API on a TCP address or, with a unix: prefix,
+// a unix socket.
+type apiServer struct {
+	srv  *http.Server
+	path string
+}
+
+func startAPIServer(addr string, f *flock, token string) (*apiServer, error) {
+	network, address := "tcp", addr
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, address = "unix", path
+		if err := removeStaleSocket(path); err != nil {
+			return nil, err
+		}
+	} else if err := checkListenToken(addr, token); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	s := &apiServer{srv: &http.Server{Handler: newAPIHandler(f, token), ReadHeaderTimeout: 10 * time.Second}}
+	if network == "unix" {
+		s.path = address
+	}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logf("WARNING: API server stopped: %v", err)
+		}
+	}()
+	logf("REST API listening on %s", ln.Addr())
+	return s, nil
+}
+
+func (s *apiServer) Close() error {
+	err := s.srv.Close()
+	if s.path != "" {
+		_ = os.Remove(s.path)
+	}
+	return err
+}
+
+// checkListenToken refuses a TCP address other machines can reach when no
+// token guards it.
+func checkListenToken(addr, token string) error {
+	if token == "" && !loopbackHost(addr) {
+		return fmt.Errorf("%s is reachable from other machines; set a token with --api-token-file or TYPING_BIRD_API_TOKEN, or listen on 127.0.0.1", addr)
+	}
+	return nil
+}
+
+// checkInjectToken refuses to inject a bird serving an API whose token would
+// come from TYPING_BIRD_API_TOKEN. tmux starts the injected bird with the
+// server's environment rather than ours, so it would serve the API without
+// the token, or fail to start in a pane that closes with its error.
+func checkInjectToken(opts options) error {
+	if (opts.grpcListen == "" && opts.apiListen == "") || opts.apiTokenFile != "" {
+		return nil
+	}
+	if strings.TrimSpace(os.Getenv("TYPING_BIRD_API_TOKEN")) != "" {
+		return errors.New("TYPING_BIRD_API_TOKEN does not reach an injected bird; put the token in a file and pass --api-token-file")
+	}
+	return nil
+}
+
+// loadAPIToken returns the bearer token from path, falling back to
+// TYPING_BIRD_API_TOKEN.
+func loadAPIToken(path string) (string, error) {
+	if path == "" {
+		return strings.TrimSpace(os.Getenv("TYPING_BIRD_API_TOKEN")), nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading API token file: %w", err)
+	}
+	token := strings.TrimSpace(string(raw))
+	if token == "" {
+		return "", fmt.Errorf("API token file %q is empty", path)
+	}
+	return token, nil
+}
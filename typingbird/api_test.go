@@ -0,0 +1,162 @@
+package typingbird
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIHandler(t *testing.T) {
+	b, err := newBird(options{timeout: time.Second, order: orderRoundRobin, session: "s", messages: []string{"a", "b"}}, "%1")
+	if err != nil {
+		t.Fatalf("newBird(...) error: %v", err)
+	}
+	h := newAPIHandler(&flock{birds: []*bird{b}}, "sekrit")
+
+	do := func(method, path, body, token string, headers ...string) (int, controlResponse) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var resp controlResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s %s response %q is not JSON: %v", method, path, rec.Body.String(), err)
+		}
+		return rec.Code, resp
+	}
+
+	if code, _ := do("GET", "/status", "", ""); code != http.StatusUnauthorized {
+		t.Fatalf("GET /status without token = %d; want 401", code)
+	}
+	if code, _ := do("GET", "/status", "", "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("GET /status with wrong token = %d; want 401", code)
+	}
+	if code, resp := do("POST", "/pause", "", "sekrit"); code != http.StatusOK || !resp.OK {
+		t.Fatalf("POST /pause = %d %+v; want 200 ok", code, resp)
+	}
+	if code, resp := do("GET", "/status", "", "sekrit"); code != http.StatusOK || !resp.Data.(map[string]any)["paused"].(bool) {
+		t.Fatalf("GET /status = %d %+v; want paused", code, resp)
+	}
+	if code, _ := do("PUT", "/messages", `{"messages":["x","y","z"]}`, "sekrit"); code != http.StatusOK {
+		t.Fatalf("PUT /messages = %d; want 200", code)
+	}
+	if got := b.options().messages; !reflect.DeepEqual(got, []string{"x", "y", "z"}) {
+		t.Fatalf("messages after PUT /messages = %q; want x y z", got)
+	}
+	if code, resp := do("PUT", "/messages", `{"messages":[]}`, "sekrit"); code != http.StatusBadRequest || resp.OK {
+		t.Fatalf("PUT /messages with none = %d %+v; want 400", code, resp)
+	}
+	if code, _ := do("POST", "/send", "", "sekrit"); code != http.StatusAccepted {
+		t.Fatalf("POST /send without body = %d; want 202", code)
+	}
+	if code, _ := do("POST", "/send", `{"message":"hi"}`, "sekrit"); code != http.StatusAccepted {
+		t.Fatalf("POST /send = %d; want 202", code)
+	}
+	if b.forced == nil || *b.forced != "hi" {
+		t.Fatalf("forced after POST /send = %v; want %q", b.forced, "hi")
+	}
+	if code, _ := do("POST", "/send", `{"msg":"hi"}`, "sekrit"); code != http.StatusBadRequest {
+		t.Fatalf("POST /send with unknown field = %d; want 400", code)
+	}
+	if code, _ := do("POST", "/send", `{"message":"hi"}`, "sekrit", "Content-Type", "text/plain"); code != http.StatusBadRequest {
+		t.Fatalf("POST /send as text/plain = %d; want 400", code)
+	}
+	if code, _ := do("POST", "/pause", "", "sekrit", "Origin", "https://evil.example"); code != http.StatusForbidden {
+		t.Fatalf("POST /pause from another origin = %d; want 403", code)
+	}
+	if code, _ := do("POST", "/pause", "", "sekrit", "Sec-Fetch-Site", "cross-site"); code != http.StatusForbidden {
+		t.Fatalf("POST /pause with Sec-Fetch-Site cross-site = %d; want 403", code)
+	}
+	if code, _ := do("GET", "/status", "", "sekrit", "Origin", "http://example.com", "Sec-Fetch-Site", "same-origin"); code != http.StatusOK {
+		t.Fatalf("GET /status from the same origin = %d; want 200", code)
+	}
+	if code, resp := do("GET", "/dead-letters", "", "sekrit"); code != http.StatusOK || !reflect.DeepEqual(resp.Data, []any{}) {
+		t.Fatalf("GET /dead-letters = %d %+v; want 200 and none", code, resp)
+	}
+	if code, _ := do("POST", "/resume", "", "sekrit"); code != http.StatusOK || b.status().Paused {
+		t.Fatalf("POST /resume = %d paused=%v; want 200 and resumed", code, b.status().Paused)
+	}
+}
+
+func TestAPIHandlerWithoutToken(t *testing.T) {
+	b, err := newBird(options{timeout: time.Second, order: orderRoundRobin, session: "s", messages: []string{"a"}}, "%1")
+	if err != nil {
+		t.Fatalf("newBird(...) error: %v", err)
+	}
+	h := newAPIHandler(&flock{birds: []*bird{b}}, "")
+	req := httptest.NewRequest("POST", "/send", strings.NewReader(`{"message":"rm -rf ~"}`))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Origin", "https://evil.example")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || b.forced != nil {
+		t.Fatalf("cross-site POST /send = %d, forced %v; want 403 and nothing sent", rec.Code, b.forced)
+	}
+}
+
+func TestStartAPIServerRequiresTokenOffLoopback(t *testing.T) {
+	if _, err := startAPIServer("0.0.0.0:0", &flock{}, ""); err == nil {
+		t.Fatalf("startAPIServer(0.0.0.0:0) without a token = nil error; want one")
+	}
+	s, err := startAPIServer("127.0.0.1:0", &flock{}, "")
+	if err != nil {
+		t.Fatalf("startAPIServer(127.0.0.1:0) = %v", err)
+	}
+	s.Close()
+}
+
+func TestLoopbackHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"127.0.0.1:8787", true},
+		{"localhost:8787", true},
+		{"[::1]:8787", true},
+		{"localhost", true},
+		{":8787", false},
+		{"0.0.0.0:8787", false},
+		{"192.168.1.4:8787", false},
+		{"evil.example:8787", false},
+	}
+	for _, tt := range tests {
+		if got := loopbackHost(tt.host); got != tt.want {
+			t.Fatalf("loopbackHost(%q) = %v; want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestCheckInjectToken(t *testing.T) {
+	t.Setenv("TYPING_BIRD_API_TOKEN", "secret")
+	for _, tt := range []struct {
+		name    string
+		opts    options
+		wantErr bool
+	}{
+		{"no API", options{}, false},
+		{"REST API", options{apiListen: "127.0.0.1:8787"}, true},
+		{"gRPC API", options{grpcListen: "unix:/tmp/bird.sock"}, true},
+		{"token file", options{apiListen: "0.0.0.0:8787", apiTokenFile: "/run/token"}, false},
+	} {
+		if err := checkInjectToken(tt.opts); (err != nil) != tt.wantErr {
+			t.Fatalf("%s: checkInjectToken() = %v; want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+	t.Setenv("TYPING_BIRD_API_TOKEN", "")
+	if err := checkInjectToken(options{apiListen: "127.0.0.1:8787"}); err != nil {
+		t.Fatalf("checkInjectToken() without a token = %v; want nil", err)
+	}
+}
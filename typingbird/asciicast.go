@@ -0,0 +1,210 @@
+package typingbird
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCastPre  = 2 * time.Second
+	defaultCastPost = 5 * time.Second
+	// castFrameInterval is how often the pane is captured for the cast.
+	castFrameInterval = 250 * time.Millisecond
+	// castClipGap separates consecutive clips in the cast's timeline.
+	castClipGap = time.Second
+)
+
+// castFrame is one distinct pane snapshot, with escapes, and when it was
+// taken.
+type castFrame struct {
+	at     time.Time
+	screen string
+}
+
+// castMark labels a send in the cast.
+type castMark struct {
+	at    time.Time
+	label string
+}
+
+// castRecorder writes an asciicast v2 file holding a clip of the target pane
+// around each send: pre before it and post after it. Sends close together
+// share one clip, and the time between clips is cut out.
+type castRecorder struct {
+	mu        sync.Mutex
+	f         *os.File
+	pre, post time.Duration
+	frames    []castFrame
+	marks     []castMark
+	// clipStart and clipEnd bound the clip being collected; zero when no
+	// send is pending.
+	clipStart, clipEnd time.Time
+	// elapsed is the cast time already written.
+	elapsed time.Duration
+}
+
+// openCast truncates path and writes the asciicast header sized to target.
+func openCast(path, target string, pre, post time.Duration) (*castRecorder, error) {
+	width, height := 80, 24
+	if out, err := tmuxOutput("display-message", "-p", "-t", target, "#{pane_width} #{pane_height}"); err == nil {
+		if w, h, ok := strings.Cut(strings.TrimSpace(string(out)), " "); ok {
+			if n, err := strconv.Atoi(w); err == nil && n > 0 {
+				width = n
+			}
+			if n, err := strconv.Atoi(h); err == nil && n > 0 {
+				height = n
+			}
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	header, _ := json.Marshal(map[string]any{
+		"version":   2,
+		"width":     width,
+		"height":    height,
+		"timestamp": time.Now().Unix(),
+		"title":     "typing-bird " + target,
+	})
+	if _, err := f.Write(append(header, '\n')); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &castRecorder{f: f, pre: pre, post: post}, nil
+}
+
+// start captures target() until ctx ends or stop is called.
+func (c *castRecorder) start(ctx context.Context, target func() string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(castFrameInterval)
+		defer ticker.Stop()
+		for {
+			if out, err := tmuxOutput("capture-pane", "-p", "-e", "-t", target()); err == nil {
+				if err := c.frame(time.Now(), string(out)); err != nil {
+					logf("WARNING: writing asciicast: %v", err)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// mark notes a send at now, opening or extending the current clip.
+func (c *castRecorder) mark(now time.Time, label string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.clipStart.IsZero() {
+		c.clipStart = now.Add(-c.pre)
+	}
+	c.clipEnd = now.Add(c.post)
+	c.marks = append(c.marks, castMark{at: now, label: label})
+}
+
+// frame records a snapshot taken at now, writing out the pending clip once
+// its post-send window has passed.
+func (c *castRecorder) frame(now time.Time, screen string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n := len(c.frames); n == 0 || c.frames[n-1].screen != screen {
+		c.frames = append(c.frames, castFrame{at: now, screen: screen})
+	}
+	if !c.clipEnd.IsZero() && !now.Before(c.clipEnd) {
+		return c.flush(c.clipEnd)
+	}
+	if c.clipStart.IsZero() {
+		c.prune(now.Add(-c.pre))
+	}
+	return nil
+}
+
+// prune drops frames before cutoff, keeping the one showing the screen at
+// cutoff.
+func (c *castRecorder) prune(cutoff time.Time) {
+	i := 0
+	for i+1 < len(c.frames) && !c.frames[i+1].at.After(cutoff) {
+		i++
+	}
+	c.frames = c.frames[i:]
+}
+
+// flush writes the pending clip, ending at end, and starts collecting
+// afresh. Callers hold c.mu.
+func (c *castRecorder) flush(end time.Time) error {
+	start := c.clipStart
+	c.prune(start)
+	var lines [][]any
+	for _, f := range c.frames {
+		if f.at.After(end) {
+			break
+		}
+		offset := max(f.at.Sub(start), 0)
+		lines = append(lines, []any{c.seconds(offset), "o", castScreen(f.screen)})
+	}
+	for _, m := range c.marks {
+		lines = append(lines, []any{c.seconds(m.at.Sub(start)), "m", m.label})
+	}
+	// Marks were appended after frames; keep the file in time order.
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i][0].(float64) < lines[j][0].(float64)
+	})
+	c.elapsed += end.Sub(start) + castClipGap
+	c.clipStart, c.clipEnd, c.marks = time.Time{}, time.Time{}, nil
+	c.prune(end)
+	for _, line := range lines {
+		raw, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		if _, err := c.f.Write(append(raw, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *castRecorder) seconds(offset time.Duration) float64 {
+	return (c.elapsed + offset).Round(time.Millisecond).Seconds()
+}
+
+// castScreen turns a capture into output that redraws the whole screen,
+// masking resolved secrets.
+func castScreen(screen string) string {
+	screen = secretRedactor.redact(strings.TrimRight(screen, "\n"))
+	return "\x1b[H\x1b[2J" + strings.ReplaceAll(screen, "\n", "\r\n")
+}
+
+// Close writes any clip still pending and closes the file.
+func (c *castRecorder) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var err error
+	if !c.clipStart.IsZero() {
+		err = c.flush(time.Now())
+	}
+	if cerr := c.f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("writing asciicast: %w", err)
+	}
+	return nil
+}
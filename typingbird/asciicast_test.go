@@ -0,0 +1,75 @@
+package typingbird
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCastRecorderClips(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bird.cast")
+	c, err := openCast(path, "%missing", 2*time.Second, 3*time.Second)
+	if err != nil {
+		t.Fatalf("openCast(...) error: %v", err)
+	}
+	t0 := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	at := func(s float64) time.Time { return t0.Add(time.Duration(s * float64(time.Second))) }
+	frames := []struct {
+		at     float64
+		screen string
+	}{
+		{0, "old"},
+		{4, "$ "},
+		{4.5, "$ "}, // unchanged, not a new frame
+		{6, "$ go\n"},
+		{8, "$ go\nok"},
+		{9, "done"}, // after the clip, which ends at 8
+	}
+	for i, f := range frames {
+		if i == 3 {
+			c.mark(at(5), "go")
+		}
+		if err := c.frame(at(f.at), f.screen); err != nil {
+			t.Fatalf("frame(%v) error: %v", f.at, err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile(...) error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	var header map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil || header["version"] != float64(2) {
+		t.Fatalf("header = %s (%v); want an asciicast v2 header", lines[0], err)
+	}
+	var got []string
+	for _, line := range lines[1:] {
+		var ev []any
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("event %s: %v", line, err)
+		}
+		got = append(got, strings.Join([]string{jsonNumber(ev[0]), ev[1].(string), ev[2].(string)}, " "))
+	}
+	// The clip runs from 3s (the send at 5s less 2s) to 8s.
+	want := []string{
+		"0 o \x1b[H\x1b[2Jold",
+		"1 o \x1b[H\x1b[2J$ ",
+		"2 m go",
+		"3 o \x1b[H\x1b[2J$ go",
+		"5 o \x1b[H\x1b[2J$ go\r\nok",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("events = %q; want %q", got, want)
+	}
+}
+
+func jsonNumber(v any) string {
+	raw, _ := json.Marshal(v)
+	return string(raw)
+}
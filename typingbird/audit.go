@@ -0,0 +1,241 @@
+package typingbird
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultAuditMaxSize = 10 << 20
+
+// auditEntry is one line of the --audit-log: a message as it was typed
+// into a pane. Prev chains every line to the one before it, across
+// rotations, so an edited, removed or reordered line breaks the chain.
+type auditEntry struct {
+	Time    time.Time `json:"time"`
+	Bird    string    `json:"bird,omitempty"`
+	Session string    `json:"session"`
+	Target  string    `json:"target"`
+	// Index is the 1-based rotation position; zero for requested sends.
+	Index   int    `json:"index,omitempty"`
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
+	// Prev is the SHA-256 of the previous line, empty for the very first.
+	Prev string `json:"prev"`
+}
+
+// auditLog appends entries to a file that is only ever appended to and,
+// past maxSize, renamed aside with a timestamp rather than truncated.
+type auditLog struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	f       *os.File
+	size    int64
+	last    string
+}
+
+func openAuditLog(path string, maxSize int64) (*auditLog, error) {
+	a := &auditLog{path: path, maxSize: maxSize}
+	// The chain continues from the last line already there.
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if lines := bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n")); len(data) > 0 {
+		a.last = auditHash(lines[len(lines)-1])
+	}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *auditLog) open() error {
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.f, a.size = f, info.Size()
+	return nil
+}
+
+// write appends e, rotating first when the line would take the file past
+// maxSize. Message bodies follow --redact and resolved secrets are masked.
+func (a *auditLog) write(e auditEntry) error {
+	e.Message = secretRedactor.redact(e.Message)
+	if redactingMessages() {
+		e.Message = logText(e.Message)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	e.Prev = a.last
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if a.maxSize > 0 && a.size > 0 && a.size+int64(len(line))+1 > a.maxSize {
+		if err := a.rotate(); err != nil {
+			return fmt.Errorf("rotating audit log: %w", err)
+		}
+	}
+	n, err := a.f.Write(append(line, '\n'))
+	a.size += int64(n)
+	if err != nil {
+		return err
+	}
+	a.last = auditHash(line)
+	return a.f.Sync()
+}
+
+// rotate renames the current file to path.YYYYMMDDTHHMMSSZ and starts a
+// fresh one.
+func (a *auditLog) rotate() error {
+	if err := a.f.Close(); err != nil {
+		return err
+	}
+	rotated := a.path + "." + time.Now().UTC().Format("20060102T150405Z")
+	for i := 1; ; i++ {
+		if _, err := os.Stat(rotated); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		rotated = fmt.Sprintf("%s.%s-%d", a.path, time.Now().UTC().Format("20060102T150405Z"), i)
+	}
+	if err := os.Rename(a.path, rotated); err != nil {
+		return err
+	}
+	return a.open()
+}
+
+func (a *auditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.f.Close()
+}
+
+func auditHash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// auditSend records a send, or a failed attempt at one, in the bird's
+// audit log.
+func (b *bird) auditSend(e auditEntry, requested bool, sendErr error) {
+	if requested {
+		e.Index = 0
+	}
+	e.Bird = b.name
+	if sendErr != nil {
+		e.Error = sendErr.Error()
+	}
+	if err := b.audit.write(e); err != nil {
+		b.logf("WARNING: writing audit log: %v", err)
+	}
+}
+
+// verifyAudit checks the chain through files given oldest first and
+// returns how many entries it covered.
+func verifyAudit(files []io.Reader, names []string) (int, error) {
+	count := 0
+	prev := ""
+	first := true
+	for i, r := range files {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1<<30)
+		for line := 1; scanner.Scan(); line++ {
+			var e auditEntry
+			if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+				return count, fmt.Errorf("%s:%d: %w", names[i], line, err)
+			}
+			// The oldest file may start mid-chain when older ones were
+			// archived elsewhere.
+			if !first && e.Prev != prev {
+				return count, fmt.Errorf("%s:%d: chain broken: the line before was changed, removed or reordered", names[i], line)
+			}
+			first = false
+			prev = auditHash(scanner.Bytes())
+			count++
+		}
+		if err := scanner.Err(); err != nil {
+			return count, fmt.Errorf("%s: %w", names[i], err)
+		}
+	}
+	return count, nil
+}
+
+// runAudit implements `typing-bird audit verify FILE...`.
+func runAudit(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird audit verify audit.log.20260101T000000Z ... audit.log")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Checks the hash chain of --audit-log files, given oldest first, and reports")
+		fmt.Fprintln(stderr, "the first line that was changed, removed or reordered.")
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() < 2 || fs.Arg(0) != "verify" {
+		fs.Usage()
+		return 2
+	}
+	names := fs.Args()[1:]
+	files := make([]io.Reader, 0, len(names))
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		files = append(files, f)
+	}
+	count, err := verifyAudit(files, names)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "ok: %d entries\n", count)
+	return 0
+}
+
+// parseByteSize reads sizes such as 10MB, 512KB or 1048576 (bytes), in
+// multiples of 1024.
+func parseByteSize(raw, name string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(raw))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if rest, ok := strings.CutSuffix(s, unit.suffix); ok {
+			s, multiplier = strings.TrimSpace(rest), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: want a size such as 10MB or 512KB", name, raw)
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,107 @@
+package typingbird
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAuditFiles(t *testing.T, paths []string) []io.Reader {
+	t.Helper()
+	var files []io.Reader
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, bytes.NewReader(data))
+	}
+	return files
+}
+
+func TestAuditLogChainsAcrossRotationAndRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	a, err := openAuditLog(path, 300)
+	if err != nil {
+		t.Fatalf("openAuditLog(...) error: %v", err)
+	}
+	for _, message := range []string{"one", "two", "three"} {
+		if err := a.write(auditEntry{Time: time.Unix(0, 0).UTC(), Session: "s", Target: "%1", Index: 1, Message: message}); err != nil {
+			t.Fatalf("write(%q) error: %v", message, err)
+		}
+	}
+	a.Close()
+	// A restart continues the chain of the current file.
+	if a, err = openAuditLog(path, 300); err != nil {
+		t.Fatalf("openAuditLog(...) error: %v", err)
+	}
+	if err := a.write(auditEntry{Session: "s", Target: "%1", Message: "four"}); err != nil {
+		t.Fatalf("write(four) error: %v", err)
+	}
+	a.Close()
+	rotated, _ := filepath.Glob(path + ".*")
+	if len(rotated) == 0 {
+		t.Fatalf("no rotated audit files next to %s", path)
+	}
+	paths := append(rotated, path)
+	count, err := verifyAudit(readAuditFiles(t, paths), paths)
+	if err != nil || count != 4 {
+		t.Fatalf("verifyAudit(...) = %d, %v; want 4, nil", count, err)
+	}
+}
+
+func TestVerifyAuditDetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	a, err := openAuditLog(path, 0)
+	if err != nil {
+		t.Fatalf("openAuditLog(...) error: %v", err)
+	}
+	for _, message := range []string{"one", "two", "three"} {
+		if err := a.write(auditEntry{Session: "s", Target: "%1", Message: message}); err != nil {
+			t.Fatalf("write(%q) error: %v", message, err)
+		}
+	}
+	a.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	tests := map[string]string{
+		"edited":  strings.Replace(string(data), `"two"`, `"TWO"`, 1),
+		"removed": lines[0] + lines[2],
+		"swapped": lines[1] + lines[0] + lines[2],
+	}
+	for name, tampered := range tests {
+		_, err := verifyAudit([]io.Reader{strings.NewReader(tampered)}, []string{"audit.log"})
+		if err == nil || !strings.Contains(err.Error(), "chain broken") {
+			t.Fatalf("verifyAudit(%s) error = %v; want chain broken", name, err)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"10MB":  10 << 20,
+		"512kb": 512 << 10,
+		"1GB":   1 << 30,
+		"2048":  2048,
+		"0":     0,
+		"64 B":  64,
+	}
+	for raw, want := range tests {
+		got, err := parseByteSize(raw, "size")
+		if err != nil || got != want {
+			t.Fatalf("parseByteSize(%q) = %d, %v; want %d", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"", "ten", "-1MB", "1TB"} {
+		if _, err := parseByteSize(raw, "size"); err == nil {
+			t.Fatalf("parseByteSize(%q) = nil error; want error", raw)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package typingbird
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultBackend is the multiplexer typing-bird drives unless --backend
+// names another.
+const defaultBackend = "tmux"
+
+// Backend is a terminal multiplexer a bird can drive. The "tmux" backend is
+// built in; other modules add theirs with RegisterBackend, typically from
+// an init function, and --backend selects one by name. Targets are pane ids
+// in whatever form the backend's ListPanes and Split return.
+type Backend interface {
+	// SessionExists returns nil when session exists.
+	SessionExists(session string) error
+	// Capture returns the visible text of the target pane.
+	Capture(target string) ([]byte, error)
+	// SendLiteral types text into the target pane as is.
+	SendLiteral(target, text string) error
+	// SendKey presses keys, given by tmux key name (Enter, C-c, Up, ...).
+	SendKey(target string, keys ...string) error
+	// ListPanes lists every pane of session.
+	ListPanes(session string) ([]Pane, error)
+	// Split opens a pane next to target running command and returns its
+	// id. size is lines (columns when horizontal) or a percentage.
+	Split(target, command string, horizontal bool, size string) (string, error)
+}
+
+// Pane is one pane of a session as listed by a Backend.
+type Pane struct {
+	ID     string
+	Active bool
+	Title  string
+	// Injected marks a pane running a bird of its own, which is never
+	// picked as a target.
+	Injected bool
+}
+
+var (
+	backendsMu sync.Mutex
+	backends   = map[string]func() (Backend, error){defaultBackend: openTmuxBackend}
+)
+
+// activeBackend is the backend every bird in the process drives, set by
+// useBackend before the first one starts.
+var activeBackend Backend = tmuxBackend{}
+
+// RegisterBackend makes a backend available to --backend under name. open
+// is called once when the backend is selected and should fail when the
+// multiplexer can't be used. Registering a name twice panics.
+func RegisterBackend(name string, open func() (Backend, error)) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if open == nil {
+		panic("typingbird: RegisterBackend open is nil")
+	}
+	if _, dup := backends[name]; dup {
+		panic("typingbird: RegisterBackend called twice for backend " + name)
+	}
+	backends[name] = open
+}
+
+// Backends returns the names of the registered backends, sorted.
+func Backends() []string {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupBackend(name string) (func() (Backend, error), error) {
+	backendsMu.Lock()
+	open, ok := backends[name]
+	backendsMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q: must be one of %s", name, strings.Join(Backends(), ", "))
+	}
+	return open, nil
+}
+
+// useBackend opens the named backend and makes it the active one.
+func useBackend(name string) error {
+	open, err := lookupBackend(name)
+	if err != nil {
+		return err
+	}
+	b, err := open()
+	if err != nil {
+		return err
+	}
+	activeBackend = b
+	return nil
+}
+
+// usingTmux reports whether the active backend is tmux, which the features
+// built on tmux options, hooks and pipe-pane need.
+func usingTmux() bool {
+	_, ok := activeBackend.(tmuxBackend)
+	return ok
+}
+
+// validateBackend checks that --backend names a registered backend and,
+// for any backend but tmux, that no tmux-only feature is asked for.
+func (f *cliFlags) validateBackend(sessionPattern bool) error {
+	if _, err := lookupBackend(f.backend); err != nil {
+		return err
+	}
+	if f.backend == defaultBackend {
+		return nil
+	}
+	var feature string
+	switch {
+	case f.inject:
+		feature = "--inject"
+	case f.create != "":
+		feature = "--create"
+	case sessionPattern:
+		feature = "a session pattern"
+	case f.idleMode != idleModeCapture:
+		feature = "--idle-mode " + f.idleMode
+	case f.tmuxControl:
+		feature = "--tmux-control"
+	case f.tmuxHooks:
+		feature = "--tmux-hooks"
+	case f.borderStatus:
+		feature = "--border-status"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%s needs the %s backend (got --backend %s)", feature, defaultBackend, f.backend)
+}
+
+// tmuxBackend drives tmux, through the --tmux-control client when there is
+// one.
+type tmuxBackend struct{}
+
+func openTmuxBackend() (Backend, error) {
+	if err := lookTmux(); err != nil {
+		return nil, err
+	}
+	return tmuxBackend{}, nil
+}
+
+func (tmuxBackend) SessionExists(session string) error {
+	return tmuxRun("has-session", "-t", session)
+}
+
+func (tmuxBackend) Capture(target string) ([]byte, error) {
+	return tmuxOutput("capture-pane", "-p", "-t", target)
+}
+
+func (tmuxBackend) SendLiteral(target, text string) error {
+	return tmuxRun(sendLiteralArgs(target, text)...)
+}
+
+func (tmuxBackend) SendKey(target string, keys ...string) error {
+	return tmuxRun(sendKeyArgs(target, keys...)...)
+}
+
+func (tmuxBackend) SendHex(target string, raw []byte) error {
+	return tmuxRun(sendHexArgs(target, raw)...)
+}
+
+func (tmuxBackend) ListPanes(session string) ([]Pane, error) {
+	out, err := tmuxOutput("list-panes", "-s", "-t", session, "-F", "#{pane_id}\t#{pane_active}\t#{@typing_bird_injected}\t#{pane_title}")
+	if err != nil {
+		return nil, err
+	}
+	return parsePaneInfo(string(out)), nil
+}
+
+func (tmuxBackend) Split(target, command string, horizontal bool, size string) (string, error) {
+	args := tmuxSplitInjectPaneArgs(target, command, injectLayout{size: size, horizontal: horizontal})
+	out, err := tmuxCombinedOutput(args...)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	paneID := strings.TrimSpace(string(out))
+	if paneID == "" {
+		return "", fmt.Errorf("tmux %s returned empty pane id", args[0])
+	}
+	return paneID, nil
+}
+
+func sessionExists(session string) error {
+	return activeBackend.SessionExists(session)
+}
+
+const defaultCaptureWorkers = 8
+
+// captureSlots is the pool every bird in the process takes a slot from to
+// capture its pane, --capture-workers, so a fleet watching dozens of panes
+// samples them in parallel without starting a tmux process for each at
+// once.
+var captureSlots = make(chan struct{}, defaultCaptureWorkers)
+
+func setCaptureWorkers(n int) {
+	if n > 0 && n != cap(captureSlots) {
+		captureSlots = make(chan struct{}, n)
+	}
+}
+
+func captureTarget(target string) ([]byte, error) {
+	slots := captureSlots
+	slots <- struct{}{}
+	defer func() { <-slots }()
+	return activeBackend.Capture(target)
+}
+
+func sendLiteral(target, value string) error {
+	return activeBackend.SendLiteral(target, value)
+}
+
+// sendKeys presses one or more key names (Enter, C-c, ...) in a single
+// call, after waiting delay.
+func sendKeys(target string, delay time.Duration, keys ...string) error {
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	return activeBackend.SendKey(target, keys...)
+}
+
+// hexSender is implemented by backends that can send exact bytes, such as
+// tmux with send-keys -H. Other backends type the bytes as literal text.
+type hexSender interface {
+	SendHex(target string, raw []byte) error
+}
+
+// sendHex sends raw as is, after waiting delay.
+func sendHex(target string, delay time.Duration, raw []byte) error {
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+	if hs, ok := activeBackend.(hexSender); ok {
+		return hs.SendHex(target, raw)
+	}
+	return activeBackend.SendLiteral(target, string(raw))
+}
+
+func sessionPanes(session string) ([]Pane, error) {
+	return activeBackend.ListPanes(session)
+}
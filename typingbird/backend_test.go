@@ -0,0 +1,165 @@
+package typingbird
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBackend records what a bird sends and serves a fixed screen.
+type fakeBackend struct {
+	screen string
+	panes  []Pane
+	sent   []string
+}
+
+func (f *fakeBackend) SessionExists(session string) error {
+	if session != "s" {
+		return errors.New("no such session")
+	}
+	return nil
+}
+
+func (f *fakeBackend) Capture(target string) ([]byte, error) { return []byte(f.screen), nil }
+
+func (f *fakeBackend) SendLiteral(target, text string) error {
+	f.sent = append(f.sent, target+" "+text)
+	return nil
+}
+
+func (f *fakeBackend) SendKey(target string, keys ...string) error {
+	f.sent = append(f.sent, target+" <"+strings.Join(keys, " ")+">")
+	return nil
+}
+
+func (f *fakeBackend) ListPanes(session string) ([]Pane, error) { return f.panes, nil }
+
+func (f *fakeBackend) Split(target, command string, horizontal bool, size string) (string, error) {
+	return "", errors.New("not supported")
+}
+
+var testBackend = &fakeBackend{}
+
+func init() {
+	RegisterBackend("fake", func() (Backend, error) { return testBackend, nil })
+}
+
+func TestFakeBackendSends(t *testing.T) {
+	defer func() { activeBackend = tmuxBackend{} }()
+	*testBackend = fakeBackend{screen: "$ ", panes: []Pane{{ID: "w1", Injected: true, Active: true}, {ID: "w2"}}}
+	if err := useBackend("fake"); err != nil {
+		t.Fatalf("useBackend(fake) error: %v", err)
+	}
+	pane, err := preferredSendPane("s")
+	if err != nil || pane != "w2" {
+		t.Fatalf("preferredSendPane(s) = %q, %v; want %q", pane, err, "w2")
+	}
+	if ok, _ := targetExists(pane); !ok {
+		t.Fatalf("targetExists(%q) = false; want true", pane)
+	}
+	if err := tmuxSendMessage(pane, "one\ntwo", 0, chunking{}, typing{}); err != nil {
+		t.Fatalf("tmuxSendMessage() error: %v", err)
+	}
+	want := []string{"w2 one", "w2 <Enter>", "w2 two", "w2 <Enter>"}
+	if !reflect.DeepEqual(testBackend.sent, want) {
+		t.Fatalf("sent = %q; want %q", testBackend.sent, want)
+	}
+	// Without SendHex the bytes are typed as they are.
+	testBackend.sent = nil
+	if err := tmuxSendMessage(pane, `{{hex "1b 5b 41"}}`, time.Millisecond, chunking{}, typing{}); err != nil {
+		t.Fatalf("tmuxSendMessage() hex error: %v", err)
+	}
+	if want := []string{"w2 \x1b[A"}; !reflect.DeepEqual(testBackend.sent, want) {
+		t.Fatalf("sent = %q; want %q", testBackend.sent, want)
+	}
+	mark, err := tmuxPaneMark(pane)
+	if err != nil {
+		t.Fatalf("tmuxPaneMark() error: %v", err)
+	}
+	testBackend.screen = "$ one\n"
+	if out, _ := tmuxOutputSince(pane, mark); out != "$ one\n" {
+		t.Fatalf("tmuxOutputSince() = %q; want the new screen", out)
+	}
+}
+
+func TestBackendsAndValidation(t *testing.T) {
+	if got := Backends(); !reflect.DeepEqual(got, []string{"fake", "tmux"}) {
+		t.Fatalf("Backends() = %q; want [fake tmux]", got)
+	}
+	tests := []struct {
+		set  func(*cliFlags)
+		args []string
+		want string
+	}{
+		{set: func(f *cliFlags) { f.backend = "screen" }, args: []string{"s"}, want: `unknown backend "screen"`},
+		{set: func(f *cliFlags) { f.backend = "fake" }, args: []string{"s"}},
+		{set: func(f *cliFlags) { f.backend, f.inject = "fake", true }, args: []string{"s"}, want: "--inject needs the tmux backend"},
+		{set: func(f *cliFlags) { f.backend, f.idleMode = "fake", idleModePipe }, args: []string{"s"}, want: "--idle-mode pipe needs"},
+		{set: func(f *cliFlags) { f.backend = "fake" }, args: []string{"agent-*"}, want: "a session pattern needs"},
+	}
+	for _, tt := range tests {
+		cli := newCLIFlags()
+		tt.set(cli)
+		_, err := cli.options(tt.args, resolvedConfig{}, "")
+		if tt.want == "" {
+			if err != nil {
+				t.Fatalf("options(%q) with --backend %s error = %v; want nil", tt.args, cli.backend, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("options(%q) with --backend %s error = %v; want %q", tt.args, cli.backend, err, tt.want)
+		}
+	}
+}
+
+// slowBackend captures slowly and records the most captures it saw running
+// at once.
+type slowBackend struct {
+	fakeBackend
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (s *slowBackend) Capture(target string) ([]byte, error) {
+	s.mu.Lock()
+	s.running++
+	s.peak = max(s.peak, s.running)
+	s.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	s.mu.Lock()
+	s.running--
+	s.mu.Unlock()
+	return nil, nil
+}
+
+func TestCaptureWorkersBoundsCaptures(t *testing.T) {
+	slow := &slowBackend{}
+	activeBackend = slow
+	defer func() { activeBackend = tmuxBackend{} }()
+	setCaptureWorkers(3)
+	defer setCaptureWorkers(defaultCaptureWorkers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = captureTarget("%1")
+		}()
+	}
+	wg.Wait()
+	if slow.peak != 3 {
+		t.Fatalf("peak concurrent captures = %d; want 3", slow.peak)
+	}
+
+	cli := newCLIFlags()
+	cli.workers = 0
+	if _, err := cli.options([]string{"s", "m"}, resolvedConfig{}, ""); err == nil {
+		t.Fatalf("options() with --capture-workers 0 = nil error; want error")
+	}
+}
@@ -0,0 +1,100 @@
+package typingbird
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// keyBinding ties a tmux key to a control command for the bird sending to
+// the current session.
+type keyBinding struct {
+	key     string
+	command string
+}
+
+// runBindKeys implements `typing-bird bind-keys`: tmux bindings that reach
+// the session's bird through `typing-bird ctl --session`.
+func runBindKeys(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("bind-keys", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	sendKey := fs.String("send-key", "B", "key that sends the next message now (empty: none)")
+	pauseKey := fs.String("pause-key", "P", "key that pauses the bird (empty: none)")
+	resumeKey := fs.String("resume-key", "R", "key that resumes the bird (empty: none)")
+	table := fs.String("table", "prefix", "tmux key table to bind in")
+	unbind := fs.Bool("unbind", false, "remove the bindings instead")
+	printOnly := fs.Bool("print", false, "print the commands as tmux.conf lines instead of running them")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird bind-keys [--send-key B] [--pause-key P] [--resume-key R] [--unbind] [--print]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Binds tmux keys (prefix+B, prefix+P and prefix+R by default) that send now,")
+		fmt.Fprintln(stderr, "pause or resume the bird sending to the current session.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	var bindings []keyBinding
+	for _, b := range []keyBinding{{*sendKey, "send"}, {*pauseKey, "pause"}, {*resumeKey, "resume"}} {
+		if b.key != "" {
+			bindings = append(bindings, b)
+		}
+	}
+	if len(bindings) == 0 {
+		fmt.Fprintln(stderr, "ERROR: no keys to bind")
+		return 2
+	}
+	exe, err := birdExecutable()
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: locating executable: %v\n", err)
+		return 1
+	}
+	commands := bindKeyCommands(exe, *table, bindings, *unbind)
+	if *printOnly {
+		for _, command := range commands {
+			fmt.Fprintln(stdout, controlCommandLine(command))
+		}
+		return 0
+	}
+	if err := lookTmux(); err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if err := tmuxBatch(commands...); err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	for _, b := range bindings {
+		if *unbind {
+			fmt.Fprintf(stdout, "unbound %s %s\n", *table, b.key)
+		} else {
+			fmt.Fprintf(stdout, "bound %s %s: %s\n", *table, b.key, b.command)
+		}
+	}
+	return 0
+}
+
+// bindKeyCommands returns the tmux commands installing (or removing) the
+// bindings. run-shell expands formats, so the session is filled in when
+// the key is pressed and the executable's own # characters are doubled.
+func bindKeyCommands(exe, table string, bindings []keyBinding, unbind bool) [][]string {
+	commands := make([][]string, 0, len(bindings))
+	for _, b := range bindings {
+		if unbind {
+			commands = append(commands, []string{"unbind-key", "-T", table, b.key})
+			continue
+		}
+		shell := strings.ReplaceAll(shellQuoteSingle(exe), "#", "##") + " ctl --session #{q:session_name} " + b.command
+		commands = append(commands, []string{"bind-key", "-T", table, b.key, "run-shell", "-b", shell})
+	}
+	return commands
+}
@@ -0,0 +1,23 @@
+package typingbird
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBindKeyCommands(t *testing.T) {
+	bindings := []keyBinding{{"B", "send"}, {"P", "pause"}}
+	got := bindKeyCommands("/opt/#1/typing-bird", "prefix", bindings, false)
+	want := [][]string{
+		{"bind-key", "-T", "prefix", "B", "run-shell", "-b", "'/opt/##1/typing-bird' ctl --session #{q:session_name} send"},
+		{"bind-key", "-T", "prefix", "P", "run-shell", "-b", "'/opt/##1/typing-bird' ctl --session #{q:session_name} pause"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("bindKeyCommands(...) = %q; want %q", got, want)
+	}
+	got = bindKeyCommands("/bin/typing-bird", "root", bindings, true)
+	want = [][]string{{"unbind-key", "-T", "root", "B"}, {"unbind-key", "-T", "root", "P"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("bindKeyCommands(unbind) = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,1116 @@
+package typingbird
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// bird runs the idle/send loop for one target. Settings, rotation and script
+// live behind mu so a reload can swap them while the loop is waiting.
+type bird struct {
+	// name identifies the bird within a fleet; it is empty for a
+	// standalone bird.
+	name    string
+	mu      sync.Mutex
+	opts    options
+	rot     *rotation
+	script  *birdScript
+	sends   int
+	target  string
+	monitor *pipeMonitor
+	// limiter is shared by the birds of a fleet; nil means unlimited.
+	limiter *sendLimiter
+	// record receives a transcript entry per send with --record.
+	record *transcript
+	// audit receives an entry per send with --audit-log.
+	audit *auditLog
+	// dead receives messages that failed to send with --dead-letter;
+	// deadCount is how many this bird put there.
+	dead      *deadLetterFile
+	deadCount int
+	// queues supply messages ahead of the rotation: the inbox, then
+	// --trigger-fifo and --redis-queue, tried in that order.
+	queues []messageQueue
+	inbox  *inbox
+	// cast records the pane around each send with --asciicast.
+	cast   *castRecorder
+	paused bool
+	// forced holds a send requested through the API; an empty message
+	// means the next one in rotation.
+	forced *string
+	// waiters are told how the forced send went.
+	waiters []chan<- error
+	// cancelWait interrupts the current idle wait or pause.
+	cancelWait context.CancelFunc
+	// lost names the tmux hook that reported the target gone.
+	lost   string
+	events *eventHub
+
+	// lock is the bird's claim on its target pane; nil without one.
+	lock *paneLock
+
+	// lastInput is when the bird last typed into the target; sendMu is
+	// held while it does, so --keepalive never interleaves with a message.
+	lastInput time.Time
+	sendMu    sync.Mutex
+	// inFlight is set while a message is being typed, which a shutdown
+	// waits for.
+	inFlight atomic.Bool
+
+	// state, waitStarted and recent feed the status report.
+	state       string
+	waitStarted time.Time
+	activeAt    time.Time
+	recent      []birdEvent
+	// flowStep is the current state of opts.flow.
+	flowStep int
+	// outcomeWatch is nil without --exit-on and --fail-on; outcome is set
+	// once one of them matched.
+	outcomeWatch *outcomeWatch
+	outcome      *birdOutcome
+
+	// resolve re-reads the config and messages file for reload.
+	resolve func() (options, error)
+	// locate finds the target pane again once a session that went away
+	// is back; nil means the session's preferred pane.
+	locate func() (string, error)
+}
+
+func newBird(opts options, target string) (*bird, error) {
+	b := &bird{opts: opts, target: target, events: &eventHub{}, inbox: &inbox{}}
+	b.queues = []messageQueue{b.inbox}
+	b.rot = newRotation(opts.order, len(opts.messages), opts.weightList, newRand())
+	if opts.exitOn != nil || opts.failOn != nil {
+		b.outcomeWatch = &outcomeWatch{exitOn: opts.exitOn, failOn: opts.failOn}
+	}
+	if opts.script != "" {
+		script, err := loadScript(opts.script, nil)
+		if err != nil {
+			return nil, err
+		}
+		b.script = script
+	}
+	return b, nil
+}
+
+// logf logs with the bird's name as a prefix when it is part of a fleet.
+func (b *bird) logf(format string, args ...any) {
+	if b.name != "" {
+		format = "[%s] " + format
+		args = append([]any{b.name}, args...)
+	}
+	logf(format, args...)
+}
+
+func (b *bird) debugf(format string, args ...any) {
+	if b.name != "" {
+		format = "[%s] " + format
+		args = append([]any{b.name}, args...)
+	}
+	debugf(format, args...)
+}
+
+func (b *bird) publish(ev birdEvent) {
+	ev.Bird = b.name
+	b.events.publish(ev)
+}
+
+func newRand() *rand.Rand {
+	return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+}
+
+func (b *bird) options() options {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.opts
+}
+
+// reload swaps in freshly resolved settings without interrupting the loop.
+// The session, target and idle backend stay fixed for the process lifetime.
+func (b *bird) reload() error {
+	if b.resolve == nil {
+		return fmt.Errorf("reload not supported")
+	}
+	next, err := b.resolve()
+	if err != nil {
+		return err
+	}
+	var script *birdScript
+	if next.script != "" {
+		if script, err = loadScript(next.script, nil); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	prev := b.opts
+	if next.session != prev.session {
+		b.logf("WARNING: reload cannot change session (%q -> %q); keeping %q", prev.session, next.session, prev.session)
+		next.session = prev.session
+	}
+	if next.idleMode != prev.idleMode {
+		b.logf("WARNING: reload cannot change idle-mode (%s -> %s); keeping %s", prev.idleMode, next.idleMode, prev.idleMode)
+		next.idleMode = prev.idleMode
+	}
+	if !reflect.DeepEqual(next.messages, prev.messages) || next.order != prev.order || !reflect.DeepEqual(next.weightList, prev.weightList) {
+		rot := newRotation(next.order, len(next.messages), next.weightList, newRand())
+		rot.next = b.rot.next % len(next.messages)
+		b.rot = rot
+	}
+	if next.flow != nil {
+		// Stay in the same state when the new flow still has it.
+		step := 0
+		if prev.flow != nil {
+			if i := next.flow.index(prev.flow.steps[b.flowStep].name); i >= 0 && !next.flow.terminal(i) {
+				step = i
+			}
+		}
+		b.flowStep = step
+	}
+	b.opts = next
+	b.script = script
+	if b.name == "" {
+		// Fleet birds share the process-wide logging settings.
+		verboseLogging = next.verbose
+		traceLogging = next.trace
+		messageRedaction = next.redact
+	}
+	b.logf(
+		"reloaded: idle-timeout=%s delay=%s messages=%d order=%s",
+		next.timeout, next.delay, len(next.messages), next.order,
+	)
+	b.publish(birdEvent{Type: eventReloaded, Total: len(next.messages)})
+	return nil
+}
+
+// setMessages replaces the message list, keeping the rotation position. A
+// later reload re-reads messages from their original source.
+func (b *bird) setMessages(messages []string) error {
+	if len(messages) == 0 {
+		return fmt.Errorf("at least one message is required")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.opts.weightList) > 0 && len(b.opts.weightList) != len(messages) {
+		return fmt.Errorf("%d weights configured for %d messages", len(b.opts.weightList), len(messages))
+	}
+	rot := newRotation(b.opts.order, len(messages), b.opts.weightList, newRand())
+	rot.next = b.rot.next % len(messages)
+	b.rot = rot
+	b.opts.messages = append([]string(nil), messages...)
+	// Directives and the flow belong to the source being replaced.
+	b.opts.expects = nil
+	b.opts.delays = nil
+	b.opts.flow = nil
+	b.logf("messages updated: messages=%d", len(messages))
+	b.publish(birdEvent{Type: eventReloaded, Total: len(messages)})
+	return nil
+}
+
+// pause stops sends until resume; idle detection is suspended meanwhile.
+func (b *bird) pause() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.paused {
+		return
+	}
+	b.paused = true
+	b.interruptWait()
+	b.logf("paused")
+	b.publish(birdEvent{Type: eventPaused})
+}
+
+func (b *bird) resume() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.paused {
+		return
+	}
+	b.paused = false
+	b.interruptWait()
+	b.logf("resumed")
+	b.publish(birdEvent{Type: eventResumed})
+}
+
+// sendNow skips the idle wait, and any pause, for one send. An empty message
+// sends the next one in rotation; otherwise message is sent as-is without
+// advancing the rotation.
+func (b *bird) sendNow(message string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.forced = &message
+	b.interruptWait()
+}
+
+// sendNowResult is sendNow that also reports how the send went: nil once
+// sent, or why it was skipped or failed.
+func (b *bird) sendNowResult(message string) <-chan error {
+	result := make(chan error, 1)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.forced = &message
+	b.waiters = append(b.waiters, result)
+	b.interruptWait()
+	return result
+}
+
+// skip drops the next message in rotation without sending it, so the next
+// send is the one after. Flows have no rotation to skip through.
+func (b *bird) skip() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.opts.flow != nil {
+		b.logf("skip ignored: flows move on by matching output")
+		return
+	}
+	index := b.rot.current()
+	b.rot.advance()
+	b.logf("skipped message %d/%d", index+1, len(b.opts.messages))
+}
+
+// interruptWait must be called with mu held.
+func (b *bird) interruptWait() {
+	if b.cancelWait != nil {
+		b.cancelWait()
+	}
+}
+
+// tmuxEvent handles a notification from the hooks registered by
+// --tmux-hooks. When it concerns our target the loop stops waiting at once
+// rather than finding out from a failed capture.
+func (b *bird) tmuxEvent(hook, subject string) {
+	b.mu.Lock()
+	target, session := b.target, b.opts.session
+	b.mu.Unlock()
+	switch hook {
+	case tmuxHookPaneDied, tmuxHookPaneExited:
+		if subject != target {
+			return
+		}
+	case tmuxHookSessionClosed:
+		if subject != session {
+			return
+		}
+	case tmuxHookAfterKillPane, tmuxHookWindowUnlinked:
+		if ok, _ := targetExists(target); ok {
+			return
+		}
+	default:
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lost = hook
+	b.interruptWait()
+}
+
+// controlCommand implements the control socket commands.
+func (b *bird) controlCommand(command string, args []string) controlResponse {
+	switch command {
+	case "reload":
+		if err := b.reload(); err != nil {
+			return controlError(err)
+		}
+		return controlOK(nil)
+	case "status":
+		return controlOK(b.status())
+	case "dead-letters":
+		return controlOK(b.deadLetters())
+	case "skip":
+		b.skip()
+		return controlOK(nil)
+	case "enqueue", "queue", "unqueue", "move", "send-queued":
+		return b.inboxCommand(command, args)
+	case "state":
+		return b.stateCommand(args)
+	case "watch":
+		return controlOK(eventStream{hub: b.events, bird: b.name})
+	case "pause":
+		b.pause()
+		return controlOK(nil)
+	case "resume":
+		b.resume()
+		return controlOK(nil)
+	case "send":
+		b.sendNow(strings.Join(args, " "))
+		return controlOK(nil)
+	case "tmux-event":
+		if len(args) != 2 {
+			return controlError(fmt.Errorf("usage: tmux-event <hook> <pane-or-session>"))
+		}
+		b.tmuxEvent(args[0], args[1])
+		return controlOK(nil)
+	}
+	return controlError(fmt.Errorf("unknown command %q", command))
+}
+
+const (
+	stateWaiting = "waiting"
+	statePaused  = "paused"
+	stateQueued  = "queued"
+	// stateInactive means outside --active-hours/--active-days.
+	stateInactive = "inactive"
+	stateSending  = "sending"
+	// stateExpecting means waiting for --expect-after output.
+	stateExpecting = "expecting"
+
+	// recentSends is how many sends the status report remembers.
+	recentSends = 10
+)
+
+// birdStatus is the status payload reported over the control socket.
+type birdStatus struct {
+	Name      string `json:"name,omitempty"`
+	PID       int    `json:"pid"`
+	Session   string `json:"session"`
+	Target    string `json:"target"`
+	IdleMode  string `json:"idle_mode"`
+	Timeout   string `json:"timeout"`
+	TimeoutMS int64  `json:"timeout_ms"`
+	Messages  int    `json:"messages"`
+	Next      int    `json:"next"`
+	Sends     int    `json:"sends"`
+	Paused    bool   `json:"paused"`
+	State     string `json:"state"`
+	// FlowState names the current state of a config-defined flow.
+	FlowState string `json:"flow_state,omitempty"`
+	// WaitStarted is when the current idle window began; the earliest
+	// next send is WaitStarted plus the timeout, pushed back by activity.
+	WaitStarted *time.Time `json:"wait_started,omitempty"`
+	// ActiveAt is when the next active window opens while inactive.
+	ActiveAt *time.Time  `json:"active_at,omitempty"`
+	Recent   []birdEvent `json:"recent,omitempty"`
+	// DeadLetters counts the messages moved to --dead-letter.
+	DeadLetters int `json:"dead_letters,omitempty"`
+	// Queued counts the messages waiting in the inbox.
+	Queued int `json:"queued,omitempty"`
+}
+
+func (b *bird) status() birdStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := birdStatus{
+		Name:      b.name,
+		PID:       os.Getpid(),
+		Session:   b.opts.session,
+		Target:    b.target,
+		IdleMode:  b.opts.idleMode,
+		Timeout:   b.opts.timeout.String(),
+		TimeoutMS: b.opts.timeout.Milliseconds(),
+		Messages:  len(b.opts.messages),
+		Next:      b.rot.next + 1,
+		Sends:     b.sends,
+		Paused:    b.paused,
+		State:     b.state,
+		Recent:    append([]birdEvent(nil), b.recent...),
+	}
+	st.DeadLetters = b.deadCount
+	if b.inbox != nil {
+		st.Queued = b.inbox.len()
+	}
+	if b.paused {
+		st.State = statePaused
+	}
+	if b.opts.flow != nil {
+		st.Next = b.flowStep + 1
+		st.FlowState = b.opts.flow.steps[b.flowStep].name
+	}
+	if st.State == stateWaiting && !b.waitStarted.IsZero() {
+		started := b.waitStarted
+		st.WaitStarted = &started
+	}
+	if st.State == stateInactive && !b.activeAt.IsZero() {
+		at := b.activeAt
+		st.ActiveAt = &at
+	}
+	return st
+}
+
+// run loops until ctx is cancelled or a send fails, returning the process
+// exit code.
+func (b *bird) run(ctx context.Context, interruptCode *atomic.Int32) int {
+	if err := b.lockTarget(b.target); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	defer b.unlockTarget()
+	if opts := b.options(); opts.stateFile != "" {
+		if err := b.loadStateFile(opts.stateFile); err != nil {
+			b.logf("WARNING: %v", err)
+		}
+	}
+	stopIndicators := b.startIndicators(ctx)
+	defer stopIndicators()
+	if b.cast != nil {
+		stopCast := b.cast.start(ctx, func() string {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			return b.target
+		})
+		defer stopCast()
+	}
+	messageIndex := 0
+	inactive := false
+	// expecting is the response to wait for before the next countdown.
+	var expecting *pendingExpect
+	// ready is set once --wait-port and --wait-http have passed.
+	ready := false
+	// flowEnd explains why the flow finished, once it has.
+	flowEnd := ""
+	shutdown := func() int {
+		if opts := b.options(); opts.stateFile != "" {
+			if err := b.saveStateFile(opts.stateFile); err != nil {
+				b.logf("WARNING: %v", err)
+			}
+		}
+		code := interruptCode.Load()
+		if code != 0 {
+			return int(code)
+		}
+		b.logf("shutdown signal received, exiting")
+		return 0
+	}
+	// follow moves to another pane after the target went away, when
+	// --reattach or --retarget allows it.
+	follow := func(opts options) bool {
+		if ok, err := b.reattach(ctx, opts); ok || err != nil {
+			if err != nil && ctx.Err() == nil {
+				b.logf("cannot re-attach: %v", err)
+			}
+			return ok && err == nil
+		}
+		if !opts.retarget {
+			return false
+		}
+		if err := b.followTarget(ctx); err != nil {
+			if ctx.Err() == nil {
+				b.logf("cannot re-target: %v", err)
+			}
+			return false
+		}
+		return true
+	}
+	// stopAt is when --max-runtime or --until ends the run. The timer only
+	// interrupts waits, so a send already under way finishes first.
+	stopAt, stopReason := b.options().deadline(time.Now())
+	if !stopAt.IsZero() {
+		b.debugf("exiting at %s", stopAt.Format(time.RFC3339))
+		timer := time.AfterFunc(time.Until(stopAt), func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.interruptWait()
+		})
+		defer timer.Stop()
+	}
+	if b.outcomeWatch != nil {
+		watchCtx, stopWatch := context.WithCancel(ctx)
+		defer stopWatch()
+		go b.watchOutcome(watchCtx)
+	}
+	if b.options().keepalive > 0 {
+		keepaliveCtx, stopKeepalive := context.WithCancel(ctx)
+		defer stopKeepalive()
+		go b.keepalive(keepaliveCtx)
+	}
+	if opts := b.options(); opts.afterPID != 0 || opts.afterCommand != "" {
+		watchCtx, stopWatch := context.WithCancel(ctx)
+		defer stopWatch()
+		go b.watchProcess(watchCtx)
+	}
+	// immediate skips the first idle wait with --send-immediately.
+	immediate := b.options().sendNow
+	// heartbeatFailing keeps a broken --heartbeat-file to one warning.
+	heartbeatFailing := false
+	if delay := b.options().initialDelay; delay > 0 {
+		// A pause or send request ends the initial delay early.
+		waitCtx, cancelWait := context.WithCancel(ctx)
+		b.mu.Lock()
+		b.cancelWait = cancelWait
+		b.state = stateWaiting
+		b.waitStarted = time.Now().Add(delay)
+		b.mu.Unlock()
+		b.logf("waiting %s before the first idle check", delay)
+		sleepWithContext(waitCtx, delay)
+		cancelWait()
+		if ctx.Err() != nil {
+			return shutdown()
+		}
+	}
+	for {
+		// waitCtx lets pause, resume and sendNow interrupt the wait.
+		waitCtx, cancelWait := context.WithCancel(ctx)
+		b.mu.Lock()
+		opts := b.opts
+		b.cancelWait = cancelWait
+		b.state = stateWaiting
+		b.waitStarted = time.Now()
+		paused := b.paused
+		forcing := b.forced != nil
+		lost := b.lost
+		outcome := b.outcome
+		b.mu.Unlock()
+
+		// Every pass through here follows a send, a skip, or one of the
+		// minutely checks outside active hours.
+		if opts.heartbeat != "" {
+			if err := touchHeartbeat(opts.heartbeat, time.Now()); err != nil {
+				if !heartbeatFailing {
+					b.logf("WARNING: touching heartbeat file: %v", err)
+				}
+				heartbeatFailing = true
+			} else {
+				heartbeatFailing = false
+			}
+		}
+
+		if outcome != nil {
+			cancelWait()
+			b.logf("%s; exiting", outcome.reason)
+			return outcome.code
+		}
+		if flowEnd != "" {
+			cancelWait()
+			b.logf("%s; exiting", flowEnd)
+			return opts.exitCode
+		}
+		if !stopAt.IsZero() && !time.Now().Before(stopAt) {
+			cancelWait()
+			b.logf("%s; exiting", stopReason)
+			return 0
+		}
+		if lost != "" {
+			cancelWait()
+			if follow(opts) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return shutdown()
+			}
+			err := fmt.Errorf("tmux target %q no longer exists (%s)", b.target, lost)
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			b.publish(birdEvent{Type: eventError, Error: err.Error()})
+			runErrorHook(ctx, opts.hooks.onError, hookEvent{session: opts.session, target: b.target, index: messageIndex, total: len(opts.messages)}, err)
+			return 1
+		}
+
+		if opts.followActive && !paused {
+			b.followActive(opts)
+		}
+		if paused && !forcing {
+			<-waitCtx.Done()
+			cancelWait()
+			if ctx.Err() != nil {
+				return shutdown()
+			}
+			continue
+		}
+		if now := time.Now(); !forcing && !opts.schedule.active(now) {
+			wake := opts.schedule.next(now)
+			b.mu.Lock()
+			b.state = stateInactive
+			b.activeAt = wake
+			b.mu.Unlock()
+			if !inactive {
+				inactive = true
+				b.logf("outside active hours; next window opens %s", wake.Format(time.RFC3339))
+			}
+			// Re-check at least every minute so reloads and clock changes
+			// take effect.
+			sleep := min(time.Until(wake), time.Minute)
+			timer := time.NewTimer(sleep)
+			select {
+			case <-waitCtx.Done():
+			case <-timer.C:
+			}
+			timer.Stop()
+			cancelWait()
+			if ctx.Err() != nil {
+				return shutdown()
+			}
+			continue
+		}
+		if inactive {
+			inactive = false
+			b.logf("active window open; resuming")
+		}
+		if forcing {
+			expecting = nil
+		}
+		if expecting != nil {
+			b.mu.Lock()
+			b.state = stateExpecting
+			b.mu.Unlock()
+			matched, err := b.awaitExpect(waitCtx, expecting)
+			if err == context.Canceled {
+				cancelWait()
+				if ctx.Err() != nil {
+					return shutdown()
+				}
+				continue
+			}
+			switch {
+			case err != nil:
+				b.logf("WARNING: stopped waiting for %s: %v", expecting, err)
+			case matched >= 0:
+				b.debugf("expected output %s seen after %s", outputPattern(expecting.patterns[matched]), time.Since(expecting.since).Round(time.Millisecond))
+			case expecting.flow == nil:
+				b.logf("WARNING: no output matching %s within %s; carrying on", expecting, expecting.timeout)
+			}
+			if expecting.flow != nil && b.resolveFlow(expecting, matched) {
+				flowEnd = fmt.Sprintf("flow reached final state %q", b.status().FlowState)
+			}
+			expecting = nil
+			b.mu.Lock()
+			b.state = stateWaiting
+			b.waitStarted = time.Now()
+			b.mu.Unlock()
+		}
+		if immediate && !forcing {
+			immediate = false
+			b.logf("sending the first message without waiting for idle")
+		} else if !forcing {
+			var baseLen int
+			var err error
+			if opts.detector != nil {
+				err = opts.detector.WaitIdle(waitCtx, b.target)
+			} else if b.monitor != nil {
+				baseLen, err = b.monitor.waitIdle(waitCtx, opts.timeout)
+			} else {
+				baseLen, err = waitForTargetIdle(waitCtx, b.target, opts.sampling, opts.timeout)
+			}
+			cancelWait()
+			if err == context.Canceled {
+				if ctx.Err() != nil {
+					return shutdown()
+				}
+				continue
+			}
+			if errors.Is(err, errTargetGone) && follow(opts) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return shutdown()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: idle wait failed for target %q in session %q: %v\n", b.target, opts.session, err)
+				b.publish(birdEvent{Type: eventError, Error: err.Error()})
+				runErrorHook(ctx, opts.hooks.onError, hookEvent{session: opts.session, target: b.target, index: messageIndex, total: len(opts.messages)}, err)
+				return 1
+			}
+			if opts.detector != nil {
+				b.logf("idle detected on pane-id=%q by %T", b.target, opts.detector)
+			} else if b.monitor != nil {
+				b.logf("idle detected on pane-id=%q: streamed=%d bytes", b.target, baseLen)
+			} else {
+				b.logf("idle detected on pane-id=%q: sample1=%d bytes", b.target, baseLen)
+			}
+			if opts.busy != nil {
+				if screen, err := captureTarget(b.target); err == nil && opts.busy.Match(screen) {
+					b.logf("pane is quiet but matches --busy-regex %s; waiting again", outputPattern(opts.busy))
+					continue
+				}
+			}
+		}
+		cancelWait()
+		// Output printed since the last poll may already end the run.
+		if b.checkOutcome() {
+			continue
+		}
+		// The agent moved while the bird was waiting: wait for the new
+		// pane to go idle instead.
+		if opts.followActive && !forcing && b.followActive(opts) {
+			continue
+		}
+
+		if b.limiter != nil {
+			b.mu.Lock()
+			b.state = stateQueued
+			b.mu.Unlock()
+			delay, err := b.limiter.wait(ctx)
+			if err != nil {
+				return shutdown()
+			}
+			if delay > 0 {
+				b.debugf("send delayed %s by --max-sends-per-minute", delay.Round(time.Millisecond))
+			}
+		}
+
+		// Pick up any reload or API request that landed while waiting.
+		b.mu.Lock()
+		b.state = stateSending
+		opts = b.opts
+		rot := b.rot
+		script := b.script
+		sends := b.sends
+		messageIndex = rot.current()
+		if opts.flow != nil {
+			messageIndex = b.flowStep
+		}
+		var forced *string
+		var waiters []chan<- error
+		if b.forced != nil {
+			forced, b.forced = b.forced, nil
+			waiters, b.waiters = b.waiters, nil
+		} else if b.paused || !opts.schedule.active(time.Now()) {
+			// Paused, or the active window closed, while the idle check
+			// was finishing.
+			b.mu.Unlock()
+			continue
+		}
+		b.mu.Unlock()
+
+		messages := opts.messages
+		message := messages[messageIndex]
+		report := func(err error) {
+			for _, w := range waiters {
+				w <- err
+			}
+			waiters = nil
+		}
+		skip := func(reason error) {
+			b.logf("skipping message %d/%d: %v", messageIndex+1, len(messages), reason)
+			b.publish(birdEvent{Type: eventSkipped, Index: messageIndex + 1, Total: len(messages), Error: reason.Error()})
+			report(reason)
+		}
+		event := hookEvent{
+			name:    hookEventIdle,
+			session: opts.session,
+			target:  b.target,
+			message: message,
+			index:   messageIndex,
+			total:   len(messages),
+		}
+		if forced != nil {
+			b.logf("sending now on request")
+		} else {
+			b.publish(birdEvent{Type: eventIdle, Index: messageIndex + 1, Total: len(messages)})
+			if err := runHook(ctx, opts.hooks.onIdle, event); err != nil {
+				b.logf("WARNING: %v", err)
+			}
+		}
+		if pane, pid, taken := b.takenOver(); taken {
+			report(fmt.Errorf("pane %q was taken over", pane))
+			b.logf("pane %q was taken over by the bird with pid %d; exiting", pane, pid)
+			return 0
+		}
+		if forced == nil && opts.humanCooldown > 0 {
+			if ago, typing := humanTypedWithin(b.target, opts.humanCooldown, time.Now()); typing {
+				skip(fmt.Errorf("someone typed %s ago (cool-down %s)", ago.Round(time.Second), opts.humanCooldown))
+				continue
+			}
+		}
+		if !opts.allowPassword {
+			if screen, err := captureTarget(b.target); err == nil {
+				if prompt, ok := passwordPrompt(screen, opts.passwordRegex); ok {
+					if opts.passwordAlert {
+						if err := alertPasswordPrompt(b.target); err != nil {
+							b.logf("WARNING: password alert: %v", err)
+						}
+					}
+					skip(fmt.Errorf("the pane is waiting at a password prompt (%q)", prompt))
+					continue
+				}
+			}
+		}
+		queued := false
+		if forced == nil && len(b.queues) > 0 {
+			if next, ok := b.popQueue(ctx); ok {
+				forced, queued = &next.text, next.text != ""
+				// A queued message that isn't sent goes back for the
+				// next cycle.
+				unqueued := skip
+				skip = func(reason error) {
+					if err := next.from.requeue(next.raw); err != nil {
+						b.logf("WARNING: returning message to queue %s: %v", next.from, err)
+					}
+					unqueued(reason)
+				}
+			} else if ctx.Err() != nil {
+				return shutdown()
+			}
+		}
+		var err error
+		scripted := false
+		requested := forced != nil && *forced != ""
+		if requested {
+			message = *forced
+			event.message = message
+		} else if script != nil {
+			message, scripted, err = scriptDecision(script, b.target, scriptState{
+				session:  opts.session,
+				target:   b.target,
+				index:    messageIndex,
+				messages: messages,
+				sends:    sends,
+			}, message)
+			if err != nil {
+				skip(err)
+				continue
+			}
+			event.message = message
+		} else if opts.llm != nil {
+			if message, err = llmDecision(ctx, opts.llm, b.target, message); err != nil {
+				if ctx.Err() != nil {
+					return shutdown()
+				}
+				skip(err)
+				continue
+			}
+			event.message = message
+		}
+		if !ready && opts.gatesSend(messageIndex, requested) {
+			if err := b.waitReady(ctx, opts); err != nil {
+				if ctx.Err() != nil {
+					return shutdown()
+				}
+				skip(err)
+				continue
+			}
+			ready = true
+		}
+		// Secrets and the clipboard are resolved last so hooks, scripts and
+		// logs only ever see the placeholder.
+		text, secrets, err := expandSecrets(message, opts.secretSources)
+		if err != nil {
+			skip(err)
+			continue
+		}
+		if text, err = expandClipboard(text); err != nil {
+			skip(err)
+			continue
+		}
+		path, paste := fileMessagePath(text)
+		if paste {
+			if text, err = readFileMessage(path); err != nil {
+				skip(err)
+				continue
+			}
+		} else {
+			text, paste = pastedText(text, opts.pasteLines)
+		}
+		if err := opts.policy.screen(text); err != nil {
+			skip(err)
+			continue
+		}
+		secretRedactor.add(secrets...)
+		event.name = hookEventPreSend
+		if err := runHook(ctx, opts.hooks.preSend, event); err != nil {
+			skip(err)
+			continue
+		}
+		var before []byte
+		if b.record != nil {
+			before, _ = captureTarget(b.target)
+		}
+		// The reply to wait for is marked before sending so even a quick
+		// answer is seen.
+		var exp *pendingExpect
+		if opts.flow != nil && !requested {
+			if step := opts.flow.steps[messageIndex]; len(step.transitions) > 0 {
+				exp = &pendingExpect{patterns: opts.flow.patterns(messageIndex), timeout: step.timeout, flow: opts.flow, step: messageIndex}
+				if exp.timeout == 0 {
+					exp.timeout = opts.expectTimeout
+				}
+			}
+		} else if re := opts.expectFor(messageIndex, requested); re != nil {
+			exp = &pendingExpect{patterns: []*regexp.Regexp{re}, timeout: opts.expectTimeout}
+		}
+		b.markOutcome(text)
+		if exp != nil {
+			exp.echo = text
+			if exp.mark, err = tmuxPaneMark(b.target); err != nil {
+				b.logf("WARNING: cannot follow output for %s: %v", exp, err)
+				exp = nil
+			}
+		}
+		// Macros press keys rather than type text and secrets are usually
+		// not echoed, so neither can be verified.
+		var verify *paneMark
+		needle := verifyNeedle(text)
+		if opts.verify && needle != "" && !isMacro(text) && len(secrets) == 0 {
+			if mark, err := tmuxPaneMark(b.target); err != nil {
+				b.logf("WARNING: cannot verify message %d/%d: %v", messageIndex+1, len(messages), err)
+			} else {
+				verify = &mark
+			}
+		}
+		// A typo in a secret could end up in a password prompt's history,
+		// and a #delay: line sets its message's pace over --wpm.
+		human := typing{typos: opts.typoRate, wpm: opts.wpm}
+		if len(secrets) > 0 {
+			human.typos = 0
+		}
+		if _, ok := opts.delays[messageIndex]; ok && !requested {
+			human.wpm = 0
+		}
+		send := func() error {
+			b.sendMu.Lock()
+			defer b.sendMu.Unlock()
+			b.inFlight.Store(true)
+			defer b.inFlight.Store(false)
+			if paste {
+				return tmuxPaste(b.target, text, opts.chunks)
+			}
+			return tmuxSendMessage(b.target, text, opts.delayFor(messageIndex, requested), opts.chunks, human)
+		}
+		sentAt := time.Now()
+		sendErr := send()
+		if ctx.Err() != nil {
+			// The shutdown came in while typing; the message was
+			// finished rather than left half-typed at the prompt.
+			b.logf("finished typing message %d/%d before exiting", messageIndex+1, len(messages))
+		}
+		attempts := 1
+		for ; sendErr != nil && attempts <= opts.sendRetries; attempts++ {
+			b.logf("WARNING: sending message %d/%d failed, retrying (%d/%d): %v", messageIndex+1, len(messages), attempts, opts.sendRetries, sendErr)
+			if sleepWithContext(ctx, sendRetryDelay) != nil {
+				break
+			}
+			sendErr = send()
+		}
+		event.name = hookEventPostSend
+		event.err = sendErr
+		if sendErr == nil && verify != nil {
+			if err := b.verifySent(ctx, needle, *verify, opts.verifyRetries, send); err != nil && ctx.Err() == nil {
+				b.logf("WARNING: message %d/%d: %v", messageIndex+1, len(messages), err)
+				b.publish(birdEvent{Type: eventError, Index: messageIndex + 1, Total: len(messages), Error: err.Error()})
+				event.err = err
+			}
+		}
+		if b.audit != nil {
+			b.auditSend(auditEntry{
+				Time:    sentAt,
+				Session: opts.session,
+				Target:  b.target,
+				Index:   messageIndex + 1,
+				Message: text,
+			}, requested, event.err)
+		}
+		if err := runHook(ctx, opts.hooks.postSend, event); err != nil {
+			b.logf("WARNING: %v", err)
+		}
+		if sendErr != nil {
+			dead := deadLetter{
+				Time:     sentAt,
+				Session:  opts.session,
+				Target:   b.target,
+				Message:  message,
+				Error:    sendErr.Error(),
+				Attempts: attempts,
+			}
+			if !requested {
+				dead.Index = messageIndex + 1
+			}
+			if ok, err := targetExists(b.target); (err != nil || !ok) && follow(opts) {
+				if b.dead != nil {
+					b.addDeadLetter(dead)
+				}
+				continue
+			}
+			if ctx.Err() != nil {
+				return shutdown()
+			}
+			report(sendErr)
+			if b.dead != nil {
+				b.logf("WARNING: failed sending message %d/%d to target %q, moved to the dead-letter file: %v", messageIndex+1, len(messages), b.target, sendErr)
+				b.publish(birdEvent{Type: eventError, Index: messageIndex + 1, Total: len(messages), Error: sendErr.Error()})
+				runErrorHook(ctx, opts.hooks.onError, event, sendErr)
+				b.addDeadLetter(dead)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "ERROR: failed sending message #%d to target %q in session %q: %v\n", messageIndex+1, b.target, opts.session, sendErr)
+			b.publish(birdEvent{Type: eventError, Index: messageIndex + 1, Total: len(messages), Error: sendErr.Error()})
+			runErrorHook(ctx, opts.hooks.onError, event, sendErr)
+			return 1
+		}
+
+		if exp != nil {
+			exp.since = sentAt
+			expecting = exp
+		}
+		if opts.flow != nil && !requested {
+			// States with transitions move on once expecting resolves.
+			switch step := opts.flow.steps[messageIndex]; {
+			case len(step.transitions) > 0:
+			case step.next != "":
+				if b.moveFlow(opts.flow, messageIndex, opts.flow.index(step.next), "sent") {
+					flowEnd = fmt.Sprintf("flow reached final state %q", step.next)
+				}
+			default:
+				flowEnd = fmt.Sprintf("flow ended after state %q", step.name)
+			}
+		}
+		if b.cast != nil {
+			label := message
+			if redactingMessages() {
+				label = logText(message)
+			}
+			b.cast.mark(sentAt, label)
+		}
+		if b.record != nil {
+			b.recordCycle(ctx, transcriptEntry{
+				Time:    sentAt,
+				Session: opts.session,
+				Target:  b.target,
+				Index:   messageIndex + 1,
+				Total:   len(messages),
+				Message: message,
+				Before:  strings.TrimRight(string(before), "\n"),
+			}, requested)
+		}
+
+		b.mu.Lock()
+		b.sends++
+		b.lastInput = time.Now()
+		inRotation := !scripted && !requested && opts.flow == nil
+		if inRotation && b.rot == rot {
+			rot.advance()
+		}
+		done := inRotation && opts.noLoop && rot.passComplete()
+		b.mu.Unlock()
+
+		shown := message
+		if redactingMessages() {
+			shown = logText(message)
+		}
+		sent := birdEvent{Time: time.Now(), Type: eventSent, Total: len(messages), Message: shown}
+		if !requested {
+			sent.Index = messageIndex + 1
+		}
+		b.mu.Lock()
+		b.recent = append(b.recent, sent)
+		if len(b.recent) > recentSends {
+			b.recent = b.recent[len(b.recent)-recentSends:]
+		}
+		b.mu.Unlock()
+		b.publish(sent)
+		report(event.err)
+		if queued {
+			b.logf("sent queued message: %s", logText(message))
+			continue
+		}
+		if requested {
+			b.logf("sent requested message: %s", logText(message))
+			continue
+		}
+		if scripted {
+			b.logf("sent scripted message: %s", logText(message))
+			continue
+		}
+		b.logf("sent message %d/%d: %s", messageIndex+1, len(messages), logText(message))
+		if done {
+			b.logf("all %d messages sent; exiting (no-loop)", len(messages))
+			return opts.exitCode
+		}
+	}
+}
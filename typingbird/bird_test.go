@@ -0,0 +1,106 @@
+package typingbird
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBirdReloadSwapsMessagesAndKeepsPosition(t *testing.T) {
+	opts := options{timeout: time.Second, order: orderRoundRobin, session: "s", messages: []string{"a", "b", "c"}}
+	b, err := newBird(opts, "%1")
+	if err != nil {
+		t.Fatalf("newBird(...) error: %v", err)
+	}
+	b.rot.current()
+	b.rot.advance()
+
+	reloaded := opts
+	reloaded.timeout = time.Minute
+	reloaded.session = "other"
+	reloaded.messages = []string{"x", "y"}
+	b.resolve = func() (options, error) { return reloaded, nil }
+	if err := b.reload(); err != nil {
+		t.Fatalf("reload() error: %v", err)
+	}
+
+	got := b.options()
+	if got.timeout != time.Minute || !reflect.DeepEqual(got.messages, []string{"x", "y"}) {
+		t.Fatalf("options() after reload = %+v; want new timeout and messages", got)
+	}
+	if got.session != "s" {
+		t.Fatalf("session after reload = %q; want unchanged %q", got.session, "s")
+	}
+	if idx := b.rot.current(); idx != 1 {
+		t.Fatalf("rotation index after reload = %d; want 1", idx)
+	}
+}
+
+func TestBuildChildArgsOmitsReloadableMessages(t *testing.T) {
+	opts := options{
+		timeout:      time.Minute,
+		delay:        defaultDelay,
+		messagesFile: "/tmp/messages.txt",
+		session:      "foobar",
+		messages:     []string{"from file"},
+		reloadable:   true,
+	}
+	got := buildChildArgs(opts, "%2")
+	want := []string{"-t", "1m0s", "-d", "15ms", "--messages-file", "/tmp/messages.txt", "--target-pane", "%2", "foobar"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("buildChildArgs(...) = %#v; want %#v", got, want)
+	}
+
+	opts.delimiter = "---"
+	got = buildChildArgs(opts, "%2")
+	want = []string{"-t", "1m0s", "-d", "15ms", "--messages-file", "/tmp/messages.txt", "--delimiter", "---", "--target-pane", "%2", "foobar"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("buildChildArgs(...) with a delimiter = %#v; want %#v", got, want)
+	}
+	opts.reloadable, opts.messagesFile = false, ""
+	got = buildChildArgs(opts, "%2")
+	want = []string{"-t", "1m0s", "-d", "15ms", "--target-pane", "%2", "foobar", "from file"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("buildChildArgs(...) with split arguments = %#v; want %#v", got, want)
+	}
+	opts.base64 = true
+	got = buildChildArgs(opts, "%2")
+	want = []string{"-t", "1m0s", "-d", "15ms", "--base64", "--target-pane", "%2", "foobar", "ZnJvbSBmaWxl"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("buildChildArgs(...) with --base64 = %#v; want %#v", got, want)
+	}
+}
+
+func TestBirdTmuxEventMarksTargetLost(t *testing.T) {
+	b, err := newBird(options{timeout: time.Second, order: orderRoundRobin, session: "agent", messages: []string{"a"}}, "%1")
+	if err != nil {
+		t.Fatalf("newBird(...) error: %v", err)
+	}
+	b.tmuxEvent(tmuxHookPaneExited, "%2")
+	b.tmuxEvent(tmuxHookSessionClosed, "other")
+	if b.lost != "" {
+		t.Fatalf("lost after unrelated events = %q; want empty", b.lost)
+	}
+	if resp := b.controlCommand("tmux-event", []string{tmuxHookPaneExited, "%1"}); !resp.OK {
+		t.Fatalf("controlCommand(tmux-event) = %+v; want OK", resp)
+	}
+	if b.lost != tmuxHookPaneExited {
+		t.Fatalf("lost after our pane exited = %q; want %q", b.lost, tmuxHookPaneExited)
+	}
+}
+
+func TestBirdSkipAdvancesRotation(t *testing.T) {
+	b, err := newBird(options{timeout: time.Second, order: orderRoundRobin, session: "agent", messages: []string{"a", "b", "c"}}, "%1")
+	if err != nil {
+		t.Fatalf("newBird(...) error: %v", err)
+	}
+	b.skip()
+	b.skip()
+	if got := b.rot.current(); got != 2 {
+		t.Fatalf("rotation after two skips = %d; want 2", got)
+	}
+	b.skip()
+	if got := b.rot.current(); got != 0 {
+		t.Fatalf("rotation after skipping past the end = %d; want 0", got)
+	}
+}
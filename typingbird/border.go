@@ -0,0 +1,69 @@
+package typingbird
+
+import "strings"
+
+// borderOptions are the pane options --border-status recolors.
+var borderOptions = []string{"pane-border-style", "pane-active-border-style"}
+
+// borderStyle picks the border style for st: red after an error, yellow
+// while paused or outside the active window and green otherwise.
+func borderStyle(st birdStatus) string {
+	if n := len(st.Recent); n > 0 {
+		last := st.Recent[n-1]
+		if last.Type == eventError || (last.Type == eventSkipped && last.Error != "") {
+			return "fg=red"
+		}
+	}
+	switch st.State {
+	case statePaused, stateInactive:
+		return "fg=yellow"
+	case "":
+		return ""
+	}
+	return "fg=green"
+}
+
+// paneBorder recolors one pane's border and remembers the pane-level
+// styles it replaced so restore can put them back.
+type paneBorder struct {
+	pane     string
+	original map[string]string
+	current  string
+}
+
+func newPaneBorder(pane string) *paneBorder {
+	p := &paneBorder{pane: pane, original: make(map[string]string)}
+	for _, name := range borderOptions {
+		out, err := tmuxOutput("show-options", "-pqv", "-t", pane, name)
+		if err == nil {
+			p.original[name] = strings.TrimSpace(string(out))
+		}
+	}
+	return p
+}
+
+func (p *paneBorder) set(style string) {
+	if style == p.current {
+		return
+	}
+	p.current = style
+	commands := make([][]string, len(borderOptions))
+	for i, name := range borderOptions {
+		commands[i] = []string{"set-option", "-pq", "-t", p.pane, name, style}
+	}
+	if err := tmuxBatch(commands...); err != nil {
+		debugf("setting the border style of pane %q: %v", p.pane, err)
+	}
+}
+
+func (p *paneBorder) restore() {
+	commands := make([][]string, len(borderOptions))
+	for i, name := range borderOptions {
+		if value := p.original[name]; value != "" {
+			commands[i] = []string{"set-option", "-pq", "-t", p.pane, name, value}
+		} else {
+			commands[i] = []string{"set-option", "-pqu", "-t", p.pane, name}
+		}
+	}
+	_ = tmuxBatch(commands...)
+}
@@ -0,0 +1,23 @@
+package typingbird
+
+import "testing"
+
+func TestBorderStyle(t *testing.T) {
+	tests := []struct {
+		st   birdStatus
+		want string
+	}{
+		{birdStatus{State: stateWaiting}, "fg=green"},
+		{birdStatus{State: stateSending, Recent: []birdEvent{{Type: eventSent}}}, "fg=green"},
+		{birdStatus{State: statePaused}, "fg=yellow"},
+		{birdStatus{State: stateInactive}, "fg=yellow"},
+		{birdStatus{State: stateWaiting, Recent: []birdEvent{{Type: eventSkipped, Error: "pre-hook failed"}}}, "fg=red"},
+		{birdStatus{State: stateWaiting, Recent: []birdEvent{{Type: eventSkipped}, {Type: eventSent}}}, "fg=green"},
+		{birdStatus{State: statePaused, Recent: []birdEvent{{Type: eventError}}}, "fg=red"},
+	}
+	for _, tt := range tests {
+		if got := borderStyle(tt.st); got != tt.want {
+			t.Fatalf("borderStyle(%+v) = %q; want %q", tt.st, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package typingbird
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultChunkPause = 100 * time.Millisecond
+	// chunkEchoTimeout bounds how long --chunk-verify waits for a chunk to
+	// show up in the pane.
+	chunkEchoTimeout = 5 * time.Second
+)
+
+// chunking splits long text into pieces sent one at a time, for panes
+// (typically on the far end of a slow SSH link) that drop input arriving
+// in one burst. The zero value sends text whole.
+type chunking struct {
+	size   int
+	pause  time.Duration
+	verify bool
+}
+
+// split breaks literal actions longer than size into chunks, never inside
+// a UTF-8 sequence.
+func (c chunking) split(actions []sendAction) []sendAction {
+	if c.size <= 0 {
+		return actions
+	}
+	out := make([]sendAction, 0, len(actions))
+	for _, action := range actions {
+		if !action.literal {
+			out = append(out, action)
+			continue
+		}
+		for _, chunk := range c.chunks(action.value) {
+			out = append(out, sendAction{value: chunk, literal: true})
+		}
+	}
+	return out
+}
+
+func (c chunking) chunks(text string) []string {
+	if c.size <= 0 || len(text) <= c.size {
+		return []string{text}
+	}
+	var chunks []string
+	for len(text) > c.size {
+		n := c.size
+		for n > 0 && !utf8.RuneStart(text[n]) {
+			n--
+		}
+		if n == 0 {
+			// A chunk smaller than one rune still sends that rune.
+			_, n = utf8.DecodeRuneInString(text)
+		}
+		chunks = append(chunks, text[:n])
+		text = text[n:]
+	}
+	if text != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// mark notes where target's output stands before a chunk goes out, when
+// chunks are verified.
+func (c chunking) mark(target string) (*paneMark, error) {
+	if !c.verify {
+		return nil, nil
+	}
+	mark, err := tmuxPaneMark(target)
+	if err != nil {
+		return nil, err
+	}
+	return &mark, nil
+}
+
+// settle waits for chunk to be echoed since mark, when verifying, and then
+// pauses before the next one.
+func (c chunking) settle(target, chunk string, mark *paneMark) error {
+	if mark != nil {
+		if err := awaitChunkEcho(target, chunk, *mark); err != nil {
+			return err
+		}
+	}
+	time.Sleep(c.pause)
+	return nil
+}
+
+// awaitChunkEcho waits for the end of chunk to appear in target's output
+// since mark.
+func awaitChunkEcho(target, chunk string, mark paneMark) error {
+	needle := verifyNeedle(chunk)
+	if needle == "" {
+		return nil
+	}
+	seen, err := awaitEcho(context.Background(), target, needle, mark, chunkEchoTimeout)
+	if err == nil && !seen {
+		err = fmt.Errorf("chunk was not echoed within %s; the pane may have dropped input", chunkEchoTimeout)
+	}
+	return err
+}
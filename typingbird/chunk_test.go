@@ -0,0 +1,41 @@
+package typingbird
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunkingChunks(t *testing.T) {
+	tests := []struct {
+		size int
+		text string
+		want []string
+	}{
+		{size: 0, text: "abcdef", want: []string{"abcdef"}},
+		{size: 4, text: "abc", want: []string{"abc"}},
+		{size: 2, text: "abcde", want: []string{"ab", "cd", "e"}},
+		{size: 3, text: "aéb€", want: []string{"aé", "b", "€"}},
+		{size: 1, text: "é", want: []string{"é"}},
+	}
+	for _, tt := range tests {
+		got := chunking{size: tt.size}.chunks(tt.text)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("chunking{size: %d}.chunks(%q) = %q; want %q", tt.size, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestChunkingSplit(t *testing.T) {
+	actions := []sendAction{{value: "abcde", literal: true}, {value: "Enter"}, {value: "xy", literal: true}}
+	got := chunking{size: 2}.split(actions)
+	want := []sendAction{
+		{value: "ab", literal: true},
+		{value: "cd", literal: true},
+		{value: "e", literal: true},
+		{value: "Enter"},
+		{value: "xy", literal: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("split(...) = %#v; want %#v", got, want)
+	}
+}
@@ -0,0 +1,140 @@
+package typingbird
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// runCleanup implements `typing-bird cleanup [--all | session]`: it stops
+// every bird injected into a pane of the session, or of the whole server
+// with --all, and removes their panes.
+func runCleanup(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("cleanup", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	all := fs.Bool("all", false, "clean up every session on the tmux server")
+	unmarked := fs.Bool("unmarked", false, "also stop typing-bird in panes it was not injected into, such as a shell where it was started by hand")
+	grace := fs.Duration("grace", defaultRestartGrace, "how long each bird gets to exit before its pane is killed")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird cleanup [--grace d] [--unmarked] [--all | session]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Stops every bird injected into a pane of the session, or of every session")
+		fmt.Fprintln(stderr, "with --all. Each bird gets two Ctrl-Cs and the grace period to finish its")
+		fmt.Fprintln(stderr, "files, then its pane is killed. --unmarked also takes panes that merely run")
+		fmt.Fprintln(stderr, "typing-bird, which kills the shell it was started from by hand. The session")
+		fmt.Fprintln(stderr, "defaults to the current one inside tmux.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if *grace < 0 {
+		fs.Usage()
+		return 2
+	}
+	session, err := cleanupSession(*all, fs.Args(), currentTmuxSession)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 2
+	}
+	panes, err := cleanupBirdPanes(session, *unmarked)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: listing bird panes: %v\n", err)
+		return 1
+	}
+	if len(panes) == 0 {
+		fmt.Fprintln(stdout, "no birds found")
+		return 0
+	}
+	stopBirdPanes(panes, *grace)
+	fmt.Fprintf(stdout, "removed %d bird pane(s): %s\n", len(panes), strings.Join(panes, ", "))
+	return 0
+}
+
+// cleanupSession picks the session to clean up from the arguments: the one
+// named, none with --all, or else the current one inside tmux.
+func cleanupSession(all bool, args []string, current func() string) (string, error) {
+	switch {
+	case len(args) > 1:
+		return "", errors.New("name at most one session")
+	case all && len(args) == 1:
+		return "", errors.New("--all cleans up every session; don't name one too")
+	case all:
+		return "", nil
+	case len(args) == 1:
+		return args[0], nil
+	}
+	if session := current(); session != "" {
+		return session, nil
+	}
+	return "", errors.New("name a session, or use --all for the whole server")
+}
+
+// currentTmuxSession names the session of the client cleanup runs in, or
+// is empty outside tmux.
+func currentTmuxSession() string {
+	if os.Getenv("TMUX") == "" {
+		return ""
+	}
+	out, err := tmuxOutput("display-message", "-p", "#{session_name}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// cleanupBirdPanes lists the panes marked as injected birds in every window
+// of session, or of the whole server when session is empty, with unmarked
+// also those running typing-bird. The pane cleanup itself runs in is left
+// out.
+func cleanupBirdPanes(session string, unmarked bool) ([]string, error) {
+	scope := []string{"-a"}
+	if session != "" {
+		if err := sessionExists(session); err != nil {
+			return nil, err
+		}
+		scope = []string{"-s", "-t", session}
+	}
+	args := append([]string{"list-panes"}, scope...)
+	out, err := tmuxOutput(append(args, "-F", "#{pane_id}\t#{@typing_bird_injected}\t#{pane_current_command}")...)
+	if err != nil {
+		return nil, err
+	}
+	commandName := "typing-bird"
+	if exe, err := birdExecutable(); err == nil {
+		commandName = filepath.Base(exe)
+	}
+	return parseCleanupPanes(string(out), commandName, strings.TrimSpace(os.Getenv("TMUX_PANE")), unmarked), nil
+}
+
+// parseCleanupPanes picks from list-panes lines of "id\tmarked\tcommand"
+// every marked pane, whatever it shows running (a bird started through
+// sh -c or env does not show as typing-bird), and with unmarked also the
+// panes running commandName. self is never picked.
+func parseCleanupPanes(raw, commandName, self string, unmarked bool) []string {
+	var panes []string
+	for _, line := range strings.Split(raw, "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		pane, command := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[2])
+		if pane == "" || pane == self || slices.Contains(panes, pane) {
+			continue
+		}
+		marked := strings.TrimSpace(parts[1]) == "1"
+		if marked || (unmarked && (command == commandName || command == "typing-bird")) {
+			panes = append(panes, pane)
+		}
+	}
+	return panes
+}
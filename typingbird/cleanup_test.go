@@ -0,0 +1,62 @@
+package typingbird
+
+import (
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestCleanupSession(t *testing.T) {
+	inside := func() string { return "agent" }
+	outside := func() string { return "" }
+	tests := []struct {
+		all     bool
+		args    []string
+		current func() string
+		want    string
+		ok      bool
+	}{
+		{false, []string{"s"}, inside, "s", true},
+		{false, nil, inside, "agent", true},
+		{false, nil, outside, "", false},
+		{true, nil, inside, "", true},
+		{true, []string{"s"}, inside, "", false},
+		{false, []string{"s", "t"}, inside, "", false},
+	}
+	for _, tt := range tests {
+		got, err := cleanupSession(tt.all, tt.args, tt.current)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Fatalf("cleanupSession(%v, %q) = %q, %v; want %q, ok %v", tt.all, tt.args, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRunCleanupRejectsArguments(t *testing.T) {
+	t.Setenv("TMUX", "")
+	for _, args := range [][]string{
+		{"--all", "s"},
+		{"s", "t"},
+		{"--grace", "-1s", "s"},
+		{"--grace", "soon", "s"},
+		{},
+	} {
+		if code := runCleanup(args, io.Discard, io.Discard); code != 2 {
+			t.Fatalf("runCleanup(%q) = %d; want 2", args, code)
+		}
+	}
+}
+
+func TestParseCleanupPanes(t *testing.T) {
+	raw := "%1\t\tbash\n" +
+		"%2\t1\ttyping-bird\n" +
+		"%3\t1\tsh\n" +
+		"%4\t\ttyping-bird\n" +
+		"%5\t1\tenv\n" +
+		"%6\t\tbird-test\n"
+	if got, want := parseCleanupPanes(raw, "bird-test", "%5", false), []string{"%2", "%3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseCleanupPanes(marked only) = %q; want %q", got, want)
+	}
+	if got, want := parseCleanupPanes(raw, "bird-test", "", true), []string{"%2", "%3", "%4", "%5", "%6"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseCleanupPanes(unmarked) = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,73 @@
+package typingbird
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// clipboardMessage is the message that types whatever is on the clipboard
+// when it is sent.
+const clipboardMessage = "@clipboard"
+
+// clipboardCommands lists the commands that print the clipboard on goos, in
+// the order they are tried. The tmux paste buffer is the last resort.
+func clipboardCommands(goos string, getenv func(string) string) [][]string {
+	var commands [][]string
+	switch goos {
+	case "darwin":
+		commands = append(commands, []string{"pbpaste"})
+	case "windows":
+		commands = append(commands, []string{"powershell.exe", "-NoProfile", "-Command", "Get-Clipboard -Raw"})
+	default:
+		if getenv("WAYLAND_DISPLAY") != "" {
+			commands = append(commands, []string{"wl-paste", "--no-newline"})
+		}
+		if getenv("DISPLAY") != "" {
+			commands = append(commands, []string{"xclip", "-selection", "clipboard", "-o"}, []string{"xsel", "--clipboard", "--output"})
+		}
+	}
+	return commands
+}
+
+// readClipboard returns the clipboard's contents without trailing line
+// breaks, which would press Enter a second time.
+func readClipboard() (string, error) {
+	var failures []string
+	for _, command := range clipboardCommands(runtime.GOOS, os.Getenv) {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+		out, err := exec.Command(command[0], command[1:]...).Output()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", command[0], err))
+			continue
+		}
+		return clipboardText(out)
+	}
+	out, err := tmuxOutput("show-buffer")
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("tmux has no paste buffer (%v)", err))
+		return "", fmt.Errorf("reading clipboard: %s", strings.Join(failures, "; "))
+	}
+	return clipboardText(out)
+}
+
+func clipboardText(out []byte) (string, error) {
+	text := strings.TrimRight(string(out), "\r\n")
+	if text == "" {
+		return "", fmt.Errorf("clipboard is empty")
+	}
+	return text, nil
+}
+
+// expandClipboard replaces the @clipboard message with the clipboard's
+// contents; other messages are returned as they are.
+func expandClipboard(message string) (string, error) {
+	if strings.TrimSpace(message) != clipboardMessage {
+		return message, nil
+	}
+	return readClipboard()
+}
@@ -0,0 +1,50 @@
+package typingbird
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClipboardCommands(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(name string) string { return vars[name] }
+	}
+	tests := []struct {
+		goos string
+		env  map[string]string
+		want []string
+	}{
+		{goos: "darwin", want: []string{"pbpaste"}},
+		{goos: "windows", want: []string{"powershell.exe"}},
+		{goos: "linux", env: map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, want: []string{"wl-paste", "xclip", "xsel"}},
+		{goos: "linux", env: map[string]string{"DISPLAY": ":0"}, want: []string{"xclip", "xsel"}},
+		{goos: "linux"},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, command := range clipboardCommands(tt.goos, env(tt.env)) {
+			got = append(got, command[0])
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("clipboardCommands(%q, %v) = %q; want %q", tt.goos, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestClipboardText(t *testing.T) {
+	got, err := clipboardText([]byte("SELECT 1;\nSELECT 2;\r\n\n"))
+	if want := "SELECT 1;\nSELECT 2;"; err != nil || got != want {
+		t.Fatalf("clipboardText(...) = %q, %v; want %q", got, err, want)
+	}
+	if _, err := clipboardText([]byte("\n")); err == nil {
+		t.Fatalf("clipboardText(\"\\n\") = nil error; want error")
+	}
+}
+
+func TestExpandClipboardLeavesOtherMessages(t *testing.T) {
+	for _, message := range []string{"continue", "@clipboard please", "@clipboards"} {
+		if got, err := expandClipboard(message); err != nil || got != message {
+			t.Fatalf("expandClipboard(%q) = %q, %v; want it unchanged", message, got, err)
+		}
+	}
+}
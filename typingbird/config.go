@@ -0,0 +1,279 @@
+package typingbird
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configKeySession  = "session"
+	configKeyMessages = "messages"
+	configKeyProfiles = "profiles"
+	configKeyFlow     = "flow"
+)
+
+// configShortFlags maps short flag aliases to the long names used as config
+// keys.
+var configShortFlags = map[string]string{
+	"t":  "timeout",
+	"d":  "delay",
+	"v":  "verbose",
+	"vv": "trace",
+	"i":  "inject",
+}
+
+// configIgnoredFlags cannot be set from a config file.
+var configIgnoredFlags = map[string]bool{
+	"config":      true,
+	"profile":     true,
+	"target-pane": true,
+}
+
+// configInjectFlags only concern the process that injects the bird. The
+// injected bird is handed the same config along with --target-pane and
+// leaves them alone rather than trying to inject itself again.
+var configInjectFlags = []string{"inject", "inject-window", "inject-popup", "respawn"}
+
+// configFile is the YAML config. Top-level keys are defaults and each entry
+// under profiles overrides them. Keys are long flag names, plus session and
+// messages, or a flow (see messageFlow) in place of messages:
+//
+//	timeout: 1m
+//	profiles:
+//	  work-agent:
+//	    session: agent
+//	    idle-mode: pipe
+//	    messages: ["continue", "keep going"]
+type configFile struct {
+	defaults map[string]any
+	profiles map[string]map[string]any
+}
+
+// resolvedConfig is the flattened view of the defaults plus the selected
+// profile.
+type resolvedConfig struct {
+	settings map[string]string
+	session  string
+	messages []string
+	// delays holds per-message delay overrides aligned with messages; nil
+	// when no entry sets one.
+	delays []string
+	// flow replaces messages; setting either clears the other so a
+	// profile can switch between them.
+	flow *messageFlow
+}
+
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "typing-bird", "config.yaml")
+}
+
+// loadConfigFile reads path. A missing file is only an error when required
+// is set, i.e. the user named the file or asked for a profile.
+func loadConfigFile(path string, required bool) (*configFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	cfg, err := parseConfig(raw)
+	if err != nil {
+		return nil, fmt.Errorf("config %q: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func parseConfig(raw []byte) (*configFile, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	cfg := &configFile{defaults: doc, profiles: map[string]map[string]any{}}
+	if cfg.defaults == nil {
+		cfg.defaults = map[string]any{}
+	}
+	if rawProfiles, ok := cfg.defaults[configKeyProfiles]; ok {
+		delete(cfg.defaults, configKeyProfiles)
+		profiles, ok := rawProfiles.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("profiles must be a mapping of name to settings")
+		}
+		for name, rawProfile := range profiles {
+			if rawProfile == nil {
+				cfg.profiles[name] = map[string]any{}
+				continue
+			}
+			profile, ok := rawProfile.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("profile %q must be a mapping of settings", name)
+			}
+			cfg.profiles[name] = profile
+		}
+	}
+	return cfg, nil
+}
+
+// resolve merges the top-level defaults with the named profile.
+func (c *configFile) resolve(profile string) (resolvedConfig, error) {
+	resolved := resolvedConfig{settings: map[string]string{}}
+	if err := resolved.merge(c.defaults); err != nil {
+		return resolvedConfig{}, err
+	}
+	if profile == "" {
+		return resolved, nil
+	}
+	settings, ok := c.profiles[profile]
+	if !ok {
+		return resolvedConfig{}, fmt.Errorf("unknown profile %q (available: %s)", profile, strings.Join(c.profileNames(), ", "))
+	}
+	if err := resolved.merge(settings); err != nil {
+		return resolvedConfig{}, fmt.Errorf("profile %q: %w", profile, err)
+	}
+	return resolved, nil
+}
+
+func (c *configFile) profileNames() []string {
+	names := make([]string, 0, len(c.profiles))
+	for name := range c.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *resolvedConfig) merge(settings map[string]any) error {
+	for key, value := range settings {
+		switch key {
+		case configKeySession:
+			r.session = fmt.Sprint(value)
+		case configKeyMessages:
+			messages, delays, err := configMessages(value)
+			if err != nil {
+				return fmt.Errorf("messages: %w", err)
+			}
+			r.messages, r.delays = messages, delays
+			r.flow = nil
+		case configKeyFlow:
+			flow, err := parseFlow(value)
+			if err != nil {
+				return fmt.Errorf("flow: %w", err)
+			}
+			r.flow = flow
+			r.messages, r.delays = nil, nil
+		default:
+			if values, ok := value.([]any); ok {
+				parts, err := configStrings(values)
+				if err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+				r.settings[key] = strings.Join(parts, ",")
+				continue
+			}
+			r.settings[key] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+// configMessages reads the messages list, where an entry is either the
+// message itself or a mapping with its text (or macro steps, see
+// configMacro) and a delay override:
+//
+//	messages:
+//	  - continue
+//	  - {text: "hunter2", delay: 200ms}
+func configMessages(value any) (messages, delays []string, err error) {
+	list, ok := value.([]any)
+	if !ok {
+		messages, err = configStrings(value)
+		return messages, nil, err
+	}
+	for i, item := range list {
+		entry, ok := item.(map[string]any)
+		if !ok {
+			one, err := configStrings([]any{item})
+			if err != nil {
+				return nil, nil, err
+			}
+			messages = append(messages, one[0])
+			continue
+		}
+		text, ok := entry["text"]
+		if steps, isMacro := entry["steps"]; isMacro {
+			stepList, ok := steps.([]any)
+			if !ok || text != nil {
+				return nil, nil, fmt.Errorf("entry %d: steps must be a list and replaces text", i+1)
+			}
+			if text, err = configMacro(stepList); err != nil {
+				return nil, nil, fmt.Errorf("entry %d: %w", i+1, err)
+			}
+		} else if !ok || text == nil {
+			return nil, nil, fmt.Errorf("entry %d needs a text", i+1)
+		}
+		for key := range entry {
+			if key != "text" && key != "steps" && key != "delay" {
+				return nil, nil, fmt.Errorf("entry %d: unknown key %q", i+1, key)
+			}
+		}
+		if delays == nil {
+			delays = make([]string, len(list))
+		}
+		messages = append(messages, fmt.Sprint(text))
+		if d, ok := entry["delay"]; ok && d != nil {
+			delays[i] = fmt.Sprint(d)
+		}
+	}
+	return messages, delays, nil
+}
+
+func configStrings(value any) ([]string, error) {
+	switch v := value.(type) {
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case []any, map[string]any:
+				return nil, fmt.Errorf("list entries must be scalars")
+			}
+			out = append(out, fmt.Sprint(item))
+		}
+		return out, nil
+	case string:
+		return []string{v}, nil
+	}
+	return nil, fmt.Errorf("expected a list, got %T", value)
+}
+
+// applyConfigSettings sets every configured flag whose long name is not in
+// explicit, so command-line flags always win over the config.
+func applyConfigSettings(fs *flag.FlagSet, explicit map[string]bool, settings map[string]string) error {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if configIgnoredFlags[key] || fs.Lookup(key) == nil {
+			return fmt.Errorf("unknown config setting %q", key)
+		}
+		if explicit[longFlagName(key)] {
+			continue
+		}
+		if err := fs.Set(key, settings[key]); err != nil {
+			return fmt.Errorf("config setting %q: %w", key, err)
+		}
+	}
+	return nil
+}
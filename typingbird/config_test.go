@@ -0,0 +1,169 @@
+package typingbird
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testConfig = `
+timeout: 45s
+idle-mode: capture
+messages:
+  - default message
+profiles:
+  work-agent:
+    session: agent
+    timeout: 2m
+    idle-mode: pipe
+    weights: [3, 1]
+    messages:
+      - continue
+      - keep going
+  empty:
+`
+
+func TestConfigResolveProfileOverridesDefaults(t *testing.T) {
+	cfg, err := parseConfig([]byte(testConfig))
+	if err != nil {
+		t.Fatalf("parseConfig(...) error: %v", err)
+	}
+	got, err := cfg.resolve("work-agent")
+	if err != nil {
+		t.Fatalf("resolve(...) error: %v", err)
+	}
+	want := resolvedConfig{
+		settings: map[string]string{"timeout": "2m", "idle-mode": "pipe", "weights": "3,1"},
+		session:  "agent",
+		messages: []string{"continue", "keep going"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("resolve(...) = %#v; want %#v", got, want)
+	}
+}
+
+func TestConfigResolveWithoutProfileUsesDefaults(t *testing.T) {
+	cfg, err := parseConfig([]byte(testConfig))
+	if err != nil {
+		t.Fatalf("parseConfig(...) error: %v", err)
+	}
+	got, err := cfg.resolve("")
+	if err != nil {
+		t.Fatalf("resolve(...) error: %v", err)
+	}
+	if got.settings["timeout"] != "45s" || !reflect.DeepEqual(got.messages, []string{"default message"}) {
+		t.Fatalf("resolve(\"\") = %#v; want top-level defaults", got)
+	}
+	if _, err := cfg.resolve("missing"); err == nil {
+		t.Fatalf("resolve(%q) = nil error; want unknown profile error", "missing")
+	}
+}
+
+func TestApplyConfigSettingsKeepsExplicitFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	timeout := fs.String("t", "30s", "")
+	fs.StringVar(timeout, "timeout", "30s", "")
+	mode := fs.String("idle-mode", "capture", "")
+	if err := fs.Parse([]string{"-t", "10s"}); err != nil {
+		t.Fatalf("Parse(...) error: %v", err)
+	}
+	explicit := explicitFlags(fs)
+	err := applyConfigSettings(fs, explicit, map[string]string{"timeout": "2m", "idle-mode": "pipe"})
+	if err != nil {
+		t.Fatalf("applyConfigSettings(...) error: %v", err)
+	}
+	if *timeout != "10s" || *mode != "pipe" {
+		t.Fatalf("timeout=%q idle-mode=%q; want 10s and pipe", *timeout, *mode)
+	}
+	if err := applyConfigSettings(fs, explicit, map[string]string{"bogus": "1"}); err == nil {
+		t.Fatalf("applyConfigSettings(bogus) = nil error; want error")
+	}
+}
+
+func TestConfigMessagesWithDelays(t *testing.T) {
+	cfg, err := parseConfig([]byte("messages:\n  - continue\n  - {text: hunter2, delay: 200ms}\n"))
+	if err != nil {
+		t.Fatalf("parseConfig(...) error: %v", err)
+	}
+	got, err := cfg.resolve("")
+	if err != nil {
+		t.Fatalf("resolve(\"\") error: %v", err)
+	}
+	if want := []string{"continue", "hunter2"}; !reflect.DeepEqual(got.messages, want) {
+		t.Fatalf("resolve(\"\").messages = %#v; want %#v", got.messages, want)
+	}
+	if want := []string{"", "200ms"}; !reflect.DeepEqual(got.delays, want) {
+		t.Fatalf("resolve(\"\").delays = %#v; want %#v", got.delays, want)
+	}
+	cfg, err = parseConfig([]byte("messages:\n  - {delay: 1s}\n"))
+	if err != nil {
+		t.Fatalf("parseConfig(...) error: %v", err)
+	}
+	if _, err := cfg.resolve(""); err == nil {
+		t.Fatalf("resolve(\"\") with an entry missing text = nil error; want error")
+	}
+}
+
+func TestConfigMessagesWithSteps(t *testing.T) {
+	cfg, err := parseConfig([]byte("messages:\n  - steps: [/model, {key: Down}, {sleep: 200ms}, {key: Enter}]\n    delay: 50ms\n"))
+	if err != nil {
+		t.Fatalf("parseConfig(...) error: %v", err)
+	}
+	got, err := cfg.resolve("")
+	if err != nil {
+		t.Fatalf("resolve(\"\") error: %v", err)
+	}
+	if want := []string{`/model{{key "Down"}}{{sleep "200ms"}}{{key "Enter"}}`}; !reflect.DeepEqual(got.messages, want) {
+		t.Fatalf("resolve(\"\").messages = %#v; want %#v", got.messages, want)
+	}
+	if want := []string{"50ms"}; !reflect.DeepEqual(got.delays, want) {
+		t.Fatalf("resolve(\"\").delays = %#v; want %#v", got.delays, want)
+	}
+}
+
+func TestInjectedBirdIgnoresConfigInjectFlags(t *testing.T) {
+	configs := []string{
+		"inject: true\n",
+		"i: true\n",
+		"inject: true\ninject-window: true\n",
+		"inject: true\ninject-popup: background\n",
+		"inject: true\nrespawn: true\n",
+	}
+	for _, raw := range configs {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(raw+"session: s\nmessages: [hi]\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		parent, err := parseTestOptions("--config", path)
+		if err != nil {
+			t.Fatalf("config %q: options() error: %v", raw, err)
+		}
+		args := buildChildArgs(parent, "%3")
+		child, err := parseTestOptions(args...)
+		if err != nil {
+			t.Fatalf("config %q: injected bird %q: options() error: %v", raw, args, err)
+		}
+		if child.session != "s" || child.respawn {
+			t.Fatalf("config %q: injected bird options = session %q, respawn %v; want s, false", raw, child.session, child.respawn)
+		}
+	}
+}
+
+// parseTestOptions resolves options from a command line the way run does.
+func parseTestOptions(args ...string) (options, error) {
+	cli := newCLIFlags()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	cli.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return options{}, err
+	}
+	config, loaded, err := cli.loadConfig(fs, explicitFlags(fs))
+	if err != nil {
+		return options{}, err
+	}
+	return cli.options(fs.Args(), config, loaded)
+}
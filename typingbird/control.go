@@ -0,0 +1,210 @@
+package typingbird
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// controlResponse is written back as a single JSON line per command.
+type controlResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	Data  any    `json:"data,omitempty"`
+}
+
+// controlHandler executes one command line split into its command name and
+// arguments.
+type controlHandler func(command string, args []string) controlResponse
+
+// controlServer accepts newline-delimited commands on a unix socket, e.g.
+// `echo reload | nc -U /path/to.sock`.
+type controlServer struct {
+	path   string
+	ln     net.Listener
+	handle controlHandler
+	done   chan struct{}
+}
+
+func startControlServer(path string, handle controlHandler) (*controlServer, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	s := &controlServer{path: path, ln: ln, handle: handle, done: make(chan struct{})}
+	go s.serve()
+	return s, nil
+}
+
+// removeStaleSocket deletes a leftover socket file nobody is listening on
+// and refuses to touch one that is still in use.
+func removeStaleSocket(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("control socket %q is already in use", path)
+	}
+	return os.Remove(path)
+}
+
+func (s *controlServer) serve() {
+	defer close(s.done)
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				debugf("control socket accept failed: %v", err)
+			}
+			return
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *controlServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		// Arguments may carry message text, so only the command is logged.
+		debugf("control command: %s (%d args)", fields[0], len(fields)-1)
+		resp := s.handle(fields[0], fields[1:])
+		if stream, ok := resp.Data.(eventStream); ok {
+			streamEvents(conn, enc, stream)
+			return
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// eventStream is the reply to watch: the connection carries one JSON line
+// per event from then on, limited to one bird when bird is set.
+type eventStream struct {
+	hub  *eventHub
+	bird string
+}
+
+// streamEvents acknowledges a watch and writes events until the client
+// hangs up.
+func streamEvents(conn net.Conn, enc *json.Encoder, stream eventStream) {
+	events, cancel := stream.hub.subscribe()
+	defer cancel()
+	if err := enc.Encode(controlOK(nil)); err != nil {
+		return
+	}
+	gone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+		close(gone)
+	}()
+	for {
+		select {
+		case ev := <-events:
+			if stream.bird != "" && ev.Bird != stream.bird {
+				continue
+			}
+			if err := enc.Encode(ev); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+// Close stops accepting commands and removes the socket file.
+func (s *controlServer) Close() error {
+	err := s.ln.Close()
+	<-s.done
+	_ = os.Remove(s.path)
+	return err
+}
+
+func controlOK(data any) controlResponse {
+	return controlResponse{OK: true, Data: data}
+}
+
+func controlError(err error) controlResponse {
+	return controlResponse{Error: err.Error()}
+}
+
+// startDefaultControlServer listens on <defaultSocketDir>/<pid>.sock.
+func startDefaultControlServer(handle controlHandler) (*controlServer, error) {
+	dir := defaultSocketDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return startControlServer(filepath.Join(dir, strconv.Itoa(os.Getpid())+".sock"), handle)
+}
+
+// defaultSocketDir is where birds started without --control-socket listen,
+// one <pid>.sock each, so `typing-bird web` can find them.
+func defaultSocketDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "typing-bird")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("typing-bird-%d", os.Getuid()))
+}
+
+// discoverSockets lists the control sockets registered in dir.
+func discoverSockets(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.sock"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// controlReply is a decoded controlResponse with its data left raw.
+type controlReply struct {
+	OK    bool            `json:"ok"`
+	Error string          `json:"error,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+// controlCall sends one command line to the control socket at path.
+func controlCall(path, command string, timeout time.Duration) (controlReply, error) {
+	conn, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		return controlReply{}, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := fmt.Fprintln(conn, command); err != nil {
+		return controlReply{}, err
+	}
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return controlReply{}, err
+	}
+	var reply controlReply
+	if err := json.Unmarshal(line, &reply); err != nil {
+		return controlReply{}, fmt.Errorf("decoding control reply: %w", err)
+	}
+	return reply, nil
+}
@@ -0,0 +1,60 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestControlServerAnswersCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bird.sock")
+	server, err := startControlServer(path, func(command string, args []string) controlResponse {
+		if command == "echo" {
+			return controlOK(args)
+		}
+		return controlError(fmt.Errorf("unknown command %q", command))
+	})
+	if err != nil {
+		t.Fatalf("startControlServer(...) error: %v", err)
+	}
+	defer server.Close()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial control socket: %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	fmt.Fprintln(conn, "echo a b")
+	var resp struct {
+		OK   bool     `json:"ok"`
+		Data []string `json:"data"`
+	}
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+	if err := json.Unmarshal(line, &resp); err != nil || !resp.OK || len(resp.Data) != 2 {
+		t.Fatalf("echo response = %s (err %v); want ok with 2 args", line, err)
+	}
+
+	fmt.Fprintln(conn, "bogus")
+	line, err = reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+	var failed controlResponse
+	if err := json.Unmarshal(line, &failed); err != nil || failed.OK || failed.Error == "" {
+		t.Fatalf("bogus response = %s (err %v); want error", line, err)
+	}
+
+	if _, err := startControlServer(path, nil); err == nil {
+		t.Fatalf("startControlServer on an in-use socket = nil error; want error")
+	}
+}
@@ -0,0 +1,42 @@
+package typingbird
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// encryptedFile reports whether path names an age or gpg encrypted file.
+func encryptedFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".age", ".gpg", ".pgp", ".asc":
+		return true
+	}
+	return false
+}
+
+// decryptFile decrypts an age (.age) or gpg encrypted file in memory with
+// the respective command-line tool. age reads its identity from
+// TYPING_BIRD_AGE_IDENTITY; gpg relies on the running agent.
+func decryptFile(path string) ([]byte, error) {
+	var cmd *exec.Cmd
+	if strings.ToLower(filepath.Ext(path)) == ".age" {
+		identity := strings.TrimSpace(os.Getenv("TYPING_BIRD_AGE_IDENTITY"))
+		if identity == "" {
+			return nil, fmt.Errorf("decrypting %q: TYPING_BIRD_AGE_IDENTITY is not set", path)
+		}
+		cmd = exec.Command("age", "--decrypt", "-i", identity, path)
+	} else {
+		cmd = exec.Command("gpg", "--batch", "--quiet", "--decrypt", path)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %q: %v: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,116 @@
+package typingbird
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// runCtl implements `typing-bird ctl SOCKET COMMAND [ARGS...]`, a client
+// for the control socket that needs no nc. With --session the command goes
+// to every bird sending to that session instead, found through the sockets
+// in --socket-dir.
+func runCtl(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("ctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	timeout := fs.Duration("timeout", 5*time.Second, "how long to wait for the bird to answer")
+	session := fs.String("session", "", "address the birds sending to this session instead of naming a socket")
+	socketDir := fs.String("socket-dir", defaultSocketDir(), "directory of bird control sockets searched by --session")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird ctl [--timeout 5s] control-socket command [args ...]")
+		fmt.Fprintln(stderr, "       typing-bird ctl [--timeout 5s] --session name command [args ...]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Sends one control command (status, reload, pause, resume, send, dead-letters")
+		fmt.Fprintln(stderr, "...) and prints the reply data. `state import` reads the state from a file,")
+		fmt.Fprintln(stderr, "or standard input for -.")
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	command, err := stateImportArgs(fs.Args(), os.Stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if *session != "" {
+		if fs.NArg() < 1 {
+			fs.Usage()
+			return 2
+		}
+		return ctlSession(*socketDir, *session, command, *timeout, stdout, stderr)
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return 2
+	}
+	return ctlCall(command[0], command[1:], *timeout, stdout, stderr)
+}
+
+func ctlCall(socket string, command []string, timeout time.Duration, stdout, stderr io.Writer) int {
+	reply, err := controlCall(socket, strings.Join(command, " "), timeout)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if !reply.OK {
+		fmt.Fprintf(stderr, "ERROR: %s\n", reply.Error)
+		return 1
+	}
+	if len(reply.Data) > 0 {
+		fmt.Fprintln(stdout, string(reply.Data))
+	}
+	return 0
+}
+
+// ctlSession sends command to each bird in socketDir whose session is
+// session. Birds in a fleet get their name inserted after the command.
+func ctlSession(socketDir, session string, command []string, timeout time.Duration, stdout, stderr io.Writer) int {
+	birds, err := findSessionBirds(socketDir, session, timeout)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if len(birds) == 0 {
+		fmt.Fprintf(stderr, "ERROR: no bird is sending to session %q\n", session)
+		return 1
+	}
+	code := 0
+	for _, wb := range birds {
+		line := command
+		if wb.Name != "" {
+			line = append([]string{command[0], wb.Name}, command[1:]...)
+		}
+		code = max(code, ctlCall(wb.Socket, line, timeout, stdout, stderr))
+	}
+	return code
+}
+
+// findSessionBirds lists the birds in socketDir sending to session.
+func findSessionBirds(socketDir, session string, timeout time.Duration) ([]webBird, error) {
+	sockets, err := discoverSockets(socketDir)
+	if err != nil {
+		return nil, err
+	}
+	var birds []webBird
+	for _, socket := range sockets {
+		reply, err := controlCall(socket, "status", timeout)
+		if err != nil || !reply.OK {
+			continue
+		}
+		for _, wb := range splitFleetStatus(socket, reply.Data) {
+			var st birdStatus
+			if json.Unmarshal(wb.Status, &st) != nil || st.Session != session {
+				continue
+			}
+			birds = append(birds, wb)
+		}
+	}
+	return birds, nil
+}
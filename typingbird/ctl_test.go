@@ -0,0 +1,75 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRunCtl(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bird.sock")
+	server, err := startControlServer(path, func(command string, args []string) controlResponse {
+		if command == "fail" {
+			return controlResponse{Error: "nope"}
+		}
+		return controlOK(append([]string{command}, args...))
+	})
+	if err != nil {
+		t.Fatalf("startControlServer(...) error: %v", err)
+	}
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := runCtl([]string{path, "send", "hello", "world"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runCtl(send) = %d (stderr %q); want 0", code, stderr.String())
+	}
+	if got, want := strings.TrimSpace(stdout.String()), `["send","hello","world"]`; got != want {
+		t.Fatalf("runCtl(send) printed %s; want %s", got, want)
+	}
+	stderr.Reset()
+	if code := runCtl([]string{path, "fail"}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "nope") {
+		t.Fatalf("runCtl(fail) = %d, stderr %q; want 1 and the error", code, stderr.String())
+	}
+	if code := runCtl([]string{path}, &stdout, &stderr); code != 2 {
+		t.Fatalf("runCtl(no command) = %d; want 2", code)
+	}
+}
+
+func TestRunCtlSession(t *testing.T) {
+	dir := t.TempDir()
+	var got []string
+	serve := func(name, status string) {
+		server, err := startControlServer(filepath.Join(dir, name+".sock"), func(command string, args []string) controlResponse {
+			if command == "status" {
+				return controlResponse{OK: true, Data: json.RawMessage(status)}
+			}
+			got = append(got, name+": "+strings.Join(append([]string{command}, args...), " "))
+			return controlOK(nil)
+		})
+		if err != nil {
+			t.Fatalf("startControlServer(...) error: %v", err)
+		}
+		t.Cleanup(func() { _ = server.Close() })
+	}
+	serve("solo", `{"session": "agent"}`)
+	serve("other", `{"session": "work"}`)
+	serve("fleet", `[{"name": "a", "session": "agent"}, {"name": "b", "session": "work"}]`)
+
+	var stdout, stderr bytes.Buffer
+	if code := runCtl([]string{"--socket-dir", dir, "--session", "agent", "send", "hi"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runCtl(--session agent send) = %d (stderr %q); want 0", code, stderr.String())
+	}
+	sort.Strings(got)
+	if want := []string{"fleet: send a hi", "solo: send hi"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("runCtl(--session agent send) sent %q; want %q", got, want)
+	}
+	if code := runCtl([]string{"--socket-dir", dir, "--session", "none", "pause"}, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), `no bird is sending to session "none"`) {
+		t.Fatalf("runCtl(--session none) = %d, stderr %q; want 1 and no bird found", code, stderr.String())
+	}
+}
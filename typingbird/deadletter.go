@@ -0,0 +1,111 @@
+package typingbird
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// sendRetryDelay is the pause between attempts at a failing send.
+	sendRetryDelay = time.Second
+	// deadLetterKeep is how many dead letters each bird lists through the
+	// control API; the file keeps them all.
+	deadLetterKeep = 100
+)
+
+// deadLetter is one line of the --dead-letter file: a message that could
+// not be sent, with what went wrong.
+type deadLetter struct {
+	Time    time.Time `json:"time"`
+	Bird    string    `json:"bird,omitempty"`
+	Session string    `json:"session"`
+	Target  string    `json:"target"`
+	// Index is the 1-based rotation position; zero for requested and
+	// queued sends.
+	Index    int    `json:"index,omitempty"`
+	Message  string `json:"message"`
+	Error    string `json:"error"`
+	Attempts int    `json:"attempts"`
+}
+
+// deadLetterFile appends messages that failed to send to a JSON-lines file
+// so the bird can carry on instead of exiting.
+type deadLetterFile struct {
+	mu     sync.Mutex
+	f      *os.File
+	recent []deadLetter
+}
+
+func openDeadLetterFile(path string) (*deadLetterFile, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &deadLetterFile{f: f}, nil
+}
+
+// add appends d. Like the audit log, bodies follow --redact and resolved
+// secrets are masked.
+func (dl *deadLetterFile) add(d deadLetter) error {
+	d.Message = secretRedactor.redact(d.Message)
+	if redactingMessages() {
+		d.Message = logText(d.Message)
+	}
+	line, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	dl.recent = append(dl.recent, d)
+	if len(dl.recent) > deadLetterKeep {
+		dl.recent = dl.recent[len(dl.recent)-deadLetterKeep:]
+	}
+	if _, err := dl.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return dl.f.Sync()
+}
+
+// list returns the dead letters added by the bird called name, oldest
+// first.
+func (dl *deadLetterFile) list(name string) []deadLetter {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	letters := []deadLetter{}
+	for _, d := range dl.recent {
+		if d.Bird == name {
+			letters = append(letters, d)
+		}
+	}
+	return letters
+}
+
+func (dl *deadLetterFile) Close() error {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	return dl.f.Close()
+}
+
+// deadLetters lists the bird's recent dead letters; none without
+// --dead-letter.
+func (b *bird) deadLetters() []deadLetter {
+	if b.dead == nil {
+		return []deadLetter{}
+	}
+	return b.dead.list(b.name)
+}
+
+// addDeadLetter records a message the bird gave up on.
+func (b *bird) addDeadLetter(d deadLetter) {
+	d.Bird = b.name
+	if err := b.dead.add(d); err != nil {
+		b.logf("WARNING: writing dead-letter file: %v", err)
+		return
+	}
+	b.mu.Lock()
+	b.deadCount++
+	b.mu.Unlock()
+}
@@ -0,0 +1,70 @@
+package typingbird
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDeadLetterFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	dl, err := openDeadLetterFile(path)
+	if err != nil {
+		t.Fatalf("openDeadLetterFile(%q) error: %v", path, err)
+	}
+	a := &bird{name: "a", dead: dl}
+	b := &bird{name: "b", dead: dl}
+	a.addDeadLetter(deadLetter{Time: time.Now(), Session: "s", Target: "%1", Index: 2, Message: "m2", Error: "boom", Attempts: 3})
+	b.addDeadLetter(deadLetter{Time: time.Now(), Session: "s", Target: "%2", Message: "hi", Error: "gone", Attempts: 1})
+	if err := dl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := a.deadLetters(); len(got) != 1 || got[0].Message != "m2" || got[0].Bird != "a" {
+		t.Fatalf("deadLetters() for a = %+v; want m2 from a", got)
+	}
+	if a.deadCount != 1 {
+		t.Fatalf("deadCount = %d; want 1", a.deadCount)
+	}
+	if got := (&bird{}).deadLetters(); got == nil || len(got) != 0 {
+		t.Fatalf("deadLetters() without --dead-letter = %#v; want empty", got)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []deadLetter
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var d deadLetter
+		if err := json.Unmarshal(sc.Bytes(), &d); err != nil {
+			t.Fatalf("line %q is not JSON: %v", sc.Text(), err)
+		}
+		lines = append(lines, d)
+	}
+	if len(lines) != 2 || lines[0].Attempts != 3 || lines[1].Bird != "b" {
+		t.Fatalf("dead-letter file = %+v; want both entries", lines)
+	}
+}
+
+func TestDeadLetterKeep(t *testing.T) {
+	dl, err := openDeadLetterFile(filepath.Join(t.TempDir(), "dead.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dl.Close()
+	for i := 0; i < deadLetterKeep+5; i++ {
+		if err := dl.add(deadLetter{Index: i + 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := dl.list("")
+	if len(got) != deadLetterKeep || got[0].Index != 6 {
+		t.Fatalf("list() kept %d starting at %d; want %d starting at 6", len(got), got[0].Index, deadLetterKeep)
+	}
+}
@@ -0,0 +1,55 @@
+package typingbird
+
+import (
+	"context"
+	"time"
+)
+
+// IdleDetector decides when a pane is idle, for Runners that need
+// something other than watching the screen, such as asking the program in
+// the pane through its own API. WaitIdle returns nil once target (a pane
+// id such as "%3") is idle and ctx's error when ctx ends first; any other
+// error stops the Runner.
+type IdleDetector interface {
+	WaitIdle(ctx context.Context, target string) error
+}
+
+// IdleFunc adapts a function to IdleDetector.
+type IdleFunc func(ctx context.Context, target string) error
+
+// WaitIdle calls f.
+func (f IdleFunc) WaitIdle(ctx context.Context, target string) error {
+	return f(ctx, target)
+}
+
+// SamplingDetector is the detector typing-bird uses by default: it
+// captures the pane Samples times across Timeout and counts it idle once
+// the captures agree, as Strategy ("all-equal", "consecutive-stable",
+// "last-k-equal" or "adaptive") decides. Zero fields take the command's
+// defaults.
+type SamplingDetector struct {
+	Timeout  time.Duration
+	Samples  int
+	Strategy string
+	// K is the number of trailing samples compared by last-k-equal.
+	K int
+}
+
+// WaitIdle waits for target to stay unchanged as d describes.
+func (d SamplingDetector) WaitIdle(ctx context.Context, target string) error {
+	timeout, sampling := d.Timeout, idleSampling{samples: d.Samples, strategy: d.Strategy, k: d.K}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if sampling.samples <= 0 {
+		sampling.samples = defaultIdleSamples
+	}
+	if sampling.strategy == "" {
+		sampling.strategy = idleStrategyAllEqual
+	}
+	if sampling.k <= 0 {
+		sampling.k = defaultIdleK
+	}
+	_, err := waitForTargetIdle(ctx, target, sampling, timeout)
+	return err
+}
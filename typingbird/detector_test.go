@@ -0,0 +1,33 @@
+package typingbird
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestIdleFunc(t *testing.T) {
+	var got string
+	var d IdleDetector = IdleFunc(func(ctx context.Context, target string) error {
+		got = target
+		return nil
+	})
+	if err := d.WaitIdle(context.Background(), "%3"); err != nil || got != "%3" {
+		t.Fatalf("WaitIdle(%%3) = %v, target %q; want nil, %q", err, got, "%3")
+	}
+}
+
+func TestSamplingDetectorCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := (SamplingDetector{}).WaitIdle(ctx, "%3"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("WaitIdle() after cancel = %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestRunnerKeepsIdleDetector(t *testing.T) {
+	d := SamplingDetector{Samples: 5}
+	if r := New(WithIdleDetector(d)); r.detector != d {
+		t.Fatalf("New(WithIdleDetector(%v)).detector = %v; want %v", d, r.detector, d)
+	}
+}
@@ -0,0 +1,184 @@
+package typingbird
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	findingOK   = "ok"
+	findingWarn = "warn"
+	findingFail = "FAIL"
+
+	// slowCapture is the average capture time above which doctor warns:
+	// every idle sample costs that much, per bird.
+	slowCapture = 100 * time.Millisecond
+)
+
+// finding is one line of the doctor report, with what to do about it
+// unless it is ok.
+type finding struct {
+	level string
+	text  string
+	fix   string
+}
+
+func (f finding) String() string {
+	s := fmt.Sprintf("%-5s %s", f.level, f.text)
+	if f.fix != "" {
+		s += "\n      -> " + f.fix
+	}
+	return s
+}
+
+// runDoctor implements `typing-bird doctor [session]`: it checks what a
+// bird needs from tmux and the session and prints what to fix.
+func runDoctor(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	samples := fs.Int("samples", 5, "number of captures timed for the capture latency check")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird doctor [--samples N] [session]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Checks tmux and its version, the session and the pane a bird would type")
+		fmt.Fprintln(stderr, "into, whether pane options can be set and how long a capture takes, and")
+		fmt.Fprintln(stderr, "says what to do about anything wrong. The session defaults to the current")
+		fmt.Fprintln(stderr, "one inside tmux. Exits 1 when a check fails.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 1 || *samples < 1 {
+		fs.Usage()
+		return 2
+	}
+	code := 0
+	for _, f := range diagnose(fs.Arg(0), *samples) {
+		fmt.Fprintln(stdout, f)
+		if f.level == findingFail {
+			code = 1
+		}
+	}
+	return code
+}
+
+// diagnose runs the checks in order, stopping at a failure the later ones
+// depend on.
+func diagnose(session string, samples int) []finding {
+	if err := lookTmux(); err != nil {
+		return []finding{{findingFail, err.Error(), "install tmux, 3.2 or newer for every feature"}}
+	}
+	findings := []finding{tmuxVersionFinding(runningTmux())}
+	names, err := tmuxSessionNames()
+	if err != nil {
+		return append(findings, finding{findingFail, "no tmux server is running", "start one with `tmux new-session -s <name>`, or run the bird with --create"})
+	}
+	if session == "" && os.Getenv("TMUX") != "" {
+		if out, err := tmuxOutput("display-message", "-p", "#{session_name}"); err == nil {
+			session = strings.TrimSpace(string(out))
+		}
+	}
+	if session == "" {
+		return append(findings, finding{findingOK, fmt.Sprintf("tmux server has %d session(s): %s; name one to check it", len(names), strings.Join(names, ", ")), ""})
+	}
+	if err := sessionExists(session); err != nil {
+		return append(findings, finding{findingFail, fmt.Sprintf("no session %q", session), "sessions are: " + strings.Join(names, ", ")})
+	}
+	pane, err := preferredSendPane(session)
+	if err != nil {
+		return append(findings, finding{findingFail, fmt.Sprintf("session %q has no pane to type into: %v", session, err), "every pane runs a bird; open another with `tmux split-window`"})
+	}
+	command := ""
+	if out, err := tmuxOutput("display-message", "-p", "-t", pane, "#{pane_current_command}"); err == nil {
+		command = strings.TrimSpace(string(out))
+	}
+	findings = append(findings, finding{findingOK, fmt.Sprintf("session %q: a bird would type into pane %s (%s)", session, pane, command), ""})
+	if birds := injectedBirdPanes(session); len(birds) > 0 {
+		findings = append(findings, finding{findingOK, fmt.Sprintf("birds already running in pane(s) %s", strings.Join(birds, ", ")), ""})
+	}
+	findings = append(findings, paneOptionsFinding(pane))
+	return append(findings, captureFinding(pane, samples))
+}
+
+// tmuxVersionFinding reports the features the running tmux lacks.
+func tmuxVersionFinding(v tmuxVersion) finding {
+	if v == (tmuxVersion{}) {
+		return finding{findingWarn, "could not tell the tmux version; assuming a current one", "check `tmux -V`"}
+	}
+	var missing []string
+	for _, f := range []tmuxFeature{tmuxPaneOptions, tmuxMenus, tmuxSplitPercent, tmuxPopups} {
+		if !f.supportedBy(v) {
+			missing = append(missing, fmt.Sprintf("%s (%s)", f.name, f.since))
+		}
+	}
+	if len(missing) > 0 {
+		return finding{findingWarn, fmt.Sprintf("tmux %s lacks %s", v, strings.Join(missing, ", ")), "upgrade tmux to 3.2 or newer; the bird falls back where it can"}
+	}
+	return finding{findingOK, fmt.Sprintf("tmux %s supports every feature", v), ""}
+}
+
+// injectedBirdPanes lists the panes of session marked as running a bird.
+func injectedBirdPanes(session string) []string {
+	out, err := tmuxOutput("list-panes", "-s", "-t", session, "-F", "#{pane_id}\t#{@typing_bird_injected}")
+	if err != nil {
+		return nil
+	}
+	var panes []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if id, mark, _ := strings.Cut(line, "\t"); mark == "1" {
+			panes = append(panes, id)
+		}
+	}
+	return panes
+}
+
+// paneOptionsFinding sets and clears a pane option on pane, as --inject
+// and --border-status do.
+func paneOptionsFinding(pane string) finding {
+	if !tmuxPaneOptions.supportedBy(runningTmux()) {
+		return finding{findingWarn, "pane options are not available", "--inject finds earlier birds by command name only and --border-status is off; upgrade tmux to 3.0 or newer"}
+	}
+	err := tmuxBatch(
+		[]string{"set-option", "-p", "-t", pane, "@typing_bird_doctor", "1"},
+		[]string{"set-option", "-pu", "-t", pane, "@typing_bird_doctor"},
+	)
+	if err != nil {
+		return finding{findingFail, fmt.Sprintf("cannot set pane options on %s: %v", pane, err), "--inject and --border-status need this; check that the tmux server belongs to you"}
+	}
+	return finding{findingOK, "pane options can be set", ""}
+}
+
+// captureFinding times samples captures of pane.
+func captureFinding(pane string, samples int) finding {
+	var total, slowest time.Duration
+	size := 0
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		screen, err := captureTarget(pane)
+		took := time.Since(start)
+		if err != nil {
+			return finding{findingFail, fmt.Sprintf("capturing pane %s: %v", pane, err), "idle detection needs capture-pane; try --idle-mode pipe"}
+		}
+		total += took
+		slowest = max(slowest, took)
+		size = len(screen)
+	}
+	return captureLatencyFinding(total/time.Duration(samples), slowest, size)
+}
+
+func captureLatencyFinding(average, slowest time.Duration, size int) finding {
+	text := fmt.Sprintf("capturing the pane takes %s on average (slowest %s, %d bytes)", average.Round(time.Microsecond), slowest.Round(time.Microsecond), size)
+	if average > slowCapture {
+		return finding{findingWarn, text, "each idle sample costs this much; try --tmux-control, --idle-mode pipe or fewer --idle-samples"}
+	}
+	return finding{findingOK, text, ""}
+}
@@ -0,0 +1,50 @@
+package typingbird
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindingString(t *testing.T) {
+	cases := []struct {
+		f    finding
+		want string
+	}{
+		{finding{findingOK, "pane options can be set", ""}, "ok    pane options can be set"},
+		{finding{findingFail, "no session \"x\"", "sessions are: a"}, "FAIL  no session \"x\"\n      -> sessions are: a"},
+	}
+	for _, tc := range cases {
+		if got := tc.f.String(); got != tc.want {
+			t.Fatalf("String() = %q; want %q", got, tc.want)
+		}
+	}
+}
+
+func TestTmuxVersionFinding(t *testing.T) {
+	cases := []struct {
+		v     tmuxVersion
+		level string
+		text  string
+	}{
+		{tmuxVersion{3, 4}, findingOK, "tmux 3.4 supports every feature"},
+		{tmuxVersion{3, 1}, findingWarn, "tmux 3.1 lacks popups (display-popup) (3.2)"},
+		{tmuxVersion{2, 9}, findingWarn, "pane options (set-option -p) (3.0), menus"},
+		{tmuxVersion{}, findingWarn, "could not tell the tmux version"},
+	}
+	for _, tc := range cases {
+		got := tmuxVersionFinding(tc.v)
+		if got.level != tc.level || !strings.Contains(got.text, tc.text) {
+			t.Fatalf("tmuxVersionFinding(%s) = %q; want %s containing %q", tc.v, got, tc.level, tc.text)
+		}
+	}
+}
+
+func TestCaptureLatencyFinding(t *testing.T) {
+	if got := captureLatencyFinding(3*time.Millisecond, 5*time.Millisecond, 120); got.level != findingOK || got.text != "capturing the pane takes 3ms on average (slowest 5ms, 120 bytes)" {
+		t.Fatalf("captureLatencyFinding(3ms) = %q; want ok", got)
+	}
+	if got := captureLatencyFinding(250*time.Millisecond, time.Second, 120); got.level != findingWarn || got.fix == "" {
+		t.Fatalf("captureLatencyFinding(250ms) = %q; want a warning with a fix", got)
+	}
+}
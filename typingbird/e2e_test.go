@@ -0,0 +1,216 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// runFakeBird runs the typing-bird command line against the fake server.
+func runFakeBird(t *testing.T, f *fakeTmux, args ...string) (string, error) {
+	t.Helper()
+	cmd := exec.Command(f.exe, args...)
+	cmd.Env = append(os.Environ(), fakeBirdEnv+"=1")
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestEndToEndSendsWhenIdle(t *testing.T) {
+	f := newFakeTmux(t, "s")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	r := New(
+		WithSession("s"),
+		WithTimeout(200*time.Millisecond),
+		WithMessages("hello", "again"),
+		WithArgs("--no-loop", "--idle-samples", "2", "--no-pane-title"),
+	)
+	if err := r.Run(ctx); err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+	if got, want := f.pane("%0").Screen, "$ hello\n$ again\n$ "; got != want {
+		t.Fatalf("screen after run = %q; want %q", got, want)
+	}
+	if got := f.load().Options["s "+statusLineOption]; got != "" {
+		t.Fatalf("%s after run = %q; want it cleared", statusLineOption, got)
+	}
+}
+
+func TestEndToEndTargetPaneCloses(t *testing.T) {
+	f := newFakeTmux(t, "s")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- New(WithSession("s"), WithTimeout(2*time.Second), WithArgs("--idle-samples", "2", "--no-pane-title")).Run(ctx)
+	}()
+	time.Sleep(300 * time.Millisecond)
+	f.update(func(st *fakeTmuxState) { st.Panes = nil })
+	var exit *ExitError
+	if err := <-done; !errors.As(err, &exit) || exit.Code != 1 {
+		t.Fatalf("Run() after the pane closed = %v; want exit code 1", err)
+	}
+}
+
+func TestEndToEndInjectReplacesBird(t *testing.T) {
+	f := newFakeTmux(t, "s")
+	if out, err := runFakeBird(t, f, "--inject", "-t", "1m", "s", "hi"); err != nil {
+		t.Fatalf("first --inject: %v\n%s", err, out)
+	}
+	bird := f.pane("%1")
+	if bird.Command != filepath.Base(f.exe) || bird.Options["@typing_bird_injected"] != "1" || bird.Options["@typing_bird_send_target"] != "%0" {
+		t.Fatalf("injected pane = %+v; want the bird marked as sending to %%0", bird)
+	}
+
+	if out, err := runFakeBird(t, f, "--inject", "--restart-grace", "1s", "-t", "1m", "s", "hi"); err != nil {
+		t.Fatalf("second --inject: %v\n%s", err, out)
+	}
+	st := f.load()
+	if st.pane("%1") != nil {
+		t.Fatalf("old bird pane %%1 still open after re-injecting")
+	}
+	if p := st.pane("%2"); p == nil || p.Options["@typing_bird_injected"] != "1" {
+		t.Fatalf("new bird pane = %+v; want %%2 marked as a bird", p)
+	}
+	if sent := strings.Join(f.sent(), "\n"); !strings.Contains(sent, "-t %1 C-c") {
+		t.Fatalf("keys sent = %q; want Ctrl-C to the old bird", sent)
+	}
+}
+
+func TestEndToEndCleanup(t *testing.T) {
+	f := newFakeTmux(t, "s", "other")
+	f.update(func(st *fakeTmuxState) {
+		st.newPane("s", "0", "sleep", false).Options["@typing_bird_injected"] = "1"
+		st.newPane("s", "typing-bird", filepath.Base(f.exe), false)
+		st.newPane("other", "0", filepath.Base(f.exe), false)
+	})
+	if out, err := runFakeBird(t, f, "cleanup", "--grace", "1s", "s"); err != nil || !strings.Contains(out, "removed 1 bird pane(s): %2") {
+		t.Fatalf("cleanup s = %v\n%s; want the marked pane %%2 removed", err, out)
+	}
+	st := f.load()
+	if st.pane("%2") != nil || st.pane("%3") == nil || st.pane("%4") == nil || st.pane("%0") == nil {
+		t.Fatalf("panes after cleanup s = %+v; want only the marked bird of s gone", st.Panes)
+	}
+	if out, err := runFakeBird(t, f, "cleanup", "--grace", "1s", "--unmarked", "s"); err != nil || !strings.Contains(out, "removed 1 bird pane(s): %3") {
+		t.Fatalf("cleanup --unmarked s = %v\n%s; want pane %%3 removed", err, out)
+	}
+	if out, err := runFakeBird(t, f, "cleanup", "--grace", "1s", "--all", "--unmarked"); err != nil || !strings.Contains(out, "removed 1 bird pane(s): %4") {
+		t.Fatalf("cleanup --all --unmarked = %v\n%s; want pane %%4 removed", err, out)
+	}
+	if out, err := runFakeBird(t, f, "cleanup", "--all", "--unmarked"); err != nil || !strings.Contains(out, "no birds found") {
+		t.Fatalf("cleanup --all again = %v\n%s; want nothing to do", err, out)
+	}
+	if len(f.load().Panes) != 2 {
+		t.Fatalf("panes after cleanup --all = %+v; want the two shells", f.load().Panes)
+	}
+}
+
+func TestEndToEndSendGuards(t *testing.T) {
+	f := newFakeTmux(t, "s")
+	snippets := filepath.Join(t.TempDir(), "snippets.yaml")
+	if err := os.WriteFile(snippets, []byte("wipe: rm -rf /\nhi: hello\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runFakeBird(t, f, "send", "--snippets", snippets, "s", "wipe"); err == nil || !strings.Contains(out, "deny pattern") {
+		t.Fatalf("send wipe = %v\n%s; want it refused", err, out)
+	}
+	if out, err := runFakeBird(t, f, "send", "--snippets", snippets, "--deny-regex", "^hel", "s", "hi"); err == nil || !strings.Contains(out, "--deny-regex") {
+		t.Fatalf("send --deny-regex hi = %v\n%s; want it refused", err, out)
+	}
+	if sent := f.sent(); len(sent) != 0 {
+		t.Fatalf("send-keys after refused sends = %q; want none", sent)
+	}
+	if out, err := runFakeBird(t, f, "send", "--snippets", snippets, "--force", "s", "wipe"); err != nil {
+		t.Fatalf("send --force wipe = %v\n%s", err, out)
+	}
+	if got := f.pane("%0").Screen; !strings.Contains(got, "rm -rf /") {
+		t.Fatalf("screen after send --force = %q; want the message typed", got)
+	}
+
+	f.update(func(st *fakeTmuxState) { st.pane("%0").Screen = "[sudo] password for jay: " })
+	if out, err := runFakeBird(t, f, "send", "--snippets", snippets, "s", "hi"); err == nil || !strings.Contains(out, "password prompt") {
+		t.Fatalf("send at a password prompt = %v\n%s; want it refused", err, out)
+	}
+	if got := f.pane("%0").Screen; got != "[sudo] password for jay: " {
+		t.Fatalf("screen after refused send = %q; want nothing typed", got)
+	}
+	if out, err := runFakeBird(t, f, "send", "--snippets", snippets, "--password-guard=false", "s", "hi"); err != nil {
+		t.Fatalf("send --password-guard=false = %v\n%s", err, out)
+	}
+	if got := f.pane("%0").Screen; !strings.Contains(got, "hello") {
+		t.Fatalf("screen after send --password-guard=false = %q; want the message typed", got)
+	}
+}
+
+func TestEndToEndPaneLock(t *testing.T) {
+	f := newFakeTmux(t, "s")
+	// This test process stands in for a live bird holding the pane.
+	held := strconv.Itoa(os.Getpid()) + " 0123456789abcdef"
+	f.update(func(st *fakeTmuxState) { st.pane("%0").Options[paneLockOption] = held })
+	out, err := runFakeBird(t, f, "--send-immediately", "--no-loop", "--no-pane-title", "-t", "1m", "s", "hello")
+	if err == nil || !strings.Contains(out, "already typed into by the bird with pid") {
+		t.Fatalf("bird on a held pane = %v\n%s; want it refused", err, out)
+	}
+	if got := f.pane("%0").Screen; got != "$ " {
+		t.Fatalf("screen after refusal = %q; want nothing typed", got)
+	}
+	if out, err := runFakeBird(t, f, "--takeover", "--send-immediately", "--no-loop", "--no-pane-title", "-t", "1m", "s", "hello"); err != nil {
+		t.Fatalf("bird with --takeover = %v\n%s", err, out)
+	}
+	p := f.pane("%0")
+	if p.Screen != "$ hello\n$ " || p.Options[paneLockOption] != "" {
+		t.Fatalf("pane after --takeover = %+v; want the message typed and the lock released", p)
+	}
+}
+
+func TestEndToEndDrainsMessageOnShutdown(t *testing.T) {
+	f := newFakeTmux(t, "s")
+	state := filepath.Join(t.TempDir(), "state.json")
+	cmd := exec.Command(f.exe, "--send-immediately", "--wpm", "300", "--state-file", state, "--no-pane-title", "-t", "1m", "s", "a slow and careful message")
+	cmd.Env = append(os.Environ(), fakeBirdEnv+"=1")
+	// Signals go to the whole group, as a Ctrl-C at a terminal would.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	var out bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	// Every tmux command starts a process, which is slow under -race.
+	deadline := time.Now().Add(30 * time.Second)
+	for !strings.Contains(f.pane("%0").Screen, "$ a") {
+		if time.Now().After(deadline) {
+			_ = cmd.Process.Kill()
+			t.Fatalf("typing never started; screen %q", f.pane("%0").Screen)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for i := 0; i < 2; i++ {
+		if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGINT); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	var exit *exec.ExitError
+	if err := cmd.Wait(); !errors.As(err, &exit) || exit.ExitCode() != 130 {
+		t.Fatalf("bird exited with %v; want code 130\n%s", err, out.String())
+	}
+	if got, want := f.pane("%0").Screen, "$ a slow and careful message\n$ "; got != want {
+		t.Fatalf("screen after shutdown = %q; want %q\n%s", got, want, out.String())
+	}
+	if !strings.Contains(out.String(), "finished typing message 1/1 before exiting") {
+		t.Fatalf("log = %q; want the drain noted", out.String())
+	}
+	if _, err := os.Stat(state); err != nil {
+		t.Fatalf("state file after shutdown: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package typingbird
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	eventIdle     = "idle"
+	eventSent     = "sent"
+	eventSkipped  = "skipped"
+	eventPaused   = "paused"
+	eventResumed  = "resumed"
+	eventReloaded = "reloaded"
+	eventError    = "error"
+
+	// eventBuffer is how many events a slow subscriber may fall behind
+	// before further events are dropped for it.
+	eventBuffer = 64
+)
+
+// birdEvent is published to API watchers as the loop makes progress.
+type birdEvent struct {
+	Bird    string    `json:"bird,omitempty"`
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`
+	Index   int       `json:"index,omitempty"`
+	Total   int       `json:"total,omitempty"`
+	Message string    `json:"message,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// eventHub fans events out to subscribers without ever blocking the loop.
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[chan birdEvent]struct{}
+}
+
+// subscribe returns a channel of future events and a func that ends the
+// subscription and closes the channel.
+func (h *eventHub) subscribe() (<-chan birdEvent, func()) {
+	ch := make(chan birdEvent, eventBuffer)
+	h.mu.Lock()
+	if h.subs == nil {
+		h.subs = map[chan birdEvent]struct{}{}
+	}
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+func (h *eventHub) publish(ev birdEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
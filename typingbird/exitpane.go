@@ -0,0 +1,64 @@
+package typingbird
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// What an injected bird does with its pane when it exits, --exit-pane.
+const (
+	exitPaneKill        = "kill"
+	exitPaneKeep        = "keep"
+	exitPaneKeepOnError = "keep-on-error"
+)
+
+func validateExitPane(mode string) error {
+	switch mode {
+	case exitPaneKill, exitPaneKeep, exitPaneKeepOnError:
+		return nil
+	}
+	return fmt.Errorf("invalid exit-pane %q: must be %q, %q or %q", mode, exitPaneKill, exitPaneKeep, exitPaneKeepOnError)
+}
+
+// keepsPane reports whether mode leaves the pane to tmux after a bird
+// exiting with code. Ctrl-C and SIGTERM are not errors.
+func keepsPane(mode string, code int) bool {
+	switch mode {
+	case exitPaneKeep:
+		return true
+	case exitPaneKeepOnError:
+		return code != 0 && code != 130 && code != 143
+	}
+	return false
+}
+
+// leaveInjectedPane runs as an injected bird sending to sendTarget exits.
+// It clears the pane options that mark its pane as a bird and kills the
+// pane, so a session with remain-on-exit is not left with dead bird panes,
+// unless mode keeps it. Only a pane marked as this bird's is touched: a
+// bird started in the background by --inject-popup has none of its own.
+func leaveInjectedPane(sendTarget, mode string, code int) {
+	pane := strings.TrimSpace(os.Getenv("TMUX_PANE"))
+	if pane == "" || !tmuxPaneOptions.supportedBy(runningTmux()) {
+		return
+	}
+	out, err := tmuxOutput("display-message", "-p", "-t", pane, "#{@typing_bird_injected}\t#{@typing_bird_send_target}")
+	if err != nil || strings.TrimSpace(string(out)) != "1\t"+sendTarget {
+		return
+	}
+	err = tmuxBatch(
+		[]string{"set-option", "-pu", "-t", pane, "@typing_bird_injected"},
+		[]string{"set-option", "-pu", "-t", pane, "@typing_bird_send_target"},
+	)
+	if err != nil {
+		debugf("clearing the bird options of pane %q: %v", pane, err)
+	}
+	if keepsPane(mode, code) {
+		return
+	}
+	debugf("closing injected pane %q", pane)
+	if err := tmuxKillPane(pane); err != nil {
+		debugf("closing injected pane %q: %v", pane, err)
+	}
+}
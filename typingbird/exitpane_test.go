@@ -0,0 +1,29 @@
+package typingbird
+
+import "testing"
+
+func TestKeepsPane(t *testing.T) {
+	tests := []struct {
+		mode string
+		code int
+		want bool
+	}{
+		{exitPaneKill, 0, false},
+		{exitPaneKill, 1, false},
+		{exitPaneKeep, 0, true},
+		{exitPaneKeep, 130, true},
+		{exitPaneKeepOnError, 0, false},
+		{exitPaneKeepOnError, 130, false},
+		{exitPaneKeepOnError, 143, false},
+		{exitPaneKeepOnError, 1, true},
+		{exitPaneKeepOnError, 2, true},
+	}
+	for _, tt := range tests {
+		if got := keepsPane(tt.mode, tt.code); got != tt.want {
+			t.Fatalf("keepsPane(%q, %d) = %v; want %v", tt.mode, tt.code, got, tt.want)
+		}
+	}
+	if err := validateExitPane("close"); err == nil {
+		t.Fatalf("validateExitPane(%q) = nil; want an error", "close")
+	}
+}
@@ -0,0 +1,174 @@
+package typingbird
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// expectPoll is how often the pane is checked for an --expect-after match.
+const expectPoll = 500 * time.Millisecond
+
+// paneMark records where a pane's output stood just before a send, so only
+// what was printed since is matched.
+type paneMark struct {
+	// line is the absolute line (history plus cursor row) of the cursor.
+	line int
+	// alternate panes (full-screen TUIs) have no history; their screen at
+	// the time is kept instead and any change counts as new output.
+	alternate bool
+	screen    string
+}
+
+// pendingExpect is a response the bird waits for before its next idle
+// countdown.
+type pendingExpect struct {
+	// patterns are tried in order; the first match wins.
+	patterns []*regexp.Regexp
+	mark     paneMark
+	since    time.Time
+	// echo is the sent text, dropped once from the output so the pane
+	// echoing it back is not mistaken for the reply.
+	echo string
+	// timeout is zero to wait indefinitely.
+	timeout time.Duration
+	// flow and step identify the flow state whose transitions these are;
+	// flow is nil for --expect-after.
+	flow *messageFlow
+	step int
+}
+
+func (e *pendingExpect) String() string {
+	parts := make([]string, len(e.patterns))
+	for i, re := range e.patterns {
+		parts[i] = outputPattern(re)
+	}
+	return strings.Join(parts, " or ")
+}
+
+// expectFor returns the pattern to wait for after sending message index, or
+// nil. A requested send only uses the global pattern.
+func (o options) expectFor(index int, requested bool) *regexp.Regexp {
+	if !requested && index < len(o.expects) && o.expects[index] != nil {
+		return o.expects[index]
+	}
+	return o.expectAfter
+}
+
+// compileOutputPattern compiles a pattern matched against pane output, where
+// ^ and $ anchor lines rather than the whole capture.
+func compileOutputPattern(pattern string) (*regexp.Regexp, error) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return nil, err
+	}
+	return regexp.Compile("(?m)" + pattern)
+}
+
+// outputPatternSource returns a pattern from compileOutputPattern as
+// written.
+func outputPatternSource(re *regexp.Regexp) string {
+	return strings.TrimPrefix(re.String(), "(?m)")
+}
+
+// outputPattern formats a pattern from compileOutputPattern between slashes.
+func outputPattern(re *regexp.Regexp) string {
+	return "/" + outputPatternSource(re) + "/"
+}
+
+// compileExpects compiles per-message #expect: patterns; nil entries mean
+// none.
+func compileExpects(patterns []string) ([]*regexp.Regexp, error) {
+	var expects []*regexp.Regexp
+	for i, p := range patterns {
+		if p == "" {
+			continue
+		}
+		if expects == nil {
+			expects = make([]*regexp.Regexp, len(patterns))
+		}
+		re, err := compileOutputPattern(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid #expect: for message %d: %w", i+1, err)
+		}
+		expects[i] = re
+	}
+	return expects, nil
+}
+
+func tmuxPaneMark(target string) (paneMark, error) {
+	if !usingTmux() {
+		// Other backends only show the screen, so compare it whole as
+		// for the alternate screen.
+		screen, err := captureTarget(target)
+		return paneMark{alternate: true, screen: string(screen)}, err
+	}
+	out, err := tmuxOutput("display-message", "-p", "-t", target, "#{history_size} #{cursor_y} #{alternate_on}")
+	if err != nil {
+		return paneMark{}, err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 3 {
+		return paneMark{}, targetGone(target)
+	}
+	if fields[2] == "1" {
+		screen, err := captureTarget(target)
+		return paneMark{alternate: true, screen: string(screen)}, err
+	}
+	history, err1 := strconv.Atoi(fields[0])
+	cursor, err2 := strconv.Atoi(fields[1])
+	if err1 != nil || err2 != nil {
+		return paneMark{}, fmt.Errorf("unexpected pane position %q", strings.TrimSpace(string(out)))
+	}
+	return paneMark{line: history + cursor}, nil
+}
+
+// tmuxOutputSince returns what target printed after mark.
+func tmuxOutputSince(target string, mark paneMark) (string, error) {
+	if mark.alternate {
+		screen, err := captureTarget(target)
+		if err != nil || string(screen) == mark.screen {
+			return "", err
+		}
+		return string(screen), nil
+	}
+	out, err := tmuxOutput("display-message", "-p", "-t", target, "#{history_size}")
+	if err != nil {
+		return "", err
+	}
+	history, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return "", targetGone(target)
+	}
+	// -S counts from the top of the visible screen, negative into history.
+	captured, err := tmuxOutput("capture-pane", "-p", "-J", "-t", target, "-S", strconv.Itoa(mark.line-history))
+	return string(captured), err
+}
+
+// awaitExpect polls the target until the output since the send matches one
+// of the patterns, ctx ends or the timeout passes since the send. It returns
+// the index of the matching pattern, or -1 on timeout.
+func (b *bird) awaitExpect(ctx context.Context, exp *pendingExpect) (int, error) {
+	for {
+		output, err := tmuxOutputSince(b.target, exp.mark)
+		if err != nil {
+			return -1, err
+		}
+		if exp.echo != "" {
+			output = strings.Replace(output, exp.echo, "", 1)
+		}
+		for i, re := range exp.patterns {
+			if re.MatchString(output) {
+				return i, nil
+			}
+		}
+		if exp.timeout > 0 && time.Since(exp.since) >= exp.timeout {
+			return -1, nil
+		}
+		if err := sleepWithContext(ctx, expectPoll); err != nil {
+			return -1, err
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package typingbird
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestCompileExpects(t *testing.T) {
+	expects, err := compileExpects([]string{"", "", ""})
+	if err != nil || expects != nil {
+		t.Fatalf("compileExpects(no patterns) = %v, %v; want nil, nil", expects, err)
+	}
+	expects, err = compileExpects([]string{"PASS", "", "^\\$ $"})
+	if err != nil || len(expects) != 3 || outputPattern(expects[0]) != "/PASS/" || expects[1] != nil {
+		t.Fatalf("compileExpects(...) = %v, %v; want patterns for messages 1 and 3", expects, err)
+	}
+	if output := "$ make\nok\n$ \n"; !expects[2].MatchString(output) {
+		t.Fatalf("%s.MatchString(%q) = false; want ^ and $ to anchor lines", outputPattern(expects[2]), output)
+	}
+	if _, err := compileExpects([]string{"", "(unclosed"}); err == nil {
+		t.Fatalf("compileExpects(invalid) error = nil; want error")
+	}
+}
+
+func TestOptionsExpectFor(t *testing.T) {
+	global := regexp.MustCompile(`\$ $`)
+	own := regexp.MustCompile("PASS|FAIL")
+	opts := options{expectAfter: global, expects: []*regexp.Regexp{own, nil}}
+	tests := []struct {
+		index     int
+		requested bool
+		want      *regexp.Regexp
+	}{
+		{0, false, own},
+		{1, false, global},
+		{5, false, global},
+		{0, true, global},
+	}
+	for _, tt := range tests {
+		if got := opts.expectFor(tt.index, tt.requested); got != tt.want {
+			t.Fatalf("expectFor(%d, %t) = %v; want %v", tt.index, tt.requested, got, tt.want)
+		}
+	}
+	if got := (options{}).expectFor(0, false); got != nil {
+		t.Fatalf("expectFor() without patterns = %v; want nil", got)
+	}
+}
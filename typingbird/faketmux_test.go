@@ -0,0 +1,601 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// The test binary doubles as a fake tmux and as the typing-bird command,
+// chosen by these variables, so tests can drive the whole program against
+// sessions and panes that live in a JSON file.
+const (
+	fakeTmuxEnv = "TYPING_BIRD_FAKE_TMUX"
+	fakeBirdEnv = "TYPING_BIRD_FAKE_BIRD"
+)
+
+func TestMain(m *testing.M) {
+	// The bird's own tmux calls inherit fakeBirdEnv, so tmux comes first.
+	if state := os.Getenv(fakeTmuxEnv); state != "" {
+		os.Exit(fakeTmuxMain(state, os.Args[1:], os.Stdout, os.Stderr))
+	}
+	if os.Getenv(fakeBirdEnv) != "" {
+		os.Exit(Main())
+	}
+	os.Exit(m.Run())
+}
+
+// fakePane is one pane of the fake server. Screen is what capture-pane
+// returns; keys sent to a shell pane are typed onto it.
+type fakePane struct {
+	ID      string            `json:"id"`
+	Session string            `json:"session"`
+	Window  string            `json:"window"`
+	Active  bool              `json:"active"`
+	Command string            `json:"command"`
+	Title   string            `json:"title"`
+	Dead    bool              `json:"dead"`
+	Screen  string            `json:"screen"`
+	Options map[string]string `json:"options"`
+}
+
+type fakeTmuxState struct {
+	NextPane int               `json:"next_pane"`
+	Sessions []string          `json:"sessions"`
+	Panes    []*fakePane       `json:"panes"`
+	Options  map[string]string `json:"options"`
+	// Log holds every command run, in order.
+	Log [][]string `json:"log"`
+}
+
+// fakeTmux is a fake tmux server for one test: a tmux script first in
+// PATH that runs the test binary against a state file.
+type fakeTmux struct {
+	t     *testing.T
+	state string
+	exe   string
+}
+
+// newFakeTmux starts a fake server with a session per name, each with one
+// idle bash pane.
+func newFakeTmux(t *testing.T, sessions ...string) *fakeTmux {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	f := &fakeTmux{t: t, state: filepath.Join(dir, "state.json"), exe: exe}
+	st := &fakeTmuxState{}
+	for _, name := range sessions {
+		st.newSession(name, "bash")
+	}
+	f.save(st)
+	script := fmt.Sprintf("#!/bin/sh\n%s=%s exec %s \"$@\"\n", fakeTmuxEnv, shellQuoteSingle(f.state), shellQuoteSingle(exe))
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("TMUX", "")
+	t.Setenv("TMUX_PANE", "")
+	t.Setenv("TMUX_TMPDIR", dir)
+	prev := detectedTmux.Load()
+	detectedTmux.Store(nil)
+	t.Cleanup(func() { detectedTmux.Store(prev) })
+	return f
+}
+
+func (f *fakeTmux) load() *fakeTmuxState {
+	f.t.Helper()
+	st, err := loadFakeTmuxState(f.state)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return st
+}
+
+func (f *fakeTmux) save(st *fakeTmuxState) {
+	f.t.Helper()
+	if err := st.save(f.state); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// update changes the state under the lock the fake tmux itself takes.
+func (f *fakeTmux) update(change func(*fakeTmuxState)) {
+	f.t.Helper()
+	lock, err := os.OpenFile(f.state+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		f.t.Fatal(err)
+	}
+	st := f.load()
+	change(st)
+	f.save(st)
+}
+
+// pane returns the pane with id, failing the test when there is none.
+func (f *fakeTmux) pane(id string) *fakePane {
+	f.t.Helper()
+	p := f.load().pane(id)
+	if p == nil {
+		f.t.Fatalf("fake tmux has no pane %s", id)
+	}
+	return p
+}
+
+// sent lists the send-keys commands run so far.
+func (f *fakeTmux) sent() []string {
+	var sent []string
+	for _, args := range f.load().Log {
+		if args[0] == "send-keys" {
+			sent = append(sent, strings.Join(args[1:], " "))
+		}
+	}
+	return sent
+}
+
+func loadFakeTmuxState(path string) (*fakeTmuxState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	st := &fakeTmuxState{}
+	return st, json.Unmarshal(data, st)
+}
+
+func (st *fakeTmuxState) save(path string) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (st *fakeTmuxState) newSession(name, command string) *fakePane {
+	st.Sessions = append(st.Sessions, name)
+	return st.newPane(name, "0", command, true)
+}
+
+func (st *fakeTmuxState) newPane(session, window, command string, active bool) *fakePane {
+	p := &fakePane{
+		ID:      "%" + strconv.Itoa(st.NextPane),
+		Session: session,
+		Window:  window,
+		Active:  active,
+		Command: command,
+		Options: map[string]string{},
+	}
+	if isShell(command) {
+		p.Screen = "$ "
+	}
+	st.NextPane++
+	st.Panes = append(st.Panes, p)
+	return p
+}
+
+func (st *fakeTmuxState) pane(id string) *fakePane {
+	for _, p := range st.Panes {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (st *fakeTmuxState) hasSession(name string) bool {
+	for _, s := range st.Sessions {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve finds the pane a -t target names: a pane id, or the active (or
+// first) pane of a session, "session:" or "session:window".
+func (st *fakeTmuxState) resolve(target string) (*fakePane, error) {
+	if strings.HasPrefix(target, "%") {
+		if p := st.pane(target); p != nil {
+			return p, nil
+		}
+		return nil, fmt.Errorf("can't find pane: %s", target)
+	}
+	session, window, windowed := strings.Cut(target, ":")
+	if target == "" && len(st.Sessions) > 0 {
+		session = st.Sessions[0]
+	}
+	var found *fakePane
+	for _, p := range st.Panes {
+		if p.Session != session || (windowed && window != "" && p.Window != window) {
+			continue
+		}
+		if found == nil || (p.Active && !found.Active) {
+			found = p
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("can't find session: %s", session)
+	}
+	return found, nil
+}
+
+func isShell(command string) bool {
+	switch command {
+	case "", "bash", "sh", "zsh":
+		return true
+	}
+	return false
+}
+
+// format expands #{name} formats for p.
+func (st *fakeTmuxState) format(format string, p *fakePane) string {
+	return fakeFormatVar.ReplaceAllStringFunc(format, func(m string) string {
+		name := m[2 : len(m)-1]
+		if p == nil {
+			return ""
+		}
+		switch name {
+		case "pane_id":
+			return p.ID
+		case "pane_active":
+			return fakeBool(p.Active)
+		case "pane_dead":
+			return fakeBool(p.Dead)
+		case "pane_title":
+			return p.Title
+		case "pane_current_command":
+			return p.Command
+		case "session_name":
+			return p.Session
+		case "window_name":
+			return p.Window
+		case "pane_width":
+			return "80"
+		case "pane_height":
+			return "24"
+		case "history_size", "cursor_y", "alternate_on", "client_activity":
+			return "0"
+		}
+		if strings.HasPrefix(name, "@") {
+			if v, ok := p.Options[name]; ok {
+				return v
+			}
+			return st.Options[p.Session+" "+name]
+		}
+		return ""
+	})
+}
+
+var fakeFormatVar = regexp.MustCompile(`#\{[^}]*\}`)
+
+func fakeBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// fakeValueFlags are the flags of each command that take a value.
+var fakeValueFlags = map[string]string{
+	"new-session":     "sncxyF",
+	"new-window":      "ntcF",
+	"split-window":    "lptcF",
+	"list-panes":      "tF",
+	"list-windows":    "tF",
+	"list-sessions":   "F",
+	"display-message": "tcF",
+	"capture-pane":    "tSEb",
+	"send-keys":       "tN",
+	"set-option":      "t",
+	"show-options":    "t",
+	"select-pane":     "tT",
+	"kill-pane":       "t",
+	"kill-session":    "t",
+	"has-session":     "t",
+	"rename-window":   "t",
+	"set-hook":        "t",
+	"load-buffer":     "b",
+	"paste-buffer":    "bt",
+	"display-popup":   "cdtwhxyTe",
+	"display-menu":    "cxyTt",
+	"run-shell":       "dt",
+	"respawn-pane":    "tce",
+}
+
+// fakeArgs splits a command's flags from its arguments. Flag letters may
+// be bunched as tmux allows, "-pqv".
+func fakeArgs(command string, args []string) (map[string]string, []string) {
+	flags := map[string]string{}
+	takes := fakeValueFlags[command]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return flags, args[i+1:]
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			return flags, args[i:]
+		}
+		for j := 1; j < len(arg); j++ {
+			c := arg[j : j+1]
+			if !strings.Contains(takes, c) {
+				flags[c] = ""
+				continue
+			}
+			if j+1 < len(arg) {
+				flags[c] = arg[j+1:]
+			} else if i+1 < len(args) {
+				i++
+				flags[c] = args[i]
+			}
+			break
+		}
+	}
+	return flags, nil
+}
+
+// fakeTmuxMain runs one tmux invocation against the state file.
+func fakeTmuxMain(path string, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 1 && args[0] == "-V" {
+		fmt.Fprintln(stdout, "tmux 3.4")
+		return 0
+	}
+	lock, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	st, err := loadFakeTmuxState(path)
+	if err != nil {
+		fmt.Fprintln(stderr, "no server running on", path)
+		return 1
+	}
+	code := 0
+	var command []string
+	for i := 0; i <= len(args); i++ {
+		if i < len(args) && args[i] != ";" {
+			command = append(command, args[i])
+			continue
+		}
+		if len(command) == 0 {
+			continue
+		}
+		st.Log = append(st.Log, command)
+		if err := st.run(command, stdout); err != nil {
+			fmt.Fprintln(stderr, err)
+			code = 1
+			break
+		}
+		command = nil
+	}
+	if err := st.save(path); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return code
+}
+
+func (st *fakeTmuxState) run(command []string, stdout io.Writer) error {
+	name := command[0]
+	flags, rest := fakeArgs(name, command[1:])
+	target, targeted := flags["t"]
+	pane := func() (*fakePane, error) {
+		if !targeted {
+			target = os.Getenv("TMUX_PANE")
+		}
+		return st.resolve(target)
+	}
+	switch name {
+	case "has-session":
+		if !st.hasSession(strings.TrimSuffix(target, ":")) {
+			return fmt.Errorf("can't find session: %s", target)
+		}
+	case "new-session":
+		if st.hasSession(flags["s"]) {
+			return fmt.Errorf("duplicate session: %s", flags["s"])
+		}
+		p := st.newSession(flags["s"], fakeCommandName(rest))
+		if _, ok := flags["P"]; ok {
+			fmt.Fprintln(stdout, st.format(flags["F"], p))
+		}
+	case "list-sessions":
+		if len(st.Sessions) == 0 {
+			return fmt.Errorf("no server running")
+		}
+		for _, s := range st.Sessions {
+			fmt.Fprintln(stdout, strings.ReplaceAll(flags["F"], "#{session_name}", s))
+		}
+	case "list-panes":
+		if _, all := flags["a"]; all {
+			for _, q := range st.Panes {
+				fmt.Fprintln(stdout, st.format(flags["F"], q))
+			}
+			break
+		}
+		p, err := pane()
+		if err != nil {
+			return err
+		}
+		_, allWindows := flags["s"]
+		for _, q := range st.Panes {
+			if q.Session == p.Session && (allWindows || q.Window == p.Window) {
+				fmt.Fprintln(stdout, st.format(flags["F"], q))
+			}
+		}
+	case "list-windows":
+		p, err := pane()
+		if err != nil {
+			return err
+		}
+		seen := map[string]bool{}
+		for _, q := range st.Panes {
+			if q.Session == p.Session && !seen[q.Window] {
+				seen[q.Window] = true
+				fmt.Fprintln(stdout, st.format(flags["F"], q))
+			}
+		}
+	case "display-message":
+		p, err := pane()
+		if err != nil {
+			if targeted {
+				return err
+			}
+			p = nil
+		}
+		if _, ok := flags["p"]; ok {
+			fmt.Fprintln(stdout, st.format(strings.Join(rest, " "), p))
+		}
+	case "capture-pane":
+		p, err := pane()
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(stdout, strings.TrimRight(p.Screen, "\n")+"\n")
+	case "send-keys":
+		p, err := pane()
+		if err != nil {
+			return err
+		}
+		if _, literal := flags["l"]; literal {
+			p.Screen += strings.Join(rest, "")
+			break
+		}
+		for _, key := range rest {
+			p.press(key)
+		}
+	case "split-window", "new-window":
+		p, err := pane()
+		if err != nil {
+			return err
+		}
+		window := p.Window
+		if name == "new-window" {
+			window = flags["n"]
+		}
+		q := st.newPane(p.Session, window, fakeCommandName(rest), false)
+		if _, ok := flags["P"]; ok {
+			fmt.Fprintln(stdout, st.format(flags["F"], q))
+		}
+	case "kill-pane":
+		p, err := pane()
+		if err != nil {
+			return err
+		}
+		for i, q := range st.Panes {
+			if q == p {
+				st.Panes = append(st.Panes[:i], st.Panes[i+1:]...)
+				break
+			}
+		}
+	case "kill-server":
+		*st = fakeTmuxState{NextPane: st.NextPane, Log: st.Log}
+	case "set-option":
+		return st.setOption(flags, rest, pane)
+	case "show-options":
+		if len(rest) == 0 {
+			return nil
+		}
+		p, err := pane()
+		if err != nil {
+			return err
+		}
+		if _, paneLevel := flags["p"]; paneLevel {
+			fmt.Fprintln(stdout, p.Options[rest[0]])
+		} else {
+			fmt.Fprintln(stdout, st.Options[p.Session+" "+rest[0]])
+		}
+	case "select-pane":
+		p, err := pane()
+		if err != nil {
+			return err
+		}
+		if title, ok := flags["T"]; ok {
+			p.Title = title
+		}
+	case "rename-window":
+		p, err := pane()
+		if err != nil {
+			return err
+		}
+		for _, q := range st.Panes {
+			if q.Session == p.Session && q.Window == p.Window && len(rest) > 0 {
+				q.Window = rest[0]
+			}
+		}
+	}
+	// Anything else (hooks, menus, popups, buffers) only goes in the log.
+	return nil
+}
+
+func (st *fakeTmuxState) setOption(flags map[string]string, rest []string, pane func() (*fakePane, error)) error {
+	if len(rest) == 0 {
+		return fmt.Errorf("set-option: missing option")
+	}
+	p, err := pane()
+	if err != nil {
+		return err
+	}
+	_, unset := flags["u"]
+	value := strings.Join(rest[1:], " ")
+	if _, paneLevel := flags["p"]; paneLevel {
+		if unset {
+			delete(p.Options, rest[0])
+		} else {
+			p.Options[rest[0]] = value
+		}
+		return nil
+	}
+	if st.Options == nil {
+		st.Options = map[string]string{}
+	}
+	if unset {
+		delete(st.Options, p.Session+" "+rest[0])
+	} else {
+		st.Options[p.Session+" "+rest[0]] = value
+	}
+	return nil
+}
+
+// press handles a key sent to p. A shell echoes Enter and Ctrl-C; any other
+// program exits on Ctrl-C.
+func (p *fakePane) press(key string) {
+	switch {
+	case key == "C-c" && !isShell(p.Command):
+		p.Dead = true
+	case key == "C-c":
+		p.Screen += "^C\n$ "
+	case key == "Enter":
+		p.Screen += "\n$ "
+	}
+}
+
+// fakeCommandName is what pane_current_command shows for a pane started
+// with args, a shell command line whose first word is the program.
+func fakeCommandName(args []string) string {
+	if len(args) == 0 {
+		return "bash"
+	}
+	fields := strings.Fields(strings.Join(args, " "))
+	return filepath.Base(strings.Trim(fields[0], `'"`))
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package typingbird
+
+import "errors"
+
+func mkfifo(path string, mode uint32) error {
+	return errors.New("named pipes require a unix platform")
+}
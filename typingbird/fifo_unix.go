@@ -0,0 +1,9 @@
+//go:build unix
+
+package typingbird
+
+import "syscall"
+
+func mkfifo(path string, mode uint32) error {
+	return syscall.Mkfifo(path, mode)
+}
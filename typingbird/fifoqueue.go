@@ -0,0 +1,108 @@
+package typingbird
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+)
+
+// fifoQueue reads messages written a line at a time to a named pipe with
+// --trigger-fifo. Lines wait for the next idle cycle, higher #priority:
+// first; an empty line asks for the next message in rotation right away.
+type fifoQueue struct {
+	path string
+	// created is set when the bird made the FIFO and so removes it.
+	created bool
+	f       *os.File
+	done    chan struct{}
+
+	mu    sync.Mutex
+	lines []string
+}
+
+// openFIFOQueue opens the FIFO at path, creating it when it doesn't exist,
+// and calls sendNow for every empty line written to it.
+func openFIFOQueue(path string, sendNow func()) (*fifoQueue, error) {
+	created := false
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if err := mkfifo(path, 0o600); err != nil {
+			return nil, fmt.Errorf("creating trigger FIFO %q: %w", path, err)
+		}
+		created = true
+	case err != nil:
+		return nil, err
+	case info.Mode()&fs.ModeNamedPipe == 0:
+		return nil, fmt.Errorf("trigger FIFO %q exists and is not a FIFO", path)
+	}
+	// Opening read-write keeps the FIFO from blocking on open and from
+	// reporting EOF each time a writer closes it.
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if created {
+			_ = os.Remove(path)
+		}
+		return nil, err
+	}
+	q := &fifoQueue{path: path, created: created, f: f, done: make(chan struct{})}
+	go q.read(sendNow)
+	return q, nil
+}
+
+func (q *fifoQueue) read(sendNow func()) {
+	defer close(q.done)
+	sc := bufio.NewScanner(q.f)
+	sc.Buffer(make([]byte, 64*1024), maxAPIBody)
+	for sc.Scan() {
+		line := strings.TrimSuffix(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			sendNow()
+			continue
+		}
+		q.mu.Lock()
+		q.lines = insertByPriority(q.lines, line, false)
+		q.mu.Unlock()
+		debugf("queued a message from %s", q.path)
+	}
+	if err := sc.Err(); err != nil && !errors.Is(err, os.ErrClosed) {
+		logf("WARNING: trigger FIFO %s stopped: %v", q.path, err)
+	}
+}
+
+func (q *fifoQueue) pop(context.Context) (string, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.lines) == 0 {
+		return "", false, nil
+	}
+	message := q.lines[0]
+	q.lines = q.lines[1:]
+	return message, true, nil
+}
+
+func (q *fifoQueue) requeue(message string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.lines = insertByPriority(q.lines, message, true)
+	return nil
+}
+
+func (q *fifoQueue) String() string {
+	return q.path
+}
+
+// Close stops reading and removes the FIFO if the bird created it.
+func (q *fifoQueue) Close() error {
+	err := q.f.Close()
+	<-q.done
+	if q.created {
+		_ = os.Remove(q.path)
+	}
+	return err
+}
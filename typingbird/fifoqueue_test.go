@@ -0,0 +1,64 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFIFOQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trigger")
+	forced := make(chan struct{}, 1)
+	q, err := openFIFOQueue(path, func() { forced <- struct{}{} })
+	if err != nil {
+		t.Fatalf("openFIFOQueue() error: %v", err)
+	}
+	w, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("opening FIFO for writing: %v", err)
+	}
+	if _, err := w.WriteString("first\r\n\nsecond\n"); err != nil {
+		t.Fatalf("writing FIFO: %v", err)
+	}
+	w.Close()
+	select {
+	case <-forced:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("empty line did not force a send")
+	}
+	var got []string
+	for deadline := time.Now().Add(2 * time.Second); len(got) < 2 && time.Now().Before(deadline); {
+		if message, ok, _ := q.pop(context.Background()); ok {
+			got = append(got, message)
+			continue
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Fatalf("pop() = %q; want [first second]", got)
+	}
+	_ = q.requeue("again")
+	if message, ok, _ := q.pop(context.Background()); !ok || message != "again" {
+		t.Fatalf("pop() after requeue = %q, %v; want %q", message, ok, "again")
+	}
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("FIFO still exists after Close: %v", err)
+	}
+}
+
+func TestFIFOQueueRejectsRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plain")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openFIFOQueue(path, func() {}); err == nil {
+		t.Fatalf("openFIFOQueue(regular file) = nil error; want error")
+	}
+}
@@ -0,0 +1,91 @@
+package typingbird
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// fileMessagePrefix starts a message naming a file whose contents are typed
+// in its place, read afresh at every send: @file:queries/report.sql.
+const fileMessagePrefix = "@file:"
+
+func fileMessagePath(message string) (string, bool) {
+	path, ok := strings.CutPrefix(strings.TrimSpace(message), fileMessagePrefix)
+	if !ok || path == "" {
+		return "", false
+	}
+	return path, true
+}
+
+// absFileMessage makes the path of an @file: message absolute so the
+// injected child reads the same file.
+func absFileMessage(message string) (string, error) {
+	path, ok := fileMessagePath(message)
+	if !ok {
+		return message, nil
+	}
+	abs, err := absPath(path)
+	if err != nil {
+		return "", err
+	}
+	return fileMessagePrefix + abs, nil
+}
+
+// readFileMessage returns the contents of the file path names, without
+// trailing line breaks: Enter is pressed after pasting anyway.
+func readFileMessage(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading message file: %w", err)
+	}
+	text := strings.TrimRight(string(data), "\r\n")
+	if text == "" {
+		return "", fmt.Errorf("message file %q is empty", path)
+	}
+	return text, nil
+}
+
+// tmuxPaste delivers text to target through a paste buffer, which copes
+// with any size where send-keys arguments do not, then presses enter. The
+// pane gets a bracketed paste when its program asked for one, so a REPL
+// sees the whole text before the Enter runs it. With chunking, each chunk
+// is a paste of its own.
+func tmuxPaste(target, text string, chunks chunking) error {
+	buffer := fmt.Sprintf("typing-bird-%d", os.Getpid())
+	for _, chunk := range chunks.chunks(text) {
+		mark, err := chunks.mark(target)
+		if err != nil {
+			return err
+		}
+		load := tmuxCommand("load-buffer", "-b", buffer, "-")
+		load.Stdin = strings.NewReader(chunk)
+		if _, err := runTraced(load, runOnly); err != nil {
+			return fmt.Errorf("loading paste buffer: %w", err)
+		}
+		if err := tmuxRun("paste-buffer", "-d", "-p", "-b", buffer, "-t", target); err != nil {
+			return err
+		}
+		if chunks.size > 0 {
+			if err := chunks.settle(target, chunk, mark); err != nil {
+				return err
+			}
+		}
+	}
+	return sendKeys(target, 0, enterKey)
+}
+
+// pasteMessagePrefix starts a message whose line breaks are part of the
+// text rather than Enter presses, for editors and multi-line REPL input:
+// the rest of the message goes in as one paste and Enter is pressed once.
+const pasteMessagePrefix = "@paste:"
+
+// pastedText returns message without its @paste: prefix and whether it is
+// delivered as a paste. With pasteNewlines every message with line breaks
+// is, except macros, whose steps are keys to press.
+func pastedText(message string, pasteNewlines bool) (string, bool) {
+	if text, ok := strings.CutPrefix(message, pasteMessagePrefix); ok {
+		return strings.ReplaceAll(text, "\r\n", "\n"), true
+	}
+	return message, pasteNewlines && strings.ContainsAny(message, "\r\n") && !isMacro(message)
+}
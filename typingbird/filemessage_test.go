@@ -0,0 +1,76 @@
+package typingbird
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPastedText(t *testing.T) {
+	tests := []struct {
+		message  string
+		newlines bool
+		want     string
+		paste    bool
+	}{
+		{"@paste:def f():\r\n    return 1", false, "def f():\n    return 1", true},
+		{"one\ntwo", false, "one\ntwo", false},
+		{"one\ntwo", true, "one\ntwo", true},
+		{"one line", true, "one line", false},
+		{"one\n{{key \"Enter\"}}", true, "one\n{{key \"Enter\"}}", false},
+	}
+	for _, tt := range tests {
+		got, paste := pastedText(tt.message, tt.newlines)
+		if got != tt.want || paste != tt.paste {
+			t.Fatalf("pastedText(%q, %t) = %q, %t; want %q, %t", tt.message, tt.newlines, got, paste, tt.want, tt.paste)
+		}
+	}
+}
+
+func TestFileMessagePath(t *testing.T) {
+	tests := map[string]string{
+		"@file:report.sql":     "report.sql",
+		" @file:/tmp/a b.sql ": "/tmp/a b.sql",
+		"@file:":               "",
+		"see @file:x":          "",
+		"continue":             "",
+	}
+	for message, want := range tests {
+		got, ok := fileMessagePath(message)
+		if got != want || ok != (want != "") {
+			t.Fatalf("fileMessagePath(%q) = %q, %t; want %q", message, got, ok, want)
+		}
+	}
+}
+
+func TestAbsFileMessage(t *testing.T) {
+	got, err := absFileMessage("@file:report.sql")
+	if err != nil || !strings.HasPrefix(got, fileMessagePrefix) || !filepath.IsAbs(strings.TrimPrefix(got, fileMessagePrefix)) {
+		t.Fatalf("absFileMessage(\"@file:report.sql\") = %q, %v; want an absolute @file: path", got, err)
+	}
+	if got, err := absFileMessage("continue"); err != nil || got != "continue" {
+		t.Fatalf("absFileMessage(\"continue\") = %q, %v; want it unchanged", got, err)
+	}
+}
+
+func TestReadFileMessage(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.sql")
+	if err := os.WriteFile(path, []byte("SELECT 1;\nSELECT 2;\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readFileMessage(path)
+	if want := "SELECT 1;\nSELECT 2;"; err != nil || got != want {
+		t.Fatalf("readFileMessage(...) = %q, %v; want %q", got, err, want)
+	}
+	empty := filepath.Join(dir, "empty.sql")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{empty, filepath.Join(dir, "missing.sql")} {
+		if _, err := readFileMessage(path); err == nil {
+			t.Fatalf("readFileMessage(%q) = nil error; want error", path)
+		}
+	}
+}
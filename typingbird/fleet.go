@@ -0,0 +1,584 @@
+package typingbird
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	fleetKeyBirds      = "birds"
+	fleetKeyName       = "name"
+	fleetKeyTargetPane = "target-pane"
+	fleetKeyProject    = "project"
+	fleetKeyWindow     = "window"
+	fleetKeyPane       = "pane"
+)
+
+// fleetProcessFlags are process-wide and set on the fleet command line, not
+// per bird.
+var fleetProcessFlags = map[string]bool{
+	"inject":          true,
+	"verbose":         true,
+	"trace":           true,
+	"redact":          true,
+	"control-socket":  true,
+	"tmux-hooks":      true,
+	"tmux-control":    true,
+	"capture-workers": true,
+	"wsl-distro":      true,
+	"grpc-listen":     true,
+	"api-listen":      true,
+	"api-token-file":  true,
+}
+
+// fleetFile is the fleet YAML. Top-level keys are defaults for every bird
+// and use the same names as the config file; each entry under birds adds
+// its own settings plus an optional name (default: the session) and
+// target-pane:
+//
+//	timeout: 1m
+//	birds:
+//	  - session: agent
+//	    messages: ["continue"]
+//	  - name: build
+//	    session: work
+//	    target-pane: "%3"
+//	    idle-mode: pipe
+//
+// A top-level project names a tmuxp or tmuxinator file. Birds then take a
+// window (default: their name, when the project has such a window) and an
+// optional pane number instead of target-pane, and the project's session
+// unless they set one.
+type fleetFile struct {
+	defaults map[string]any
+	birds    []fleetEntry
+	project  string
+}
+
+type fleetEntry struct {
+	name       string
+	targetPane string
+	// window and pane place the bird in the project's layout; pane counts
+	// from 0 in the order the project lists them.
+	window   string
+	pane     int
+	settings map[string]any
+}
+
+func loadFleetFile(path string) (*fleetFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading fleet file: %w", err)
+	}
+	fleet, err := parseFleet(raw)
+	if err != nil {
+		return nil, fmt.Errorf("fleet file %q: %w", path, err)
+	}
+	if fleet.project != "" {
+		projectPath := fleet.project
+		if !filepath.IsAbs(projectPath) {
+			projectPath = filepath.Join(filepath.Dir(path), projectPath)
+		}
+		project, err := loadTmuxProject(projectPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := fleet.applyProject(project); err != nil {
+			return nil, fmt.Errorf("fleet file %q: %w", path, err)
+		}
+	}
+	return fleet, nil
+}
+
+func parseFleet(raw []byte) (*fleetFile, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	rawBirds, ok := doc[fleetKeyBirds].([]any)
+	if !ok || len(rawBirds) == 0 {
+		return nil, fmt.Errorf("birds must be a non-empty list")
+	}
+	delete(doc, fleetKeyBirds)
+	fleet := &fleetFile{}
+	if v, ok := doc[fleetKeyProject]; ok {
+		fleet.project = strings.TrimSpace(fmt.Sprint(v))
+		delete(doc, fleetKeyProject)
+	}
+	if err := checkFleetSettings(doc); err != nil {
+		return nil, err
+	}
+	fleet.defaults = doc
+	seen := map[string]bool{}
+	for i, rawBird := range rawBirds {
+		settings, ok := rawBird.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("birds[%d] must be a mapping of settings", i)
+		}
+		entry := fleetEntry{settings: settings}
+		if v, ok := settings[fleetKeyTargetPane]; ok {
+			entry.targetPane = strings.TrimSpace(fmt.Sprint(v))
+			delete(settings, fleetKeyTargetPane)
+		}
+		if v, ok := settings[fleetKeyWindow]; ok {
+			entry.window = strings.TrimSpace(fmt.Sprint(v))
+			delete(settings, fleetKeyWindow)
+		}
+		if v, ok := settings[fleetKeyPane]; ok {
+			n, isInt := v.(int)
+			if !isInt || n < 0 {
+				return nil, fmt.Errorf("birds[%d]: pane must be a pane number counting from 0", i)
+			}
+			entry.pane = n
+			delete(settings, fleetKeyPane)
+			if entry.window == "" {
+				return nil, fmt.Errorf("birds[%d]: pane requires window", i)
+			}
+		}
+		if entry.window != "" && fleet.project == "" {
+			return nil, fmt.Errorf("birds[%d]: window requires a project", i)
+		}
+		if entry.window != "" && entry.targetPane != "" {
+			return nil, fmt.Errorf("birds[%d]: window cannot be combined with target-pane", i)
+		}
+		if v, ok := settings[fleetKeyName]; ok {
+			entry.name = strings.TrimSpace(fmt.Sprint(v))
+			delete(settings, fleetKeyName)
+		} else if entry.window != "" {
+			entry.name = entry.window
+		} else if session, ok := settings[configKeySession]; ok {
+			entry.name = fmt.Sprint(session)
+		} else if session, ok := doc[configKeySession]; ok {
+			entry.name = fmt.Sprint(session)
+		}
+		if entry.name == "" {
+			return nil, fmt.Errorf("birds[%d] needs a name or session", i)
+		}
+		if strings.ContainsAny(entry.name, " \t\n") {
+			return nil, fmt.Errorf("bird name %q must not contain whitespace", entry.name)
+		}
+		if seen[entry.name] {
+			return nil, fmt.Errorf("duplicate bird name %q", entry.name)
+		}
+		seen[entry.name] = true
+		if err := checkFleetSettings(settings); err != nil {
+			return nil, fmt.Errorf("bird %q: %w", entry.name, err)
+		}
+		fleet.birds = append(fleet.birds, entry)
+	}
+	return fleet, nil
+}
+
+func checkFleetSettings(settings map[string]any) error {
+	for key := range settings {
+		if fleetProcessFlags[longFlagName(key)] {
+			return fmt.Errorf("%q applies to the whole fleet; pass --%s to typing-bird fleet instead", key, longFlagName(key))
+		}
+		if key == configKeyProfiles {
+			return fmt.Errorf("profiles are not supported in fleet files")
+		}
+	}
+	return nil
+}
+
+// applyProject matches birds to the project's windows and fills in the
+// project's session for birds that don't name one.
+func (f *fleetFile) applyProject(p *tmuxProject) error {
+	for i := range f.birds {
+		entry := &f.birds[i]
+		if entry.window == "" && entry.targetPane == "" && p.window(entry.name) != nil {
+			entry.window = entry.name
+		}
+		if entry.window == "" {
+			continue
+		}
+		w := p.window(entry.window)
+		if w == nil {
+			return fmt.Errorf("bird %q: window %q is not in the project", entry.name, entry.window)
+		}
+		if entry.pane >= w.panes {
+			return fmt.Errorf("bird %q: window %q has %d panes in the project; pane %d is not one of them", entry.name, w.name, w.panes, entry.pane)
+		}
+		if entry.settings[configKeySession] == nil && f.defaults[configKeySession] == nil {
+			entry.settings[configKeySession] = p.session
+		}
+	}
+	return nil
+}
+
+func (f *fleetFile) entry(name string) (fleetEntry, error) {
+	for _, entry := range f.birds {
+		if entry.name == name {
+			return entry, nil
+		}
+	}
+	return fleetEntry{}, fmt.Errorf("bird %q is no longer in the fleet file", name)
+}
+
+// options resolves one bird's settings through the same flag parsing and
+// validation as the standalone command line.
+func (f *fleetFile) options(entry fleetEntry, path string) (options, error) {
+	config := resolvedConfig{settings: map[string]string{}}
+	if err := config.merge(f.defaults); err != nil {
+		return options{}, fmt.Errorf("bird %q: %w", entry.name, err)
+	}
+	if err := config.merge(entry.settings); err != nil {
+		return options{}, fmt.Errorf("bird %q: %w", entry.name, err)
+	}
+	cli := newCLIFlags()
+	fs := flag.NewFlagSet(entry.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	cli.register(fs)
+	if err := applyConfigSettings(fs, nil, config.settings); err != nil {
+		return options{}, fmt.Errorf("bird %q: %w", entry.name, err)
+	}
+	if err := cli.applyPreset(fs, nil, config.settings); err != nil {
+		return options{}, fmt.Errorf("bird %q: %w", entry.name, err)
+	}
+	opts, err := cli.options(nil, config, path)
+	if err == errUsage {
+		return options{}, fmt.Errorf("bird %q: no session given", entry.name)
+	}
+	if err != nil {
+		return options{}, fmt.Errorf("bird %q: %w", entry.name, err)
+	}
+	return opts, nil
+}
+
+// runFleet implements `typing-bird fleet fleet.yaml`: one bird per entry,
+// each in its own goroutine, sharing logging, signal handling and the
+// control APIs.
+func runFleet(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("fleet", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		verbose        bool
+		trace          bool
+		redact         redactMode
+		servers        flockServers
+		sendsPerMinute int
+		tmuxControlled bool
+		captureWorkers int
+		interrupts     = interruptHandling{window: defaultInterruptWindow, action: interruptHint}
+	)
+	fs.BoolVar(&verbose, "v", false, "enable debug logging")
+	fs.BoolVar(&verbose, "verbose", false, "enable debug logging")
+	fs.BoolVar(&trace, "vv", false, "enable debug logging plus a trace of every tmux command")
+	fs.BoolVar(&trace, "trace", false, "enable debug logging plus a trace of every tmux command")
+	fs.IntVar(&sendsPerMinute, "max-sends-per-minute", 0, "stagger sends across all birds to at most this many per minute (0: unlimited)")
+	fs.DurationVar(&interrupts.window, "interrupt-window", interrupts.window, "a second Ctrl-C within this long exits")
+	fs.StringVar(&interrupts.action, "interrupt-action", interrupts.action, "what a first Ctrl-C does: hint (print how to restart), exit, pause (or resume) or skip the next message")
+	fs.Var(&redact, "redact", "log message bodies as a hash (default) or length instead of text")
+	fs.StringVar(&servers.controlSocket, "control-socket", "", "serve the control socket at this path")
+	fs.BoolVar(&servers.tmuxHooks, "tmux-hooks", false, "register tmux hooks so a dead pane or closed session stops its bird immediately")
+	fs.BoolVar(&tmuxControlled, "tmux-control", false, "run tmux commands through one control-mode client instead of a process each")
+	fs.IntVar(&captureWorkers, "capture-workers", defaultCaptureWorkers, "capture at most this many panes at once across all birds")
+	fs.StringVar(&wslDistro, "wsl-distro", "", "on Windows, the WSL distribution running tmux (default: the default distribution)")
+	fs.StringVar(&servers.grpcListen, "grpc-listen", "", "serve the gRPC API on host:port or unix:/path")
+	fs.StringVar(&servers.apiListen, "api-listen", "", "serve the REST API on host:port or unix:/path")
+	fs.StringVar(&servers.apiTokenFile, "api-token-file", "", "require the bearer token in this file for REST and gRPC requests")
+	fs.StringVar(&servers.mqttBroker, "mqtt-broker", "", "take commands from and publish events to this MQTT broker")
+	fs.StringVar(&servers.mqttTopic, "mqtt-topic", defaultMQTTTopic, "MQTT topic prefix; each bird uses <topic>/<name>/cmd and <topic>/<name>/events")
+	fs.StringVar(&servers.natsURL, "nats-url", "", "take messages and commands from these NATS servers")
+	fs.StringVar(&servers.natsSubject, "nats-subject", defaultNATSSubject, "NATS subject prefix; each bird uses <subject>.<name>.msg and <subject>.<name>.cmd")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird fleet [flags] fleet.yaml")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Runs one bird per entry in the fleet file. Control API requests name their")
+		fmt.Fprintln(stderr, "bird; SIGHUP reloads every bird from the fleet file. SIGUSR1 and SIGUSR2 send")
+		fmt.Fprintln(stderr, "or skip the next message of every bird.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if sendsPerMinute < 0 {
+		fmt.Fprintf(stderr, "ERROR: max-sends-per-minute must be >= 0 (got %d)\n", sendsPerMinute)
+		return 2
+	}
+	if captureWorkers < 1 {
+		fmt.Fprintf(stderr, "ERROR: capture-workers must be >= 1 (got %d)\n", captureWorkers)
+		return 2
+	}
+	err := validateInterruptAction(interrupts.action)
+	if err == nil && interrupts.window <= 0 && interrupts.action != interruptExit {
+		err = fmt.Errorf("interrupt-window must be greater than 0 (got %s)", interrupts.window)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 2
+	}
+	path, err := absPath(fs.Arg(0))
+	if err == nil {
+		servers.controlSocket, err = absPath(servers.controlSocket)
+	}
+	if err == nil {
+		servers.grpcListen, err = resolveListenAddr("grpc-listen", servers.grpcListen)
+	}
+	if err == nil {
+		servers.apiListen, err = resolveListenAddr("api-listen", servers.apiListen)
+	}
+	if err == nil {
+		servers.apiTokenFile, err = absPath(servers.apiTokenFile)
+	}
+	if err == nil && servers.mqttBroker != "" {
+		_, err = parseMQTTBroker(servers.mqttBroker)
+	}
+	if err == nil && servers.natsURL != "" {
+		err = parseNATSURL(servers.natsURL)
+		if err == nil && !validNATSSubject(servers.natsSubject) {
+			err = fmt.Errorf("invalid nats-subject %q: want dot-separated tokens without wildcards", servers.natsSubject)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 2
+	}
+	verboseLogging = verbose || trace
+	traceLogging = trace
+	messageRedaction = redact.String()
+	setCaptureWorkers(captureWorkers)
+
+	fleet, err := loadFleetFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 2
+	}
+	birdOpts := make([]options, len(fleet.birds))
+	for i, entry := range fleet.birds {
+		if birdOpts[i], err = fleet.options(entry, path); err != nil {
+			fmt.Fprintf(stderr, "ERROR: %v\n", err)
+			return 2
+		}
+	}
+	if wslDistro != "" && !wslProxy {
+		fmt.Fprintln(stderr, "ERROR: --wsl-distro only applies on Windows")
+		return 2
+	}
+	if err := lookTmux(); err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	for i, entry := range fleet.birds {
+		if err := birdOpts[i].tmuxFeatures(); err != nil {
+			fmt.Fprintf(stderr, "ERROR: bird %q: %v\n", entry.name, err)
+			return 2
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interruptCode := atomic.Int32{}
+	f := &flock{}
+	interrupts.birds = f
+	stopInterrupts := installInterruptHandlers(cancel, buildLaunchCommand(os.Args), interrupts, &interruptCode)
+	defer stopInterrupts()
+
+	events := &eventHub{}
+	limiter := newSendLimiter(sendsPerMinute)
+	// Birds writing to the same audit log share it to keep one chain.
+	audits := map[string]*auditLog{}
+	deads := map[string]*deadLetterFile{}
+	for i, entry := range fleet.birds {
+		opts := birdOpts[i]
+		created, err := tmuxEnsureSession(opts.session, opts.create)
+		if err != nil {
+			fmt.Fprintf(stderr, "ERROR: bird %q: tmux session %q not available: %v\n", entry.name, opts.session, err)
+			return 1
+		}
+		if created {
+			logf("bird %q: created session %q running %q", entry.name, opts.session, opts.create)
+		}
+		if tmuxControlled && i == 0 {
+			// One client serves every bird; targets are always explicit.
+			if stop, err := useControlClient(opts.session); err != nil {
+				logf("WARNING: not using a tmux control-mode client: %v", err)
+			} else {
+				defer stop()
+			}
+		}
+		target := entry.targetPane
+		if target == "" && entry.window != "" {
+			if target, err = tmuxProjectPane(opts.session, entry.window, entry.pane); err != nil {
+				fmt.Fprintf(stderr, "ERROR: bird %q: %v\n", entry.name, err)
+				return 1
+			}
+		}
+		if target == "" {
+			if target, err = preferredSendPane(opts.session); err != nil {
+				fmt.Fprintf(stderr, "ERROR: bird %q: failed resolving target pane for session %q: %v\n", entry.name, opts.session, err)
+				return 1
+			}
+		}
+		b, err := newBird(opts, target)
+		if err != nil {
+			fmt.Fprintf(stderr, "ERROR: bird %q: %v\n", entry.name, err)
+			return 2
+		}
+		b.name = entry.name
+		b.events = events
+		if entry.window != "" {
+			session, window, pane := opts.session, entry.window, entry.pane
+			b.locate = func() (string, error) { return tmuxProjectPane(session, window, pane) }
+		}
+		b.limiter = limiter
+		name := entry.name
+		b.resolve = func() (options, error) {
+			fleet, err := loadFleetFile(path)
+			if err != nil {
+				return options{}, err
+			}
+			entry, err := fleet.entry(name)
+			if err != nil {
+				return options{}, err
+			}
+			return fleet.options(entry, path)
+		}
+		if opts.record != "" {
+			t, err := openTranscript(opts.record)
+			if err != nil {
+				fmt.Fprintf(stderr, "ERROR: bird %q: opening transcript: %v\n", entry.name, err)
+				return 1
+			}
+			defer t.Close()
+			b.record = t
+		}
+		if opts.auditLog != "" {
+			if audits[opts.auditLog] == nil {
+				a, err := openAuditLog(opts.auditLog, opts.auditMaxSize)
+				if err != nil {
+					fmt.Fprintf(stderr, "ERROR: bird %q: opening audit log: %v\n", entry.name, err)
+					return 1
+				}
+				defer a.Close()
+				audits[opts.auditLog] = a
+			}
+			b.audit = audits[opts.auditLog]
+		}
+		if opts.deadLetter != "" {
+			if deads[opts.deadLetter] == nil {
+				dl, err := openDeadLetterFile(opts.deadLetter)
+				if err != nil {
+					fmt.Fprintf(stderr, "ERROR: bird %q: opening dead-letter file: %v\n", entry.name, err)
+					return 1
+				}
+				defer dl.Close()
+				deads[opts.deadLetter] = dl
+			}
+			b.dead = deads[opts.deadLetter]
+		}
+		if opts.triggerFIFO != "" {
+			q, err := openFIFOQueue(opts.triggerFIFO, func() { b.sendNow("") })
+			if err != nil {
+				fmt.Fprintf(stderr, "ERROR: bird %q: %v\n", entry.name, err)
+				return 1
+			}
+			defer q.Close()
+			b.queues = append(b.queues, q)
+		}
+		if opts.redisQueue != "" {
+			q, err := openRedisQueue(opts.redisQueue)
+			if err != nil {
+				fmt.Fprintf(stderr, "ERROR: bird %q: %v\n", entry.name, err)
+				return 2
+			}
+			defer q.Close()
+			b.queues = append(b.queues, q)
+		}
+		if opts.asciicast != "" {
+			c, err := openCast(opts.asciicast, target, opts.castPre, opts.castPost)
+			if err != nil {
+				fmt.Fprintf(stderr, "ERROR: bird %q: opening asciicast: %v\n", entry.name, err)
+				return 1
+			}
+			defer func() {
+				if err := c.Close(); err != nil {
+					b.logf("WARNING: %v", err)
+				}
+			}()
+			b.cast = c
+		}
+		if opts.idleMode == idleModePipe || opts.idleMode == idleModePrompt {
+			m, err := startPipeMonitor(target, opts.idleMode == idleModePrompt)
+			if err != nil {
+				fmt.Fprintf(stderr, "ERROR: bird %q: failed attaching pipe-pane monitor to target %q: %v\n", entry.name, target, err)
+				return 1
+			}
+			b.monitor = m
+			defer b.closeMonitor()
+		}
+		if opts.watch {
+			watcher, err := startFileWatcher(opts.messagesFile, watchDebounce, func() {
+				b.logf("messages file %q changed; reloading", opts.messagesFile)
+				if err := b.reload(); err != nil {
+					b.logf("WARNING: reload failed: %v", err)
+				}
+			})
+			if err != nil {
+				fmt.Fprintf(stderr, "ERROR: bird %q: failed watching messages file %q: %v\n", entry.name, opts.messagesFile, err)
+				return 1
+			}
+			defer watcher.Close()
+		}
+		f.birds = append(f.birds, b)
+	}
+
+	stopReload := installReloadHandler(cancel, &interruptCode, func() {
+		if err := f.reload(""); err != nil {
+			logf("WARNING: reload failed: %v", err)
+		}
+	})
+	defer stopReload()
+	defer installUserSignals(f)()
+	stopServers, code := startFlockServers(f, servers)
+	if code != 0 {
+		return code
+	}
+	defer stopServers()
+
+	codes := make([]int, len(f.birds))
+	var wg sync.WaitGroup
+	for i, b := range f.birds {
+		opts := b.options()
+		b.logf(
+			"session=%q send-target=%q idle-mode=%s idle-timeout=%s delay=%s messages=%d",
+			opts.session, b.target, opts.idleMode, opts.timeout, opts.delay, len(opts.messages),
+		)
+		wg.Add(1)
+		go func(i int, b *bird) {
+			defer wg.Done()
+			codes[i] = b.run(ctx, &interruptCode)
+			if ctx.Err() == nil {
+				b.logf("finished with exit code %d", codes[i])
+			}
+		}(i, b)
+	}
+	wg.Wait()
+	return firstNonZero(codes)
+}
+
+func firstNonZero(codes []int) int {
+	for _, code := range codes {
+		if code != 0 {
+			return code
+		}
+	}
+	return 0
+}
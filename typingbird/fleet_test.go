@@ -0,0 +1,130 @@
+package typingbird
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testFleet = `
+timeout: 45s
+messages: [continue]
+birds:
+  - session: agent
+  - name: build
+    session: work
+    target-pane: "%3"
+    timeout: 2m
+    order: random
+    messages: [a, b]
+`
+
+func TestParseFleet(t *testing.T) {
+	fleet, err := parseFleet([]byte(testFleet))
+	if err != nil {
+		t.Fatalf("parseFleet(...) error: %v", err)
+	}
+	var names, targets []string
+	for _, entry := range fleet.birds {
+		names = append(names, entry.name)
+		targets = append(targets, entry.targetPane)
+	}
+	if want := []string{"agent", "build"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("parseFleet(...) names = %q; want %q", names, want)
+	}
+	if want := []string{"", "%3"}; !reflect.DeepEqual(targets, want) {
+		t.Fatalf("parseFleet(...) target panes = %q; want %q", targets, want)
+	}
+
+	agent, err := fleet.options(fleet.birds[0], "fleet.yaml")
+	if err != nil {
+		t.Fatalf("options(agent) error: %v", err)
+	}
+	if agent.session != "agent" || agent.timeout != 45*time.Second || !reflect.DeepEqual(agent.messages, []string{"continue"}) {
+		t.Fatalf("options(agent) = session %q timeout %s messages %q; want fleet defaults", agent.session, agent.timeout, agent.messages)
+	}
+	build, err := fleet.options(fleet.birds[1], "fleet.yaml")
+	if err != nil {
+		t.Fatalf("options(build) error: %v", err)
+	}
+	if build.session != "work" || build.timeout != 2*time.Minute || build.order != orderRandom || !reflect.DeepEqual(build.messages, []string{"a", "b"}) {
+		t.Fatalf("options(build) = session %q timeout %s order %s messages %q; want per-bird overrides", build.session, build.timeout, build.order, build.messages)
+	}
+}
+
+func TestParseFleetErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"no birds", "timeout: 1m\n", "non-empty list"},
+		{"duplicate names", "birds:\n  - session: a\n  - session: a\n", "duplicate bird name"},
+		{"unnamed", "birds:\n  - timeout: 1m\n", "needs a name or session"},
+		{"process-wide key", "birds:\n  - session: a\n    api-listen: :8080\n", "whole fleet"},
+		{"short process-wide key", "v: true\nbirds:\n  - session: a\n", "whole fleet"},
+		{"profiles", "profiles: {}\nbirds:\n  - session: a\n", "profiles"},
+		{"window without project", "birds:\n  - window: editor\n", "requires a project"},
+		{"pane without window", "project: p.yaml\nbirds:\n  - session: a\n    pane: 1\n", "pane requires window"},
+		{"negative pane", "project: p.yaml\nbirds:\n  - window: a\n    pane: -1\n", "pane must be"},
+		{"window and target pane", "project: p.yaml\nbirds:\n  - window: a\n    target-pane: \"%1\"\n", "cannot be combined"},
+	}
+	for _, tt := range tests {
+		if _, err := parseFleet([]byte(tt.raw)); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("%s: parseFleet(...) error = %v; want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestFleetOptionsValidatesEachBird(t *testing.T) {
+	fleet, err := parseFleet([]byte("birds:\n  - session: a\n    timeout: nope\n"))
+	if err != nil {
+		t.Fatalf("parseFleet(...) error: %v", err)
+	}
+	if _, err := fleet.options(fleet.birds[0], "fleet.yaml"); err == nil || !strings.Contains(err.Error(), `bird "a"`) {
+		t.Fatalf("options(...) error = %v; want an invalid timeout error naming the bird", err)
+	}
+	if _, err := fleet.entry("gone"); err == nil {
+		t.Fatalf("entry(%q) = nil error; want missing bird error", "gone")
+	}
+}
+
+func TestFleetProject(t *testing.T) {
+	dir := t.TempDir()
+	project := "name: work\nwindows:\n  - editor:\n      panes: [vim, ~]\n  - server: rails s\n"
+	if err := os.WriteFile(filepath.Join(dir, "work.yml"), []byte(project), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "fleet.yaml")
+	raw := "project: work.yml\nmessages: [continue]\nbirds:\n  - window: editor\n    pane: 1\n  - name: server\n  - name: other\n    session: elsewhere\n"
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fleet, err := loadFleetFile(path)
+	if err != nil {
+		t.Fatalf("loadFleetFile(...) error: %v", err)
+	}
+	var got []string
+	for _, entry := range fleet.birds {
+		opts, err := fleet.options(entry, path)
+		if err != nil {
+			t.Fatalf("options(%q) error: %v", entry.name, err)
+		}
+		got = append(got, fmt.Sprintf("%s=%s:%s.%d", entry.name, opts.session, entry.window, entry.pane))
+	}
+	if want := []string{"editor=work:editor.1", "server=work:server.0", "other=elsewhere:.0"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("fleet birds = %q; want %q", got, want)
+	}
+
+	raw = "project: work.yml\nbirds:\n  - window: editor\n    pane: 2\n"
+	if err := os.WriteFile(path, []byte(raw), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadFleetFile(path); err == nil || !strings.Contains(err.Error(), "has 2 panes") {
+		t.Fatalf("loadFleetFile(pane 2 of 2) error = %v; want it to mention the pane count", err)
+	}
+}
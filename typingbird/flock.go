@@ -0,0 +1,151 @@
+package typingbird
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// flock is the set of birds served by one process's control APIs. A
+// standalone process is a flock of one unnamed bird; fleet birds share an
+// event hub and are addressed by name. Birds may join while the APIs are
+// serving, so they are read through list.
+type flock struct {
+	mu    sync.Mutex
+	birds []*bird
+	// hub is the fleet's shared event hub, for a flock that may start
+	// empty; nil means the first bird's.
+	hub *eventHub
+}
+
+func (f *flock) list() []*bird {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.birds[:len(f.birds):len(f.birds)]
+}
+
+func (f *flock) add(b *bird) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.birds = append(f.birds, b)
+}
+
+func (f *flock) fleet() bool {
+	birds := f.list()
+	return len(birds) != 1 || birds[0].name != ""
+}
+
+func (f *flock) names() []string {
+	birds := f.list()
+	names := make([]string, 0, len(birds))
+	for _, b := range birds {
+		names = append(names, b.name)
+	}
+	return names
+}
+
+// lookup finds the bird an API request is for. A standalone bird answers to
+// the empty name; in a fleet the name is required.
+func (f *flock) lookup(name string) (*bird, error) {
+	if !f.fleet() {
+		if b := f.list()[0]; name == "" || name == b.name {
+			return b, nil
+		}
+		return nil, fmt.Errorf("unknown bird %q", name)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("bird name required (one of: %s)", strings.Join(f.names(), ", "))
+	}
+	for _, b := range f.list() {
+		if b.name == name {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown bird %q (one of: %s)", name, strings.Join(f.names(), ", "))
+}
+
+// status reports one bird, or every bird of a fleet when name is empty.
+func (f *flock) status(name string) (any, error) {
+	if name == "" && f.fleet() {
+		birds := f.list()
+		statuses := make([]birdStatus, 0, len(birds))
+		for _, b := range birds {
+			statuses = append(statuses, b.status())
+		}
+		return statuses, nil
+	}
+	b, err := f.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return b.status(), nil
+}
+
+// reload reloads one bird, or every bird of a fleet when name is empty.
+func (f *flock) reload(name string) error {
+	if name == "" && f.fleet() {
+		var errs []error
+		for _, b := range f.list() {
+			if err := b.reload(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", b.name, err))
+			}
+		}
+		return errors.Join(errs...)
+	}
+	b, err := f.lookup(name)
+	if err != nil {
+		return err
+	}
+	return b.reload()
+}
+
+func (f *flock) events() *eventHub {
+	if f.hub != nil {
+		return f.hub
+	}
+	return f.list()[0].events
+}
+
+// controlCommand dispatches control socket commands. In a fleet the first
+// argument names the bird; status and reload without one apply to all.
+func (f *flock) controlCommand(command string, args []string) controlResponse {
+	if !f.fleet() {
+		return f.list()[0].controlCommand(command, args)
+	}
+	if command == "tmux-event" {
+		// Hook notifications are for whichever bird they concern.
+		for _, b := range f.list() {
+			if resp := b.controlCommand(command, args); !resp.OK {
+				return resp
+			}
+		}
+		return controlOK(nil)
+	}
+	name := ""
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	switch command {
+	case "status":
+		st, err := f.status(name)
+		if err != nil {
+			return controlError(err)
+		}
+		return controlOK(st)
+	case "reload":
+		if err := f.reload(name); err != nil {
+			return controlError(err)
+		}
+		return controlOK(nil)
+	case "watch":
+		if name == "" {
+			return controlOK(eventStream{hub: f.events()})
+		}
+	}
+	b, err := f.lookup(name)
+	if err != nil {
+		return controlError(err)
+	}
+	return b.controlCommand(command, args)
+}
@@ -0,0 +1,57 @@
+package typingbird
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestFlock(t *testing.T, names ...string) *flock {
+	t.Helper()
+	f := &flock{}
+	events := &eventHub{}
+	for _, name := range names {
+		b, err := newBird(options{timeout: time.Second, order: orderRoundRobin, session: "s", messages: []string{"a", "b"}}, "%1")
+		if err != nil {
+			t.Fatalf("newBird(...) error: %v", err)
+		}
+		b.name = name
+		b.events = events
+		f.birds = append(f.birds, b)
+	}
+	return f
+}
+
+func TestFlockLookup(t *testing.T) {
+	solo := newTestFlock(t, "")
+	if b, err := solo.lookup(""); err != nil || b != solo.birds[0] {
+		t.Fatalf("standalone lookup(\"\") = %v, %v; want the only bird", b, err)
+	}
+	fleet := newTestFlock(t, "one", "two")
+	if _, err := fleet.lookup(""); err == nil {
+		t.Fatalf("fleet lookup(\"\") = nil error; want name required")
+	}
+	if b, err := fleet.lookup("two"); err != nil || b != fleet.birds[1] {
+		t.Fatalf("fleet lookup(%q) = %v, %v; want the second bird", "two", b, err)
+	}
+	if _, err := fleet.lookup("three"); err == nil {
+		t.Fatalf("fleet lookup(%q) = nil error; want unknown bird", "three")
+	}
+}
+
+func TestFlockControlCommand(t *testing.T) {
+	f := newTestFlock(t, "one", "two")
+	resp := f.controlCommand("status", nil)
+	statuses, ok := resp.Data.([]birdStatus)
+	if !resp.OK || !ok || len(statuses) != 2 || statuses[1].Name != "two" {
+		t.Fatalf("controlCommand(status) = %+v; want both birds", resp)
+	}
+	if resp := f.controlCommand("pause", []string{"two"}); !resp.OK {
+		t.Fatalf("controlCommand(pause two) = %+v; want OK", resp)
+	}
+	if f.birds[0].status().Paused || !f.birds[1].status().Paused {
+		t.Fatalf("after pause two: paused = %v, %v; want only two paused", f.birds[0].status().Paused, f.birds[1].status().Paused)
+	}
+	if resp := f.controlCommand("pause", nil); resp.OK {
+		t.Fatalf("controlCommand(pause) without a bird = %+v; want error", resp)
+	}
+}
@@ -0,0 +1,253 @@
+package typingbird
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// messageFlow is a config-defined state machine that replaces the flat
+// message rotation. Each step sends its message once the pane is idle, then
+// watches the output for its on-match patterns to pick the next step:
+//
+//	flow:
+//	  - state: build
+//	    message: run the build
+//	    on-match:
+//	      - 'BUILD OK -> test'
+//	      - 'error: -> fix'
+//	    timeout: 10m
+//	    on-timeout: build
+//	  - state: fix
+//	    message: fix the errors above
+//	    next: build
+//	  - state: test
+//	    message: run the tests
+//	    on-match: ['PASS -> done', 'FAIL -> fix']
+//	  - state: done
+//
+// The first state is the start. A step with neither on-match nor next ends
+// the flow after sending, and one without a message ends it on arrival.
+type messageFlow struct {
+	steps []flowStep
+}
+
+type flowStep struct {
+	name        string
+	message     string
+	transitions []flowTransition
+	// timeout bounds the wait for a transition; zero falls back to
+	// --expect-timeout.
+	timeout time.Duration
+	// onTimeout is the step taken when nothing matched in time; empty
+	// repeats this step.
+	onTimeout string
+	// next is taken straight after sending when there are no transitions.
+	next string
+	// delay overrides --delay for this step's message; "" keeps it.
+	delay string
+}
+
+type flowTransition struct {
+	re   *regexp.Regexp
+	next string
+}
+
+// parseFlow builds a flow from the config's flow key.
+func parseFlow(value any) (*messageFlow, error) {
+	entries, ok := value.([]any)
+	if !ok || len(entries) == 0 {
+		return nil, fmt.Errorf("expected a list of states")
+	}
+	f := &messageFlow{}
+	seen := map[string]bool{}
+	for i, entry := range entries {
+		fields, ok := entry.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("state %d must be a mapping", i+1)
+		}
+		step, err := parseFlowStep(fields)
+		if err != nil {
+			return nil, fmt.Errorf("state %d: %w", i+1, err)
+		}
+		if seen[step.name] {
+			return nil, fmt.Errorf("duplicate state %q", step.name)
+		}
+		if i == 0 && step.message == "" {
+			return nil, fmt.Errorf("start state %q needs a message", step.name)
+		}
+		seen[step.name] = true
+		f.steps = append(f.steps, step)
+	}
+	for _, step := range f.steps {
+		targets := []string{step.onTimeout, step.next}
+		for _, t := range step.transitions {
+			targets = append(targets, t.next)
+		}
+		for _, target := range targets {
+			if target != "" && !seen[target] {
+				return nil, fmt.Errorf("state %q: unknown state %q", step.name, target)
+			}
+		}
+	}
+	return f, nil
+}
+
+func parseFlowStep(fields map[string]any) (flowStep, error) {
+	var step flowStep
+	for key, value := range fields {
+		if value == nil {
+			continue
+		}
+		switch key {
+		case "state", "message", "on-timeout", "next", "timeout", "delay":
+			switch value.(type) {
+			case []any, map[string]any:
+				return flowStep{}, fmt.Errorf("%s must be a scalar", key)
+			}
+		}
+		switch key {
+		case "state":
+			step.name = fmt.Sprint(value)
+		case "message":
+			step.message = fmt.Sprint(value)
+		case "on-timeout":
+			step.onTimeout = fmt.Sprint(value)
+		case "next":
+			step.next = fmt.Sprint(value)
+		case "timeout":
+			d, err := parseDuration(fmt.Sprint(value), "timeout", false)
+			if err != nil {
+				return flowStep{}, err
+			}
+			step.timeout = d
+		case "delay":
+			step.delay = fmt.Sprint(value)
+			if _, err := parseDuration(step.delay, "delay", false); err != nil {
+				return flowStep{}, err
+			}
+		case "on-match":
+			rules, err := configStrings(value)
+			if err != nil {
+				return flowStep{}, fmt.Errorf("on-match: %w", err)
+			}
+			for _, rule := range rules {
+				t, err := parseFlowTransition(rule)
+				if err != nil {
+					return flowStep{}, err
+				}
+				step.transitions = append(step.transitions, t)
+			}
+		default:
+			return flowStep{}, fmt.Errorf("unknown key %q", key)
+		}
+	}
+	if step.name == "" {
+		return flowStep{}, fmt.Errorf("state name is required")
+	}
+	if len(step.transitions) > 0 && step.next != "" {
+		return flowStep{}, fmt.Errorf("state %q: next cannot be combined with on-match", step.name)
+	}
+	if step.message == "" && (len(step.transitions) > 0 || step.next != "" || step.onTimeout != "") {
+		return flowStep{}, fmt.Errorf("state %q: a state without a message ends the flow and cannot have transitions", step.name)
+	}
+	return step, nil
+}
+
+// parseFlowTransition parses "REGEX -> STATE". The last arrow separates the
+// two, so the pattern itself may contain one.
+func parseFlowTransition(rule string) (flowTransition, error) {
+	i := strings.LastIndex(rule, "->")
+	if i < 0 {
+		return flowTransition{}, fmt.Errorf("invalid on-match %q: want 'REGEX -> STATE'", rule)
+	}
+	pattern, next := strings.TrimSpace(rule[:i]), strings.TrimSpace(rule[i+2:])
+	if pattern == "" || next == "" {
+		return flowTransition{}, fmt.Errorf("invalid on-match %q: want 'REGEX -> STATE'", rule)
+	}
+	re, err := compileOutputPattern(pattern)
+	if err != nil {
+		return flowTransition{}, fmt.Errorf("invalid on-match %q: %w", rule, err)
+	}
+	return flowTransition{re: re, next: next}, nil
+}
+
+// index returns the position of the named state, or -1.
+func (f *messageFlow) index(name string) int {
+	for i, step := range f.steps {
+		if step.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// messages lists each state's message by position, so the usual message
+// index, logs and events refer to the current state.
+func (f *messageFlow) messages() []string {
+	out := make([]string, len(f.steps))
+	for i, step := range f.steps {
+		out[i] = step.message
+	}
+	return out
+}
+
+// delays lists each state's delay override by position.
+func (f *messageFlow) delays() []string {
+	out := make([]string, len(f.steps))
+	for i, step := range f.steps {
+		out[i] = step.delay
+	}
+	return out
+}
+
+// patterns returns the transition patterns of step i in order.
+func (f *messageFlow) patterns(i int) []*regexp.Regexp {
+	var out []*regexp.Regexp
+	for _, t := range f.steps[i].transitions {
+		out = append(out, t.re)
+	}
+	return out
+}
+
+// terminal reports whether the flow stops on arriving at step i.
+func (f *messageFlow) terminal(i int) bool {
+	return f.steps[i].message == ""
+}
+
+// moveFlow switches the bird from step from to step to of flow. It reports
+// whether the flow ends there; a flow replaced by a reload meanwhile is left
+// alone.
+func (b *bird) moveFlow(flow *messageFlow, from, to int, why string) bool {
+	b.mu.Lock()
+	current := b.opts.flow == flow
+	if current {
+		b.flowStep = to
+	}
+	b.mu.Unlock()
+	if !current {
+		return false
+	}
+	if from == to {
+		b.debugf("flow: staying in %q (%s)", flow.steps[to].name, why)
+	} else {
+		b.logf("flow: %q -> %q (%s)", flow.steps[from].name, flow.steps[to].name, why)
+	}
+	return flow.terminal(to)
+}
+
+// resolveFlow leaves the step whose transitions exp watched, given the
+// index of the matching pattern or -1 when none matched in time.
+func (b *bird) resolveFlow(exp *pendingExpect, matched int) bool {
+	step := exp.flow.steps[exp.step]
+	next, why := exp.step, "no transition matched"
+	switch {
+	case matched >= 0:
+		t := step.transitions[matched]
+		next, why = exp.flow.index(t.next), fmt.Sprintf("matched %s", outputPattern(t.re))
+	case step.onTimeout != "":
+		next, why = exp.flow.index(step.onTimeout), fmt.Sprintf("nothing matched within %s", exp.timeout)
+	}
+	return b.moveFlow(exp.flow, exp.step, next, why)
+}
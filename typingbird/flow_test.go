@@ -0,0 +1,100 @@
+package typingbird
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testFlowConfig = `
+flow:
+  - state: build
+    message: run the build
+    on-match:
+      - 'BUILD OK -> test'
+      - 'error: -> fix'
+    timeout: 10m
+    on-timeout: build
+  - state: fix
+    message: fix the errors above
+    delay: 50ms
+    next: build
+  - state: test
+    message: run the tests
+    on-match: ['PASS -> done', 'FAIL -> fix']
+  - state: done
+profiles:
+  plain:
+    messages: [continue]
+`
+
+func TestConfigFlow(t *testing.T) {
+	cfg, err := parseConfig([]byte(testFlowConfig))
+	if err != nil {
+		t.Fatalf("parseConfig(...) error: %v", err)
+	}
+	got, err := cfg.resolve("")
+	if err != nil {
+		t.Fatalf("resolve(\"\") error: %v", err)
+	}
+	if got.flow == nil {
+		t.Fatalf("resolve(\"\").flow = nil; want a flow")
+	}
+	f := got.flow
+	if want := []string{"run the build", "fix the errors above", "run the tests", ""}; !reflect.DeepEqual(f.messages(), want) {
+		t.Fatalf("messages() = %q; want %q", f.messages(), want)
+	}
+	if want := []string{"", "50ms", "", ""}; !reflect.DeepEqual(f.delays(), want) {
+		t.Fatalf("delays() = %q; want %q", f.delays(), want)
+	}
+	build := f.steps[0]
+	if build.timeout != 10*time.Minute || build.onTimeout != "build" || len(build.transitions) != 2 {
+		t.Fatalf("steps[0] = %+v; want timeout, on-timeout and two transitions", build)
+	}
+	if tr := build.transitions[1]; outputPattern(tr.re) != "/error:/" || tr.next != "fix" {
+		t.Fatalf("steps[0].transitions[1] = %s -> %q; want /error:/ -> fix", outputPattern(tr.re), tr.next)
+	}
+	if f.index("test") != 2 || f.index("missing") != -1 || !f.terminal(3) || f.terminal(1) {
+		t.Fatalf("index/terminal disagree with the config order")
+	}
+
+	plain, err := cfg.resolve("plain")
+	if err != nil {
+		t.Fatalf("resolve(%q) error: %v", "plain", err)
+	}
+	if plain.flow != nil || !reflect.DeepEqual(plain.messages, []string{"continue"}) {
+		t.Fatalf("resolve(%q) = %#v; want messages replacing the flow", "plain", plain)
+	}
+}
+
+func TestParseFlowErrors(t *testing.T) {
+	tests := map[string]string{
+		"not a list":        `flow: {state: a}`,
+		"unknown target":    "flow:\n  - {state: a, message: m, next: b}\n",
+		"duplicate":         "flow:\n  - {state: a, message: m}\n  - {state: a, message: n}\n",
+		"missing arrow":     "flow:\n  - {state: a, message: m, on-match: [done]}\n",
+		"bad regex":         "flow:\n  - {state: a, message: m, on-match: ['( -> a']}\n",
+		"next and on-match": "flow:\n  - {state: a, message: m, next: a, on-match: ['x -> a']}\n",
+		"silent start":      "flow:\n  - {state: a}\n",
+		"unknown key":       "flow:\n  - {state: a, message: m, goto: a}\n",
+	}
+	for name, raw := range tests {
+		cfg, err := parseConfig([]byte(raw))
+		if err != nil {
+			t.Fatalf("%s: parseConfig(...) error: %v", name, err)
+		}
+		if _, err := cfg.resolve(""); err == nil {
+			t.Fatalf("%s: resolve(\"\") error = nil; want error", name)
+		}
+	}
+}
+
+func TestParseFlowTransition(t *testing.T) {
+	tr, err := parseFlowTransition("a -> b -> next")
+	if err != nil {
+		t.Fatalf("parseFlowTransition(...) error: %v", err)
+	}
+	if outputPattern(tr.re) != "/a -> b/" || tr.next != "next" {
+		t.Fatalf("parseFlowTransition(...) = %s -> %q; want /a -> b/ -> next", outputPattern(tr.re), tr.next)
+	}
+}
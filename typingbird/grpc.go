@@ -0,0 +1,208 @@
+package typingbird
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	typingbirdv1 "typing-bird/api/typingbird/v1"
+)
+
+var eventTypes = map[string]typingbirdv1.EventType{
+	eventIdle:     typingbirdv1.EventType_EVENT_TYPE_IDLE,
+	eventSent:     typingbirdv1.EventType_EVENT_TYPE_SENT,
+	eventSkipped:  typingbirdv1.EventType_EVENT_TYPE_SKIPPED,
+	eventPaused:   typingbirdv1.EventType_EVENT_TYPE_PAUSED,
+	eventResumed:  typingbirdv1.EventType_EVENT_TYPE_RESUMED,
+	eventReloaded: typingbirdv1.EventType_EVENT_TYPE_RELOADED,
+	eventError:    typingbirdv1.EventType_EVENT_TYPE_ERROR,
+}
+
+// grpcService implements the TypingBird gRPC service on top of a flock.
+type grpcService struct {
+	typingbirdv1.UnimplementedTypingBirdServer
+	flock *flock
+}
+
+func (s *grpcService) lookup(name string) (*bird, error) {
+	b, err := s.flock.lookup(name)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	return b, nil
+}
+
+func (s *grpcService) GetStatus(_ context.Context, req *typingbirdv1.GetStatusRequest) (*typingbirdv1.Status, error) {
+	b, err := s.lookup(req.GetBird())
+	if err != nil {
+		return nil, err
+	}
+	return statusProto(b.status()), nil
+}
+
+func (s *grpcService) ListBirds(context.Context, *typingbirdv1.ListBirdsRequest) (*typingbirdv1.ListBirdsResponse, error) {
+	resp := &typingbirdv1.ListBirdsResponse{}
+	for _, b := range s.flock.list() {
+		resp.Birds = append(resp.Birds, statusProto(b.status()))
+	}
+	return resp, nil
+}
+
+func statusProto(st birdStatus) *typingbirdv1.Status {
+	return &typingbirdv1.Status{
+		Name:     st.Name,
+		Pid:      int32(st.PID),
+		Session:  st.Session,
+		Target:   st.Target,
+		IdleMode: st.IdleMode,
+		Timeout:  st.Timeout,
+		Messages: int32(st.Messages),
+		Next:     int32(st.Next),
+		Sends:    int32(st.Sends),
+		Paused:   st.Paused,
+	}
+}
+
+func (s *grpcService) SendNow(_ context.Context, req *typingbirdv1.SendNowRequest) (*typingbirdv1.SendNowResponse, error) {
+	b, err := s.lookup(req.GetBird())
+	if err != nil {
+		return nil, err
+	}
+	b.sendNow(req.GetMessage())
+	return &typingbirdv1.SendNowResponse{}, nil
+}
+
+func (s *grpcService) Pause(_ context.Context, req *typingbirdv1.PauseRequest) (*typingbirdv1.PauseResponse, error) {
+	b, err := s.lookup(req.GetBird())
+	if err != nil {
+		return nil, err
+	}
+	b.pause()
+	return &typingbirdv1.PauseResponse{}, nil
+}
+
+func (s *grpcService) Resume(_ context.Context, req *typingbirdv1.ResumeRequest) (*typingbirdv1.ResumeResponse, error) {
+	b, err := s.lookup(req.GetBird())
+	if err != nil {
+		return nil, err
+	}
+	b.resume()
+	return &typingbirdv1.ResumeResponse{}, nil
+}
+
+func (s *grpcService) UpdateMessages(_ context.Context, req *typingbirdv1.UpdateMessagesRequest) (*typingbirdv1.UpdateMessagesResponse, error) {
+	b, err := s.lookup(req.GetBird())
+	if err != nil {
+		return nil, err
+	}
+	if err := b.setMessages(req.GetMessages()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &typingbirdv1.UpdateMessagesResponse{}, nil
+}
+
+func (s *grpcService) Watch(_ *typingbirdv1.WatchRequest, stream typingbirdv1.TypingBird_WatchServer) error {
+	events, unsubscribe := s.flock.events().subscribe()
+	defer unsubscribe()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev, ok := <-events:
+			if !ok {
+				return nil
+			}
+			err := stream.Send(&typingbirdv1.Event{
+				Bird:    ev.Bird,
+				Time:    timestamppb.New(ev.Time),
+				Type:    eventTypes[ev.Type],
+				Index:   int32(ev.Index),
+				Total:   int32(ev.Total),
+				Message: ev.Message,
+				Error:   ev.Error,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// grpcServer serves the gRPC API on a TCP address or, with a unix: prefix,
+// a unix socket.
+type grpcServer struct {
+	srv  *grpc.Server
+	path string
+}
+
+func startGRPCServer(addr string, f *flock, token string) (*grpcServer, error) {
+	network, address := "tcp", addr
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		network, address = "unix", path
+		if err := removeStaleSocket(path); err != nil {
+			return nil, err
+		}
+	} else if err := checkListenToken(addr, token); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen(network, address)
+	if err != nil {
+		return nil, err
+	}
+	var opts []grpc.ServerOption
+	if token != "" {
+		opts = append(opts, grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := checkGRPCToken(ctx, token); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}), grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := checkGRPCToken(ss.Context(), token); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}))
+	}
+	srv := grpc.NewServer(opts...)
+	typingbirdv1.RegisterTypingBirdServer(srv, &grpcService{flock: f})
+	s := &grpcServer{srv: srv}
+	if network == "unix" {
+		s.path = address
+	}
+	go func() {
+		if err := srv.Serve(ln); err != nil {
+			logf("WARNING: gRPC server stopped: %v", err)
+		}
+	}()
+	logf("gRPC API listening on %s", ln.Addr())
+	return s, nil
+}
+
+// checkGRPCToken requires "authorization: Bearer <token>" metadata, as the
+// REST API requires the header.
+func checkGRPCToken(ctx context.Context, token string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, got := range md.Get("authorization") {
+		if subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "unauthorized")
+}
+
+// Close stops the server, ending any open Watch streams.
+func (s *grpcServer) Close() error {
+	s.srv.Stop()
+	if s.path != "" {
+		_ = os.Remove(s.path)
+	}
+	return nil
+}
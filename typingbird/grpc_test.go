@@ -0,0 +1,161 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	typingbirdv1 "typing-bird/api/typingbird/v1"
+)
+
+func TestGRPCServerControlsBird(t *testing.T) {
+	b, err := newBird(options{timeout: time.Second, order: orderRoundRobin, session: "s", messages: []string{"a", "b"}}, "%1")
+	if err != nil {
+		t.Fatalf("newBird(...) error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "grpc.sock")
+	server, err := startGRPCServer("unix:"+path, &flock{birds: []*bird{b}}, "")
+	if err != nil {
+		t.Fatalf("startGRPCServer(...) error: %v", err)
+	}
+	defer server.Close()
+
+	conn, err := grpc.NewClient("unix://"+path, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient(...) error: %v", err)
+	}
+	defer conn.Close()
+	client := typingbirdv1.NewTypingBirdClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &typingbirdv1.WatchRequest{})
+	if err != nil {
+		t.Fatalf("Watch() error: %v", err)
+	}
+	// Wait until the subscription is registered before triggering events.
+	for i := 0; ; i++ {
+		b.events.mu.Lock()
+		n := len(b.events.subs)
+		b.events.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if i > 100 {
+			t.Fatalf("Watch() subscription never registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if _, err := client.Pause(ctx, &typingbirdv1.PauseRequest{}); err != nil {
+		t.Fatalf("Pause() error: %v", err)
+	}
+	ev, err := stream.Recv()
+	if err != nil || ev.GetType() != typingbirdv1.EventType_EVENT_TYPE_PAUSED {
+		t.Fatalf("Watch().Recv() = %v, %v; want paused event", ev, err)
+	}
+	st, err := client.GetStatus(ctx, &typingbirdv1.GetStatusRequest{})
+	if err != nil || !st.GetPaused() || st.GetMessages() != 2 {
+		t.Fatalf("GetStatus() = %v, %v; want paused with 2 messages", st, err)
+	}
+
+	if _, err := client.UpdateMessages(ctx, &typingbirdv1.UpdateMessagesRequest{Messages: []string{"x", "y", "z"}}); err != nil {
+		t.Fatalf("UpdateMessages() error: %v", err)
+	}
+	if got := b.options().messages; !reflect.DeepEqual(got, []string{"x", "y", "z"}) {
+		t.Fatalf("messages after UpdateMessages() = %q; want x y z", got)
+	}
+	if _, err := client.UpdateMessages(ctx, &typingbirdv1.UpdateMessagesRequest{}); err == nil {
+		t.Fatalf("UpdateMessages(empty) error = nil; want error")
+	}
+
+	if _, err := client.SendNow(ctx, &typingbirdv1.SendNowRequest{Message: "now"}); err != nil {
+		t.Fatalf("SendNow() error: %v", err)
+	}
+	b.mu.Lock()
+	forced := b.forced
+	b.mu.Unlock()
+	if forced == nil || *forced != "now" {
+		t.Fatalf("forced after SendNow() = %v; want %q", forced, "now")
+	}
+
+	list, err := client.ListBirds(ctx, &typingbirdv1.ListBirdsRequest{})
+	if err != nil || len(list.GetBirds()) != 1 {
+		t.Fatalf("ListBirds() = %v, %v; want one bird", list, err)
+	}
+	if _, err := client.Pause(ctx, &typingbirdv1.PauseRequest{Bird: "other"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Pause(bird=other) error = %v; want NotFound", err)
+	}
+}
+
+func TestGRPCServerRequiresToken(t *testing.T) {
+	b, err := newBird(options{timeout: time.Second, order: orderRoundRobin, session: "s", messages: []string{"a"}}, "%1")
+	if err != nil {
+		t.Fatalf("newBird(...) error: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "grpc.sock")
+	server, err := startGRPCServer("unix:"+path, &flock{birds: []*bird{b}}, "secret")
+	if err != nil {
+		t.Fatalf("startGRPCServer(...) error: %v", err)
+	}
+	defer server.Close()
+
+	conn, err := grpc.NewClient("unix://"+path, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient(...) error: %v", err)
+	}
+	defer conn.Close()
+	client := typingbirdv1.NewTypingBirdClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := client.SendNow(ctx, &typingbirdv1.SendNowRequest{Message: "rm -rf /"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("SendNow() without token error = %v; want Unauthenticated", err)
+	}
+	wrong := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer wrong")
+	if _, err := client.Pause(wrong, &typingbirdv1.PauseRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Pause() with wrong token error = %v; want Unauthenticated", err)
+	}
+	stream, err := client.Watch(ctx, &typingbirdv1.WatchRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Watch() without token error = %v; want Unauthenticated", err)
+	}
+	b.mu.Lock()
+	forced, paused := b.forced, b.paused
+	b.mu.Unlock()
+	if forced != nil || paused {
+		t.Fatalf("unauthenticated calls reached the bird: forced=%v paused=%v", forced, paused)
+	}
+
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+	if _, err := client.Pause(authed, &typingbirdv1.PauseRequest{}); err != nil {
+		t.Fatalf("Pause() with token error: %v", err)
+	}
+	if !b.status().Paused {
+		t.Fatalf("bird not paused after authorized Pause()")
+	}
+}
+
+func TestStartGRPCServerRequiresTokenOffLoopback(t *testing.T) {
+	if _, err := startGRPCServer("0.0.0.0:0", &flock{}, ""); err == nil {
+		t.Fatalf("startGRPCServer(0.0.0.0:0, no token) error = nil; want error")
+	}
+	server, err := startGRPCServer("127.0.0.1:0", &flock{}, "")
+	if err != nil {
+		t.Fatalf("startGRPCServer(127.0.0.1:0, no token) error: %v", err)
+	}
+	server.Close()
+}
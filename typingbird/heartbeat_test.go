@@ -0,0 +1,30 @@
+package typingbird
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTouchHeartbeat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "heartbeat")
+	for _, now := range []time.Time{
+		time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 1, 12, 5, 0, 0, time.UTC),
+	} {
+		if err := touchHeartbeat(path, now); err != nil {
+			t.Fatalf("touchHeartbeat(%v) = %v", now, err)
+		}
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !fi.ModTime().Equal(now) {
+			t.Fatalf("mtime after touchHeartbeat(%v) = %v", now, fi.ModTime())
+		}
+	}
+	if err := touchHeartbeat(filepath.Join(path, "missing", "heartbeat"), time.Now()); err == nil {
+		t.Fatalf("touchHeartbeat(under a file) = nil; want error")
+	}
+}
@@ -0,0 +1,101 @@
+package typingbird
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+const (
+	hookTimeout = 30 * time.Second
+
+	hookEventPreSend  = "pre-send"
+	hookEventPostSend = "post-send"
+	hookEventIdle     = "idle"
+	hookEventError    = "error"
+)
+
+// hooks holds the user shell commands run around the idle loop.
+type hooks struct {
+	preSend  string
+	postSend string
+	onIdle   string
+	onError  string
+}
+
+// hookEvent describes the loop state exposed to a hook via its environment.
+type hookEvent struct {
+	name    string
+	session string
+	target  string
+	message string
+	index   int
+	total   int
+	err     error
+}
+
+// runHook runs command with sh -c, passing the event through TYPING_BIRD_*
+// environment variables. Hook output is forwarded to stderr alongside the
+// bird's own logging.
+func runHook(ctx context.Context, command string, ev hookEvent) error {
+	if command == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, hookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), hookEnv(ev)...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	started := time.Now()
+	err := cmd.Run()
+	debugf("%s hook finished in %s: err=%v", ev.name, time.Since(started).Round(time.Millisecond), err)
+	if err != nil {
+		return fmt.Errorf("%s hook: %w", ev.name, err)
+	}
+	return nil
+}
+
+func hookEnv(ev hookEvent) []string {
+	env := []string{
+		"TYPING_BIRD_EVENT=" + ev.name,
+		"TYPING_BIRD_SESSION=" + ev.session,
+		"TYPING_BIRD_PANE=" + ev.target,
+		"TYPING_BIRD_MESSAGE=" + ev.message,
+		"TYPING_BIRD_MESSAGE_INDEX=" + strconv.Itoa(ev.index+1),
+		"TYPING_BIRD_MESSAGE_COUNT=" + strconv.Itoa(ev.total),
+	}
+	switch ev.name {
+	case hookEventPostSend:
+		result := "ok"
+		errText := ""
+		if ev.err != nil {
+			result = "error"
+			errText = ev.err.Error()
+		}
+		env = append(env, "TYPING_BIRD_RESULT="+result, "TYPING_BIRD_ERROR="+errText)
+	case hookEventError:
+		errText := ""
+		if ev.err != nil {
+			errText = ev.err.Error()
+		}
+		env = append(env, "TYPING_BIRD_ERROR="+errText)
+	}
+	return env
+}
+
+// runErrorHook fires the on-error hook for a failure that is about to end the
+// loop. Hook failures are only logged since the original error wins.
+func runErrorHook(ctx context.Context, command string, ev hookEvent, cause error) {
+	if command == "" {
+		return
+	}
+	ev.name = hookEventError
+	ev.err = cause
+	if err := runHook(ctx, command, ev); err != nil {
+		logf("WARNING: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package typingbird
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHookEnvPreSend(t *testing.T) {
+	got := hookEnv(hookEvent{name: hookEventPreSend, session: "s", target: "%1", message: "hi", index: 0, total: 2})
+	want := []string{
+		"TYPING_BIRD_EVENT=pre-send",
+		"TYPING_BIRD_SESSION=s",
+		"TYPING_BIRD_PANE=%1",
+		"TYPING_BIRD_MESSAGE=hi",
+		"TYPING_BIRD_MESSAGE_INDEX=1",
+		"TYPING_BIRD_MESSAGE_COUNT=2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("hookEnv(...) = %#v; want %#v", got, want)
+	}
+}
+
+func TestHookEnvPostSendIncludesResult(t *testing.T) {
+	got := hookEnv(hookEvent{name: hookEventPostSend, session: "s", target: "%1", message: "hi", index: 1, total: 2, err: errors.New("boom")})
+	tail := got[len(got)-2:]
+	want := []string{"TYPING_BIRD_RESULT=error", "TYPING_BIRD_ERROR=boom"}
+	if !reflect.DeepEqual(tail, want) {
+		t.Fatalf("hookEnv(...) tail = %#v; want %#v", tail, want)
+	}
+}
+
+func TestHookEnvErrorIncludesError(t *testing.T) {
+	got := hookEnv(hookEvent{name: hookEventError, session: "s", err: errors.New("pane gone")})
+	if last := got[len(got)-1]; last != "TYPING_BIRD_ERROR=pane gone" {
+		t.Fatalf("hookEnv(...) last = %q; want %q", last, "TYPING_BIRD_ERROR=pane gone")
+	}
+}
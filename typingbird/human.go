@@ -0,0 +1,57 @@
+package typingbird
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tmuxLastKeypress returns when a client attached to target's session last
+// sent input. It is the zero time when nobody is attached. send-keys does
+// not count, so the bird's own typing is never mistaken for a human's.
+func tmuxLastKeypress(target string) (time.Time, error) {
+	// A --tmux-control client is not a person; its line is left empty.
+	out, err := tmuxOutput("list-clients", "-t", target, "-F", "#{?client_control_mode,,#{client_activity}}")
+	if err != nil {
+		return time.Time{}, err
+	}
+	return latestClientActivity(string(out))
+}
+
+// latestClientActivity picks the most recent of list-clients'
+// #{client_activity} Unix timestamps.
+func latestClientActivity(raw string) (time.Time, error) {
+	var latest int64
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		ts, err := strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid client activity %q", line)
+		}
+		latest = max(latest, ts)
+	}
+	if latest == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(latest, 0), nil
+}
+
+// humanTypedWithin reports how long ago someone typed into target's session
+// when that was less than cooldown ago.
+func humanTypedWithin(target string, cooldown time.Duration, now time.Time) (time.Duration, bool) {
+	last, err := tmuxLastKeypress(target)
+	if err != nil {
+		debugf("checking client activity for %q: %v", target, err)
+		return 0, false
+	}
+	if last.IsZero() {
+		return 0, false
+	}
+	// client_activity has one-second resolution.
+	ago := max(now.Sub(last), 0)
+	return ago, ago < cooldown
+}
@@ -0,0 +1,29 @@
+package typingbird
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatestClientActivity(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"1700000000\n", time.Unix(1700000000, 0)},
+		{"1700000000\n1700000100\n1700000050\n", time.Unix(1700000100, 0)},
+	}
+	for _, tt := range tests {
+		got, err := latestClientActivity(tt.raw)
+		if err != nil {
+			t.Fatalf("latestClientActivity(%q) error: %v", tt.raw, err)
+		}
+		if !got.Equal(tt.want) {
+			t.Fatalf("latestClientActivity(%q) = %v; want %v", tt.raw, got, tt.want)
+		}
+	}
+	if _, err := latestClientActivity("soon\n"); err == nil {
+		t.Fatalf("latestClientActivity(%q) = nil error; want error", "soon")
+	}
+}
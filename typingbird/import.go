@@ -0,0 +1,478 @@
+package typingbird
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// expectDefaultTimeout is expect's own default for "set timeout".
+const expectDefaultTimeout = 10 * time.Second
+
+// runImport converts scripts written for other tools into a typing-bird
+// config on stdout. Only expect is supported so far.
+func runImport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	session := fs.String("session", "", "session name written to the config (default: the script's base name)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird import expect [--session name] script.exp")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Converts a simple spawn/expect/send script into a config with a flow.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "expect" {
+		fs.Usage()
+		return 2
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	path := fs.Arg(0)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if *session == "" {
+		*session = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	out, err := importExpect(string(raw), filepath.Base(path), *session)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %s: %v\n", path, err)
+		return 1
+	}
+	_, _ = io.WriteString(stdout, out)
+	return 0
+}
+
+// importedConfig is the config written by import; its field order is the
+// order of the YAML keys.
+type importedConfig struct {
+	Session string          `yaml:"session"`
+	Create  string          `yaml:"create,omitempty"`
+	Flow    []importedState `yaml:"flow"`
+}
+
+type importedState struct {
+	State     string   `yaml:"state"`
+	Message   string   `yaml:"message,omitempty"`
+	OnMatch   []string `yaml:"on-match,omitempty"`
+	Timeout   string   `yaml:"timeout,omitempty"`
+	OnTimeout string   `yaml:"on-timeout,omitempty"`
+	Next      string   `yaml:"next,omitempty"`
+}
+
+// importExpect converts an expect script into a config whose flow sends each
+// send's text in turn, moving on when the expect after it matches. Like
+// expect, a step also moves on once its timeout passes. Commands with no
+// counterpart are listed in comments at the top; anything that needs Tcl
+// (variables, procs, branches) is an error.
+func importExpect(src, name, session string) (string, error) {
+	commands, err := parseExpectScript(src)
+	if err != nil {
+		return "", err
+	}
+	cfg := importedConfig{Session: session}
+	var notes []string
+	// Each send becomes a step; expects attach to the step before them.
+	type step struct {
+		message string
+		pattern string
+		timeout time.Duration
+	}
+	var steps []step
+	var pending strings.Builder
+	pendingLine := 0
+	flush := func() {
+		if pendingLine == 0 {
+			return
+		}
+		text := pending.String()
+		if trimmed := strings.TrimRight(text, "\r\n"); trimmed != text {
+			text = trimmed
+		} else {
+			notes = append(notes, fmt.Sprintf("line %d: send has no trailing \\r; typing-bird presses Enter anyway", pendingLine))
+		}
+		text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+		steps = append(steps, step{message: text})
+		pending.Reset()
+		pendingLine = 0
+	}
+	timeout := expectDefaultTimeout
+	for _, c := range commands {
+		args := c.words[1:]
+		switch c.words[0] {
+		case "spawn":
+			for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+				args = args[1:]
+			}
+			if cfg.Create != "" {
+				return "", fmt.Errorf("line %d: only one spawn is supported", c.line)
+			}
+			if len(args) == 0 {
+				return "", fmt.Errorf("line %d: spawn needs a command", c.line)
+			}
+			words := make([]string, len(args))
+			for i, arg := range args {
+				words[i] = arg
+				if arg == "" || strings.ContainsFunc(arg, shellSpecial) {
+					words[i] = shellQuoteSingle(arg)
+				}
+			}
+			cfg.Create = strings.Join(words, " ")
+		case "send", "exp_send":
+			args = skipExpectFlags(args)
+			if len(args) != 1 {
+				return "", fmt.Errorf("line %d: send takes one string", c.line)
+			}
+			if pendingLine == 0 {
+				pendingLine = c.line
+			}
+			pending.WriteString(args[0])
+		case "expect", "exp_expect":
+			flush()
+			pattern, stepTimeout, eof, err := expectPattern(args, timeout)
+			if err != nil {
+				return "", fmt.Errorf("line %d: %w", c.line, err)
+			}
+			if eof {
+				continue
+			}
+			if len(steps) == 0 {
+				notes = append(notes, fmt.Sprintf("line %d: expect before the first send dropped; the bird waits for the pane to go idle instead", c.line))
+				continue
+			}
+			last := &steps[len(steps)-1]
+			if last.pattern != "" {
+				notes = append(notes, fmt.Sprintf("line %d: only the first expect after a send is kept", c.line))
+				continue
+			}
+			last.pattern, last.timeout = pattern, stepTimeout
+		case "set":
+			if len(args) == 2 && args[0] == "timeout" {
+				n, err := strconv.Atoi(args[1])
+				if err != nil {
+					return "", fmt.Errorf("line %d: invalid timeout %q", c.line, args[1])
+				}
+				timeout = time.Duration(n) * time.Second
+				continue
+			}
+			notes = append(notes, fmt.Sprintf("line %d: %s ignored", c.line, strings.Join(c.words, " ")))
+		case "sleep", "interact", "close", "wait", "exit", "log_user", "log_file", "exp_internal", "match_max", "puts", "send_user", "stty":
+			notes = append(notes, fmt.Sprintf("line %d: %s ignored", c.line, c.words[0]))
+		default:
+			return "", fmt.Errorf("line %d: unsupported command %q", c.line, c.words[0])
+		}
+	}
+	flush()
+	if len(steps) == 0 {
+		return "", fmt.Errorf("no send commands found")
+	}
+	stepName := func(i int) string {
+		if i == len(steps) {
+			return "done"
+		}
+		return fmt.Sprintf("step%d", i+1)
+	}
+	needDone := false
+	for i, s := range steps {
+		state := importedState{State: stepName(i), Message: s.message}
+		switch {
+		case s.pattern != "":
+			state.OnMatch = []string{s.pattern + " -> " + stepName(i+1)}
+			if s.timeout > 0 {
+				state.Timeout = s.timeout.String()
+				state.OnTimeout = stepName(i + 1)
+			}
+			needDone = needDone || i == len(steps)-1
+		case i < len(steps)-1:
+			state.Next = stepName(i + 1)
+		}
+		cfg.Flow = append(cfg.Flow, state)
+	}
+	if needDone {
+		cfg.Flow = append(cfg.Flow, importedState{State: stepName(len(steps))})
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Imported from %s by typing-bird import expect.\n", name)
+	for _, note := range notes {
+		fmt.Fprintf(&b, "# %s\n", note)
+	}
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// shellSpecial reports whether r needs quoting in a shell word.
+func shellSpecial(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+}
+
+// skipExpectFlags drops leading options such as -- or -s.
+func skipExpectFlags(args []string) []string {
+	for len(args) > 1 && strings.HasPrefix(args[0], "-") {
+		if args[0] == "--" {
+			return args[1:]
+		}
+		args = args[1:]
+	}
+	return args
+}
+
+// expectPattern turns an expect command's arguments into a regexp. Glob
+// patterns (expect's default) and -exact strings are translated; -re is
+// kept as written.
+func expectPattern(args []string, timeout time.Duration) (pattern string, stepTimeout time.Duration, eof bool, err error) {
+	kind := "-gl"
+	for len(args) > 1 && strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "-re", "-gl", "-ex":
+			kind = args[0]
+		case "-exact":
+			kind = "-ex"
+		case "-timeout":
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return "", 0, false, fmt.Errorf("invalid timeout %q", args[1])
+			}
+			timeout = time.Duration(n) * time.Second
+			args = args[1:]
+		case "--":
+		default:
+			return "", 0, false, fmt.Errorf("unsupported expect option %s", args[0])
+		}
+		args = args[1:]
+	}
+	if len(args) != 1 {
+		return "", 0, false, fmt.Errorf("expect with several patterns or actions is not supported")
+	}
+	if strings.ContainsAny(args[0], "\n") && kind == "-gl" {
+		return "", 0, false, fmt.Errorf("expect with several patterns or actions is not supported")
+	}
+	switch {
+	case kind == "-gl" && args[0] == "eof":
+		return "", 0, true, nil
+	case kind == "-gl" && args[0] == "timeout":
+		return "", 0, false, fmt.Errorf("expect timeout is not supported")
+	}
+	switch kind {
+	case "-re":
+		pattern = args[0]
+	case "-ex":
+		pattern = regexp.QuoteMeta(args[0])
+	default:
+		pattern = globRegexp(args[0])
+	}
+	if _, err := compileOutputPattern(pattern); err != nil {
+		return "", 0, false, err
+	}
+	return pattern, timeout, false, nil
+}
+
+// globRegexp translates an expect glob: * and ? wildcards, [...] sets,
+// backslash escapes and ^ or $ anchoring at either end.
+func globRegexp(glob string) string {
+	var b strings.Builder
+	if strings.HasPrefix(glob, "^") {
+		b.WriteByte('^')
+		glob = glob[1:]
+	}
+	anchored := strings.HasSuffix(glob, "$") && !strings.HasSuffix(glob, `\$`)
+	if anchored {
+		glob = glob[:len(glob)-1]
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		case '[':
+			if end := strings.IndexByte(glob[i+1:], ']'); end >= 0 {
+				b.WriteString(glob[i : i+end+2])
+				i += end + 1
+				continue
+			}
+			b.WriteString(`\[`)
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if anchored {
+		b.WriteByte('$')
+	}
+	return b.String()
+}
+
+// expectCommand is one Tcl command from a script with its words already
+// substituted.
+type expectCommand struct {
+	line  int
+	words []string
+}
+
+// parseExpectScript splits src into commands following Tcl's quoting:
+// "double quotes" with backslash escapes, {braces} taken literally, # comments
+// at the start of a command, and newlines or ; ending a command. Variable and
+// command substitution are rejected since there is nothing to evaluate them.
+func parseExpectScript(src string) ([]expectCommand, error) {
+	var commands []expectCommand
+	var current expectCommand
+	line := 1
+	end := func() {
+		if len(current.words) > 0 {
+			commands = append(commands, current)
+		}
+		current = expectCommand{}
+	}
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+			end()
+			continue
+		case c == ';':
+			i++
+			end()
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case c == '\\' && i+1 < len(src) && src[i+1] == '\n':
+			line++
+			i += 2
+			continue
+		case c == '#' && len(current.words) == 0:
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		}
+		if len(current.words) == 0 {
+			current.line = line
+		}
+		var word strings.Builder
+		switch c {
+		case '{':
+			depth := 0
+			start := i + 1
+			for ; i < len(src); i++ {
+				switch src[i] {
+				case '{':
+					depth++
+				case '}':
+					depth--
+				case '\n':
+					line++
+				}
+				if depth == 0 {
+					break
+				}
+			}
+			if i == len(src) {
+				return nil, fmt.Errorf("line %d: missing close-brace", current.line)
+			}
+			word.WriteString(src[start:i])
+			i++
+		case '"':
+			i++
+			for ; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\n' {
+					line++
+				}
+				n, err := expectSubstitute(src[i:], &word)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+				i += n - 1
+			}
+			if i == len(src) {
+				return nil, fmt.Errorf("line %d: missing close-quote", current.line)
+			}
+			i++
+		default:
+			for i < len(src) && !strings.ContainsRune(" \t\r\n;", rune(src[i])) {
+				n, err := expectSubstitute(src[i:], &word)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+				i += n
+			}
+		}
+		current.words = append(current.words, word.String())
+	}
+	end()
+	return commands, nil
+}
+
+// expectSubstitute copies the character or backslash escape at the start of
+// s to word and returns how many bytes it used.
+func expectSubstitute(s string, word *strings.Builder) (int, error) {
+	switch s[0] {
+	case '$':
+		// As in Tcl, a $ not followed by a name is literal.
+		if len(s) > 1 && (s[1] == '_' || s[1] == '{' || s[1] == '(' || s[1] >= '0' && s[1] <= '9' || (s[1]|0x20) >= 'a' && (s[1]|0x20) <= 'z') {
+			return 0, fmt.Errorf("variables are not supported")
+		}
+	case '[':
+		return 0, fmt.Errorf("command substitution is not supported")
+	case '\\':
+		if len(s) < 2 {
+			word.WriteByte('\\')
+			return 1, nil
+		}
+		switch s[1] {
+		case 'r':
+			word.WriteByte('\r')
+		case 'n':
+			word.WriteByte('\n')
+		case 't':
+			word.WriteByte('\t')
+		case 'e':
+			word.WriteByte('\x1b')
+		case 'x':
+			if len(s) >= 4 {
+				if v, err := strconv.ParseUint(s[2:4], 16, 8); err == nil {
+					word.WriteByte(byte(v))
+					return 4, nil
+				}
+			}
+			word.WriteByte('x')
+		default:
+			word.WriteByte(s[1])
+		}
+		return 2, nil
+	}
+	word.WriteByte(s[0])
+	return 1, nil
+}
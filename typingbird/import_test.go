@@ -0,0 +1,93 @@
+package typingbird
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testExpectScript = `#!/usr/bin/expect -f
+# Log in and run the nightly job.
+set timeout 30
+spawn ssh -t build@ci "cd /srv && bash"
+expect "$ "
+send "make nightly\r"
+expect {BUILD OK}
+send -- "exit\r"
+expect eof
+`
+
+const testExpectConfig = `# Imported from nightly.exp by typing-bird import expect.
+# line 5: expect before the first send dropped; the bird waits for the pane to go idle instead
+session: ci
+create: ssh -t build@ci 'cd /srv && bash'
+flow:
+  - state: step1
+    message: make nightly
+    on-match:
+      - BUILD OK -> step2
+    timeout: 30s
+    on-timeout: step2
+  - state: step2
+    message: exit
+`
+
+func TestImportExpect(t *testing.T) {
+	got, err := importExpect(testExpectScript, "nightly.exp", "ci")
+	if err != nil {
+		t.Fatalf("importExpect(...) error: %v", err)
+	}
+	if got != testExpectConfig {
+		t.Fatalf("importExpect(...) =\n%s\nwant\n%s", got, testExpectConfig)
+	}
+	cfg, err := parseConfig([]byte(got))
+	if err != nil {
+		t.Fatalf("parseConfig(imported) error: %v", err)
+	}
+	resolved, err := cfg.resolve("")
+	if err != nil {
+		t.Fatalf("resolve(\"\") error: %v", err)
+	}
+	if want := []string{"make nightly", "exit"}; resolved.flow == nil || !reflect.DeepEqual(resolved.flow.messages(), want) {
+		t.Fatalf("imported flow = %+v; want messages %q", resolved.flow, want)
+	}
+}
+
+func TestImportExpectPatterns(t *testing.T) {
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{script: "send \"a\\r\"\nexpect {*\\[ok\\]?}\n", want: `.*\[ok\]. -> done`},
+		{script: "send \"a\\r\"\nexpect \"\\[ok]\"\n", want: `[ok] -> done`},
+		{script: "send \"a\\r\"\nexpect -re {^(PASS|FAIL)$}\n", want: `^(PASS|FAIL)$ -> done`},
+		{script: "send \"a\\r\"\nexpect -exact \"1+1\"\n", want: `1\+1 -> done`},
+		{script: "send \"a\\r\"\nexpect \"^>>\"\n", want: `^>> -> done`},
+	}
+	for _, tt := range tests {
+		got, err := importExpect(tt.script, "t.exp", "t")
+		if err != nil {
+			t.Fatalf("importExpect(%q) error: %v", tt.script, err)
+		}
+		if !strings.Contains(got, "- "+tt.want+"\n") && !strings.Contains(got, "- '"+tt.want+"'\n") {
+			t.Fatalf("importExpect(%q) =\n%s\nwant on-match %s", tt.script, got, tt.want)
+		}
+	}
+}
+
+func TestImportExpectErrors(t *testing.T) {
+	tests := map[string]string{
+		"send \"$password\\r\"\n":                                "variables are not supported",
+		"send [exec date]\n":                                     "command substitution is not supported",
+		"send \"a\\r\"\nexpect {\n  ok {send b}\n  no exit\n}\n": "several patterns",
+		"if {1} {send a}\n":                                      `unsupported command "if"`,
+		"expect foo\n":                                           "no send commands found",
+		"send \"unterminated\n":                                  "missing close-quote",
+	}
+	for script, want := range tests {
+		_, err := importExpect(script, "t.exp", "t")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("importExpect(%q) error = %v; want %q", script, err, want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package typingbird
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// inbox holds messages enqueued over the control socket, e.g. from
+// `typing-bird repl`. It is the first of the bird's queues and, unlike the
+// others, can be listed and reordered.
+type inbox struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// push queues message by its #priority: and returns the queue after.
+func (q *inbox) push(message string) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.lines = insertByPriority(q.lines, message, false)
+	return slices.Clone(q.lines)
+}
+
+func (q *inbox) pop(context.Context) (string, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.lines) == 0 {
+		return "", false, nil
+	}
+	message := q.lines[0]
+	q.lines = q.lines[1:]
+	return message, true, nil
+}
+
+func (q *inbox) requeue(message string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.lines = insertByPriority(q.lines, message, true)
+	return nil
+}
+
+func (q *inbox) String() string {
+	return "inbox"
+}
+
+func (q *inbox) list() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return slices.Clone(q.lines)
+}
+
+func (q *inbox) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.lines)
+}
+
+// replace swaps the whole queue for lines, kept in priority order.
+func (q *inbox) replace(lines []string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.lines = nil
+	for _, line := range lines {
+		q.lines = insertByPriority(q.lines, line, false)
+	}
+}
+
+// remove takes out and returns the message at 1-based position n.
+func (q *inbox) remove(n int) (string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if n < 1 || n > len(q.lines) {
+		return "", fmt.Errorf("no queued message %d (%d queued)", n, len(q.lines))
+	}
+	message := q.lines[n-1]
+	q.lines = slices.Delete(q.lines, n-1, n)
+	return message, nil
+}
+
+// move puts the message at 1-based position from at position to and
+// returns the queue after. Moving a message past others doesn't change its
+// priority.
+func (q *inbox) move(from, to int) ([]string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, n := range []int{from, to} {
+		if n < 1 || n > len(q.lines) {
+			return nil, fmt.Errorf("no queued message %d (%d queued)", n, len(q.lines))
+		}
+	}
+	message := q.lines[from-1]
+	q.lines = slices.Insert(slices.Delete(q.lines, from-1, from), to-1, message)
+	return slices.Clone(q.lines), nil
+}
+
+// inboxCommand runs the control commands that manage the inbox: enqueue
+// TEXT, queue, unqueue N, move N M and send-queued N, which takes message N
+// out of the queue and sends it right away.
+func (b *bird) inboxCommand(command string, args []string) controlResponse {
+	positions := make([]int, 0, len(args))
+	if command == "unqueue" || command == "move" || command == "send-queued" {
+		for _, arg := range args {
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				return controlError(fmt.Errorf("invalid position %q", arg))
+			}
+			positions = append(positions, n)
+		}
+	}
+	switch command {
+	case "enqueue":
+		message := strings.Join(args, " ")
+		if message == "" {
+			return controlError(fmt.Errorf("usage: enqueue <message>"))
+		}
+		return controlOK(b.inbox.push(message))
+	case "queue":
+		return controlOK(b.inbox.list())
+	case "unqueue":
+		if len(positions) != 1 {
+			return controlError(fmt.Errorf("usage: unqueue <position>"))
+		}
+		if _, err := b.inbox.remove(positions[0]); err != nil {
+			return controlError(err)
+		}
+		return controlOK(b.inbox.list())
+	case "send-queued":
+		if len(positions) != 1 {
+			return controlError(fmt.Errorf("usage: send-queued <position>"))
+		}
+		message, err := b.inbox.remove(positions[0])
+		if err != nil {
+			return controlError(err)
+		}
+		_, text := parsePriority(message)
+		b.sendNow(text)
+		return controlOK(b.inbox.list())
+	case "move":
+		if len(positions) != 2 {
+			return controlError(fmt.Errorf("usage: move <from> <to>"))
+		}
+		lines, err := b.inbox.move(positions[0], positions[1])
+		if err != nil {
+			return controlError(err)
+		}
+		return controlOK(lines)
+	}
+	return controlError(fmt.Errorf("unknown command %q", command))
+}
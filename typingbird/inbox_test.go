@@ -0,0 +1,73 @@
+package typingbird
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestInbox(t *testing.T) {
+	q := &inbox{}
+	q.push("a")
+	q.push("b")
+	if got, want := q.push("#priority:1 urgent"), []string{"#priority:1 urgent", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("push(urgent) = %q; want %q", got, want)
+	}
+	if got, err := q.move(3, 2); err != nil || !reflect.DeepEqual(got, []string{"#priority:1 urgent", "b", "a"}) {
+		t.Fatalf("move(3, 2) = %q, %v; want urgent b a", got, err)
+	}
+	if got, err := q.remove(1); err != nil || got != "#priority:1 urgent" {
+		t.Fatalf("remove(1) = %q, %v; want the urgent message", got, err)
+	}
+	for _, n := range []int{0, 3} {
+		if _, err := q.remove(n); err == nil {
+			t.Fatalf("remove(%d) with 2 queued = nil error; want error", n)
+		}
+	}
+	if _, err := q.move(1, 3); err == nil {
+		t.Fatalf("move(1, 3) with 2 queued = nil error; want error")
+	}
+	if message, ok, _ := q.pop(context.Background()); !ok || message != "b" {
+		t.Fatalf("pop() = %q, %v; want b", message, ok)
+	}
+	if got := q.len(); got != 1 {
+		t.Fatalf("len() = %d; want 1", got)
+	}
+}
+
+func TestInboxCommand(t *testing.T) {
+	b, err := newBird(options{timeout: 1, session: "s", messages: []string{"m"}}, "%1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		command string
+		args    []string
+		ok      bool
+		want    any
+	}{
+		{"enqueue", []string{"run", "the", "tests"}, true, []string{"run the tests"}},
+		{"enqueue", nil, false, nil},
+		{"enqueue", []string{"lint"}, true, []string{"run the tests", "lint"}},
+		{"move", []string{"2", "1"}, true, []string{"lint", "run the tests"}},
+		{"move", []string{"2"}, false, nil},
+		{"unqueue", []string{"x"}, false, nil},
+		{"unqueue", []string{"2"}, true, []string{"lint"}},
+		{"queue", nil, true, []string{"lint"}},
+		{"enqueue", []string{"#priority:2", "now"}, true, []string{"#priority:2 now", "lint"}},
+		{"send-queued", []string{"3"}, false, nil},
+		{"send-queued", []string{"1"}, true, []string{"lint"}},
+	}
+	for _, tc := range cases {
+		resp := b.controlCommand(tc.command, tc.args)
+		if resp.OK != tc.ok || tc.ok && !reflect.DeepEqual(resp.Data, tc.want) {
+			t.Fatalf("controlCommand(%q, %q) = %+v; want ok=%v data %q", tc.command, tc.args, resp, tc.ok, tc.want)
+		}
+	}
+	if got := b.status().Queued; got != 1 {
+		t.Fatalf("status().Queued = %d; want 1", got)
+	}
+	if b.forced == nil || *b.forced != "now" {
+		t.Fatalf("forced after send-queued = %v; want %q", b.forced, "now")
+	}
+}
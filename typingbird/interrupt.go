@@ -0,0 +1,158 @@
+package typingbird
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultInterruptWindow = 5 * time.Second
+
+	// Actions for a first Ctrl-C, --interrupt-action. A second one within
+	// the window exits whatever the action.
+	interruptHint  = "hint"
+	interruptExit  = "exit"
+	interruptPause = "pause"
+	interruptSkip  = "skip"
+)
+
+func validateInterruptAction(action string) error {
+	switch action {
+	case interruptHint, interruptExit, interruptPause, interruptSkip:
+		return nil
+	}
+	return fmt.Errorf("invalid interrupt-action %q: must be %q, %q, %q or %q", action, interruptHint, interruptExit, interruptPause, interruptSkip)
+}
+
+// interruptHandling is how installInterruptHandlers treats Ctrl-C.
+type interruptHandling struct {
+	window time.Duration
+	action string
+	// birds are paused or skipped by the pause and skip actions.
+	birds *flock
+}
+
+func (o options) interrupts(birds *flock) interruptHandling {
+	return interruptHandling{window: o.interruptWin, action: o.interruptAct, birds: birds}
+}
+
+// installInterruptHandlers cancels on SIGTERM and on a second Ctrl-C within
+// the window. The first Ctrl-C prints how to restart, pauses (or resumes)
+// the birds or skips their next message, depending on the action; with
+// exit it is enough on its own. A message being typed is finished before
+// the birds exit, unless yet another signal comes in meanwhile; once none
+// is, further signals wait for the birds to clean up.
+func installInterruptHandlers(cancel context.CancelFunc, launchCommand string, h interruptHandling, exitCode *atomic.Int32) (stop func()) {
+	c := make(chan os.Signal, 2)
+	done := make(chan struct{})
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+	var last time.Time
+	draining := false
+	exit := func(code int32, format string, args ...any) {
+		fmt.Fprintf(os.Stderr, "[%s] INFO: "+format+"\n", append([]any{time.Now().Format(time.RFC3339)}, args...)...)
+		exitCode.Store(code)
+		cancel()
+		draining = true
+		if h.typing() {
+			fmt.Fprintf(os.Stderr, "[%s] INFO: Finishing the message being typed; signal again to exit at once.\n", time.Now().Format(time.RFC3339))
+		}
+	}
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig, ok := <-c:
+				if !ok {
+					return
+				}
+				now := time.Now()
+				switch {
+				case draining && h.typing():
+					fmt.Fprintf(os.Stderr, "[%s] INFO: %s received again; exiting now.\n", now.Format(time.RFC3339), signalName(sig))
+					os.Exit(int(exitCode.Load()))
+				case draining:
+					// Exiting here would skip releasing the pane lock,
+					// restoring the border and saving state, which take
+					// no time without a message to finish.
+					fmt.Fprintf(os.Stderr, "[%s] INFO: %s received again; already exiting.\n", now.Format(time.RFC3339), signalName(sig))
+					continue
+				case sig == syscall.SIGTERM:
+					exit(143, "SIGTERM received; exiting.")
+					continue
+				case h.action == interruptExit:
+					exit(130, "Ctrl-C received; exiting.")
+					continue
+				case !last.IsZero() && now.Sub(last) <= h.window:
+					exit(130, "Second Ctrl-C within %s; exiting.", h.window)
+					continue
+				}
+				switch h.action {
+				case interruptPause:
+					h.togglePause()
+				case interruptSkip:
+					for _, b := range h.birds.list() {
+						b.skip()
+					}
+				default:
+					fmt.Fprintf(os.Stderr, "[%s] INFO: Ctrl-C received; restart with:\n", now.Format(time.RFC3339))
+					if launchCommand != "" {
+						fmt.Fprintf(os.Stderr, "$ %s\n", launchCommand)
+					} else {
+						fmt.Fprintln(os.Stderr, "$ <unknown command>")
+					}
+				}
+				fmt.Fprintf(os.Stderr, "[%s] INFO: Press Ctrl-C again within %s to exit.\n", time.Now().Format(time.RFC3339), h.window)
+				last = now
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+func signalName(sig os.Signal) string {
+	if sig == syscall.SIGTERM {
+		return "SIGTERM"
+	}
+	return "Ctrl-C"
+}
+
+// typing reports whether any bird is in the middle of typing a message.
+func (h interruptHandling) typing() bool {
+	if h.birds == nil {
+		return false
+	}
+	for _, b := range h.birds.list() {
+		if b.inFlight.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// togglePause resumes the birds when all of them are paused and pauses
+// them otherwise.
+func (h interruptHandling) togglePause() {
+	birds := h.birds.list()
+	paused := len(birds) > 0
+	for _, b := range birds {
+		b.mu.Lock()
+		paused = paused && b.paused
+		b.mu.Unlock()
+	}
+	for _, b := range birds {
+		if paused {
+			b.resume()
+		} else {
+			b.pause()
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package typingbird
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestValidateInterruptAction(t *testing.T) {
+	for _, action := range []string{interruptHint, interruptExit, interruptPause, interruptSkip} {
+		if err := validateInterruptAction(action); err != nil {
+			t.Fatalf("validateInterruptAction(%q) = %v; want nil", action, err)
+		}
+	}
+	for _, action := range []string{"", "stop", "Pause"} {
+		if err := validateInterruptAction(action); err == nil {
+			t.Fatalf("validateInterruptAction(%q) = nil; want error", action)
+		}
+	}
+}
+
+func TestInterruptTogglePause(t *testing.T) {
+	a := &bird{name: "a", events: &eventHub{}}
+	b := &bird{name: "b", events: &eventHub{}, paused: true}
+	h := interruptHandling{birds: &flock{birds: []*bird{a, b}}}
+	h.togglePause()
+	if !a.paused || !b.paused {
+		t.Fatalf("after a toggle with one bird paused: paused = %v, %v; want both", a.paused, b.paused)
+	}
+	h.togglePause()
+	if a.paused || b.paused {
+		t.Fatalf("after a toggle with both paused: paused = %v, %v; want neither", a.paused, b.paused)
+	}
+}
+
+func TestOptionsInterrupts(t *testing.T) {
+	cases := []struct {
+		window, action string
+		ok             bool
+	}{
+		{"5s", interruptHint, true},
+		{"0s", interruptPause, false},
+		{"0s", interruptExit, true},
+		{"-1s", interruptExit, false},
+		{"5s", "stop", false},
+	}
+	for _, tc := range cases {
+		cli := newCLIFlags()
+		cli.interruptWin, cli.interruptAct = tc.window, tc.action
+		_, err := cli.options([]string{"s", "m"}, resolvedConfig{}, "")
+		if (err == nil) != tc.ok {
+			t.Fatalf("options() with --interrupt-window %s --interrupt-action %s error = %v; want ok %v", tc.window, tc.action, err, tc.ok)
+		}
+	}
+}
+
+func TestInterruptWhileDrainingWaitsForCleanup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var code atomic.Int32
+	stop := installInterruptHandlers(cancel, "", interruptHandling{window: time.Second, action: interruptHint, birds: &flock{}}, &code)
+	defer stop()
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := self.Signal(syscall.SIGTERM); err != nil {
+		t.Skipf("cannot signal this process: %v", err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("first SIGTERM did not cancel")
+	}
+	// With nothing being typed, a second signal must not exit before the
+	// deferred cleanup runs; exiting would end this test binary.
+	if err := self.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := code.Load(); got != 143 {
+		t.Fatalf("exit code = %d; want 143", got)
+	}
+}
@@ -0,0 +1,48 @@
+package typingbird
+
+import (
+	"context"
+	"time"
+)
+
+// defaultKeepaliveKey is NUL, which shells and most programs ignore but
+// which still travels down an ssh connection as input.
+const defaultKeepaliveKey = "C-@"
+
+// keepalive presses opts.keepaliveKey in the target whenever nothing was
+// sent to it for opts.keepalive, independently of the message rotation, so
+// idle timeouts on the far side of an ssh session never fire. It runs until
+// ctx ends or a reload turns it off.
+func (b *bird) keepalive(ctx context.Context) {
+	b.mu.Lock()
+	b.lastInput = time.Now()
+	b.mu.Unlock()
+	for {
+		opts := b.options()
+		if opts.keepalive <= 0 {
+			return
+		}
+		b.mu.Lock()
+		due := b.lastInput.Add(opts.keepalive)
+		target := b.target
+		b.mu.Unlock()
+		if wait := time.Until(due); wait > 0 {
+			if sleepWithContext(ctx, wait) != nil {
+				return
+			}
+			continue
+		}
+		// sendMu keeps the key from landing in the middle of a message.
+		b.sendMu.Lock()
+		err := tmuxRun(sendKeyArgs(target, opts.keepaliveKey)...)
+		b.sendMu.Unlock()
+		if err != nil {
+			b.debugf("keepalive to %q failed: %v", target, err)
+		} else {
+			b.debugf("sent keepalive %s to %q", opts.keepaliveKey, target)
+		}
+		b.mu.Lock()
+		b.lastInput = time.Now()
+		b.mu.Unlock()
+	}
+}
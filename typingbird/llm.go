@@ -0,0 +1,164 @@
+package typingbird
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultLLMKeyEnv names the environment variable holding the API key sent
+// to the --llm endpoint.
+const defaultLLMKeyEnv = "OPENAI_API_KEY"
+
+// errLLMDeclined reports an empty reply, which skips the send.
+var errLLMDeclined = errors.New("llm replied with nothing to send")
+
+// llmTimeout bounds one chat completion request.
+const llmTimeout = 2 * time.Minute
+
+// defaultLLMPrompt is the system prompt without --llm-prompt-file.
+const defaultLLMPrompt = `You supervise a coding agent running in a terminal. You are shown the
+current screen of its tmux pane after it went quiet. Reply with exactly the
+next message to type into the pane to keep the agent making good progress:
+answer its question, approve a sensible plan, or nudge it to continue. Reply
+with nothing at all if no message should be sent. Never add explanations,
+quotes or formatting around the message.`
+
+// llmConfig is the OpenAI-compatible chat completions endpoint --llm asks
+// for the next message.
+type llmConfig struct {
+	// endpoint is the API base URL, e.g. https://api.openai.com/v1.
+	endpoint string
+	model    string
+	// promptFile is empty for defaultLLMPrompt; prompt is its contents.
+	promptFile string
+	prompt     string
+	keyEnv     string
+}
+
+func newLLMConfig(endpoint, model, promptFile, keyEnv string) (*llmConfig, error) {
+	if endpoint == "" {
+		if model != "" || promptFile != "" {
+			return nil, fmt.Errorf("--llm-model and --llm-prompt-file require --llm")
+		}
+		return nil, nil
+	}
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return nil, fmt.Errorf("--llm must be an http:// or https:// URL (got %q)", endpoint)
+	}
+	if model == "" {
+		return nil, fmt.Errorf("--llm requires --llm-model")
+	}
+	c := &llmConfig{endpoint: strings.TrimSuffix(endpoint, "/"), model: model, promptFile: promptFile, prompt: defaultLLMPrompt, keyEnv: keyEnv}
+	if promptFile != "" {
+		raw, err := os.ReadFile(promptFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading llm prompt file: %w", err)
+		}
+		c.prompt = strings.TrimSpace(string(raw))
+	}
+	return c, nil
+}
+
+type llmMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type llmRequest struct {
+	Model    string       `json:"model"`
+	Messages []llmMessage `json:"messages"`
+}
+
+type llmResponse struct {
+	Choices []struct {
+		Message llmMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// llmUserMessage is what the model is asked: the pane capture, plus the
+// message the rotation would have sent as a hint.
+func llmUserMessage(capture, fallback string) string {
+	var b strings.Builder
+	b.WriteString("Current pane contents:\n```\n")
+	b.WriteString(strings.TrimRight(capture, "\n"))
+	b.WriteString("\n```\n")
+	if strings.TrimSpace(fallback) != "" {
+		b.WriteString("\nWithout your answer this message would be sent: ")
+		b.WriteString(fallback)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// nextMessage asks the model for the message to type given the pane
+// capture. An empty reply means nothing should be sent.
+func (c *llmConfig) nextMessage(ctx context.Context, capture, fallback string) (string, error) {
+	body, err := json.Marshal(llmRequest{
+		Model: c.model,
+		Messages: []llmMessage{
+			{Role: "system", Content: c.prompt},
+			{Role: "user", Content: llmUserMessage(capture, fallback)},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, llmTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key := os.Getenv(c.keyEnv); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("llm request: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("reading llm response: %w", err)
+	}
+	var parsed llmResponse
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		return "", fmt.Errorf("llm returned %s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+	if parsed.Error != nil {
+		return "", fmt.Errorf("llm returned %s: %s", resp.Status, parsed.Error.Message)
+	}
+	if resp.StatusCode != http.StatusOK || len(parsed.Choices) == 0 {
+		return "", fmt.Errorf("llm returned %s with no choices", resp.Status)
+	}
+	return strings.TrimSpace(parsed.Choices[0].Message.Content), nil
+}
+
+// llmDecision captures target and asks the model for the next message,
+// declining the send when it replies with nothing.
+func llmDecision(ctx context.Context, c *llmConfig, target, fallback string) (string, error) {
+	capture, err := captureTarget(target)
+	if err != nil {
+		return "", fmt.Errorf("capturing target for llm: %w", err)
+	}
+	message, err := c.nextMessage(ctx, string(capture), fallback)
+	if err != nil {
+		return "", err
+	}
+	if message == "" {
+		return "", errLLMDeclined
+	}
+	return message, nil
+}
@@ -0,0 +1,77 @@
+package typingbird
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLLMNextMessage(t *testing.T) {
+	t.Setenv("TEST_LLM_KEY", "sekrit")
+	var got llmRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %q; want /v1/chat/completions", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer sekrit" {
+			t.Errorf("Authorization = %q; want Bearer sekrit", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"  yes, go ahead\n"}}]}`))
+	}))
+	defer server.Close()
+
+	c, err := newLLMConfig(server.URL+"/v1/", "test-model", "", "TEST_LLM_KEY")
+	if err != nil {
+		t.Fatalf("newLLMConfig(...) error: %v", err)
+	}
+	message, err := c.nextMessage(context.Background(), "Proceed? [y/n]\n", "continue")
+	if err != nil {
+		t.Fatalf("nextMessage(...) error: %v", err)
+	}
+	if message != "yes, go ahead" {
+		t.Fatalf("nextMessage(...) = %q; want %q", message, "yes, go ahead")
+	}
+	if got.Model != "test-model" || len(got.Messages) != 2 || got.Messages[0].Content != defaultLLMPrompt {
+		t.Fatalf("request = %+v; want test-model with the default system prompt", got)
+	}
+	if user := got.Messages[1].Content; !strings.Contains(user, "Proceed? [y/n]") || !strings.Contains(user, "would be sent: continue") {
+		t.Fatalf("user message = %q; want the capture and the fallback", user)
+	}
+}
+
+func TestLLMNextMessageError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"bad key"}}`))
+	}))
+	defer server.Close()
+	c, err := newLLMConfig(server.URL, "m", "", "TEST_LLM_UNSET")
+	if err != nil {
+		t.Fatalf("newLLMConfig(...) error: %v", err)
+	}
+	if _, err := c.nextMessage(context.Background(), "", ""); err == nil || !strings.Contains(err.Error(), "bad key") {
+		t.Fatalf("nextMessage(...) error = %v; want bad key", err)
+	}
+}
+
+func TestNewLLMConfigErrors(t *testing.T) {
+	tests := []struct {
+		endpoint, model, want string
+	}{
+		{endpoint: "", model: "m", want: "require --llm"},
+		{endpoint: "api.openai.com", model: "m", want: "http:// or https://"},
+		{endpoint: "https://api.openai.com/v1", model: "", want: "requires --llm-model"},
+	}
+	for _, tt := range tests {
+		_, err := newLLMConfig(tt.endpoint, tt.model, "", defaultLLMKeyEnv)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("newLLMConfig(%q, %q) error = %v; want %q", tt.endpoint, tt.model, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package typingbird
+
+import (
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// macroPattern matches the steps of a macro message besides its literal
+// text: {{key "Down"}} presses a tmux key, {{hex "1b 5b 41"}} sends those
+// exact bytes and {{sleep "200ms"}} pauses. A message containing any of them
+// is a macro and gets no Enter at the end unless a step presses it.
+var macroPattern = regexp.MustCompile(`\{\{\s*(key|hex|sleep)\s+"([^"]+)"\s*\}\}`)
+
+func isMacro(message string) bool {
+	return macroPattern.MatchString(message)
+}
+
+// validateMacro checks the sleep and hex steps of a macro message; key
+// names are left for tmux to judge.
+func validateMacro(message string) error {
+	for _, m := range macroPattern.FindAllStringSubmatch(message, -1) {
+		var err error
+		switch m[1] {
+		case "sleep":
+			_, err = parseMacroSleep(m[2])
+		case "hex":
+			_, err = parseMacroHex(m[2])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseMacroSleep reads a sleep step as a duration, or as plain
+// milliseconds.
+func parseMacroSleep(raw string) (time.Duration, error) {
+	if ms, err := strconv.Atoi(raw); err == nil && ms > 0 {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid macro sleep %q: want a positive duration such as 200ms", raw)
+	}
+	return d, nil
+}
+
+// parseMacroHex reads a hex step as the bytes to send, written as pairs of
+// hex digits with or without spaces or 0x prefixes: "1b 5b 41", "1b5b41"
+// and "0x1b 0x5b 0x41" are the same three bytes, and "d" is 0d.
+func parseMacroHex(raw string) ([]byte, error) {
+	var digits strings.Builder
+	for _, field := range strings.Fields(raw) {
+		field = strings.TrimPrefix(strings.TrimPrefix(field, "0x"), "0X")
+		if len(field) == 1 {
+			field = "0" + field
+		}
+		digits.WriteString(field)
+	}
+	b, err := hex.DecodeString(digits.String())
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid macro hex %q: want bytes as hex digits such as 1b 5b 41", raw)
+	}
+	return b, nil
+}
+
+// configMacro turns a config message written as a list of steps into the
+// macro's message text:
+//
+//	messages:
+//	  - steps: [{text: /model}, {key: Down}, {key: Down}, {sleep: 200ms}, {key: Enter}]
+//	  - steps: [{hex: 1b 5b 32 30 30 7e}, {text: pasted}, {hex: 1b 5b 32 30 31 7e}]
+//
+// A plain string step is text.
+func configMacro(steps []any) (string, error) {
+	var b strings.Builder
+	for i, step := range steps {
+		fields, ok := step.(map[string]any)
+		if !ok {
+			switch step.(type) {
+			case nil, []any:
+				return "", fmt.Errorf("step %d must be text or a mapping", i+1)
+			}
+			b.WriteString(fmt.Sprint(step))
+			continue
+		}
+		if len(fields) != 1 {
+			return "", fmt.Errorf("step %d: want exactly one of text, key, hex or sleep", i+1)
+		}
+		for kind, value := range fields {
+			if value == nil {
+				return "", fmt.Errorf("step %d: %s needs a value", i+1, kind)
+			}
+			raw := fmt.Sprint(value)
+			if _, isText := value.(string); kind == "hex" && !isText {
+				// YAML reads 0x1b as the number 27.
+				return "", fmt.Errorf("step %d: quote hex %s so it is read as text", i+1, raw)
+			}
+			switch kind {
+			case "text":
+				b.WriteString(raw)
+			case "key", "hex", "sleep":
+				if strings.Contains(raw, `"`) {
+					return "", fmt.Errorf("step %d: %s %q must not contain quotes", i+1, kind, raw)
+				}
+				fmt.Fprintf(&b, "{{%s %q}}", kind, raw)
+			default:
+				return "", fmt.Errorf("step %d: unknown step %q", i+1, kind)
+			}
+		}
+	}
+	if !isMacro(b.String()) {
+		return "", fmt.Errorf("steps need at least one key, hex or sleep; use text for a plain message")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,97 @@
+package typingbird
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMacroSleep(t *testing.T) {
+	tests := map[string]time.Duration{
+		"200":   200 * time.Millisecond,
+		"1.5s":  1500 * time.Millisecond,
+		"250ms": 250 * time.Millisecond,
+	}
+	for raw, want := range tests {
+		got, err := parseMacroSleep(raw)
+		if err != nil || got != want {
+			t.Fatalf("parseMacroSleep(%q) = %v, %v; want %v", raw, got, err, want)
+		}
+	}
+	for _, raw := range []string{"0", "-5", "soon", "-1s"} {
+		if _, err := parseMacroSleep(raw); err == nil {
+			t.Fatalf("parseMacroSleep(%q) = nil error; want error", raw)
+		}
+	}
+}
+
+func TestValidateMacro(t *testing.T) {
+	tests := map[string]bool{
+		"plain message":                           true,
+		`a{{key "Down"}}{{sleep "100ms"}}`:        true,
+		`a{{sleep "later"}}`:                      false,
+		`{{key "C-c"}}{{ sleep "0" }}`:            false,
+		`not a step: {{pause "1s"}}`:              true,
+		`{{key "Up"}}{{key "Up"}}{{key "Enter"}}`: true,
+		`{{hex "1b 5b 41"}}`:                      true,
+		`{{hex "1g"}}`:                            false,
+	}
+	for message, ok := range tests {
+		if err := validateMacro(message); (err == nil) != ok {
+			t.Fatalf("validateMacro(%q) = %v; want ok=%t", message, err, ok)
+		}
+	}
+}
+
+func TestParseMacroHex(t *testing.T) {
+	tests := map[string][]byte{
+		"1b 5b 41":       {0x1b, 0x5b, 0x41},
+		"1b5b41":         {0x1b, 0x5b, 0x41},
+		"0x1b 0x5b 0X41": {0x1b, 0x5b, 0x41},
+		"d":              {0x0d},
+		"e2 82 ac":       []byte("€"),
+		"zz":             nil,
+		"  ":             nil,
+		"1b5":            nil,
+	}
+	for raw, want := range tests {
+		got, err := parseMacroHex(raw)
+		if want == nil {
+			if err == nil {
+				t.Fatalf("parseMacroHex(%q) = %x; want an error", raw, got)
+			}
+			continue
+		}
+		if err != nil || !bytes.Equal(got, want) {
+			t.Fatalf("parseMacroHex(%q) = %x, %v; want %x", raw, got, err, want)
+		}
+	}
+}
+
+func TestConfigMacro(t *testing.T) {
+	got, err := configMacro([]any{
+		"/model",
+		map[string]any{"key": "Down"},
+		map[string]any{"sleep": 200},
+		map[string]any{"text": "x"},
+		map[string]any{"key": "Enter"},
+	})
+	if want := `/model{{key "Down"}}{{sleep "200"}}x{{key "Enter"}}`; err != nil || got != want {
+		t.Fatalf("configMacro(...) = %q, %v; want %q", got, err, want)
+	}
+	tests := map[string][]any{
+		"at least one key, hex or sleep": {"only text"},
+		"exactly one":                    {map[string]any{"key": "Up", "text": "a"}},
+		`unknown step "press"`:           {map[string]any{"press": "Up"}},
+		"needs a value":                  {map[string]any{"key": nil}},
+		"must not contain quotes":        {map[string]any{"key": `"`}},
+		"quote hex 27":                   {map[string]any{"hex": 27}},
+		"must be text or a mapping":      {[]any{"a"}},
+	}
+	for want, steps := range tests {
+		if _, err := configMacro(steps); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("configMacro(%v) error = %v; want %q", steps, err, want)
+		}
+	}
+}
@@ -0,0 +1,1925 @@
+package typingbird
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultTimeout      = 30 * time.Second
+	defaultDelay        = 15 * time.Millisecond
+	defaultIdleSamples  = 5
+	defaultIdleK        = 3
+	defaultInjectSize   = "5"
+	defaultRestartGrace = 2 * time.Second
+	enterKey            = "Enter"
+	// restartPoll is how often a bird being replaced by --inject is
+	// checked for having exited.
+	restartPoll = 50 * time.Millisecond
+
+	idleModeCapture = "capture"
+	idleModePipe    = "pipe"
+	idleModePrompt  = "prompt"
+
+	injectVertical   = "vertical"
+	injectHorizontal = "horizontal"
+	injectWindowName = "typing-bird"
+
+	idleStrategyAllEqual    = "all-equal"
+	idleStrategyConsecutive = "consecutive-stable"
+	idleStrategyLastKEqual  = "last-k-equal"
+	idleStrategyAdaptive    = "adaptive"
+
+	minAdaptiveInterval = 250 * time.Millisecond
+)
+
+var verboseLogging bool
+
+// traceLogging (-vv) additionally logs every tmux command that runs.
+var traceLogging bool
+
+var errSendDeclined = errors.New("script declined send")
+
+// idleSampling controls how capture-mode samples are taken and judged.
+type idleSampling struct {
+	samples     int
+	strategy    string
+	k           int
+	minInterval time.Duration
+	// ignoreSpace compares captures by their words, --ignore-whitespace.
+	ignoreSpace bool
+}
+
+// injectLayout places the injected pane: size is lines (columns when
+// horizontal) or a percentage such as "20%". With window set the pane goes
+// into a dedicated window instead and size and direction are unused.
+type injectLayout struct {
+	size       string
+	horizontal bool
+	window     bool
+}
+
+// options holds the settings shared by the idle loop and forwarded to the
+// injected child process.
+type options struct {
+	timeout  time.Duration
+	delay    time.Duration
+	verbose  bool
+	trace    bool
+	idleMode string
+	sampling idleSampling
+	// detector, when set by a Runner, replaces the idle mode.
+	detector IdleDetector
+	layout   injectLayout
+	popup    string
+	hooks    hooks
+	script   string
+	llm      *llmConfig
+	order    string
+	weights  string
+	noLoop   bool
+	exitCode int
+	config   string
+	profile  string
+	session  string
+	messages []string
+
+	weightList   []float64
+	noStatusLine bool
+	borderStatus bool
+	noPaneTitle  bool
+	windowCount  bool
+	record       string
+	auditLog     string
+	auditMaxSize int64
+	deadLetter   string
+	// heartbeat is touched after every cycle with --heartbeat-file.
+	heartbeat   string
+	sendRetries int
+	// stateFile keeps the bird's state across restarts, --state-file.
+	stateFile string
+	// captures is how many panes may be captured at once,
+	// --capture-workers.
+	captures int
+	// interruptWin and interruptAct are --interrupt-window and
+	// --interrupt-action.
+	interruptWin time.Duration
+	interruptAct string
+	// restartGrace is how long a bird replaced by --inject gets to exit.
+	restartGrace time.Duration
+	// exitPane is what an injected bird does with its pane on exit.
+	exitPane string
+	// respawn runs the injected child under supervise.
+	respawn       bool
+	asciicast     string
+	castPre       time.Duration
+	castPost      time.Duration
+	messagesFile  string
+	delimiter     string
+	base64        bool
+	watch         bool
+	secretSources []secretSource
+	redact        string
+	create        string
+	busy          *regexp.Regexp
+	exitOn        *regexp.Regexp
+	failOn        *regexp.Regexp
+	expectAfter   *regexp.Regexp
+	// expects holds per-message #expect: patterns aligned with messages;
+	// nil when the messages file has none.
+	expects       []*regexp.Regexp
+	expectTimeout time.Duration
+	// flow drives the messages as a state machine when the config
+	// defines one; messages then holds each state's message.
+	flow *messageFlow
+	// delays overrides delay for individual messages by index.
+	delays        map[int]time.Duration
+	initialDelay  time.Duration
+	sendNow       bool
+	retarget      bool
+	retargetTitle *regexp.Regexp
+	takeover      bool
+	reattach      time.Duration
+	followActive  bool
+	keepalive     time.Duration
+	keepaliveKey  string
+	chunks        chunking
+	pasteLines    bool
+	typoRate      float64
+	wpm           int
+	verify        bool
+	verifyRetries int
+	// sessions is set when the session argument is a glob or /regexp/;
+	// a bird then runs for every matching session.
+	sessions      *sessionPattern
+	rescan        time.Duration
+	humanCooldown time.Duration
+	// allowPassword turns off the guard against typing into password
+	// prompts.
+	allowPassword bool
+	passwordRegex *regexp.Regexp
+	passwordAlert bool
+	policy        messagePolicy
+	activeHours   string
+	activeDays    string
+	timezone      string
+	maxRuntime    time.Duration
+	until         string
+	untilClock    *stopClock
+	schedule      *activeSchedule
+	controlSocket string
+	tmuxHooks     bool
+	tmuxControl   bool
+	wslDistro     string
+	backend       string
+	grpcListen    string
+	apiListen     string
+	apiTokenFile  string
+	mqttBroker    string
+	mqttTopic     string
+	triggerFIFO   string
+	afterPID      int
+	afterCommand  string
+	afterMessage  string
+	waitPort      string
+	waitHTTP      string
+	waitBefore    int
+	waitTimeout   time.Duration
+	redisQueue    string
+	natsURL       string
+	natsSubject   string
+	// reloadable is set when messages come from the messages file or
+	// config rather than positional args, so the injected child re-reads
+	// them instead of receiving a fixed copy.
+	reloadable bool
+}
+
+// Main runs the typing-bird command line on os.Args and returns the exit
+// code for the process.
+func Main() int {
+	return run()
+}
+
+func run() int {
+	// Subcommands are only recognised as the first argument, so a session
+	// with the same name can still be targeted after any flag or "--".
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "version":
+			return runVersion(os.Args[2:], os.Stdout, os.Stderr)
+		case "web":
+			return runWeb(os.Args[2:], os.Stdout, os.Stderr)
+		case "fleet":
+			return runFleet(os.Args[2:], os.Stdout, os.Stderr)
+		case "ctl":
+			return runCtl(os.Args[2:], os.Stdout, os.Stderr)
+		case "statusline":
+			return runStatusLine(os.Args[2:], os.Stdout, os.Stderr)
+		case "import":
+			return runImport(os.Args[2:], os.Stdout, os.Stderr)
+		case "send":
+			return runSend(os.Args[2:], os.Stdout, os.Stderr)
+		case "bind-keys":
+			return runBindKeys(os.Args[2:], os.Stdout, os.Stderr)
+		case "audit":
+			return runAudit(os.Args[2:], os.Stdout, os.Stderr)
+		case "repl":
+			return runREPL(os.Args[2:], os.Stdout, os.Stderr)
+		case "menu":
+			return runMenu(os.Args[2:], os.Stdout, os.Stderr)
+		case "supervise":
+			return runSupervise(os.Args[2:], os.Stdout, os.Stderr)
+		case "doctor":
+			return runDoctor(os.Args[2:], os.Stdout, os.Stderr)
+		case "record":
+			return runRecordMode(os.Args[2:], os.Stdout, os.Stderr)
+		case "cleanup":
+			return runCleanup(os.Args[2:], os.Stdout, os.Stderr)
+		}
+	}
+	cli := newCLIFlags()
+	fs := flag.CommandLine
+	cli.register(fs)
+	fs.Usage = func() {
+		printUsage(fs.Output(), os.Args[0])
+	}
+	_ = fs.Parse(os.Args[1:])
+	explicit := explicitFlags(fs)
+
+	// resolveOptions is reused by reload to re-read the config and messages
+	// file on top of the original command line.
+	resolveOptions := func() (options, error) {
+		resetFlags(fs, explicit)
+		config, loadedConfig, err := cli.loadConfig(fs, explicit)
+		if err != nil {
+			return options{}, err
+		}
+		return cli.options(fs.Args(), config, loadedConfig)
+	}
+	opts, err := resolveOptions()
+	if err == errUsage {
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+	applyLogging(opts)
+	if opts.script != "" {
+		if _, err := loadScript(opts.script, nil); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 2
+		}
+	}
+	session := opts.session
+
+	if err := useBackend(opts.backend); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if usingTmux() {
+		if err := opts.tmuxFeatures(); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 2
+		}
+	}
+	if opts.sessions != nil {
+		return runSessionPattern(opts, resolveOptions)
+	}
+	created, err := tmuxEnsureSession(session, opts.create)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: tmux session %q not available: %v\n", session, err)
+		return 1
+	}
+	if created {
+		logf("created session %q running %q", session, opts.create)
+	}
+	if cli.inject {
+		exePath, err := birdExecutable()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed locating executable path: %v\n", err)
+			return 1
+		}
+		exeBase := filepath.Base(exePath)
+		currentPane := strings.TrimSpace(os.Getenv("TMUX_PANE"))
+		skippedCurrentPane, err := tmuxRestartExistingBirdPanes(session, currentPane, exeBase, opts.restartGrace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed restarting existing typing-bird panes in session %q: %v\n", session, err)
+			return 1
+		}
+		if opts.layout.window {
+			// The birds window is not the active one, so its panes need a
+			// pass of their own.
+			if exists, _ := tmuxWindowExists(session, injectWindowName); exists {
+				skipped, err := tmuxRestartExistingBirdPanes(session+":"+injectWindowName, currentPane, exeBase, opts.restartGrace)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "ERROR: failed restarting existing typing-bird panes in session %q: %v\n", session, err)
+					return 1
+				}
+				skippedCurrentPane = skippedCurrentPane || skipped
+			}
+		}
+
+		sendTargetPane, err := resolveInjectionSendTarget(session)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed resolving injection target pane for session %q: %v\n", session, err)
+			return 1
+		}
+		// Refused here, the error is not lost with a pane that closes as
+		// the injected bird exits.
+		if err := checkInjectToken(opts); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 2
+		}
+		if pid, err := paneLockHolder(sendTargetPane); err == nil && pid != 0 && !opts.takeover {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", errPaneLocked(sendTargetPane, pid))
+			return 1
+		}
+
+		childArgs := buildChildArgs(opts, sendTargetPane)
+		if opts.respawn {
+			childArgs = append([]string{"supervise"}, childArgs...)
+		}
+		childCommand := shellCommandForExec(exePath, childArgs)
+		if opts.popup != popupOff {
+			logPath, err := startPopupBird(sendTargetPane, childCommand)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: failed starting background bird for session %q: %v\n", session, err)
+				return 1
+			}
+			logf(
+				"started in the background target-pane=%q session=%q timeout=%s delay=%s messages=%d log=%q",
+				sendTargetPane, session, opts.timeout, opts.delay, len(opts.messages), logPath,
+			)
+			if opts.popup == popupShow {
+				if err := tmuxShowPopup(session, sendTargetPane, logPath); err != nil {
+					logf("WARNING: could not show popup: %v", err)
+				}
+			}
+			return 0
+		}
+		injectedPaneID, err := tmuxInjectPane(session, sendTargetPane, childCommand, opts.layout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed injecting pane into session %q: %v\n", session, err)
+			return 1
+		}
+		if err := tmuxMarkInjectedPane(injectedPaneID, sendTargetPane); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed marking injected pane %q: %v\n", injectedPaneID, err)
+			return 1
+		}
+		if skippedCurrentPane && currentPane != "" {
+			_ = tmuxKillPane(currentPane)
+		}
+		logf(
+			"injected pane=%q target-pane=%q session=%q timeout=%s delay=%s messages=%d",
+			injectedPaneID, sendTargetPane, session, opts.timeout, opts.delay, len(opts.messages),
+		)
+		return 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	launchCommand := buildLaunchCommand(os.Args)
+	interruptCode := atomic.Int32{}
+	f := &flock{}
+	stopInterrupts := installInterruptHandlers(cancel, launchCommand, opts.interrupts(f), &interruptCode)
+	defer stopInterrupts()
+	code := runBird(ctx, cancel, opts, cli.targetPane, resolveOptions, f, &interruptCode, true)
+	if cli.targetPane != "" && usingTmux() {
+		leaveInjectedPane(cli.targetPane, opts.exitPane, code)
+	}
+	return code
+}
+
+// runBird runs one bird, adding it to f, which sends to session's preferred
+// pane, or targetPane when set, until ctx ends or the bird stops. With
+// signals, SIGHUP reloads and the user signals pause and resume, as on the
+// command line.
+func runBird(ctx context.Context, cancel context.CancelFunc, opts options, targetPane string, resolveOptions func() (options, error), f *flock, interruptCode *atomic.Int32, signals bool) int {
+	session := opts.session
+	sendTarget := strings.TrimSpace(targetPane)
+	if sendTarget == "" {
+		resolved, err := preferredSendPane(session)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed resolving target pane for session %q: %v\n", session, err)
+			runErrorHook(ctx, opts.hooks.onError, hookEvent{session: session, total: len(opts.messages)}, err)
+			return 1
+		}
+		sendTarget = resolved
+	}
+	if opts.tmuxControl {
+		if stop, err := useControlClient(session); err != nil {
+			logf("WARNING: not using a tmux control-mode client: %v", err)
+		} else {
+			defer stop()
+		}
+	}
+
+	b, err := newBird(opts, sendTarget)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 2
+	}
+	b.resolve = resolveOptions
+	if opts.record != "" {
+		t, err := openTranscript(opts.record)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: opening transcript: %v\n", err)
+			return 1
+		}
+		defer t.Close()
+		b.record = t
+	}
+	if opts.auditLog != "" {
+		a, err := openAuditLog(opts.auditLog, opts.auditMaxSize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: opening audit log: %v\n", err)
+			return 1
+		}
+		defer a.Close()
+		b.audit = a
+	}
+	if opts.deadLetter != "" {
+		dl, err := openDeadLetterFile(opts.deadLetter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: opening dead-letter file: %v\n", err)
+			return 1
+		}
+		defer dl.Close()
+		b.dead = dl
+	}
+	if opts.triggerFIFO != "" {
+		q, err := openFIFOQueue(opts.triggerFIFO, func() { b.sendNow("") })
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		defer q.Close()
+		b.queues = append(b.queues, q)
+	}
+	if opts.redisQueue != "" {
+		q, err := openRedisQueue(opts.redisQueue)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 2
+		}
+		defer q.Close()
+		b.queues = append(b.queues, q)
+	}
+	if opts.asciicast != "" {
+		c, err := openCast(opts.asciicast, sendTarget, opts.castPre, opts.castPost)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: opening asciicast: %v\n", err)
+			return 1
+		}
+		defer func() {
+			if err := c.Close(); err != nil {
+				logf("WARNING: %v", err)
+			}
+		}()
+		b.cast = c
+	}
+
+	if opts.detector == nil && (opts.idleMode == idleModePipe || opts.idleMode == idleModePrompt) {
+		m, err := startPipeMonitor(sendTarget, opts.idleMode == idleModePrompt)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed attaching pipe-pane monitor to target %q: %v\n", sendTarget, err)
+			runErrorHook(ctx, opts.hooks.onError, hookEvent{session: session, target: sendTarget, total: len(opts.messages)}, err)
+			return 1
+		}
+		b.monitor = m
+		defer b.closeMonitor()
+	}
+
+	f.add(b)
+	if signals {
+		stopReload := installReloadHandler(cancel, interruptCode, func() {
+			if err := b.reload(); err != nil {
+				logf("WARNING: reload failed: %v", err)
+			}
+		})
+		defer stopReload()
+		defer installUserSignals(f)()
+	}
+	if opts.watch {
+		watcher, err := startFileWatcher(opts.messagesFile, watchDebounce, func() {
+			logf("messages file %q changed; reloading", opts.messagesFile)
+			if err := b.reload(); err != nil {
+				logf("WARNING: reload failed: %v", err)
+			}
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed watching messages file %q: %v\n", opts.messagesFile, err)
+			return 1
+		}
+		defer watcher.Close()
+	}
+	stopServers, code := startFlockServers(f, flockServers{
+		controlSocket: opts.controlSocket,
+		grpcListen:    opts.grpcListen,
+		apiListen:     opts.apiListen,
+		apiTokenFile:  opts.apiTokenFile,
+		mqttBroker:    opts.mqttBroker,
+		mqttTopic:     opts.mqttTopic,
+		natsURL:       opts.natsURL,
+		natsSubject:   opts.natsSubject,
+		tmuxHooks:     opts.tmuxHooks,
+	})
+	if code != 0 {
+		return code
+	}
+	defer stopServers()
+
+	logf(
+		"session=%q send-target=%q idle-mode=%s idle-timeout=%s delay=%s messages=%d",
+		session, sendTarget, opts.idleMode, opts.timeout, opts.delay, len(opts.messages),
+	)
+	if len(opts.messages) == 1 && opts.messages[0] == "" {
+		logf("no messages supplied; sending newline only each timeout")
+	}
+
+	return b.run(ctx, interruptCode)
+}
+
+// applyLogging sets the process-wide logging, tracing, redaction and WSL
+// settings and the capture pool from opts.
+func applyLogging(opts options) {
+	verboseLogging = opts.verbose
+	wslDistro = opts.wslDistro
+	traceLogging = opts.trace
+	messageRedaction = opts.redact
+	setCaptureWorkers(opts.captures)
+}
+
+// flockServers are the addresses of the control APIs a process serves.
+type flockServers struct {
+	controlSocket string
+	grpcListen    string
+	apiListen     string
+	apiTokenFile  string
+	mqttBroker    string
+	mqttTopic     string
+	natsURL       string
+	natsSubject   string
+	// tmuxHooks registers tmux hooks that report the birds' panes and
+	// sessions going away over the control socket.
+	tmuxHooks bool
+}
+
+// startFlockServers starts the control socket and whichever APIs are
+// configured. Without an explicit control socket one is registered in the
+// default socket directory for the dashboard to find. On failure it reports
+// the error and returns a non-zero exit code.
+func startFlockServers(f *flock, s flockServers) (stop func(), code int) {
+	var closers []io.Closer
+	stop = func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			_ = closers[i].Close()
+		}
+	}
+	var control *controlServer
+	if s.controlSocket != "" {
+		server, err := startControlServer(s.controlSocket, f.controlCommand)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed starting control socket %q: %v\n", s.controlSocket, err)
+			return stop, 1
+		}
+		control = server
+		closers = append(closers, server)
+	} else if server, err := startDefaultControlServer(f.controlCommand); err != nil {
+		debugf("not registering a default control socket: %v", err)
+	} else {
+		control = server
+		closers = append(closers, server)
+	}
+	if s.tmuxHooks {
+		if control == nil {
+			fmt.Fprintln(os.Stderr, "ERROR: --tmux-hooks needs a control socket; pass --control-socket")
+			stop()
+			return stop, 1
+		}
+		hooks, err := registerTmuxHooks(control.path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed registering tmux hooks: %v\n", err)
+			stop()
+			return stop, 1
+		}
+		closers = append(closers, hooks)
+	}
+	var token string
+	if s.grpcListen != "" || s.apiListen != "" {
+		var err error
+		if token, err = loadAPIToken(s.apiTokenFile); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			stop()
+			return stop, 2
+		}
+	}
+	if s.grpcListen != "" {
+		server, err := startGRPCServer(s.grpcListen, f, token)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed starting gRPC API on %q: %v\n", s.grpcListen, err)
+			stop()
+			return stop, 1
+		}
+		closers = append(closers, server)
+	}
+	if s.apiListen != "" {
+		server, err := startAPIServer(s.apiListen, f, token)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed starting REST API on %q: %v\n", s.apiListen, err)
+			stop()
+			return stop, 1
+		}
+		closers = append(closers, server)
+	}
+	if s.mqttBroker != "" {
+		bridge, err := startMQTTBridge(s.mqttBroker, s.mqttTopic, f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			stop()
+			return stop, 1
+		}
+		closers = append(closers, bridge)
+	}
+	if s.natsURL != "" {
+		bridge, err := startNATSBridge(s.natsURL, s.natsSubject, f)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			stop()
+			return stop, 1
+		}
+		closers = append(closers, bridge)
+	}
+	return stop, 0
+}
+
+// scriptDecision captures the target and consults the script. It returns the
+// message to send and whether the script supplied it, or errSendDeclined when
+// should_send vetoes this cycle.
+func scriptDecision(script *birdScript, target string, st scriptState, fallback string) (string, bool, error) {
+	capture, err := captureTarget(target)
+	if err != nil {
+		return "", false, fmt.Errorf("capturing target for script: %w", err)
+	}
+	ok, err := script.ShouldSend(string(capture), st)
+	if err != nil {
+		return "", false, err
+	}
+	if !ok {
+		return "", false, errSendDeclined
+	}
+	message, scripted, err := script.NextMessage(string(capture), st)
+	if err != nil {
+		return "", false, err
+	}
+	if !scripted {
+		return fallback, false, nil
+	}
+	return message, true, nil
+}
+
+func parseDuration(raw, name string, requirePositive bool) (time.Duration, error) {
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, raw, err)
+	}
+	if requirePositive {
+		if value <= 0 {
+			return 0, fmt.Errorf("%s must be greater than 0 (got %s)", name, value)
+		}
+		return value, nil
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("%s must be >= 0 (got %s)", name, value)
+	}
+	return value, nil
+}
+
+func validateIdleMode(mode string) error {
+	switch mode {
+	case idleModeCapture, idleModePipe, idleModePrompt:
+		return nil
+	}
+	return fmt.Errorf("invalid idle-mode %q: must be %q, %q or %q", mode, idleModeCapture, idleModePipe, idleModePrompt)
+}
+
+func validateIdleSampling(sampling idleSampling) error {
+	if sampling.samples < 2 {
+		return fmt.Errorf("idle-samples must be >= 2 (got %d)", sampling.samples)
+	}
+	switch sampling.strategy {
+	case idleStrategyAllEqual, idleStrategyConsecutive, idleStrategyAdaptive:
+		return nil
+	case idleStrategyLastKEqual:
+		if sampling.k < 2 || sampling.k > sampling.samples {
+			return fmt.Errorf("idle-k must be between 2 and idle-samples (%d), got %d", sampling.samples, sampling.k)
+		}
+		return nil
+	}
+	return fmt.Errorf(
+		"invalid idle-strategy %q: must be %q, %q, %q or %q",
+		sampling.strategy, idleStrategyAllEqual, idleStrategyConsecutive, idleStrategyLastKEqual, idleStrategyAdaptive,
+	)
+}
+
+// tmuxEnsureSession checks that session exists. When it doesn't and command
+// is set, it creates the session detached, running command, and reports that
+// it did.
+func tmuxEnsureSession(session, command string) (bool, error) {
+	err := sessionExists(session)
+	if err == nil || command == "" {
+		return false, err
+	}
+	out, err := tmuxCombinedOutput(tmuxNewSessionArgs(session, command)...)
+	if err != nil {
+		return false, fmt.Errorf("creating session: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return true, nil
+}
+
+func tmuxNewSessionArgs(session, command string) []string {
+	return []string{"new-session", "-d", "-s", session, command}
+}
+
+func waitForTargetIdle(ctx context.Context, target string, sampling idleSampling, duration time.Duration) (int, error) {
+	switch sampling.strategy {
+	case idleStrategyConsecutive:
+		return waitForTargetStable(ctx, target, sampling.samples, sampling.ignoreSpace, duration)
+	case idleStrategyAdaptive:
+		return waitForTargetAdaptive(ctx, target, adaptiveMinInterval(sampling.minInterval, duration), sampling.ignoreSpace, duration)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, context.Canceled
+		default:
+		}
+
+		idle, baseLen, diffsBase, diffsPrev, err := idleSamplesTarget(ctx, target, sampling, duration)
+		if err != nil {
+			if err == context.Canceled {
+				return 0, context.Canceled
+			}
+			if ok, _ := targetExists(target); !ok {
+				return 0, targetGone(target)
+			}
+			if sleepErr := sleepWithContext(ctx, 200*time.Millisecond); sleepErr != nil {
+				return 0, sleepErr
+			}
+			continue
+		}
+		if idle {
+			return baseLen, nil
+		}
+		debugf("not idle yet on %q; %s", target, formatIdleDifferences(diffsBase, diffsPrev))
+	}
+}
+
+// waitForTargetStable captures continuously at duration/(samples-1) intervals
+// and reports idle once the pane has stayed unchanged for samples consecutive
+// captures, so a change only restarts the window from that point.
+func waitForTargetStable(ctx context.Context, target string, samples int, ignoreSpace bool, duration time.Duration) (int, error) {
+	interval := time.Duration(int64(duration) / int64(samples-1))
+	var prev *paneSample
+	stable := 0
+	for {
+		b, err := captureTarget(target)
+		if err != nil {
+			if ok, _ := targetExists(target); !ok {
+				return 0, targetGone(target)
+			}
+			prev = nil
+			stable = 0
+			if sleepErr := sleepWithContext(ctx, 200*time.Millisecond); sleepErr != nil {
+				return 0, sleepErr
+			}
+			continue
+		}
+		cur := newPaneSample(b, ignoreSpace)
+		if prev != nil && prev.equal(cur) {
+			stable++
+		} else {
+			if prev != nil {
+				debugf("not idle yet on %q; changed %d bytes after %d stable samples", target, prev.diff(cur), stable+1)
+			}
+			stable = 0
+		}
+		prev = &cur
+		if stable >= samples-1 {
+			return cur.size, nil
+		}
+		if err := sleepWithContext(ctx, interval); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// waitForTargetAdaptive captures every minInterval while the pane is changing
+// and backs off exponentially once it goes quiet, timing the final capture to
+// land when the pane has been unchanged for the full duration.
+func waitForTargetAdaptive(ctx context.Context, target string, minInterval time.Duration, ignoreSpace bool, duration time.Duration) (int, error) {
+	var prev *paneSample
+	var lastChange time.Time
+	var interval time.Duration
+	for {
+		b, err := captureTarget(target)
+		now := time.Now()
+		if err != nil {
+			if ok, _ := targetExists(target); !ok {
+				return 0, targetGone(target)
+			}
+			prev = nil
+			if sleepErr := sleepWithContext(ctx, 200*time.Millisecond); sleepErr != nil {
+				return 0, sleepErr
+			}
+			continue
+		}
+		cur := newPaneSample(b, ignoreSpace)
+		if prev == nil || !prev.equal(cur) {
+			if prev != nil {
+				debugf("not idle yet on %q; changed %d bytes after %s quiet", target, prev.diff(cur), now.Sub(lastChange).Round(time.Millisecond))
+			}
+			lastChange = now
+			interval = 0
+		}
+		prev = &cur
+		quiet := now.Sub(lastChange)
+		if quiet >= duration {
+			return cur.size, nil
+		}
+		interval = nextAdaptiveInterval(interval, minInterval, quiet, duration)
+		if err := sleepWithContext(ctx, interval); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// adaptiveMinInterval resolves the fastest adaptive capture interval,
+// defaulting to a twentieth of the timeout.
+func adaptiveMinInterval(configured, duration time.Duration) time.Duration {
+	if configured > 0 {
+		return configured
+	}
+	interval := duration / 20
+	if interval < minAdaptiveInterval {
+		interval = minAdaptiveInterval
+	}
+	return interval
+}
+
+// nextAdaptiveInterval doubles the previous interval (starting at min) and
+// clamps it so the next capture never overshoots the end of the quiet window.
+func nextAdaptiveInterval(prev, min, quiet, duration time.Duration) time.Duration {
+	remaining := duration - quiet
+	if remaining <= 0 {
+		return 0
+	}
+	next := prev * 2
+	if next < min {
+		next = min
+	}
+	if next > remaining {
+		next = remaining
+	}
+	return next
+}
+
+// idleSamplesTarget mirrors idle-latch sampling: capture N times across total duration.
+func idleSamplesTarget(ctx context.Context, target string, sampling idleSampling, duration time.Duration) (bool, int, []int, []int, error) {
+	samples := sampling.samples
+	if samples < 1 {
+		return false, 0, nil, nil, fmt.Errorf("samples must be >= 1")
+	}
+	var interval time.Duration
+	if samples > 1 {
+		interval = time.Duration(int64(duration) / int64(samples-1))
+	}
+
+	caps := make([]paneSample, 0, samples)
+	for i := 0; i < samples; i++ {
+		select {
+		case <-ctx.Done():
+			return false, 0, nil, nil, context.Canceled
+		default:
+		}
+
+		b, err := captureTarget(target)
+		if err != nil {
+			return false, 0, nil, nil, err
+		}
+		caps = append(caps, newPaneSample(b, sampling.ignoreSpace))
+		if i < samples-1 && interval > 0 {
+			if err := sleepWithContext(ctx, interval); err != nil {
+				return false, 0, nil, nil, err
+			}
+		}
+	}
+
+	base := caps[0]
+	diffsFromBase := make([]int, samples)
+	diffsFromPrev := make([]int, samples)
+	for i := 1; i < samples; i++ {
+		diffsFromBase[i] = base.diff(caps[i])
+		diffsFromPrev[i] = caps[i-1].diff(caps[i])
+	}
+	return samplesSettled(caps, sampling), base.size, diffsFromBase, diffsFromPrev, nil
+}
+
+// samplesSettled applies a batch strategy to a set of captures: all-equal
+// requires every capture to match the first, last-k-equal only the trailing k.
+func samplesSettled(caps []paneSample, sampling idleSampling) bool {
+	from := 0
+	if sampling.strategy == idleStrategyLastKEqual && sampling.k < len(caps) {
+		from = len(caps) - sampling.k
+	}
+	for i := from + 1; i < len(caps); i++ {
+		if !caps[from].equal(caps[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return context.Canceled
+	case <-timer.C:
+		return nil
+	}
+}
+
+// errTargetGone marks errors caused by the target pane disappearing.
+var errTargetGone = errors.New("no longer exists")
+
+func targetGone(target string) error {
+	return fmt.Errorf("tmux target %q %w", target, errTargetGone)
+}
+
+// targetExists reports whether target names a live pane. Some tmux
+// versions answer display-message for a missing target with an empty
+// expansion and exit 0, so the pane id must actually come back.
+func targetExists(target string) (bool, error) {
+	if !usingTmux() {
+		_, err := captureTarget(target)
+		return err == nil, nil
+	}
+	out, err := tmuxOutput("display-message", "-p", "-t", target, "#{pane_id}")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) != "", nil
+}
+
+func byteDiffCount(a, b []byte) int {
+	min := len(a)
+	if len(b) < min {
+		min = len(b)
+	}
+	diffs := 0
+	for i := 0; i < min; i++ {
+		if a[i] != b[i] {
+			diffs++
+		}
+	}
+	diffs += abs(len(a) - len(b))
+	return diffs
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func formatIdleDifferences(diffsBase, diffsPrev []int) string {
+	var b strings.Builder
+	b.WriteString("differences relative to sample 1: ")
+	first := true
+	for i := 1; i < len(diffsBase); i++ {
+		if diffsBase[i] != 0 {
+			if !first {
+				b.WriteString(", ")
+			}
+			first = false
+			fmt.Fprintf(&b, "sample %d: base=%d prev=%d", i+1, diffsBase[i], diffsPrev[i])
+		}
+	}
+	return b.String()
+}
+
+// tmuxRestartExistingBirdPanes stops the birds already running in panes of
+// session, other than currentPane, to make way for a new one.
+func tmuxRestartExistingBirdPanes(session, currentPane, commandName string, grace time.Duration) (bool, error) {
+	out, err := tmuxOutput("list-panes", "-t", session, "-F", "#{pane_id}\t#{@typing_bird_injected}\t#{pane_current_command}")
+	if err != nil {
+		return false, err
+	}
+	panes := parseBirdPaneIDs(string(out), commandName)
+	skippedCurrent := false
+	var stopping []string
+	for _, paneID := range panes {
+		if paneID == currentPane && currentPane != "" {
+			skippedCurrent = true
+			continue
+		}
+		stopping = append(stopping, paneID)
+	}
+	stopBirdPanes(stopping, grace)
+	return skippedCurrent, nil
+}
+
+// stopBirdPanes stops the birds in the stopping panes. Each gets two
+// Ctrl-Cs, enough to stop a bird whatever its --interrupt-action, and up to
+// grace to exit and flush its files before its pane is killed.
+func stopBirdPanes(stopping []string, grace time.Duration) {
+	for _, paneID := range stopping {
+		_ = sendKeys(paneID, 0, "C-c")
+	}
+	if len(stopping) > 0 {
+		// Sent together, the two would arrive as one signal.
+		time.Sleep(restartPoll)
+		for _, paneID := range stopping {
+			if !tmuxPaneExited(paneID) {
+				_ = sendKeys(paneID, 0, "C-c")
+			}
+		}
+	}
+	deadline := time.Now().Add(grace)
+	for _, paneID := range stopping {
+		for !tmuxPaneExited(paneID) && time.Now().Before(deadline) {
+			time.Sleep(restartPoll)
+		}
+		// A pane kept by remain-on-exit is dead but still there.
+		_ = tmuxKillPane(paneID)
+	}
+}
+
+// tmuxPaneExited reports whether the pane's process is gone: the pane
+// closed, or is dead and kept by remain-on-exit. tmux answers for a closed
+// pane with an empty line rather than an error.
+func tmuxPaneExited(paneID string) bool {
+	out, err := tmuxOutput("display-message", "-p", "-t", paneID, "#{pane_dead}")
+	return err != nil || strings.TrimSpace(string(out)) != "0"
+}
+
+func parseBirdPaneIDs(raw, commandName string) []string {
+	lines := strings.Split(raw, "\n")
+	seen := make(map[string]struct{})
+	panes := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		paneID := strings.TrimSpace(parts[0])
+		injectedFlag := strings.TrimSpace(parts[1])
+		currentCommand := strings.TrimSpace(parts[2])
+		if paneID == "" {
+			continue
+		}
+		if injectedFlag != "1" && currentCommand != commandName && currentCommand != "typing-bird" {
+			continue
+		}
+		if _, exists := seen[paneID]; exists {
+			continue
+		}
+		seen[paneID] = struct{}{}
+		panes = append(panes, paneID)
+	}
+	return panes
+}
+
+func resolveInjectionSendTarget(session string) (string, error) {
+	if pane := strings.TrimSpace(os.Getenv("TMUX_PANE")); pane != "" {
+		belongs, err := tmuxPaneBelongsToSession(pane, session)
+		if err == nil && belongs {
+			injected, injErr := tmuxPaneIsInjected(pane)
+			if injErr == nil && !injected {
+				return pane, nil
+			}
+		}
+	}
+	return preferredSendPane(session)
+}
+
+func tmuxPaneIsInjected(paneID string) (bool, error) {
+	out, err := tmuxOutput("display-message", "-p", "-t", paneID, "#{@typing_bird_injected}")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "1", nil
+}
+
+func preferredSendPane(session string) (string, error) {
+	var pane string
+	if usingTmux() {
+		out, err := tmuxOutput("list-panes", "-t", session, "-F", "#{pane_id}\t#{pane_active}\t#{@typing_bird_injected}")
+		if err != nil {
+			return "", err
+		}
+		pane = pickPreferredSendPane(string(out))
+	} else {
+		panes, err := sessionPanes(session)
+		if err != nil {
+			return "", err
+		}
+		pane = pickRetargetPane(panes, nil)
+	}
+	if pane != "" {
+		return pane, nil
+	}
+	return "", fmt.Errorf("no non-injected pane found in session")
+}
+
+func pickPreferredSendPane(raw string) string {
+	lines := strings.Split(raw, "\n")
+	firstNonInjected := ""
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) < 3 {
+			continue
+		}
+		paneID := strings.TrimSpace(parts[0])
+		active := strings.TrimSpace(parts[1])
+		injected := strings.TrimSpace(parts[2]) == "1"
+		if paneID == "" || injected {
+			continue
+		}
+		if active == "1" {
+			return paneID
+		}
+		if firstNonInjected == "" {
+			firstNonInjected = paneID
+		}
+	}
+	return firstNonInjected
+}
+
+func tmuxPaneBelongsToSession(paneID, session string) (bool, error) {
+	out, err := tmuxOutput("display-message", "-p", "-t", paneID, "#{session_name}")
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == session, nil
+}
+
+func tmuxActivePaneForSession(session string) (string, error) {
+	out, err := tmuxOutput("display-message", "-p", "-t", session, "#{pane_id}")
+	if err != nil {
+		return "", err
+	}
+	pane := strings.TrimSpace(string(out))
+	if pane == "" {
+		return "", fmt.Errorf("tmux returned empty pane id")
+	}
+	return pane, nil
+}
+
+func buildChildArgs(opts options, targetPane string) []string {
+	args := []string{"-t", opts.timeout.String(), "-d", opts.delay.String()}
+	if opts.trace {
+		args = append(args, "--trace")
+	} else if opts.verbose {
+		args = append(args, "--verbose")
+	}
+	if opts.initialDelay > 0 {
+		args = append(args, "--initial-delay", opts.initialDelay.String())
+	}
+	if opts.sendNow {
+		args = append(args, "--send-immediately")
+	}
+	if opts.idleMode != "" && opts.idleMode != idleModeCapture {
+		args = append(args, "--idle-mode", opts.idleMode)
+	}
+	if opts.sampling.samples != 0 && opts.sampling.samples != defaultIdleSamples {
+		args = append(args, "--idle-samples", strconv.Itoa(opts.sampling.samples))
+	}
+	if opts.sampling.strategy != "" && opts.sampling.strategy != idleStrategyAllEqual {
+		args = append(args, "--idle-strategy", opts.sampling.strategy)
+	}
+	if opts.sampling.k != 0 && opts.sampling.k != defaultIdleK {
+		args = append(args, "--idle-k", strconv.Itoa(opts.sampling.k))
+	}
+	if opts.sampling.minInterval > 0 {
+		args = append(args, "--idle-min-interval", opts.sampling.minInterval.String())
+	}
+	if opts.sampling.ignoreSpace {
+		args = append(args, "--ignore-whitespace")
+	}
+	if opts.busy != nil {
+		args = append(args, "--busy-regex", outputPatternSource(opts.busy))
+	}
+	if opts.exitOn != nil {
+		args = append(args, "--exit-on", outputPatternSource(opts.exitOn))
+	}
+	if opts.failOn != nil {
+		args = append(args, "--fail-on", outputPatternSource(opts.failOn))
+	}
+	if opts.hooks.preSend != "" {
+		args = append(args, "--pre-hook", opts.hooks.preSend)
+	}
+	if opts.hooks.postSend != "" {
+		args = append(args, "--post-hook", opts.hooks.postSend)
+	}
+	if opts.hooks.onIdle != "" {
+		args = append(args, "--on-idle", opts.hooks.onIdle)
+	}
+	if opts.hooks.onError != "" {
+		args = append(args, "--on-error", opts.hooks.onError)
+	}
+	if opts.script != "" {
+		args = append(args, "--script", opts.script)
+	}
+	if opts.llm != nil {
+		args = append(args, "--llm", opts.llm.endpoint, "--llm-model", opts.llm.model)
+		if opts.llm.promptFile != "" {
+			args = append(args, "--llm-prompt-file", opts.llm.promptFile)
+		}
+		if opts.llm.keyEnv != defaultLLMKeyEnv {
+			args = append(args, "--llm-key-env", opts.llm.keyEnv)
+		}
+	}
+	if opts.order != "" && opts.order != orderRoundRobin {
+		args = append(args, "--order", opts.order)
+	}
+	if opts.weights != "" {
+		args = append(args, "--weights", opts.weights)
+	}
+	if opts.noLoop {
+		args = append(args, "--no-loop")
+	}
+	if opts.noStatusLine {
+		args = append(args, "--no-statusline")
+	}
+	if opts.borderStatus {
+		args = append(args, "--border-status")
+	}
+	if opts.noPaneTitle {
+		args = append(args, "--no-pane-title")
+	}
+	if opts.interruptWin > 0 && opts.interruptWin != defaultInterruptWindow {
+		args = append(args, "--interrupt-window", opts.interruptWin.String())
+	}
+	if opts.interruptAct != "" && opts.interruptAct != interruptHint {
+		args = append(args, "--interrupt-action", opts.interruptAct)
+	}
+	if opts.exitPane != "" && opts.exitPane != exitPaneKill {
+		args = append(args, "--exit-pane", opts.exitPane)
+	}
+	if opts.windowCount {
+		args = append(args, "--window-countdown")
+	}
+	if opts.auditLog != "" {
+		args = append(args, "--audit-log", opts.auditLog)
+		if opts.auditMaxSize != defaultAuditMaxSize {
+			args = append(args, "--audit-max-size", strconv.FormatInt(opts.auditMaxSize, 10))
+		}
+	}
+	if opts.deadLetter != "" {
+		args = append(args, "--dead-letter", opts.deadLetter)
+	}
+	if opts.heartbeat != "" {
+		args = append(args, "--heartbeat-file", opts.heartbeat)
+	}
+	if opts.stateFile != "" {
+		args = append(args, "--state-file", opts.stateFile)
+	}
+	if opts.sendRetries > 0 {
+		args = append(args, "--send-retries", strconv.Itoa(opts.sendRetries))
+	}
+	if opts.captures > 0 && opts.captures != defaultCaptureWorkers {
+		args = append(args, "--capture-workers", strconv.Itoa(opts.captures))
+	}
+	if opts.record != "" {
+		args = append(args, "--record", opts.record)
+	}
+	if opts.asciicast != "" {
+		args = append(args, "--asciicast", opts.asciicast)
+		if opts.castPre != defaultCastPre {
+			args = append(args, "--asciicast-pre", opts.castPre.String())
+		}
+		if opts.castPost != defaultCastPost {
+			args = append(args, "--asciicast-post", opts.castPost.String())
+		}
+	}
+	if opts.exitCode != 0 {
+		args = append(args, "--no-loop-exit-code", strconv.Itoa(opts.exitCode))
+	}
+	if opts.config != "" {
+		args = append(args, "--config", opts.config)
+	}
+	if opts.profile != "" {
+		args = append(args, "--profile", opts.profile)
+	}
+	if opts.messagesFile != "" {
+		args = append(args, "--messages-file", opts.messagesFile)
+	}
+	// Messages given as arguments are forwarded already split, and
+	// encoded again with --base64.
+	if opts.delimiter != "" && opts.reloadable {
+		args = append(args, "--delimiter", opts.delimiter)
+	} else if opts.base64 && !opts.reloadable {
+		args = append(args, "--base64")
+	}
+	if opts.watch {
+		args = append(args, "--watch")
+	}
+	if len(opts.secretSources) > 0 {
+		sources := make([]string, 0, len(opts.secretSources))
+		for _, source := range opts.secretSources {
+			sources = append(sources, source.String())
+		}
+		args = append(args, "--secrets", strings.Join(sources, ","))
+	}
+	if opts.redact != "" && opts.redact != redactOff {
+		args = append(args, "--redact="+opts.redact)
+	}
+	if opts.expectAfter != nil {
+		args = append(args, "--expect-after", outputPatternSource(opts.expectAfter))
+	}
+	if opts.expectTimeout > 0 {
+		args = append(args, "--expect-timeout", opts.expectTimeout.String())
+	}
+	if opts.retargetTitle != nil {
+		args = append(args, "--retarget-title", opts.retargetTitle.String())
+	} else if opts.retarget {
+		args = append(args, "--retarget")
+	}
+	if opts.takeover {
+		args = append(args, "--takeover")
+	}
+	if opts.reattach > 0 {
+		args = append(args, "--reattach", opts.reattach.String())
+	}
+	if opts.followActive {
+		args = append(args, "--follow-active")
+	}
+	if opts.keepalive > 0 {
+		args = append(args, "--keepalive", opts.keepalive.String())
+		if opts.keepaliveKey != defaultKeepaliveKey {
+			args = append(args, "--keepalive-key", opts.keepaliveKey)
+		}
+	}
+	if opts.chunks.size > 0 {
+		args = append(args, "--chunk-size", strconv.Itoa(opts.chunks.size))
+		if opts.chunks.pause != defaultChunkPause {
+			args = append(args, "--chunk-pause", opts.chunks.pause.String())
+		}
+		if opts.chunks.verify {
+			args = append(args, "--chunk-verify")
+		}
+	}
+	if opts.pasteLines {
+		args = append(args, "--paste-newlines")
+	}
+	if opts.wpm > 0 {
+		args = append(args, "--wpm", strconv.Itoa(opts.wpm))
+	}
+	if opts.typoRate > 0 {
+		args = append(args, "--typo-rate", strconv.FormatFloat(opts.typoRate, 'g', -1, 64))
+	}
+	if opts.verify {
+		args = append(args, "--verify")
+		if opts.verifyRetries != 1 {
+			args = append(args, "--verify-retries", strconv.Itoa(opts.verifyRetries))
+		}
+	}
+	if opts.humanCooldown > 0 {
+		args = append(args, "--human-cooldown", opts.humanCooldown.String())
+	}
+	if opts.allowPassword {
+		args = append(args, "--password-guard=false")
+	}
+	if opts.passwordRegex != nil {
+		args = append(args, "--password-regex", opts.passwordRegex.String())
+	}
+	if opts.passwordAlert {
+		args = append(args, "--password-alert")
+	}
+	if opts.policy.deny != nil {
+		args = append(args, "--deny-regex", opts.policy.deny.String())
+	}
+	if opts.policy.allow != nil {
+		args = append(args, "--allow-regex", opts.policy.allow.String())
+	}
+	if opts.policy.force {
+		args = append(args, "--force")
+	}
+	if opts.activeHours != "" {
+		args = append(args, "--active-hours", opts.activeHours)
+	}
+	if opts.activeDays != "" {
+		args = append(args, "--active-days", opts.activeDays)
+	}
+	if opts.timezone != "" {
+		args = append(args, "--timezone", opts.timezone)
+	}
+	if opts.maxRuntime > 0 {
+		args = append(args, "--max-runtime", opts.maxRuntime.String())
+	}
+	if opts.until != "" {
+		args = append(args, "--until", opts.until)
+	}
+	if opts.tmuxHooks {
+		args = append(args, "--tmux-hooks")
+	}
+	if opts.tmuxControl {
+		args = append(args, "--tmux-control")
+	}
+	if opts.wslDistro != "" {
+		args = append(args, "--wsl-distro", opts.wslDistro)
+	}
+	if opts.controlSocket != "" {
+		args = append(args, "--control-socket", opts.controlSocket)
+	}
+	if opts.grpcListen != "" {
+		args = append(args, "--grpc-listen", opts.grpcListen)
+	}
+	if opts.apiListen != "" {
+		args = append(args, "--api-listen", opts.apiListen)
+	}
+	if opts.apiTokenFile != "" {
+		args = append(args, "--api-token-file", opts.apiTokenFile)
+	}
+	if opts.afterPID != 0 {
+		args = append(args, "--after-pid", strconv.Itoa(opts.afterPID))
+	}
+	if opts.afterCommand != "" {
+		args = append(args, "--after-command", opts.afterCommand)
+	}
+	if opts.afterMessage != "" {
+		args = append(args, "--after-message", opts.afterMessage)
+	}
+	if opts.waitPort != "" {
+		args = append(args, "--wait-port", opts.waitPort)
+	}
+	if opts.waitHTTP != "" {
+		args = append(args, "--wait-http", opts.waitHTTP)
+	}
+	if opts.waitBefore > 0 {
+		args = append(args, "--wait-before", strconv.Itoa(opts.waitBefore))
+	}
+	if opts.waitTimeout > 0 {
+		args = append(args, "--wait-timeout", opts.waitTimeout.String())
+	}
+	if opts.triggerFIFO != "" {
+		args = append(args, "--trigger-fifo", opts.triggerFIFO)
+	}
+	if opts.redisQueue != "" {
+		args = append(args, "--redis-queue", opts.redisQueue)
+	}
+	if opts.natsURL != "" {
+		args = append(args, "--nats-url", opts.natsURL)
+		if opts.natsSubject != defaultNATSSubject {
+			args = append(args, "--nats-subject", opts.natsSubject)
+		}
+	}
+	if opts.mqttBroker != "" {
+		args = append(args, "--mqtt-broker", opts.mqttBroker)
+		if opts.mqttTopic != defaultMQTTTopic {
+			args = append(args, "--mqtt-topic", opts.mqttTopic)
+		}
+	}
+	if strings.TrimSpace(targetPane) != "" {
+		args = append(args, "--target-pane", targetPane)
+	}
+	args = append(args, opts.session)
+	if !opts.reloadable && opts.base64 {
+		// Encoded again, so the child's command line is plain words.
+		for _, message := range opts.messages {
+			args = append(args, base64.StdEncoding.EncodeToString([]byte(message)))
+		}
+	} else if !opts.reloadable {
+		args = append(args, opts.messages...)
+	}
+	return args
+}
+
+func buildLaunchCommand(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(args))
+	for _, arg := range args {
+		parts = append(parts, shellQuoteSingle(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// installReloadHandler calls reload on SIGHUP. A SIGHUP caused by the
+// terminal going away (e.g. the injected pane being killed) is treated as a
+// shutdown instead.
+func installReloadHandler(cancel context.CancelFunc, exitCode *atomic.Int32, reload func()) (stop func()) {
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	hadTTY := canOpenTTY()
+	signal.Notify(c, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-c:
+				if terminalHungUp(hadTTY) {
+					exitCode.Store(129)
+					cancel()
+					return
+				}
+				logf("SIGHUP received; reloading")
+				reload()
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+// installUserSignals makes SIGUSR1 send the next message right away and
+// SIGUSR2 skip it, for every bird in the process.
+func installUserSignals(f *flock) (stop func()) {
+	if sendNowSignal == nil {
+		return func() {}
+	}
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, sendNowSignal, skipSignal)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-c:
+				for _, b := range f.list() {
+					if sig == sendNowSignal {
+						b.logf("SIGUSR1 received; sending now")
+						b.sendNow("")
+					} else {
+						b.skip()
+					}
+				}
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
+
+// terminalHungUp reports whether the controlling terminal we started with,
+// or our own tmux pane, is gone. tmux may deliver SIGHUP before the pane
+// disappears from its listing, so the terminal is checked first.
+func terminalHungUp(hadTTY bool) bool {
+	if hadTTY && !canOpenTTY() {
+		return true
+	}
+	if pane := strings.TrimSpace(os.Getenv("TMUX_PANE")); pane != "" {
+		ok, _ := targetExists(pane)
+		return !ok
+	}
+	return false
+}
+
+func canOpenTTY() bool {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false
+	}
+	_ = tty.Close()
+	return true
+}
+
+func shellCommandForExec(executable string, args []string) string {
+	parts := make([]string, 0, len(args)+1)
+	parts = append(parts, shellQuoteSingle(executable))
+	for _, arg := range args {
+		parts = append(parts, shellQuoteSingle(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseInjectLayout validates --inject-size, --inject-direction and
+// --inject-window.
+func parseInjectLayout(size, direction string, window bool) (injectLayout, error) {
+	layout := injectLayout{size: strings.TrimSpace(size), window: window}
+	n, err := strconv.Atoi(strings.TrimSuffix(layout.size, "%"))
+	if err != nil || n < 1 || (strings.HasSuffix(layout.size, "%") && n > 99) {
+		return injectLayout{}, fmt.Errorf("invalid inject-size %q: want a number of lines or a percentage such as 20%%", size)
+	}
+	switch direction {
+	case injectVertical:
+	case injectHorizontal:
+		layout.horizontal = true
+	default:
+		return injectLayout{}, fmt.Errorf("invalid inject-direction %q: must be %q or %q", direction, injectVertical, injectHorizontal)
+	}
+	return layout, nil
+}
+
+func tmuxSplitInjectPaneArgs(targetPane, shellCommand string, layout injectLayout) []string {
+	split := "-v"
+	if layout.horizontal {
+		split = "-h"
+	}
+	args := []string{"split-window", split, "-d"}
+	args = append(args, tmuxSplitSizeArgs(layout.size)...)
+	return append(args, "-P", "-F", "#{pane_id}", "-t", targetPane, shellCommand)
+}
+
+// tmuxInjectWindowArgs adds a pane to the session's birds window, creating
+// the window when it doesn't exist yet.
+func tmuxInjectWindowArgs(session, shellCommand string, exists bool) []string {
+	if exists {
+		return []string{"split-window", "-d", "-P", "-F", "#{pane_id}", "-t", session + ":" + injectWindowName, shellCommand}
+	}
+	return []string{"new-window", "-d", "-n", injectWindowName, "-P", "-F", "#{pane_id}", "-t", session + ":", shellCommand}
+}
+
+func tmuxWindowExists(session, name string) (bool, error) {
+	out, err := tmuxOutput("list-windows", "-t", session, "-F", "#{window_name}")
+	if err != nil {
+		return false, err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func tmuxInjectPane(session, targetPane, shellCommand string, layout injectLayout) (string, error) {
+	if !layout.window {
+		return activeBackend.Split(targetPane, shellCommand, layout.horizontal, layout.size)
+	}
+	exists, err := tmuxWindowExists(session, injectWindowName)
+	if err != nil {
+		return "", err
+	}
+	cmdArgs := tmuxInjectWindowArgs(session, shellCommand, exists)
+	out, err := tmuxCombinedOutput(cmdArgs...)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	paneID := strings.TrimSpace(string(out))
+	if paneID == "" {
+		return "", fmt.Errorf("tmux %s returned empty pane id", cmdArgs[0])
+	}
+	return paneID, nil
+}
+
+func tmuxMarkInjectedPane(paneID, sendTargetPane string) error {
+	if !tmuxPaneOptions.supportedBy(runningTmux()) {
+		// Old birds are then only found by their command name.
+		debugf("not marking pane %q as a bird: tmux %s has no %s", paneID, runningTmux(), tmuxPaneOptions.name)
+		return nil
+	}
+	if err := tmuxRun("set-option", "-p", "-t", paneID, "@typing_bird_injected", "1"); err != nil {
+		return err
+	}
+	if err := tmuxRun("set-option", "-p", "-t", paneID, "@typing_bird_send_target", sendTargetPane); err != nil {
+		return err
+	}
+	return nil
+}
+
+func tmuxKillPane(paneID string) error {
+	return tmuxRun("kill-pane", "-t", paneID)
+}
+
+func tmuxSendMessage(target, message string, keyDelay time.Duration, chunks chunking, human typing) error {
+	actions := human.apply(chunks.split(messageSendActions(message, enterKey)))
+	if keyDelay == 0 && human.wpm == 0 && chunks.size == 0 && usingTmux() {
+		// Nothing to wait for between keys, so one tmux call sends
+		// everything up to the next macro sleep.
+		var commands [][]string
+		for _, action := range actions {
+			switch {
+			case action.sleep > 0:
+				if len(commands) > 0 {
+					if err := tmuxBatch(commands...); err != nil {
+						return err
+					}
+					commands = nil
+				}
+				time.Sleep(action.sleep)
+			case action.literal:
+				commands = append(commands, sendLiteralArgs(target, action.value))
+			case action.hex:
+				commands = append(commands, sendHexArgs(target, []byte(action.value)))
+			default:
+				commands = append(commands, sendKeyArgs(target, action.value))
+			}
+		}
+		if len(commands) == 0 {
+			return nil
+		}
+		return tmuxBatch(commands...)
+	}
+	for _, action := range actions {
+		if action.sleep > 0 {
+			time.Sleep(action.sleep)
+			continue
+		}
+		if action.literal {
+			if human.perChar() {
+				// Characters typed one at a time are paced like keys.
+				if d := human.delay(keyDelay); d > 0 {
+					time.Sleep(d)
+				}
+			}
+			if chunks.size == 0 {
+				if err := sendLiteral(target, action.value); err != nil {
+					return err
+				}
+				continue
+			}
+			mark, err := chunks.mark(target)
+			if err != nil {
+				return err
+			}
+			if err := sendLiteral(target, action.value); err != nil {
+				return err
+			}
+			if err := chunks.settle(target, action.value, mark); err != nil {
+				return err
+			}
+			continue
+		}
+		if action.hex {
+			if err := sendHex(target, human.delay(keyDelay), []byte(action.value)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := sendKeys(target, human.delay(keyDelay), action.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sendLiteralArgs(target, value string) []string {
+	return []string{"send-keys", "-t", target, "-l", "--", value}
+}
+
+func sendKeyArgs(target string, keys ...string) []string {
+	return append([]string{"send-keys", "-t", target}, keys...)
+}
+
+func sendHexArgs(target string, raw []byte) []string {
+	args := []string{"send-keys", "-t", target, "-H"}
+	for _, b := range raw {
+		args = append(args, fmt.Sprintf("%02x", b))
+	}
+	return args
+}
+
+type sendAction struct {
+	value   string
+	literal bool
+	// hex is set for a macro's hex step; value holds the bytes.
+	hex bool
+	// sleep is set for a macro's sleep step, which sends nothing.
+	sleep time.Duration
+}
+
+// messageSendActions splits message into literal text and key presses,
+// pressing enter for each line break and at the end. Macro steps become
+// their own actions, and a macro gets no final enter.
+func messageSendActions(message, enter string) []sendAction {
+	actions := make([]sendAction, 0, 2)
+	steps := macroPattern.FindAllStringSubmatchIndex(message, -1)
+	last := 0
+	for _, m := range steps {
+		actions = appendTextActions(actions, message[last:m[0]], enter)
+		value := message[m[4]:m[5]]
+		if message[m[2]:m[3]] == "sleep" {
+			// Sleeps were checked when the messages were loaded.
+			d, _ := parseMacroSleep(value)
+			actions = append(actions, sendAction{sleep: d})
+		} else if message[m[2]:m[3]] == "hex" {
+			b, _ := parseMacroHex(value)
+			actions = append(actions, sendAction{value: string(b), hex: true})
+		} else {
+			actions = append(actions, sendAction{value: value})
+		}
+		last = m[1]
+	}
+	actions = appendTextActions(actions, message[last:], enter)
+	if len(steps) == 0 {
+		actions = append(actions, sendAction{value: enter})
+	}
+	return actions
+}
+
+func appendTextActions(actions []sendAction, message, enter string) []sendAction {
+	var current strings.Builder
+	prevWasCR := false
+
+	flushLiteral := func() {
+		if current.Len() == 0 {
+			return
+		}
+		actions = append(actions, sendAction{value: current.String(), literal: true})
+		current.Reset()
+	}
+
+	for _, r := range message {
+		switch r {
+		case '\r':
+			flushLiteral()
+			actions = append(actions, sendAction{value: enter})
+			prevWasCR = true
+		case '\n':
+			if prevWasCR {
+				prevWasCR = false
+				continue
+			}
+			flushLiteral()
+			actions = append(actions, sendAction{value: enter})
+			prevWasCR = false
+		default:
+			prevWasCR = false
+			current.WriteRune(r)
+		}
+	}
+
+	flushLiteral()
+	return actions
+}
+
+func shellQuoteSingle(value string) string {
+	if value == "" {
+		return "''"
+	}
+
+	var builder strings.Builder
+	builder.WriteByte('\'')
+	for _, r := range value {
+		if r == '\'' {
+			builder.WriteString("'\\''")
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	builder.WriteByte('\'')
+	return builder.String()
+}
+
+func logf(format string, args ...any) {
+	all := make([]any, 0, len(args)+1)
+	all = append(all, time.Now().Format(time.RFC3339))
+	all = append(all, args...)
+	fmt.Fprint(os.Stderr, secretRedactor.redact(fmt.Sprintf("[%s] INFO: "+format+"\n", all...)))
+}
+
+func tracef(format string, args ...any) {
+	if !traceLogging {
+		return
+	}
+	all := make([]any, 0, len(args)+1)
+	all = append(all, time.Now().Format(time.RFC3339))
+	all = append(all, args...)
+	fmt.Fprint(os.Stderr, secretRedactor.redact(fmt.Sprintf("[%s] TRACE: "+format+"\n", all...)))
+}
+
+func debugf(format string, args ...any) {
+	if !verboseLogging {
+		return
+	}
+	all := make([]any, 0, len(args)+1)
+	all = append(all, time.Now().Format(time.RFC3339))
+	all = append(all, args...)
+	fmt.Fprint(os.Stderr, secretRedactor.redact(fmt.Sprintf("[%s] DEBUG: "+format+"\n", all...)))
+}
@@ -0,0 +1,620 @@
+package typingbird
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMessageSendActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		enterKey string
+		want     []sendAction
+	}{
+		{
+			name:     "plain message gets one trailing enter",
+			message:  "hello",
+			enterKey: "Enter",
+			want: []sendAction{
+				{value: "hello", literal: true},
+				{value: "Enter"},
+			},
+		},
+		{
+			name:     "empty message still sends enter",
+			message:  "",
+			enterKey: "Enter",
+			want: []sendAction{
+				{value: "Enter"},
+			},
+		},
+		{
+			name:     "lf becomes enter",
+			message:  "one\ntwo",
+			enterKey: "Enter",
+			want: []sendAction{
+				{value: "one", literal: true},
+				{value: "Enter"},
+				{value: "two", literal: true},
+				{value: "Enter"},
+			},
+		},
+		{
+			name:     "cr becomes enter",
+			message:  "one\rtwo",
+			enterKey: "Enter",
+			want: []sendAction{
+				{value: "one", literal: true},
+				{value: "Enter"},
+				{value: "two", literal: true},
+				{value: "Enter"},
+			},
+		},
+		{
+			name:     "crlf becomes one enter",
+			message:  "one\r\ntwo",
+			enterKey: "Enter",
+			want: []sendAction{
+				{value: "one", literal: true},
+				{value: "Enter"},
+				{value: "two", literal: true},
+				{value: "Enter"},
+			},
+		},
+		{
+			name:     "consecutive delimiters send consecutive enters",
+			message:  "a\n\nb",
+			enterKey: "Enter",
+			want: []sendAction{
+				{value: "a", literal: true},
+				{value: "Enter"},
+				{value: "Enter"},
+				{value: "b", literal: true},
+				{value: "Enter"},
+			},
+		},
+		{
+			name:     "custom enter key",
+			message:  "a\nb",
+			enterKey: "C-m",
+			want: []sendAction{
+				{value: "a", literal: true},
+				{value: "C-m"},
+				{value: "b", literal: true},
+				{value: "C-m"},
+			},
+		},
+		{
+			name:     "macro steps and no trailing enter",
+			message:  `/model{{key "Down"}}{{ sleep "200" }}{{key "Enter"}}`,
+			enterKey: "Enter",
+			want: []sendAction{
+				{value: "/model", literal: true},
+				{value: "Down"},
+				{sleep: 200 * time.Millisecond},
+				{value: "Enter"},
+			},
+		},
+		{
+			name:     "macro hex step",
+			message:  "x{{hex \"1b 5b 41\"}}{{key \"Enter\"}}",
+			enterKey: "Enter",
+			want: []sendAction{
+				{value: "x", literal: true},
+				{value: "\x1b[A", hex: true},
+				{value: "Enter"},
+			},
+		},
+		{
+			name:     "macro text keeps its line breaks",
+			message:  "a\nb{{key \"Escape\"}}",
+			enterKey: "Enter",
+			want: []sendAction{
+				{value: "a", literal: true},
+				{value: "Enter"},
+				{value: "b", literal: true},
+				{value: "Escape"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := messageSendActions(tt.message, tt.enterKey)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("messageSendActions(%q, %q) = %#v; want %#v", tt.message, tt.enterKey, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestByteDiffCount(t *testing.T) {
+	a := []byte("abcdef")
+	b := []byte("abcXefghi")
+	got := byteDiffCount(a, b)
+	if got != 4 {
+		t.Fatalf("byteDiffCount(...) = %d; want %d", got, 4)
+	}
+}
+
+func TestBuildChildArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   options
+		target string
+		want   []string
+	}{
+		{
+			name:   "target pane without inject flag",
+			opts:   options{timeout: 30 * time.Second, delay: 15 * time.Millisecond, session: "foobar", messages: []string{"m1", "m2"}},
+			target: "%123",
+			want:   []string{"-t", "30s", "-d", "15ms", "--target-pane", "%123", "foobar", "m1", "m2"},
+		},
+		{
+			name:   "verbose",
+			opts:   options{timeout: 30 * time.Second, delay: 15 * time.Millisecond, verbose: true, session: "foobar", messages: []string{"m1"}},
+			target: "%123",
+			want:   []string{"-t", "30s", "-d", "15ms", "--verbose", "--target-pane", "%123", "foobar", "m1"},
+		},
+		{
+			name:   "idle mode",
+			opts:   options{timeout: time.Minute, delay: 0, idleMode: idleModePipe, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--idle-mode", "pipe", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "active window",
+			opts:   options{timeout: time.Minute, activeHours: "09:00-18:00", activeDays: "mon-fri", timezone: "Europe/Berlin", session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--active-hours", "09:00-18:00", "--active-days", "mon-fri", "--timezone", "Europe/Berlin", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "trace",
+			opts:   options{timeout: time.Minute, verbose: true, trace: true, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--trace", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "retarget",
+			opts:   options{timeout: time.Minute, retarget: true, retargetTitle: regexp.MustCompile("^agent"), session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--retarget-title", "^agent", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "idle sampling",
+			opts:   options{timeout: time.Minute, delay: defaultDelay, sampling: idleSampling{samples: 8, strategy: idleStrategyLastKEqual, k: 4, ignoreSpace: true}, session: "foobar"},
+			target: "",
+			want:   []string{"-t", "1m0s", "-d", "15ms", "--idle-samples", "8", "--idle-strategy", "last-k-equal", "--idle-k", "4", "--ignore-whitespace", "foobar"},
+		},
+		{
+			name:   "hooks",
+			opts:   options{timeout: time.Minute, delay: defaultDelay, hooks: hooks{preSend: "pre", postSend: "post", onIdle: "idle", onError: "err"}, session: "foobar"},
+			target: "",
+			want:   []string{"-t", "1m0s", "-d", "15ms", "--pre-hook", "pre", "--post-hook", "post", "--on-idle", "idle", "--on-error", "err", "foobar"},
+		},
+		{
+			name:   "first send timing",
+			opts:   options{timeout: time.Minute, initialDelay: 30 * time.Second, sendNow: true, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--initial-delay", "30s", "--send-immediately", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "expect after",
+			opts:   options{timeout: time.Minute, expectAfter: regexp.MustCompile(`(?m)^DONE`), session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--expect-after", "^DONE", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "busy regex",
+			opts:   options{timeout: time.Minute, busy: regexp.MustCompile(`(?m)Compiling|\[\d+%\]`), session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--busy-regex", `Compiling|\[\d+%\]`, "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "WSL distro",
+			opts:   options{timeout: time.Minute, wslDistro: "Ubuntu", session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--wsl-distro", "Ubuntu", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "stop limits",
+			opts:   options{timeout: time.Minute, maxRuntime: 8 * time.Hour, until: "17:30", session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--max-runtime", "8h0m0s", "--until", "17:30", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "outcome patterns",
+			opts:   options{timeout: time.Minute, exitOn: regexp.MustCompile(`(?m)All tests passed`), failOn: regexp.MustCompile(`(?m)^FAIL`), session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--exit-on", "All tests passed", "--fail-on", "^FAIL", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "reattach",
+			opts:   options{timeout: time.Minute, retarget: true, reattach: 5 * time.Minute, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--retarget", "--reattach", "5m0s", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "takeover",
+			opts:   options{timeout: time.Minute, takeover: true, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--takeover", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "follow active",
+			opts:   options{timeout: time.Minute, followActive: true, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--follow-active", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "keepalive",
+			opts:   options{timeout: time.Minute, keepalive: 30 * time.Second, keepaliveKey: "F24", session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--keepalive", "30s", "--keepalive-key", "F24", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "LLM",
+			opts:   options{timeout: time.Minute, llm: &llmConfig{endpoint: "http://localhost:11434/v1", model: "qwen", keyEnv: "LOCAL_KEY"}, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--llm", "http://localhost:11434/v1", "--llm-model", "qwen", "--llm-key-env", "LOCAL_KEY", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "chunking",
+			opts:   options{timeout: time.Minute, chunks: chunking{size: 512, pause: time.Second, verify: true}, pasteLines: true, typoRate: 0.05, wpm: 80, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--chunk-size", "512", "--chunk-pause", "1s", "--chunk-verify", "--paste-newlines", "--wpm", "80", "--typo-rate", "0.05", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "verify",
+			opts:   options{timeout: time.Minute, verify: true, verifyRetries: 3, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--verify", "--verify-retries", "3", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "password guard",
+			opts:   options{timeout: time.Minute, allowPassword: true, passwordRegex: regexp.MustCompile(`^PIN:`), passwordAlert: true, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--password-guard=false", "--password-regex", "^PIN:", "--password-alert", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "policy",
+			opts:   options{timeout: time.Minute, policy: messagePolicy{deny: regexp.MustCompile(`DROP`), allow: regexp.MustCompile(`^go`), force: true}, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--deny-regex", "DROP", "--allow-regex", "^go", "--force", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "audit log",
+			opts:   options{timeout: time.Minute, auditLog: "/var/log/bird.audit", auditMaxSize: 1 << 20, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--audit-log", "/var/log/bird.audit", "--audit-max-size", "1048576", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "MQTT",
+			opts:   options{timeout: time.Minute, mqttBroker: "tcp://localhost:1883", mqttTopic: "home/bird", session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--mqtt-broker", "tcp://localhost:1883", "--mqtt-topic", "home/bird", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "redis queue",
+			opts:   options{timeout: time.Minute, redisQueue: "redis://cache/0/work", session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--redis-queue", "redis://cache/0/work", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "NATS",
+			opts:   options{timeout: time.Minute, natsURL: "nats://glue:4222", natsSubject: "agents.one", session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--nats-url", "nats://glue:4222", "--nats-subject", "agents.one", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "trigger FIFO",
+			opts:   options{timeout: time.Minute, triggerFIFO: "/tmp/bird.fifo", session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--trigger-fifo", "/tmp/bird.fifo", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "after command",
+			opts:   options{timeout: time.Minute, afterCommand: "make test", afterMessage: "done", session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--after-command", "make test", "--after-message", "done", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "wait port",
+			opts:   options{timeout: time.Minute, waitPort: "localhost:5432", waitBefore: 2, waitTimeout: time.Minute, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--wait-port", "localhost:5432", "--wait-before", "2", "--wait-timeout", "1m0s", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "dead letter",
+			opts:   options{timeout: time.Minute, deadLetter: "/tmp/dead.jsonl", heartbeat: "/tmp/bird.beat", sendRetries: 3, captures: 16, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--dead-letter", "/tmp/dead.jsonl", "--heartbeat-file", "/tmp/bird.beat", "--send-retries", "3", "--capture-workers", "16", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "title flags",
+			opts:   options{timeout: time.Minute, noPaneTitle: true, windowCount: true, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--no-pane-title", "--window-countdown", "--target-pane", "%4", "foobar", "m1"},
+		},
+		{
+			name:   "interrupts",
+			opts:   options{timeout: time.Minute, interruptWin: 2 * time.Second, interruptAct: interruptPause, exitPane: exitPaneKeepOnError, session: "foobar", messages: []string{"m1"}},
+			target: "%4",
+			want:   []string{"-t", "1m0s", "-d", "0s", "--interrupt-window", "2s", "--interrupt-action", "pause", "--exit-pane", "keep-on-error", "--target-pane", "%4", "foobar", "m1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildChildArgs(tt.opts, tt.target)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("buildChildArgs(...) = %#v; want %#v", got, tt.want)
+			}
+			for _, arg := range got {
+				if arg == "-i" || arg == "--inject" || strings.HasPrefix(arg, "--inject=") {
+					t.Fatalf("buildChildArgs included inject flag unexpectedly: %#v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateIdleMode(t *testing.T) {
+	for _, mode := range []string{idleModeCapture, idleModePipe, idleModePrompt} {
+		if err := validateIdleMode(mode); err != nil {
+			t.Fatalf("validateIdleMode(%q) = %v; want nil", mode, err)
+		}
+	}
+	if err := validateIdleMode("poll"); err == nil {
+		t.Fatalf("validateIdleMode(%q) = nil; want error", "poll")
+	}
+}
+
+func TestShellCommandForExecQuotesArguments(t *testing.T) {
+	got := shellCommandForExec("/tmp/typing-bird", []string{"-t", "30s", "foo bar", "a'b"})
+	want := "'/tmp/typing-bird' '-t' '30s' 'foo bar' 'a'\\''b'"
+	if got != want {
+		t.Fatalf("shellCommandForExec(...) = %q; want %q", got, want)
+	}
+}
+
+func TestTmuxNewSessionArgs(t *testing.T) {
+	got := tmuxNewSessionArgs("agent", "claude --resume")
+	want := []string{"new-session", "-d", "-s", "agent", "claude --resume"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tmuxNewSessionArgs(...) = %#v; want %#v", got, want)
+	}
+}
+
+func TestTmuxSplitInjectPaneArgsLayoutAndHeight(t *testing.T) {
+	got := tmuxSplitInjectPaneArgs("%3", "'/bin/typing-bird' '-t' '30s' 'foo'", injectLayout{size: defaultInjectSize})
+	want := []string{
+		"split-window",
+		"-v",
+		"-d",
+		"-l",
+		"5",
+		"-P",
+		"-F",
+		"#{pane_id}",
+		"-t",
+		"%3",
+		"'/bin/typing-bird' '-t' '30s' 'foo'",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tmuxSplitInjectPaneArgs(...) = %#v; want %#v", got, want)
+	}
+	got = tmuxSplitInjectPaneArgs("%3", "cmd", injectLayout{size: "30%", horizontal: true})
+	if got[1] != "-h" || got[4] != "30%" {
+		t.Fatalf("tmuxSplitInjectPaneArgs(horizontal 30%%) = %#v; want -h -l 30%%", got)
+	}
+}
+
+func TestTmuxInjectWindowArgs(t *testing.T) {
+	got := tmuxInjectWindowArgs("agent", "cmd", false)
+	want := []string{"new-window", "-d", "-n", "typing-bird", "-P", "-F", "#{pane_id}", "-t", "agent:", "cmd"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tmuxInjectWindowArgs(new) = %#v; want %#v", got, want)
+	}
+	got = tmuxInjectWindowArgs("agent", "cmd", true)
+	want = []string{"split-window", "-d", "-P", "-F", "#{pane_id}", "-t", "agent:typing-bird", "cmd"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tmuxInjectWindowArgs(reuse) = %#v; want %#v", got, want)
+	}
+}
+
+func TestParseInjectLayout(t *testing.T) {
+	tests := []struct {
+		size, direction string
+		want            injectLayout
+		wantErr         bool
+	}{
+		{"5", injectVertical, injectLayout{size: "5"}, false},
+		{" 20% ", injectHorizontal, injectLayout{size: "20%", horizontal: true}, false},
+		{"0", injectVertical, injectLayout{}, true},
+		{"100%", injectVertical, injectLayout{}, true},
+		{"big", injectVertical, injectLayout{}, true},
+		{"8", "diagonal", injectLayout{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseInjectLayout(tt.size, tt.direction, false)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Fatalf("parseInjectLayout(%q, %q) = %+v, %v; want %+v (error %t)", tt.size, tt.direction, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseBirdPaneIDs(t *testing.T) {
+	raw := strings.Join([]string{
+		"%1\t1\tbash",
+		"%2\t\ttyping-bird",
+		"%3\t\tvim",
+		"%4\t\t" + "typing-bird",
+		"",
+	}, "\n")
+	got := parseBirdPaneIDs(raw, "typing-bird")
+	want := []string{"%1", "%2", "%4"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseBirdPaneIDs(...) = %#v; want %#v", got, want)
+	}
+}
+
+func TestPickPreferredSendPane(t *testing.T) {
+	raw := strings.Join([]string{
+		"%9\t0\t1",
+		"%2\t1\t",
+		"%3\t0\t",
+		"",
+	}, "\n")
+	got := pickPreferredSendPane(raw)
+	if got != "%2" {
+		t.Fatalf("pickPreferredSendPane(...) = %q; want %q", got, "%2")
+	}
+}
+
+func TestPickPreferredSendPaneFallsBackToFirstNonInjected(t *testing.T) {
+	raw := strings.Join([]string{
+		"%9\t1\t1",
+		"%3\t0\t",
+		"%2\t0\t",
+		"",
+	}, "\n")
+	got := pickPreferredSendPane(raw)
+	if got != "%3" {
+		t.Fatalf("pickPreferredSendPane(...) = %q; want %q", got, "%3")
+	}
+}
+
+func TestFormatIdleDifferences(t *testing.T) {
+	got := formatIdleDifferences([]int{0, 2, 0, 4}, []int{0, 1, 0, 3})
+	want := "differences relative to sample 1: sample 2: base=2 prev=1, sample 4: base=4 prev=3"
+	if got != want {
+		t.Fatalf("formatIdleDifferences(...) = %q; want %q", got, want)
+	}
+}
+
+func TestSamplesSettled(t *testing.T) {
+	var caps []paneSample
+	for _, text := range []string{"a", "b", "c", "c", "c"} {
+		caps = append(caps, newPaneSample([]byte(text), false))
+	}
+	tests := []struct {
+		name     string
+		sampling idleSampling
+		want     bool
+	}{
+		{name: "all-equal rejects early churn", sampling: idleSampling{samples: 5, strategy: idleStrategyAllEqual}, want: false},
+		{name: "last 3 equal accepts", sampling: idleSampling{samples: 5, strategy: idleStrategyLastKEqual, k: 3}, want: true},
+		{name: "last 4 equal rejects", sampling: idleSampling{samples: 5, strategy: idleStrategyLastKEqual, k: 4}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := samplesSettled(caps, tt.sampling); got != tt.want {
+				t.Fatalf("samplesSettled(...) = %t; want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateIdleSampling(t *testing.T) {
+	valid := []idleSampling{
+		{samples: 5, strategy: idleStrategyAllEqual},
+		{samples: 2, strategy: idleStrategyConsecutive},
+		{samples: 6, strategy: idleStrategyLastKEqual, k: 6},
+	}
+	for _, sampling := range valid {
+		if err := validateIdleSampling(sampling); err != nil {
+			t.Fatalf("validateIdleSampling(%+v) = %v; want nil", sampling, err)
+		}
+	}
+	invalid := []idleSampling{
+		{samples: 1, strategy: idleStrategyAllEqual},
+		{samples: 5, strategy: "most-equal"},
+		{samples: 5, strategy: idleStrategyLastKEqual, k: 6},
+		{samples: 5, strategy: idleStrategyLastKEqual, k: 1},
+	}
+	for _, sampling := range invalid {
+		if err := validateIdleSampling(sampling); err == nil {
+			t.Fatalf("validateIdleSampling(%+v) = nil; want error", sampling)
+		}
+	}
+}
+
+func TestNextAdaptiveInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		prev     time.Duration
+		quiet    time.Duration
+		duration time.Duration
+		want     time.Duration
+	}{
+		{name: "starts at minimum", prev: 0, quiet: 0, duration: time.Minute, want: time.Second},
+		{name: "doubles while quiet", prev: 4 * time.Second, quiet: 7 * time.Second, duration: time.Minute, want: 8 * time.Second},
+		{name: "clamps to remaining window", prev: 16 * time.Second, quiet: 40 * time.Second, duration: time.Minute, want: 20 * time.Second},
+		{name: "window elapsed", prev: 16 * time.Second, quiet: time.Minute, duration: time.Minute, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := nextAdaptiveInterval(tt.prev, time.Second, tt.quiet, tt.duration)
+			if got != tt.want {
+				t.Fatalf("nextAdaptiveInterval(...) = %s; want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdaptiveMinInterval(t *testing.T) {
+	if got := adaptiveMinInterval(0, time.Minute); got != 3*time.Second {
+		t.Fatalf("adaptiveMinInterval(0, 1m) = %s; want 3s", got)
+	}
+	if got := adaptiveMinInterval(0, time.Second); got != minAdaptiveInterval {
+		t.Fatalf("adaptiveMinInterval(0, 1s) = %s; want %s", got, minAdaptiveInterval)
+	}
+	if got := adaptiveMinInterval(100*time.Millisecond, time.Minute); got != 100*time.Millisecond {
+		t.Fatalf("adaptiveMinInterval(100ms, 1m) = %s; want 100ms", got)
+	}
+}
+
+func TestSendKeyArgs(t *testing.T) {
+	tests := []struct {
+		keys []string
+		want []string
+	}{
+		{keys: []string{"Enter"}, want: []string{"send-keys", "-t", "%1", "Enter"}},
+		{keys: []string{"Escape", "C-c", "Enter"}, want: []string{"send-keys", "-t", "%1", "Escape", "C-c", "Enter"}},
+		// Keys reach tmux as argv elements, so shell metacharacters are inert.
+		{keys: []string{"'; touch x #"}, want: []string{"send-keys", "-t", "%1", "'; touch x #"}},
+	}
+	for _, tt := range tests {
+		if got := sendKeyArgs("%1", tt.keys...); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("sendKeyArgs(%%1, %q) = %q; want %q", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestOptionsRestartGrace(t *testing.T) {
+	cli := newCLIFlags()
+	opts, err := cli.options([]string{"s", "m"}, resolvedConfig{}, "")
+	if err != nil || opts.restartGrace != defaultRestartGrace {
+		t.Fatalf("options() restartGrace = %s, %v; want %s", opts.restartGrace, err, defaultRestartGrace)
+	}
+	cli.restartGrace = "-1s"
+	if _, err := cli.options([]string{"s", "m"}, resolvedConfig{}, ""); err == nil {
+		t.Fatalf("options() with --restart-grace -1s = nil error; want error")
+	}
+}
+
+func TestOptionsRespawnRequiresInject(t *testing.T) {
+	cli := newCLIFlags()
+	cli.respawn = true
+	if _, err := cli.options([]string{"s", "m"}, resolvedConfig{}, ""); err == nil {
+		t.Fatalf("options() with --respawn and no --inject = nil error; want error")
+	}
+	cli.inject = true
+	if opts, err := cli.options([]string{"s", "m"}, resolvedConfig{}, ""); err != nil || !opts.respawn {
+		t.Fatalf("options() with --inject --respawn = respawn %v, %v; want true, nil", opts.respawn, err)
+	}
+}
@@ -0,0 +1,265 @@
+package typingbird
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// menuLabelWidth is how much of a queued message its menu item shows.
+const menuLabelWidth = 40
+
+// menuItem is one display-menu entry; the zero value is a separator.
+type menuItem struct {
+	name    string
+	key     string
+	command string
+}
+
+// runMenu implements `typing-bird menu [session|control-socket]`: a tmux
+// display-menu for the session's bird listing its queue with actions, or
+// with --popup the REPL in a popup.
+func runMenu(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("menu", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	timeout := fs.Duration("timeout", 2*time.Second, "how long to wait for the bird to answer")
+	socketDir := fs.String("socket-dir", defaultSocketDir(), "directory of bird control sockets searched for the session")
+	popup := fs.Bool("popup", false, "open typing-bird repl in a popup instead of a menu")
+	item := fs.Int("item", 0, "show the actions for queued message N")
+	printOnly := fs.Bool("print", false, "print the tmux command instead of running it")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird menu [--popup] [--print] [session|control-socket]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Opens a tmux menu for the bird sending to the session (the current one by")
+		fmt.Fprintln(stderr, "default) listing its queued messages, with actions to send them now, move or")
+		fmt.Fprintln(stderr, "delete them, skip the next message and pause or resume the bird, e.g.")
+		fmt.Fprintln(stderr, "bind-key T run-shell -b 'typing-bird menu #{q:session_name}'.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 1 || *item < 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := lookTmux(); err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	target := fs.Arg(0)
+	if target == "" {
+		out, err := tmuxOutput("display-message", "-p", "#{session_name}")
+		if err != nil {
+			fmt.Fprintf(stderr, "ERROR: finding the current session (pass one): %v\n", err)
+			return 1
+		}
+		target = strings.TrimSpace(string(out))
+	}
+	c, err := dialREPL(*socketDir, target, *timeout)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	exe, err := birdExecutable()
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: locating executable: %v\n", err)
+		return 1
+	}
+	m := birdMenu{exe: exe, target: target, socketDir: *socketDir, c: c, noPopups: !tmuxPopups.supportedBy(runningTmux())}
+	var command []string
+	switch {
+	case *popup:
+		command = m.popupCommand()
+	case *item > 0:
+		queued, err := menuQueue(c)
+		if err != nil {
+			fmt.Fprintf(stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		if *item > len(queued) {
+			fmt.Fprintf(stderr, "ERROR: no queued message %d (%d queued)\n", *item, len(queued))
+			return 1
+		}
+		command = m.itemCommand(*item, queued[*item-1])
+	default:
+		reply, err := controlCall(c.socket, c.line("status", nil), c.timeout)
+		if err != nil {
+			fmt.Fprintf(stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		var st birdStatus
+		if !reply.OK || json.Unmarshal(reply.Data, &st) != nil {
+			fmt.Fprintf(stderr, "ERROR: reading the bird's status: %s\n", reply.Error)
+			return 1
+		}
+		queued, err := menuQueue(c)
+		if err != nil {
+			fmt.Fprintf(stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		command = m.command(st, queued)
+	}
+	if *printOnly {
+		fmt.Fprintln(stdout, controlCommandLine(command))
+		return 0
+	}
+	feature, what := tmuxMenus, "menu"
+	if *popup {
+		feature, what = tmuxPopups, "menu --popup"
+	}
+	if err := feature.require(what); err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if err := tmuxRun(command...); err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// menuQueue fetches the bird's inbox.
+func menuQueue(c *replClient) ([]string, error) {
+	reply, err := controlCall(c.socket, c.line("queue", nil), c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	if !reply.OK {
+		return nil, fmt.Errorf("%s", reply.Error)
+	}
+	var queued []string
+	if err := json.Unmarshal(reply.Data, &queued); err != nil {
+		return nil, fmt.Errorf("decoding queue: %w", err)
+	}
+	return queued, nil
+}
+
+// birdMenu builds the menus for the bird behind c, found from target.
+type birdMenu struct {
+	exe       string
+	target    string
+	socketDir string
+	c         *replClient
+	// noPopups leaves out the popup item on tmux before 3.2.
+	noPopups bool
+}
+
+// command is the display-menu for the bird: its actions, then one item per
+// queued message opening that message's actions.
+func (m birdMenu) command(st birdStatus, queued []string) []string {
+	pause := menuItem{"Pause", "p", m.ctl("pause")}
+	if st.Paused {
+		pause = menuItem{"Resume", "p", m.ctl("resume")}
+	}
+	items := []menuItem{
+		{"Send next message now", "n", m.ctl("send")},
+		{"Skip next message", "s", m.ctl("skip")},
+		pause,
+	}
+	if !m.noPopups {
+		items = append(items, menuItem{"Edit queue in a popup", "e", formatEscape(controlCommandLine(m.popupCommand()))})
+	}
+	items = append(items, menuItem{})
+	if len(queued) == 0 {
+		items = append(items, menuItem{name: "-(queue empty)"})
+	}
+	for i, message := range queued {
+		key := ""
+		if i < 9 {
+			key = strconv.Itoa(i + 1)
+		}
+		name := fmt.Sprintf("%d. %s", i+1, menuLabel(message))
+		items = append(items, menuItem{name, key, m.self("--item", strconv.Itoa(i+1))})
+	}
+	return displayMenu(fmt.Sprintf("typing-bird: %s (%d queued)", m.target, len(queued)), items)
+}
+
+// itemCommand is the display-menu of actions for queued message n.
+func (m birdMenu) itemCommand(n int, message string) []string {
+	pos := strconv.Itoa(n)
+	items := []menuItem{
+		{"Send now", "n", m.ctl("send-queued", pos)},
+		{"Move to top", "t", m.ctl("move", pos, "1")},
+		{"Delete", "d", m.ctl("unqueue", pos)},
+		{},
+		{"Back", "b", m.self()},
+	}
+	return displayMenu(fmt.Sprintf("%d. %s", n, menuLabel(message)), items)
+}
+
+// popupCommand opens the REPL for the bird in a popup.
+func (m birdMenu) popupCommand() []string {
+	shell := m.shell("repl")
+	return []string{"display-popup", "-E", "-w", "80%", "-h", "60%", "-T", formatEscape("typing-bird: " + m.target), formatEscape(shell)}
+}
+
+// shell is the shell command running a typing-bird subcommand for the
+// same bird.
+func (m birdMenu) shell(subcommand string, args ...string) string {
+	fields := []string{shellQuoteSingle(m.exe), subcommand, "--socket-dir", shellQuoteSingle(m.socketDir)}
+	for _, arg := range args {
+		fields = append(fields, shellQuoteSingle(arg))
+	}
+	return strings.Join(append(fields, shellQuoteSingle(m.target)), " ")
+}
+
+// ctl is the menu command running a control command for the bird. Its
+// reply is dropped: run-shell shows output by putting the pane in view
+// mode, where it would swallow the bird's keys. Errors still show.
+func (m birdMenu) ctl(command string, args ...string) string {
+	fields := []string{shellQuoteSingle(m.exe), "ctl", shellQuoteSingle(m.c.socket), command}
+	if m.c.name != "" {
+		fields = append(fields, shellQuoteSingle(m.c.name))
+	}
+	return menuShell(strings.Join(append(append(fields, args...), ">/dev/null"), " "))
+}
+
+// self is the menu command reopening a menu for the bird.
+func (m birdMenu) self(args ...string) string {
+	return menuShell(m.shell("menu", args...))
+}
+
+func displayMenu(title string, items []menuItem) []string {
+	args := []string{"display-menu", "-T", formatEscape(title)}
+	for _, item := range items {
+		if item.name == "" {
+			args = append(args, "")
+			continue
+		}
+		args = append(args, formatEscape(item.name), item.key, item.command)
+	}
+	return args
+}
+
+// menuShell wraps a shell command as a menu item's tmux command. Both the
+// menu and run-shell expand formats, so # is doubled for each.
+func menuShell(shell string) string {
+	return formatEscape(controlCommandLine([]string{"run-shell", "-b", formatEscape(shell)}))
+}
+
+func formatEscape(s string) string {
+	return strings.ReplaceAll(s, "#", "##")
+}
+
+// menuLabel shortens a queued message to one menu line, showing its
+// #priority: as a prefix.
+func menuLabel(message string) string {
+	priority, text := parsePriority(message)
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > menuLabelWidth {
+		text = string(r[:menuLabelWidth-1]) + "…"
+	}
+	if priority != 0 {
+		text = fmt.Sprintf("[%d] %s", priority, text)
+	}
+	return text
+}
@@ -0,0 +1,62 @@
+package typingbird
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMenuLabel(t *testing.T) {
+	long := strings.Repeat("x", menuLabelWidth+5)
+	cases := []struct {
+		message string
+		want    string
+	}{
+		{"run the tests", "run the tests"},
+		{"  run\tthe\n tests ", "run the tests"},
+		{"#priority:5 deploy", "[5] deploy"},
+		{"echo #1", "echo #1"},
+		{long, strings.Repeat("x", menuLabelWidth-1) + "…"},
+	}
+	for _, tc := range cases {
+		if got := menuLabel(tc.message); got != tc.want {
+			t.Fatalf("menuLabel(%q) = %q; want %q", tc.message, got, tc.want)
+		}
+	}
+}
+
+func TestBirdMenuCommand(t *testing.T) {
+	m := birdMenu{exe: "/bin/tb", target: "agent", socketDir: "/tmp/s", c: &replClient{socket: "/tmp/s/a.sock"}}
+
+	empty := strings.Join(m.command(birdStatus{}, nil), "\n")
+	for _, want := range []string{"typing-bird: agent (0 queued)", "-(queue empty)", "Pause", "ctl '/tmp/s/a.sock' skip >/dev/null"} {
+		if !strings.Contains(empty, want) {
+			t.Fatalf("command(empty) = %q; want it to contain %q", empty, want)
+		}
+	}
+	if paused := strings.Join(m.command(birdStatus{Paused: true}, nil), "\n"); !strings.Contains(paused, "Resume") {
+		t.Fatalf("command(paused) = %q; want a Resume item", paused)
+	}
+
+	queued := m.command(birdStatus{}, []string{"one", "two"})
+	got := strings.Join(queued, "\n")
+	for _, want := range []string{"1. one\n1\n", "2. two\n2\n", "menu --socket-dir '/tmp/s' '--item' '2' 'agent'"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("command(queued) = %q; want it to contain %q", got, want)
+		}
+	}
+
+	m.noPopups = true
+	if got := strings.Join(m.command(birdStatus{}, nil), "\n"); strings.Contains(got, "display-popup") {
+		t.Fatalf("command() without popups = %q; want no popup item", got)
+	}
+}
+
+func TestBirdMenuItemCommand(t *testing.T) {
+	m := birdMenu{exe: "/bin/tb", target: "agent", socketDir: "/tmp/s", c: &replClient{socket: "/tmp/s/fleet.sock", name: "b1"}}
+	got := strings.Join(m.itemCommand(3, "echo #x"), "\n")
+	for _, want := range []string{"-T\n3. echo ##x\n", "send-queued 'b1' 3 >/dev/null", "move 'b1' 3 1", "unqueue 'b1' 3"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("itemCommand(3) = %q; want it to contain %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package typingbird
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// loadMessagesFile reads one message per line, skipping blank lines and
+// lines starting with '#', and returns them with their directives. With a
+// delimiter, messages are the blocks between delimiter lines instead (see
+// parseMessages). Encrypted files are decrypted in memory only.
+func loadMessagesFile(path, delimiter string) ([]string, messageDirectives, error) {
+	var raw []byte
+	var err error
+	if encryptedFile(path) {
+		raw, err = decryptFile(path)
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, messageDirectives{}, fmt.Errorf("reading messages file: %w", err)
+	}
+	messages, directives := parseMessages(string(raw), delimiter)
+	if len(messages) == 0 {
+		return nil, messageDirectives{}, fmt.Errorf("messages file %q contains no messages", path)
+	}
+	return messages, directives, nil
+}
+
+// Directives are comment lines that apply to the message after them:
+// #expect: gives its --expect-after pattern and #delay: its --delay.
+const (
+	expectDirective = "#expect:"
+	delayDirective  = "#delay:"
+)
+
+// messageDirectives holds the directive values given for each message,
+// aligned with the messages ("" where there is none).
+type messageDirectives struct {
+	expects []string
+	delays  []string
+}
+
+// parseMessages returns the messages in raw and the directives before each.
+// Without a delimiter every line is a message. With one, a message is the
+// block of lines up to the next line reading just the delimiter, blank lines
+// and all; comments and directives only count at the top of a block, so a
+// message can contain lines starting with '#'.
+func parseMessages(raw, delimiter string) ([]string, messageDirectives) {
+	if delimiter != "" {
+		return parseDelimitedMessages(raw, delimiter)
+	}
+	lines := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	messages := make([]string, 0, len(lines))
+	var directives messageDirectives
+	expect, delay := "", ""
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, expectDirective):
+			expect = strings.TrimSpace(strings.TrimPrefix(trimmed, expectDirective))
+			continue
+		case strings.HasPrefix(trimmed, delayDirective):
+			delay = strings.TrimSpace(strings.TrimPrefix(trimmed, delayDirective))
+			continue
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			continue
+		}
+		messages = append(messages, line)
+		directives.expects = append(directives.expects, expect)
+		directives.delays = append(directives.delays, delay)
+		expect, delay = "", ""
+	}
+	return messages, directives
+}
+
+func parseDelimitedMessages(raw, delimiter string) ([]string, messageDirectives) {
+	var messages []string
+	var directives messageDirectives
+	for _, block := range splitDelimited(raw, delimiter) {
+		lines := strings.Split(block, "\n")
+		expect, delay := "", ""
+		for len(lines) > 0 {
+			trimmed := strings.TrimSpace(lines[0])
+			switch {
+			case strings.HasPrefix(trimmed, expectDirective):
+				expect = strings.TrimSpace(strings.TrimPrefix(trimmed, expectDirective))
+			case strings.HasPrefix(trimmed, delayDirective):
+				delay = strings.TrimSpace(strings.TrimPrefix(trimmed, delayDirective))
+			case trimmed != "" && !strings.HasPrefix(trimmed, "#"):
+				messages = append(messages, strings.Join(lines, "\n"))
+				directives.expects = append(directives.expects, expect)
+				directives.delays = append(directives.delays, delay)
+				lines = nil
+				continue
+			}
+			lines = lines[1:]
+		}
+	}
+	return messages, directives
+}
+
+// splitDelimited splits raw at lines reading just delimiter and returns the
+// blocks in between without their leading and trailing blank lines,
+// leaving out blocks with nothing in them.
+func splitDelimited(raw, delimiter string) []string {
+	var blocks []string
+	var block []string
+	flush := func() {
+		for len(block) > 0 && strings.TrimSpace(block[0]) == "" {
+			block = block[1:]
+		}
+		for len(block) > 0 && strings.TrimSpace(block[len(block)-1]) == "" {
+			block = block[:len(block)-1]
+		}
+		if len(block) > 0 {
+			blocks = append(blocks, strings.Join(block, "\n"))
+		}
+		block = nil
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == delimiter {
+			flush()
+			continue
+		}
+		block = append(block, line)
+	}
+	flush()
+	return blocks
+}
+
+// delayFor returns the key delay for message index; a requested send uses
+// the global --delay.
+func (o options) delayFor(index int, requested bool) time.Duration {
+	if d, ok := o.delays[index]; ok && !requested {
+		return d
+	}
+	return o.delay
+}
+
+// parseDelays parses per-message delay overrides into a map by message
+// index; blank entries keep the global --delay.
+func parseDelays(raw []string) (map[int]time.Duration, error) {
+	var delays map[int]time.Duration
+	for i, r := range raw {
+		if r == "" {
+			continue
+		}
+		d, err := parseDuration(r, fmt.Sprintf("delay for message %d", i+1), false)
+		if err != nil {
+			return nil, err
+		}
+		if delays == nil {
+			delays = map[int]time.Duration{}
+		}
+		delays[i] = d
+	}
+	return delays, nil
+}
+
+// decodeBase64Messages decodes messages given with --base64. Standard and
+// URL-safe alphabets are accepted, with or without padding, and whitespace
+// is ignored so the wrapped output of base64(1) can be pasted as is.
+func decodeBase64Messages(encoded []string) ([]string, error) {
+	messages := make([]string, 0, len(encoded))
+	for i, raw := range encoded {
+		raw = strings.Join(strings.Fields(raw), "")
+		var decoded []byte
+		var err error
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			if decoded, err = enc.DecodeString(raw); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("message %d: invalid base64: %w", i+1, err)
+		}
+		if !utf8.Valid(decoded) {
+			return nil, fmt.Errorf("message %d: base64 does not decode to UTF-8 text", i+1)
+		}
+		messages = append(messages, string(decoded))
+	}
+	return messages, nil
+}
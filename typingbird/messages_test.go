@@ -0,0 +1,132 @@
+package typingbird
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMessagesSkipsBlankAndCommentLines(t *testing.T) {
+	raw := "# keep-alive phrases\r\ncontinue\n\n  # indented comment\nkeep going  \n"
+	got, _ := parseMessages(raw, "")
+	want := []string{"continue", "keep going  "}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseMessages(...) = %#v; want %#v", got, want)
+	}
+}
+
+func TestParseMessagesExpectDirectives(t *testing.T) {
+	raw := "#expect: (PASS|FAIL)\nrun the tests\ncontinue\n  #expect:  \\$ $\n# plain comment\ncommit it\n"
+	messages, directives := parseMessages(raw, "")
+	if want := []string{"run the tests", "continue", "commit it"}; !reflect.DeepEqual(messages, want) {
+		t.Fatalf("parseMessages(...) messages = %#v; want %#v", messages, want)
+	}
+	if want := []string{"(PASS|FAIL)", "", "\\$ $"}; !reflect.DeepEqual(directives.expects, want) {
+		t.Fatalf("parseMessages(...) expects = %#v; want %#v", directives.expects, want)
+	}
+}
+
+func TestParseMessagesDelayDirectives(t *testing.T) {
+	raw := "#delay: 200ms\n#expect: Password:\nhunter2\nls\n#delay: 0s\nmake\n"
+	messages, directives := parseMessages(raw, "")
+	if want := []string{"hunter2", "ls", "make"}; !reflect.DeepEqual(messages, want) {
+		t.Fatalf("parseMessages(...) messages = %#v; want %#v", messages, want)
+	}
+	if want := []string{"200ms", "", "0s"}; !reflect.DeepEqual(directives.delays, want) {
+		t.Fatalf("parseMessages(...) delays = %#v; want %#v", directives.delays, want)
+	}
+	delays, err := parseDelays(directives.delays)
+	if err != nil {
+		t.Fatalf("parseDelays(...) error: %v", err)
+	}
+	opts := options{delay: 15 * time.Millisecond, delays: delays}
+	tests := []struct {
+		index     int
+		requested bool
+		want      time.Duration
+	}{
+		{0, false, 200 * time.Millisecond},
+		{1, false, 15 * time.Millisecond},
+		{2, false, 0},
+		{0, true, 15 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := opts.delayFor(tt.index, tt.requested); got != tt.want {
+			t.Fatalf("delayFor(%d, %t) = %s; want %s", tt.index, tt.requested, got, tt.want)
+		}
+	}
+	if _, err := parseDelays([]string{"", "soon"}); err == nil {
+		t.Fatalf("parseDelays(invalid) error = nil; want error")
+	}
+}
+
+func TestEncryptedFile(t *testing.T) {
+	tests := map[string]bool{
+		"msgs.txt":        false,
+		"secrets.tb.age":  true,
+		"secrets.tb.gpg":  true,
+		"secrets.tb.ASC":  true,
+		"age":             false,
+		"/tmp/gpg/msgs":   false,
+		"prompts.pgp.txt": false,
+	}
+	for path, want := range tests {
+		if got := encryptedFile(path); got != want {
+			t.Fatalf("encryptedFile(%q) = %v; want %v", path, got, want)
+		}
+	}
+}
+
+func TestDecryptFileUppercaseAge(t *testing.T) {
+	t.Setenv("TYPING_BIRD_AGE_IDENTITY", "")
+	// Only age needs the identity, so the error shows which tool was picked.
+	if _, err := decryptFile("secrets.AGE"); err == nil || !strings.Contains(err.Error(), "TYPING_BIRD_AGE_IDENTITY") {
+		t.Fatalf("decryptFile(secrets.AGE) error = %v; want it decrypted with age", err)
+	}
+}
+
+func TestParseMessagesDelimited(t *testing.T) {
+	raw := "# prompts\n#delay: 50ms\nReview this:\n\n# Heading kept\n  indented\n---\n\n\n---\n#expect: done\n\nsecond\n\n ---  \nthird\n"
+	messages, directives := parseMessages(raw, "---")
+	if want := []string{"Review this:\n\n# Heading kept\n  indented", "second", "third"}; !reflect.DeepEqual(messages, want) {
+		t.Fatalf("parseMessages(..., \"---\") messages = %#v; want %#v", messages, want)
+	}
+	if want := []string{"", "done", ""}; !reflect.DeepEqual(directives.expects, want) {
+		t.Fatalf("parseMessages(..., \"---\") expects = %#v; want %#v", directives.expects, want)
+	}
+	if want := []string{"50ms", "", ""}; !reflect.DeepEqual(directives.delays, want) {
+		t.Fatalf("parseMessages(..., \"---\") delays = %#v; want %#v", directives.delays, want)
+	}
+}
+
+func TestOptionsSplitsArgumentsAtDelimiter(t *testing.T) {
+	cli := newCLIFlags()
+	cli.delimiter = "%%"
+	opts, err := cli.options([]string{"s", "first line", "", "# not a comment", "%%", "second\n%%\nthird"}, resolvedConfig{}, "")
+	if err != nil {
+		t.Fatalf("options() error: %v", err)
+	}
+	if want := []string{"first line\n\n# not a comment", "second", "third"}; !reflect.DeepEqual(opts.messages, want) {
+		t.Fatalf("options() messages = %#v; want %#v", opts.messages, want)
+	}
+	cli.delimiter = "-- "
+	if _, err := cli.options([]string{"s", "m"}, resolvedConfig{}, ""); err == nil {
+		t.Fatalf("options() with delimiter %q = nil error; want one", cli.delimiter)
+	}
+}
+
+func TestDecodeBase64Messages(t *testing.T) {
+	got, err := decodeBase64Messages([]string{"aGVsbG8gJ3dvcmxkJwpuZXh0", "aGk", "Pz8-", "aGVs\nbG8=\n"})
+	if err != nil {
+		t.Fatalf("decodeBase64Messages(...) error: %v", err)
+	}
+	if want := []string{"hello 'world'\nnext", "hi", "??>", "hello"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("decodeBase64Messages(...) = %#v; want %#v", got, want)
+	}
+	for _, bad := range []string{"not base64!", "/w=="} {
+		if _, err := decodeBase64Messages([]string{bad}); err == nil {
+			t.Fatalf("decodeBase64Messages(%q) = nil error; want one", bad)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package typingbird
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMQTTCommand(t *testing.T) {
+	tests := []struct {
+		topic, payload string
+		name, command  string
+		args           []string
+		err            string
+	}{
+		{topic: "tb/cmd", payload: "pause", command: "pause"},
+		{topic: "tb/cmd", payload: " send \n", command: "send"},
+		{topic: "tb/cmd", payload: "send run  the tests", command: "send", args: []string{"run  the tests"}},
+		{topic: "tb/agent/cmd", payload: "resume", name: "agent", command: "resume"},
+		{topic: "tb/cmd", payload: "status", err: "unknown command"},
+		{topic: "tb/cmd", payload: "", err: "unknown command"},
+		{topic: "other/cmd", payload: "pause", err: "unexpected topic"},
+	}
+	for _, tt := range tests {
+		name, command, args, err := parseMQTTCommand("tb", tt.topic, tt.payload)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("parseMQTTCommand(%q, %q) error = %v; want %q", tt.topic, tt.payload, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || name != tt.name || command != tt.command || !reflect.DeepEqual(args, tt.args) {
+			t.Fatalf("parseMQTTCommand(%q, %q) = %q, %q, %q, %v; want %q, %q, %q", tt.topic, tt.payload, name, command, args, err, tt.name, tt.command, tt.args)
+		}
+	}
+}
+
+func TestParseMQTTBroker(t *testing.T) {
+	for _, broker := range []string{"tcp://localhost:1883", "ssl://u:p@broker:8883", "ws://h/mqtt"} {
+		if _, err := parseMQTTBroker(broker); err != nil {
+			t.Fatalf("parseMQTTBroker(%q) error: %v", broker, err)
+		}
+	}
+	for _, broker := range []string{"localhost:1883", "http://h", "tcp://"} {
+		if _, err := parseMQTTBroker(broker); err == nil {
+			t.Fatalf("parseMQTTBroker(%q) = nil error; want error", broker)
+		}
+	}
+}
+
+func TestMQTTEventTopic(t *testing.T) {
+	if got := mqttEventTopic("tb", false, birdEvent{}); got != "tb/events" {
+		t.Fatalf("mqttEventTopic(standalone) = %q; want %q", got, "tb/events")
+	}
+	if got := mqttEventTopic("tb", true, birdEvent{Bird: "agent"}); got != "tb/agent/events" {
+		t.Fatalf("mqttEventTopic(fleet) = %q; want %q", got, "tb/agent/events")
+	}
+}
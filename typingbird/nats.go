@@ -0,0 +1,169 @@
+package typingbird
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// defaultNATSSubject prefixes every subject typing-bird listens on.
+	defaultNATSSubject = "typing-bird"
+	// natsSendTimeout bounds how long a request on the msg subject waits
+	// for the send to happen before replying.
+	natsSendTimeout = time.Minute
+)
+
+// natsBridge connects a flock to NATS. A request on <subject>.msg (or
+// <subject>.<bird>.msg in a fleet) sends its payload now, an empty one
+// meaning the next message in rotation, and the reply carries the result
+// once the send is done. <subject>.cmd takes a control command line, as on
+// the control socket, and replies with its response.
+type natsBridge struct {
+	conn    *nats.Conn
+	subject string
+}
+
+func parseNATSURL(raw string) error {
+	for _, server := range strings.Split(raw, ",") {
+		u, err := url.Parse(strings.TrimSpace(server))
+		if err != nil {
+			return fmt.Errorf("invalid nats-url %q: %v", raw, err)
+		}
+		switch u.Scheme {
+		case "nats", "tls", "ws", "wss":
+		default:
+			return fmt.Errorf("invalid nats-url %q: scheme must be nats, tls, ws or wss", u.Redacted())
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid nats-url %q: no host", u.Redacted())
+		}
+	}
+	return nil
+}
+
+// validNATSSubject reports whether subject can prefix the bird's subjects:
+// dot-separated tokens without wildcards or whitespace.
+func validNATSSubject(subject string) bool {
+	if subject == "" || strings.ContainsAny(subject, "*> \t\r\n") {
+		return false
+	}
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func startNATSBridge(servers, subject string, f *flock) (*natsBridge, error) {
+	conn, err := nats.Connect(servers,
+		nats.Name("typing-bird"),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				logf("WARNING: NATS disconnected: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			logf("NATS reconnected to %s", c.ConnectedUrlRedacted())
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to NATS: %w", err)
+	}
+	n := &natsBridge{conn: conn, subject: subject}
+	for _, s := range []string{subject + ".msg", subject + ".cmd", subject + ".*.msg", subject + ".*.cmd"} {
+		if _, err := conn.Subscribe(s, func(msg *nats.Msg) { n.handle(f, msg) }); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("subscribing to %s: %w", s, err)
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("subscribing on NATS: %w", err)
+	}
+	logf("NATS connected to %s, subject %s", conn.ConnectedUrlRedacted(), subject)
+	return n, nil
+}
+
+// parseNATSSubject splits a request subject into the bird it names, if
+// any, and its kind, msg or cmd.
+func parseNATSSubject(prefix, subject string) (name, kind string, ok bool) {
+	rest, ok := strings.CutPrefix(subject, prefix+".")
+	if !ok {
+		return "", "", false
+	}
+	name, kind, found := strings.Cut(rest, ".")
+	if !found {
+		name, kind = "", rest
+	}
+	return name, kind, kind == "msg" || kind == "cmd"
+}
+
+func (n *natsBridge) handle(f *flock, msg *nats.Msg) {
+	name, kind, ok := parseNATSSubject(n.subject, msg.Subject)
+	if !ok {
+		return
+	}
+	if kind == "msg" {
+		// Sends wait for the bird, so they must not hold up the
+		// subscription.
+		go func() { n.reply(msg, n.send(f, name, string(msg.Data))) }()
+		return
+	}
+	fields := strings.Fields(string(msg.Data))
+	if len(fields) == 0 {
+		n.reply(msg, controlError(fmt.Errorf("empty command")))
+		return
+	}
+	args := fields[1:]
+	if f.fleet() {
+		args = append([]string{name}, args...)
+	} else if _, err := f.lookup(name); err != nil {
+		n.reply(msg, controlError(err))
+		return
+	}
+	n.reply(msg, f.controlCommand(fields[0], args))
+}
+
+func (n *natsBridge) reply(msg *nats.Msg, resp controlResponse) {
+	if !resp.OK {
+		logf("WARNING: NATS %s: %s", msg.Subject, resp.Error)
+	}
+	if msg.Reply == "" {
+		return
+	}
+	payload, err := json.Marshal(resp)
+	if err == nil {
+		err = msg.Respond(payload)
+	}
+	if err != nil {
+		debugf("replying on %s: %v", msg.Reply, err)
+	}
+}
+
+// send asks the bird for a send and waits for its outcome.
+func (n *natsBridge) send(f *flock, name, message string) controlResponse {
+	b, err := f.lookup(name)
+	if err != nil {
+		return controlError(err)
+	}
+	select {
+	case err := <-b.sendNowResult(message):
+		if err != nil {
+			return controlError(err)
+		}
+		return controlOK(nil)
+	case <-time.After(natsSendTimeout):
+		return controlError(fmt.Errorf("not sent within %s", natsSendTimeout))
+	}
+}
+
+func (n *natsBridge) Close() error {
+	return n.conn.Drain()
+}
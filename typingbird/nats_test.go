@@ -0,0 +1,36 @@
+package typingbird
+
+import "testing"
+
+func TestParseNATSSubject(t *testing.T) {
+	tests := []struct {
+		subject    string
+		name, kind string
+		ok         bool
+	}{
+		{subject: "tb.msg", kind: "msg", ok: true},
+		{subject: "tb.cmd", kind: "cmd", ok: true},
+		{subject: "tb.build.msg", name: "build", kind: "msg", ok: true},
+		{subject: "tb.build.status", name: "build", kind: "status"},
+		{subject: "other.msg"},
+	}
+	for _, tt := range tests {
+		name, kind, ok := parseNATSSubject("tb", tt.subject)
+		if name != tt.name || kind != tt.kind || ok != tt.ok {
+			t.Fatalf("parseNATSSubject(tb, %q) = %q, %q, %v; want %q, %q, %v", tt.subject, name, kind, ok, tt.name, tt.kind, tt.ok)
+		}
+	}
+}
+
+func TestNATSSettings(t *testing.T) {
+	for subject, want := range map[string]bool{"typing-bird": true, "home.agents": true, "": false, "a.*": false, "a.>": false, "a..b": false, "a b": false} {
+		if got := validNATSSubject(subject); got != want {
+			t.Fatalf("validNATSSubject(%q) = %v; want %v", subject, got, want)
+		}
+	}
+	for url, want := range map[string]bool{"nats://localhost:4222": true, "nats://a:4222, tls://b:4222": true, "localhost:4222": false, "http://h": false} {
+		if got := parseNATSURL(url) == nil; got != want {
+			t.Fatalf("parseNATSURL(%q) ok = %v; want %v", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package typingbird
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// birdOutcome is how the run ends once the pane output matched --exit-on
+// or --fail-on.
+type birdOutcome struct {
+	code   int
+	reason string
+}
+
+// outcomeWatch follows the pane output for --exit-on and --fail-on. Only
+// what was printed since the last send is matched, with the sent text's echo
+// dropped once so a message mentioning the pattern does not end the run.
+type outcomeWatch struct {
+	exitOn, failOn *regexp.Regexp
+	mark           *paneMark
+	echo           string
+}
+
+// match returns the outcome for output, or nil. --fail-on wins when both
+// patterns match.
+func (w *outcomeWatch) match(output string) *birdOutcome {
+	if w.echo != "" {
+		output = strings.Replace(output, w.echo, "", 1)
+	}
+	if w.failOn != nil && w.failOn.MatchString(output) {
+		return &birdOutcome{code: 1, reason: "output matched --fail-on " + outputPattern(w.failOn)}
+	}
+	if w.exitOn != nil && w.exitOn.MatchString(output) {
+		return &birdOutcome{code: 0, reason: "output matched --exit-on " + outputPattern(w.exitOn)}
+	}
+	return nil
+}
+
+// checkOutcome matches the target's output against --exit-on and --fail-on,
+// recording the outcome and interrupting the current wait on a match. It
+// reports whether the run should end.
+func (b *bird) checkOutcome() bool {
+	b.mu.Lock()
+	watch, target := b.outcomeWatch, b.target
+	done := b.outcome != nil
+	b.mu.Unlock()
+	if watch == nil || done {
+		return done
+	}
+	if watch.mark == nil {
+		mark, err := tmuxPaneMark(target)
+		if err != nil {
+			b.debugf("cannot mark output for --exit-on/--fail-on: %v", err)
+			return false
+		}
+		b.mu.Lock()
+		if watch.mark == nil {
+			watch.mark = &mark
+		}
+		b.mu.Unlock()
+		return false
+	}
+	output, err := tmuxOutputSince(target, *watch.mark)
+	if err != nil {
+		b.debugf("cannot read output for --exit-on/--fail-on: %v", err)
+		return false
+	}
+	outcome := watch.match(output)
+	if outcome == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.outcome = outcome
+	b.interruptWait()
+	return true
+}
+
+// markOutcome restarts the output matched by --exit-on and --fail-on just
+// before text is sent.
+func (b *bird) markOutcome(text string) {
+	b.mu.Lock()
+	watch, target := b.outcomeWatch, b.target
+	b.mu.Unlock()
+	if watch == nil {
+		return
+	}
+	mark, err := tmuxPaneMark(target)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.debugf("cannot mark output for --exit-on/--fail-on: %v", err)
+		watch.mark = nil
+		return
+	}
+	watch.mark, watch.echo = &mark, text
+}
+
+// watchOutcome polls the output every expectPoll until ctx ends or a
+// pattern matched.
+func (b *bird) watchOutcome(ctx context.Context) {
+	for !b.checkOutcome() {
+		if sleepWithContext(ctx, expectPoll) != nil {
+			return
+		}
+	}
+}
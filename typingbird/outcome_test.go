@@ -0,0 +1,32 @@
+package typingbird
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestOutcomeWatchMatch(t *testing.T) {
+	w := &outcomeWatch{
+		exitOn: regexp.MustCompile(`(?m)All tests passed`),
+		failOn: regexp.MustCompile(`(?m)^FAIL`),
+		echo:   "make test && echo All tests passed",
+	}
+	tests := []struct {
+		output string
+		want   int // -1 for no outcome
+	}{
+		{output: "$ make test && echo All tests passed\nok\n", want: -1},
+		{output: "$ make test && echo All tests passed\nAll tests passed\n", want: 0},
+		{output: "FAIL pkg/x\nAll tests passed\n", want: 1},
+		{output: "no FAIL here\n", want: -1},
+	}
+	for _, tt := range tests {
+		got := w.match(tt.output)
+		switch {
+		case tt.want < 0 && got != nil:
+			t.Fatalf("match(%q) = %+v; want nil", tt.output, got)
+		case tt.want >= 0 && (got == nil || got.code != tt.want):
+			t.Fatalf("match(%q) = %+v; want code %d", tt.output, got, tt.want)
+		}
+	}
+}
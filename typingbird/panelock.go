@@ -0,0 +1,170 @@
+package typingbird
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// paneLockOption is the pane option a bird claims its target pane with,
+// "<pid> <nonce>". Two birds typing into one pane interleave their
+// keystrokes, so a bird refuses a pane another live bird holds.
+const paneLockOption = "@typing_bird_lock"
+
+// paneLock is a bird's claim on a pane.
+type paneLock struct {
+	pane  string
+	value string
+}
+
+// parsePaneLock returns the pid in a paneLockOption value, or 0 for an
+// empty or malformed one.
+func parsePaneLock(value string) int {
+	pid, _, _ := strings.Cut(strings.TrimSpace(value), " ")
+	n, err := strconv.Atoi(pid)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return n
+}
+
+func readPaneLock(pane string) (string, error) {
+	out, err := tmuxOutput("display-message", "-p", "-t", pane, "#{"+paneLockOption+"}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// paneLockHolder returns the pid of the live bird holding pane, which may
+// be another bird of this process, or 0 when the pane is free. A claim
+// left by a process that is gone does not count.
+func paneLockHolder(pane string) (int, error) {
+	current, err := readPaneLock(pane)
+	if err != nil {
+		return 0, fmt.Errorf("reading the lock of pane %q: %w", pane, err)
+	}
+	pid := parsePaneLock(current)
+	if pid == 0 || pid == os.Getpid() {
+		return pid, nil
+	}
+	// Without a way to tell, the other bird is assumed alive.
+	if alive, err := processAlive(pid); !alive && err == nil {
+		debugf("pane %q has a stale lock from pid %d", pane, pid)
+		return 0, nil
+	}
+	return pid, nil
+}
+
+// errPaneLocked is the refusal to type into a pane another bird holds.
+func errPaneLocked(pane string, pid int) error {
+	return fmt.Errorf("pane %q is already typed into by the bird with pid %d; stop it first or use --takeover", pane, pid)
+}
+
+// acquirePaneLock claims pane for a new bird. A pane held by another live
+// bird is an error unless takeover is set, and then that bird stops before
+// its next send.
+func acquirePaneLock(pane string, takeover bool) (*paneLock, error) {
+	pid, err := paneLockHolder(pane)
+	if err != nil {
+		return nil, err
+	}
+	if pid != 0 {
+		if !takeover {
+			return nil, errPaneLocked(pane, pid)
+		}
+		logf("taking pane %q over from the bird with pid %d", pane, pid)
+	}
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	l := &paneLock{pane: pane, value: strconv.Itoa(os.Getpid()) + " " + hex.EncodeToString(nonce)}
+	if err := tmuxRun("set-option", "-p", "-t", pane, paneLockOption, l.value); err != nil {
+		return nil, fmt.Errorf("locking pane %q: %w", pane, err)
+	}
+	// A bird starting at the same moment may have written its claim over
+	// this one; whichever claim is left wins.
+	time.Sleep(restartPoll)
+	if pid, stolen := l.stolen(); stolen {
+		return nil, fmt.Errorf("pane %q was claimed at the same time by the bird with pid %d", pane, pid)
+	}
+	return l, nil
+}
+
+// stolen reports whether another bird has claimed the pane since, and
+// that bird's pid. A claim that is gone, with the server restarted, say,
+// is not stolen.
+func (l *paneLock) stolen() (int, bool) {
+	current, err := readPaneLock(l.pane)
+	if err != nil || current == "" || current == l.value {
+		return 0, false
+	}
+	return parsePaneLock(current), true
+}
+
+// release drops the claim unless another bird has taken the pane over.
+func (l *paneLock) release() {
+	if current, err := readPaneLock(l.pane); err != nil || current != l.value {
+		return
+	}
+	if err := tmuxRun("set-option", "-pu", "-t", l.pane, paneLockOption); err != nil {
+		debugf("unlocking pane %q: %v", l.pane, err)
+	}
+}
+
+// lockTarget claims pane for the bird before it types there, dropping the
+// claim on the pane it typed into before. tmux too old for pane options
+// cannot hold a claim, and other backends have no panes to claim.
+func (b *bird) lockTarget(pane string) error {
+	if !usingTmux() || !tmuxPaneOptions.supportedBy(runningTmux()) {
+		return nil
+	}
+	b.mu.Lock()
+	old := b.lock
+	b.mu.Unlock()
+	if old != nil && old.pane == pane {
+		if _, stolen := old.stolen(); !stolen {
+			return nil
+		}
+	}
+	lock, err := acquirePaneLock(pane, b.options().takeover)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.lock = lock
+	b.mu.Unlock()
+	if old != nil && old.pane != pane {
+		old.release()
+	}
+	return nil
+}
+
+// unlockTarget drops the bird's claim on its pane as it exits.
+func (b *bird) unlockTarget() {
+	b.mu.Lock()
+	lock := b.lock
+	b.lock = nil
+	b.mu.Unlock()
+	if lock != nil {
+		lock.release()
+	}
+}
+
+// takenOver reports whether another bird has claimed the bird's pane with
+// --takeover, and that bird's pid.
+func (b *bird) takenOver() (string, int, bool) {
+	b.mu.Lock()
+	lock := b.lock
+	b.mu.Unlock()
+	if lock == nil {
+		return "", 0, false
+	}
+	pid, stolen := lock.stolen()
+	return lock.pane, pid, stolen
+}
@@ -0,0 +1,70 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+)
+
+func TestPaneLock(t *testing.T) {
+	f := newFakeTmux(t, "s")
+	l, err := acquirePaneLock("%0", false)
+	if err != nil {
+		t.Fatalf("acquirePaneLock() = %v", err)
+	}
+	if got := f.pane("%0").Options[paneLockOption]; got != l.value || parsePaneLock(got) != os.Getpid() {
+		t.Fatalf("%s = %q; want %q with this pid", paneLockOption, got, l.value)
+	}
+	if _, err := acquirePaneLock("%0", false); err == nil {
+		t.Fatalf("acquirePaneLock() of a held pane = nil error; want one")
+	}
+
+	taker, err := acquirePaneLock("%0", true)
+	if err != nil {
+		t.Fatalf("acquirePaneLock() with takeover = %v", err)
+	}
+	if pid, stolen := l.stolen(); !stolen || pid != os.Getpid() {
+		t.Fatalf("stolen() after a takeover = %d, %v; want this pid, true", pid, stolen)
+	}
+	l.release()
+	if got := f.pane("%0").Options[paneLockOption]; got != taker.value {
+		t.Fatalf("%s after the old bird released = %q; want the taker's %q", paneLockOption, got, taker.value)
+	}
+	taker.release()
+	if got := f.pane("%0").Options[paneLockOption]; got != "" {
+		t.Fatalf("%s after release = %q; want it cleared", paneLockOption, got)
+	}
+
+	// A bird that died without releasing leaves a stale lock.
+	gone := exec.Command("true")
+	if err := gone.Run(); err != nil {
+		t.Fatal(err)
+	}
+	f.update(func(st *fakeTmuxState) {
+		st.pane("%0").Options[paneLockOption] = strconv.Itoa(gone.Process.Pid) + " 0123456789abcdef"
+	})
+	if _, err := acquirePaneLock("%0", false); err != nil {
+		t.Fatalf("acquirePaneLock() over a stale lock = %v", err)
+	}
+}
+
+func TestParsePaneLock(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"", 0},
+		{"123 abcd", 123},
+		{"x abcd", 0},
+		{"-4 abcd", 0},
+		{"77", 77},
+	}
+	for _, tt := range tests {
+		if got := parsePaneLock(tt.value); got != tt.want {
+			t.Fatalf("parsePaneLock(%q) = %d; want %d", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package typingbird
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultPasswordPatterns match the prompts the bird never types into:
+// sudo, ssh, gpg and git asking for a password, passphrase or PIN, and
+// logins asking for a one-time code.
+var defaultPasswordPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(password|passphrase|passcode|pin)\b.*:\s*$`),
+	regexp.MustCompile(`(?i)\b(verification|one-time|otp|2fa|mfa|authenticator) code\b.*:\s*$`),
+}
+
+// promptLine returns the last non-blank line of screen, where a prompt
+// waiting for input sits. Prompts already answered higher up don't count.
+func promptLine(screen []byte) string {
+	lines := bytes.Split(bytes.TrimRight(screen, " \t\r\n"), []byte("\n"))
+	return strings.TrimSpace(string(lines[len(lines)-1]))
+}
+
+// passwordPrompt returns the password prompt the screen is waiting at, if
+// any, trying the defaults and then extra.
+func passwordPrompt(screen []byte, extra *regexp.Regexp) (string, bool) {
+	line := promptLine(screen)
+	if line == "" {
+		return "", false
+	}
+	for _, re := range defaultPasswordPatterns {
+		if re.MatchString(line) {
+			return line, true
+		}
+	}
+	if extra != nil && extra.MatchString(line) {
+		return line, true
+	}
+	return "", false
+}
+
+// alertPasswordPrompt shows a message on the clients attached to the
+// target's session, for --password-alert.
+func alertPasswordPrompt(target string) error {
+	return tmuxRun("display-message", "-t", target, fmt.Sprintf("typing-bird: pane %s is asking for a password; not typing into it", target))
+}
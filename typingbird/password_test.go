@@ -0,0 +1,35 @@
+package typingbird
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPasswordPrompt(t *testing.T) {
+	tests := map[string]bool{
+		"$ sudo make install\n[sudo] password for jay: \n\n":     true,
+		"Enter passphrase for key '/home/jay/.ssh/id_ed25519': ": true,
+		"Password for 'https://jay@github.com': ":                true,
+		"Password:":                         true,
+		"Enter PIN for 'YubiKey':":          true,
+		"Enter the verification code:":      true,
+		"[sudo] password for jay: \nok\n$ ": false,
+		"> please reset the password\n":     false,
+		"Spinning up workers:\nDone.":       false,
+		"":                                  false,
+		"Changing the password policy requires a restart; continue? ": false,
+	}
+	for screen, want := range tests {
+		if _, got := passwordPrompt([]byte(screen), nil); got != want {
+			t.Fatalf("passwordPrompt(%q) = %t; want %t", screen, got, want)
+		}
+	}
+}
+
+func TestPasswordPromptExtra(t *testing.T) {
+	extra := regexp.MustCompile(`^Kennwort:`)
+	got, ok := passwordPrompt([]byte("login\nKennwort: "), extra)
+	if !ok || got != "Kennwort:" {
+		t.Fatalf("passwordPrompt(...) = %q, %t; want %q, true", got, ok, "Kennwort:")
+	}
+}
@@ -0,0 +1,156 @@
+package typingbird
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// pipeMonitor streams a pane's output through tmux pipe-pane into a FIFO and
+// tracks when the last byte arrived, so idleness can be measured without
+// repeatedly capturing the whole screen.
+type pipeMonitor struct {
+	target   string
+	dir      string
+	fifo     *os.File
+	attached bool
+	lastNano atomic.Int64
+	total    atomic.Int64
+	done     chan struct{}
+	// prompts follows shell-integration marks for --idle-mode prompt; nil
+	// otherwise.
+	prompts *promptTracker
+}
+
+func startPipeMonitor(target string, prompts bool) (*pipeMonitor, error) {
+	m, err := newPipeMonitor(target, prompts)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmuxPipePane(target, "cat >> "+shellQuoteSingle(m.fifoPath())); err != nil {
+		m.Close()
+		return nil, err
+	}
+	m.attached = true
+	return m, nil
+}
+
+// newPipeMonitor creates the FIFO and starts reading from it without
+// attaching it to tmux.
+func newPipeMonitor(target string, prompts bool) (*pipeMonitor, error) {
+	dir, err := os.MkdirTemp("", "typing-bird-pipe-")
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "pane.fifo")
+	if err := mkfifo(path, 0o600); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("creating fifo %q: %w", path, err)
+	}
+	// Opening read-write keeps the FIFO from blocking on open and from
+	// reporting EOF whenever the tmux-side writer goes away.
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	m := &pipeMonitor{
+		target: target,
+		dir:    dir,
+		fifo:   f,
+		done:   make(chan struct{}),
+	}
+	if prompts {
+		m.prompts = &promptTracker{}
+	}
+	m.lastNano.Store(time.Now().UnixNano())
+	go m.read()
+	return m, nil
+}
+
+func (m *pipeMonitor) fifoPath() string {
+	return filepath.Join(m.dir, "pane.fifo")
+}
+
+func (m *pipeMonitor) read() {
+	defer close(m.done)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := m.fifo.Read(buf)
+		if n > 0 {
+			m.total.Add(int64(n))
+			m.lastNano.Store(time.Now().UnixNano())
+			if m.prompts != nil {
+				m.prompts.feed(buf[:n])
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				debugf("pipe-pane reader for %q stopped: %v", m.target, err)
+			}
+			return
+		}
+	}
+}
+
+func (m *pipeMonitor) lastOutput() time.Time {
+	return time.Unix(0, m.lastNano.Load())
+}
+
+// waitIdle blocks until no output has been streamed for the full timeout and
+// returns the total number of bytes observed so far. When following prompts,
+// a command that started and hasn't finished is never idle, however quiet.
+func (m *pipeMonitor) waitIdle(ctx context.Context, timeout time.Duration) (int, error) {
+	for {
+		quiet := time.Since(m.lastOutput())
+		if quiet >= timeout {
+			if ok, _ := targetExists(m.target); !ok {
+				return 0, targetGone(m.target)
+			}
+			if m.prompts != nil && m.prompts.current() == shellRunning {
+				debugf("pipe-pane on %q quiet for %s but a command is still running", m.target, quiet.Round(time.Millisecond))
+				if err := sleepWithContext(ctx, timeout); err != nil {
+					return 0, err
+				}
+				continue
+			}
+			return int(m.total.Load()), nil
+		}
+		if err := sleepWithContext(ctx, timeout-quiet); err != nil {
+			return 0, err
+		}
+		debugf("pipe-pane on %q quiet for %s of %s", m.target, time.Since(m.lastOutput()).Round(time.Millisecond), timeout)
+	}
+}
+
+// Close detaches the pipe from the pane and removes the FIFO.
+func (m *pipeMonitor) Close() error {
+	var err error
+	if m.attached {
+		err = tmuxPipePane(m.target, "")
+		m.attached = false
+	}
+	_ = m.fifo.Close()
+	<-m.done
+	_ = os.RemoveAll(m.dir)
+	return err
+}
+
+// tmuxPipePane pipes the target's output to shellCommand, or closes any
+// existing pipe when shellCommand is empty.
+func tmuxPipePane(target, shellCommand string) error {
+	args := []string{"pipe-pane", "-t", target}
+	if shellCommand != "" {
+		args = append(args, shellCommand)
+	}
+	out, err := tmuxCombinedOutput(args...)
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPipeMonitorTracksStreamedBytes(t *testing.T) {
+	m, err := newPipeMonitor("%0", false)
+	if err != nil {
+		t.Fatalf("newPipeMonitor(...) error: %v", err)
+	}
+	defer m.Close()
+
+	before := m.lastOutput()
+	w, err := os.OpenFile(m.fifoPath(), os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("opening fifo for writing: %v", err)
+	}
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatalf("writing fifo: %v", err)
+	}
+	_ = w.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for m.total.Load() < 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("pipeMonitor total = %d; want 5", m.total.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if !m.lastOutput().After(before) {
+		t.Fatalf("lastOutput() = %s; want after %s", m.lastOutput(), before)
+	}
+}
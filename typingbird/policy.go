@@ -0,0 +1,47 @@
+package typingbird
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// defaultDenyPatterns match commands no message should ever type: deleting
+// the root or home directory, a fork bomb, and overwriting or formatting a
+// disk.
+var defaultDenyPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\brm\s+(-\S+\s+)*(/|/\*|~/?|\$HOME/?)(\s|;|$)`),
+	regexp.MustCompile(`:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`),
+	regexp.MustCompile(`\bmkfs(\.\w+)?\s`),
+	regexp.MustCompile(`\bdd\s.*\bof=/dev/(sd|hd|vd|xvd|nvme|disk|mmcblk)`),
+	regexp.MustCompile(`>\s*/dev/(sd|hd|vd|xvd|nvme|disk|mmcblk)\w*`),
+}
+
+// messagePolicy decides which messages may be sent at all, guarding
+// against a mis-pasted messages file or a model gone astray.
+type messagePolicy struct {
+	deny  *regexp.Regexp
+	allow *regexp.Regexp
+	// force turns the policy off.
+	force bool
+}
+
+// screen checks text about to be sent: it must not match a deny pattern
+// (the defaults or --deny-regex) and, with --allow-regex, must match that.
+// Sending just Enter is always allowed.
+func (p messagePolicy) screen(text string) error {
+	if p.force || text == "" {
+		return nil
+	}
+	for _, re := range defaultDenyPatterns {
+		if re.MatchString(text) {
+			return fmt.Errorf("message matches the built-in deny pattern /%s/ (use --force to send it anyway)", re)
+		}
+	}
+	if p.deny != nil && p.deny.MatchString(text) {
+		return fmt.Errorf("message matches --deny-regex /%s/", p.deny)
+	}
+	if p.allow != nil && !p.allow.MatchString(text) {
+		return fmt.Errorf("message doesn't match --allow-regex /%s/", p.allow)
+	}
+	return nil
+}
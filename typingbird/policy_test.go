@@ -0,0 +1,67 @@
+package typingbird
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMessagePolicyDefaults(t *testing.T) {
+	tests := map[string]bool{
+		"rm -rf /":                          false,
+		"sudo rm -rf --no-preserve-root / ": false,
+		"rm -rf ~":                          false,
+		"rm -fr $HOME/":                     false,
+		"rm -rf /*; echo done":              false,
+		":(){ :|:& };:":                     false,
+		"mkfs.ext4 /dev/sda1":               false,
+		"dd if=/dev/zero of=/dev/nvme0n1":   false,
+		"cat image > /dev/sdb":              false,
+		"rm -rf ./build /tmp/cache":         true,
+		"rm -rf ~/project/node_modules":     true,
+		"dd if=disk.img of=backup.img":      true,
+		"continue":                          true,
+		"":                                  true,
+	}
+	for text, ok := range tests {
+		if err := (messagePolicy{}).screen(text); (err == nil) != ok {
+			t.Fatalf("screen(%q) = %v; want ok=%t", text, err, ok)
+		}
+	}
+	if err := (messagePolicy{force: true}).screen("rm -rf /"); err != nil {
+		t.Fatalf("screen(\"rm -rf /\") with force = %v; want nil", err)
+	}
+}
+
+func TestMessagePolicyRegexps(t *testing.T) {
+	p := messagePolicy{deny: regexp.MustCompile(`(?i)drop table`), allow: regexp.MustCompile(`^(continue|/\w+)`)}
+	tests := map[string]string{
+		"continue":               "",
+		"/compact":               "",
+		"DROP TABLE users":       "--deny-regex",
+		"make the tests pass":    "--allow-regex",
+		"continue; rm -rf ~ ":    "built-in deny pattern",
+		"continue, drop table x": "--deny-regex",
+	}
+	for text, want := range tests {
+		err := p.screen(text)
+		if (want == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), want)) {
+			t.Fatalf("screen(%q) = %v; want error mentioning %q", text, err, want)
+		}
+	}
+}
+
+func TestOptionsScreensMessages(t *testing.T) {
+	cli := newCLIFlags()
+	if _, err := cli.options([]string{"s", "continue", "rm -rf ~"}, resolvedConfig{}, ""); err == nil || !strings.Contains(err.Error(), "message 2") {
+		t.Fatalf("options() error = %v; want message 2 denied", err)
+	}
+	cli.allowRegex = "^continue$"
+	if _, err := cli.options([]string{"s", "continue", "@clipboard"}, resolvedConfig{}, ""); err != nil {
+		t.Fatalf("options() error = %v; want @clipboard left for send time", err)
+	}
+	cli.force = true
+	if _, err := cli.options([]string{"s", "rm -rf ~"}, resolvedConfig{}, ""); err != nil {
+		t.Fatalf("options() with force error = %v; want nil", err)
+	}
+}
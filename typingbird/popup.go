@@ -0,0 +1,115 @@
+package typingbird
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	popupOff        = ""
+	popupShow       = "show"
+	popupBackground = "background"
+)
+
+// popupMode is the --inject-popup flag. It acts as a boolean flag so a bare
+// --inject-popup shows the popup, while --inject-popup=background only
+// starts the bird.
+type popupMode string
+
+func (m *popupMode) String() string {
+	if m == nil {
+		return popupOff
+	}
+	return string(*m)
+}
+
+func (m *popupMode) Set(value string) error {
+	switch value {
+	case "true", popupShow:
+		*m = popupShow
+	case "false", "":
+		*m = popupOff
+	case popupBackground:
+		*m = popupBackground
+	default:
+		return fmt.Errorf("must be show or background")
+	}
+	return nil
+}
+
+func (m *popupMode) IsBoolFlag() bool {
+	return true
+}
+
+// popupFiles returns the log and pid file of the popup bird sending to
+// targetPane.
+func popupFiles(targetPane string) (logPath, pidPath string) {
+	base := filepath.Join(defaultSocketDir(), "popup-"+strings.TrimPrefix(targetPane, "%"))
+	return base + ".log", base + ".pid"
+}
+
+// popupBirdCommand runs childCommand in the background with its output
+// appended to logPath, keeping its pid in pidPath while it runs.
+func popupBirdCommand(childCommand, logPath, pidPath string) string {
+	return fmt.Sprintf("%s >>%s 2>&1 & echo $! >%s; wait $!; rm -f %s",
+		childCommand, shellQuoteSingle(logPath), shellQuoteSingle(pidPath), shellQuoteSingle(pidPath))
+}
+
+// tmuxPopupArgs opens a popup on client following logPath. Closing the
+// popup only stops tail; the bird keeps running.
+func tmuxPopupArgs(client, targetPane, logPath string) []string {
+	follow := shellCommandForExec("tail", []string{"-n", "200", "-F", logPath})
+	return []string{"display-popup", "-E", "-c", client, "-t", targetPane, "-w", "80%", "-h", "50%", follow}
+}
+
+// startPopupBird starts the bird for targetPane as a tmux background job,
+// replacing any popup bird already sending there, and returns its log file.
+func startPopupBird(targetPane, childCommand string) (string, error) {
+	logPath, pidPath := popupFiles(targetPane)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o700); err != nil {
+		return "", err
+	}
+	stopPopupBird(pidPath)
+	// run-shell expands formats, so # has to be doubled.
+	job := strings.ReplaceAll(popupBirdCommand(childCommand, logPath, pidPath), "#", "##")
+	if out, err := tmuxCombinedOutput("run-shell", "-b", job); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return logPath, nil
+}
+
+func stopPopupBird(pidPath string) {
+	raw, err := os.ReadFile(pidPath)
+	if err != nil {
+		return
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil || pid <= 0 {
+		return
+	}
+	if p, err := os.FindProcess(pid); err == nil {
+		_ = p.Signal(syscall.SIGTERM)
+	}
+	_ = os.Remove(pidPath)
+}
+
+// tmuxShowPopup follows logPath in a popup on a client attached to session
+// and blocks until the popup is closed.
+func tmuxShowPopup(session, targetPane, logPath string) error {
+	out, err := tmuxOutput("list-clients", "-t", session, "-F", "#{?client_control_mode,,#{client_tty}}")
+	if err != nil {
+		return err
+	}
+	client, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if client == "" {
+		return fmt.Errorf("no client is attached to session %q", session)
+	}
+	if out, err := tmuxCombinedOutput(tmuxPopupArgs(client, targetPane, logPath)...); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
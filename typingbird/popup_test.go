@@ -0,0 +1,51 @@
+package typingbird
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestInjectPopupFlagForms(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: nil, want: popupOff},
+		{args: []string{"--inject-popup"}, want: popupShow},
+		{args: []string{"--inject-popup=background"}, want: popupBackground},
+		{args: []string{"--inject-popup=false"}, want: popupOff},
+	}
+	for _, tc := range tests {
+		var mode popupMode
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Var(&mode, "inject-popup", "")
+		if err := fs.Parse(tc.args); err != nil {
+			t.Fatalf("Parse(%q) error = %v", tc.args, err)
+		}
+		if got := mode.String(); got != tc.want {
+			t.Fatalf("Parse(%q) inject-popup = %q; want %q", tc.args, got, tc.want)
+		}
+	}
+	var mode popupMode
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&mode, "inject-popup", "")
+	if err := fs.Parse([]string{"--inject-popup=modal"}); err == nil {
+		t.Fatalf("Parse(--inject-popup=modal) succeeded; want error")
+	}
+}
+
+func TestPopupCommands(t *testing.T) {
+	got := popupBirdCommand("'/bin/typing-bird' 'agent'", "/run/tb/popup-3.log", "/run/tb/popup-3.pid")
+	want := "'/bin/typing-bird' 'agent' >>'/run/tb/popup-3.log' 2>&1 & echo $! >'/run/tb/popup-3.pid'; wait $!; rm -f '/run/tb/popup-3.pid'"
+	if got != want {
+		t.Fatalf("popupBirdCommand(...) = %q; want %q", got, want)
+	}
+	args := tmuxPopupArgs("/dev/pts/2", "%3", "/run/tb/popup-3.log")
+	wantArgs := []string{"display-popup", "-E", "-c", "/dev/pts/2", "-t", "%3", "-w", "80%", "-h", "50%", "'tail' '-n' '200' '-F' '/run/tb/popup-3.log'"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Fatalf("tmuxPopupArgs(...) = %#v; want %#v", args, wantArgs)
+	}
+}
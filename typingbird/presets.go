@@ -0,0 +1,73 @@
+package typingbird
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// builtinPresets bundle settings for agent CLIs, keyed like a config file.
+// The config file and the command line override any of them. Each one's
+// deny-regex blocks the tool's slash commands that throw away the
+// conversation or its work or end the session, on top of the built-in
+// deny list.
+var builtinPresets = map[string]map[string]string{
+	// Claude Code shows "esc to interrupt" under the spinner while it
+	// works, and a numbered menu while it waits for a permission answer.
+	"claude-code": {
+		"timeout":        "45s",
+		"busy-regex":     `esc to interrupt|Do you want to proceed\?`,
+		"deny-regex":     `(?m)^\s*/(clear|exit|logout)\b`,
+		"human-cooldown": "30s",
+	},
+	// aider prints its spinner and "Waiting for <model>" while the model
+	// answers, and asks y/n questions that a free-form message would
+	// answer wrongly. /undo reverts its last commit.
+	"aider": {
+		"timeout":        "30s",
+		"busy-regex":     `Waiting for |\(Y\)es/\(N\)o`,
+		"deny-regex":     `(?m)^\s*/(clear|reset|drop|undo|exit|quit)\b`,
+		"human-cooldown": "30s",
+	},
+	// Codex shows "esc to interrupt" while working and an approval prompt
+	// before running commands.
+	"codex": {
+		"timeout":        "45s",
+		"busy-regex":     `esc to interrupt|Allow command\?`,
+		"deny-regex":     `(?m)^\s*/(new|logout|exit|quit)\b`,
+		"human-cooldown": "30s",
+	},
+}
+
+func presetNames() []string {
+	names := make([]string, 0, len(builtinPresets))
+	for name := range builtinPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyPreset applies the --preset settings to fs, skipping flags given
+// explicitly or set by the config.
+func (f *cliFlags) applyPreset(fs *flag.FlagSet, explicit map[string]bool, configured map[string]string) error {
+	if f.preset == "" {
+		return nil
+	}
+	settings, ok := builtinPresets[f.preset]
+	if !ok {
+		return fmt.Errorf("unknown preset %q (one of: %s)", f.preset, strings.Join(presetNames(), ", "))
+	}
+	skip := make(map[string]bool, len(explicit)+len(configured))
+	for key := range explicit {
+		skip[key] = true
+	}
+	for key := range configured {
+		skip[key] = true
+	}
+	if err := applyConfigSettings(fs, skip, settings); err != nil {
+		return fmt.Errorf("preset %q: %w", f.preset, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package typingbird
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestApplyPreset(t *testing.T) {
+	cli := newCLIFlags()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	cli.register(fs)
+	if err := fs.Parse([]string{"--preset", "claude-code", "-t", "2m"}); err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	configured := map[string]string{"human-cooldown": "5s"}
+	if err := applyConfigSettings(fs, explicitFlags(fs), configured); err != nil {
+		t.Fatalf("applyConfigSettings error: %v", err)
+	}
+	if err := cli.applyPreset(fs, explicitFlags(fs), configured); err != nil {
+		t.Fatalf("applyPreset error: %v", err)
+	}
+	if cli.timeout != "2m" {
+		t.Fatalf("timeout = %q; want the explicit 2m", cli.timeout)
+	}
+	if cli.humanCooldown != "5s" {
+		t.Fatalf("human-cooldown = %q; want the configured 5s", cli.humanCooldown)
+	}
+	if want := builtinPresets["claude-code"]["busy-regex"]; cli.busyRegex != want {
+		t.Fatalf("busy-regex = %q; want the preset's %q", cli.busyRegex, want)
+	}
+}
+
+func TestBuiltinPresetsValid(t *testing.T) {
+	for _, name := range presetNames() {
+		cli := newCLIFlags()
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		cli.register(fs)
+		cli.preset = name
+		if err := cli.applyPreset(fs, nil, nil); err != nil {
+			t.Fatalf("applyPreset(%q) error: %v", name, err)
+		}
+		opts, err := cli.options([]string{"s"}, resolvedConfig{}, "")
+		if err != nil {
+			t.Fatalf("options() with preset %q error: %v", name, err)
+		}
+		if err := opts.policy.screen("  /exit"); err == nil {
+			t.Fatalf("preset %q lets /exit through; want it denied", name)
+		}
+		if err := opts.policy.screen("keep going\n/exit"); err == nil {
+			t.Fatalf("preset %q lets /exit on a later line through; want it denied", name)
+		}
+		if err := opts.policy.screen("continue; the tests should exit cleanly"); err != nil {
+			t.Fatalf("preset %q denies a plain message: %v", name, err)
+		}
+	}
+}
+
+func TestApplyPresetUnknown(t *testing.T) {
+	cli := newCLIFlags()
+	cli.preset = "nope"
+	err := cli.applyPreset(flag.NewFlagSet("test", flag.ContinueOnError), nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "aider, claude-code, codex") {
+		t.Fatalf("applyPreset(nope) error = %v; want the preset names", err)
+	}
+}
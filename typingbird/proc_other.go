@@ -0,0 +1,14 @@
+//go:build !unix
+
+package typingbird
+
+import (
+	"errors"
+	"os/exec"
+)
+
+func processAlive(pid int) (bool, error) {
+	return false, errors.New("watching processes requires a unix platform")
+}
+
+func ownProcessGroup(cmd *exec.Cmd) {}
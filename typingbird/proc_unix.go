@@ -0,0 +1,28 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// processAlive reports whether a process with pid exists.
+func processAlive(pid int) (bool, error) {
+	err := syscall.Kill(pid, 0)
+	switch {
+	case err == nil, errors.Is(err, syscall.EPERM):
+		return true, nil
+	case errors.Is(err, syscall.ESRCH):
+		return false, nil
+	}
+	return false, err
+}
+
+// ownProcessGroup starts cmd in a process group of its own. A Ctrl-C at the
+// terminal then reaches only the bird, which finishes the message it is
+// typing, and not the tmux commands doing the typing.
+func ownProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
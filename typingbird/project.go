@@ -0,0 +1,122 @@
+package typingbird
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// tmuxProject is the layout read from a tmuxp or tmuxinator project file:
+// the session it creates and its windows in order.
+type tmuxProject struct {
+	session string
+	windows []projectWindow
+}
+
+type projectWindow struct {
+	name  string
+	panes int
+}
+
+func loadTmuxProject(path string) (*tmuxProject, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading project file: %w", err)
+	}
+	p, err := parseTmuxProject(raw)
+	if err != nil {
+		return nil, fmt.Errorf("project file %q: %w", path, err)
+	}
+	return p, nil
+}
+
+// parseTmuxProject reads tmuxp files (session_name, windows of window_name
+// and panes) and tmuxinator files (name, windows of single-key mappings
+// whose value is a command or a mapping with panes). JSON tmuxp files parse
+// as YAML too.
+func parseTmuxProject(raw []byte) (*tmuxProject, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	windows, ok := doc["windows"].([]any)
+	if !ok || len(windows) == 0 {
+		return nil, fmt.Errorf("windows must be a non-empty list")
+	}
+	p := &tmuxProject{}
+	tmuxp := doc["session_name"] != nil
+	if tmuxp {
+		p.session = fmt.Sprint(doc["session_name"])
+	} else if doc["name"] != nil {
+		p.session = fmt.Sprint(doc["name"])
+	} else if doc["project_name"] != nil {
+		p.session = fmt.Sprint(doc["project_name"])
+	} else {
+		return nil, fmt.Errorf("neither session_name (tmuxp) nor name (tmuxinator) is set")
+	}
+	for i, raw := range windows {
+		w, err := parseProjectWindow(raw, tmuxp)
+		if err != nil {
+			return nil, fmt.Errorf("windows[%d]: %w", i, err)
+		}
+		if p.window(w.name) != nil {
+			return nil, fmt.Errorf("duplicate window %q", w.name)
+		}
+		p.windows = append(p.windows, w)
+	}
+	return p, nil
+}
+
+func parseProjectWindow(raw any, tmuxp bool) (projectWindow, error) {
+	fields, ok := raw.(map[string]any)
+	if !ok {
+		return projectWindow{}, fmt.Errorf("must be a mapping")
+	}
+	var w projectWindow
+	var settings any
+	if tmuxp {
+		if fields["window_name"] == nil {
+			return projectWindow{}, fmt.Errorf("window_name is required")
+		}
+		w.name, settings = fmt.Sprint(fields["window_name"]), raw
+	} else {
+		if len(fields) != 1 {
+			return projectWindow{}, fmt.Errorf("want a single window name mapping to its command or settings")
+		}
+		for name, value := range fields {
+			w.name, settings = name, value
+		}
+	}
+	w.panes = 1
+	if m, ok := settings.(map[string]any); ok {
+		if panes, ok := m["panes"].([]any); ok && len(panes) > 0 {
+			w.panes = len(panes)
+		}
+	}
+	return w, nil
+}
+
+func (p *tmuxProject) window(name string) *projectWindow {
+	for i := range p.windows {
+		if p.windows[i].name == name {
+			return &p.windows[i]
+		}
+	}
+	return nil
+}
+
+// tmuxProjectPane resolves pane number pane (0 for the first, in the order
+// the project lists them) of window in session to its pane id.
+func tmuxProjectPane(session, window string, pane int) (string, error) {
+	out, err := tmuxOutput("list-panes", "-t", "="+session+":="+window, "-F", "#{pane_id}")
+	if err != nil {
+		return "", fmt.Errorf("listing panes of window %q: %w", window, err)
+	}
+	ids := strings.Fields(string(out))
+	if pane >= len(ids) {
+		return "", fmt.Errorf("window %q has %d panes; pane %d is not one of them", window, len(ids), pane)
+	}
+	return ids[pane], nil
+}
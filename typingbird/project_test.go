@@ -0,0 +1,76 @@
+package typingbird
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTmuxProject(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want *tmuxProject
+	}{
+		{
+			name: "tmuxp",
+			raw: `session_name: dev
+windows:
+  - window_name: editor
+    layout: main-vertical
+    panes:
+      - vim
+      - shell_command: [git status]
+      - null
+  - window_name: logs
+    panes: [tail -f log]
+  - window_name: scratch
+`,
+			want: &tmuxProject{session: "dev", windows: []projectWindow{{"editor", 3}, {"logs", 1}, {"scratch", 1}}},
+		},
+		{
+			name: "tmuxp json",
+			raw:  `{"session_name": "dev", "windows": [{"window_name": "a", "panes": ["x", "y"]}]}`,
+			want: &tmuxProject{session: "dev", windows: []projectWindow{{"a", 2}}},
+		},
+		{
+			name: "tmuxinator",
+			raw: `name: app
+root: ~/app
+windows:
+  - editor:
+      layout: main-vertical
+      panes:
+        - vim
+        - guard
+  - server: bundle exec rails s
+  - console:
+`,
+			want: &tmuxProject{session: "app", windows: []projectWindow{{"editor", 2}, {"server", 1}, {"console", 1}}},
+		},
+	}
+	for _, tt := range tests {
+		got, err := parseTmuxProject([]byte(tt.raw))
+		if err != nil {
+			t.Fatalf("%s: parseTmuxProject(...) error: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: parseTmuxProject(...) = %+v; want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTmuxProjectErrors(t *testing.T) {
+	tests := map[string]string{
+		"windows: [{window_name: a}]\n":                                    "neither session_name",
+		"name: a\n":                                                        "non-empty list",
+		"session_name: a\nwindows: [{panes: [x]}]\n":                       "window_name is required",
+		"name: a\nwindows: [{a: x, b: y}]\n":                               "single window name",
+		"session_name: a\nwindows: [{window_name: w}, {window_name: w}]\n": "duplicate window",
+	}
+	for raw, want := range tests {
+		if _, err := parseTmuxProject([]byte(raw)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("parseTmuxProject(%q) error = %v; want it to mention %q", raw, err, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package typingbird
+
+import (
+	"bytes"
+	"sync"
+)
+
+// osc133 starts a shell-integration mark (FinalTerm/OSC 133). The letter
+// after it says what the shell is doing: A prompt start, B prompt end,
+// C command output start, D command finished.
+var osc133 = []byte("\x1b]133;")
+
+// Shell states tracked from OSC 133 marks.
+const (
+	// shellUnknown means no mark has been seen yet, e.g. a shell without
+	// integration or one that was already sitting at its prompt.
+	shellUnknown = iota
+	// shellAtPrompt follows A, B or D: a fresh prompt is up.
+	shellAtPrompt
+	// shellRunning follows C: a command is producing output.
+	shellRunning
+)
+
+// promptTracker follows the OSC 133 marks in a pane's raw output for
+// --idle-mode prompt.
+type promptTracker struct {
+	mu    sync.Mutex
+	state int
+	// carry holds the tail of the last chunk, which may be the start of
+	// a mark split across reads.
+	carry []byte
+}
+
+func (t *promptTracker) feed(p []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	data := append(t.carry, p...)
+	pos := 0
+	for {
+		i := bytes.Index(data[pos:], osc133)
+		if i < 0 {
+			break
+		}
+		i += pos
+		if i+len(osc133) >= len(data) {
+			pos = i
+			break
+		}
+		switch data[i+len(osc133)] {
+		case 'A', 'B', 'D':
+			t.state = shellAtPrompt
+		case 'C':
+			t.state = shellRunning
+		}
+		pos = i + len(osc133) + 1
+	}
+	start := max(pos, len(data)-len(osc133))
+	t.carry = append(t.carry[:0], data[start:]...)
+}
+
+func (t *promptTracker) current() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state
+}
@@ -0,0 +1,27 @@
+package typingbird
+
+import "testing"
+
+func TestPromptTrackerFollowsMarks(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   int
+	}{
+		{"no marks", []string{"$ ls\r\nfile\r\n"}, shellUnknown},
+		{"prompt", []string{"\x1b]133;A\x07$ \x1b]133;B\x07"}, shellAtPrompt},
+		{"running", []string{"\x1b]133;A\x07$ make\r\n\x1b]133;C\x07cc -c main.c"}, shellRunning},
+		{"finished", []string{"\x1b]133;C\x07ok\r\n\x1b]133;D;0\x07\x1b]133;A\x07$ "}, shellAtPrompt},
+		{"split prefix", []string{"out\x1b]13", "3;C\x1b\\more"}, shellRunning},
+		{"split letter", []string{"\x1b]133;C\x07x\x1b]133;", "D;1\x07"}, shellAtPrompt},
+	}
+	for _, tt := range tests {
+		var p promptTracker
+		for _, chunk := range tt.chunks {
+			p.feed([]byte(chunk))
+		}
+		if got := p.current(); got != tt.want {
+			t.Fatalf("%s: current() = %d; want %d", tt.name, got, tt.want)
+		}
+	}
+}
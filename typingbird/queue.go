@@ -0,0 +1,106 @@
+package typingbird
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// messageQueue supplies one-off messages that go out ahead of the rotation,
+// one per idle cycle.
+type messageQueue interface {
+	// pop takes the next message, reporting false when there is none.
+	pop(ctx context.Context) (string, bool, error)
+	// requeue puts a message that was taken but not sent back at the
+	// head of the queue.
+	requeue(message string) error
+	fmt.Stringer
+}
+
+// priorityDirective starts a queued message that should jump ahead of
+// others, e.g. "#priority:10 stop and fix the build". Messages without one
+// have priority 0.
+const priorityDirective = "#priority:"
+
+// parsePriority splits a queued message into its priority and the text to
+// send. A malformed directive is left as part of the text.
+func parsePriority(message string) (int, string) {
+	rest, ok := strings.CutPrefix(message, priorityDirective)
+	if !ok {
+		return 0, message
+	}
+	raw, text, _ := strings.Cut(rest, " ")
+	priority, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, message
+	}
+	return priority, strings.TrimLeft(text, " ")
+}
+
+// queuedMessage is a message taken from one of the bird's queues.
+type queuedMessage struct {
+	// raw is the message as queued, directive included, for requeue.
+	raw      string
+	text     string
+	priority int
+	from     messageQueue
+}
+
+// popQueue takes the head of each of the bird's queues and returns the one
+// with the highest priority, the earlier queue winning a tie; the others go
+// back. A broken queue is logged and skipped.
+func (b *bird) popQueue(ctx context.Context) (queuedMessage, bool) {
+	var taken []queuedMessage
+	best := -1
+	for _, q := range b.queues {
+		raw, ok, err := q.pop(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			b.logf("WARNING: reading queue %s: %v", q, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		priority, text := parsePriority(raw)
+		taken = append(taken, queuedMessage{raw: raw, text: text, priority: priority, from: q})
+		if best < 0 || priority > taken[best].priority {
+			best = len(taken) - 1
+		}
+	}
+	if ctx.Err() != nil {
+		// Shutting down: everything taken goes back.
+		best = -1
+	}
+	for i, m := range taken {
+		if i == best {
+			continue
+		}
+		if err := m.from.requeue(m.raw); err != nil {
+			b.logf("WARNING: returning message to queue %s: %v", m.from, err)
+		}
+	}
+	if best < 0 {
+		return queuedMessage{}, false
+	}
+	return taken[best], true
+}
+
+// insertByPriority adds raw to lines, kept highest priority first: behind
+// the messages of the same priority, or ahead of them when it is being
+// returned unsent.
+func insertByPriority(lines []string, raw string, returned bool) []string {
+	priority, _ := parsePriority(raw)
+	i := 0
+	for ; i < len(lines); i++ {
+		p, _ := parsePriority(lines[i])
+		if p < priority || returned && p == priority {
+			break
+		}
+	}
+	return slices.Insert(lines, i, raw)
+}
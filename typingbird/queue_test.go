@@ -0,0 +1,106 @@
+package typingbird
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// sliceQueue is a messageQueue over a fixed list.
+type sliceQueue struct {
+	name     string
+	messages []string
+	err      error
+}
+
+func (q *sliceQueue) pop(context.Context) (string, bool, error) {
+	if q.err != nil || len(q.messages) == 0 {
+		return "", false, q.err
+	}
+	message := q.messages[0]
+	q.messages = q.messages[1:]
+	return message, true, nil
+}
+
+func (q *sliceQueue) requeue(message string) error {
+	q.messages = append([]string{message}, q.messages...)
+	return nil
+}
+
+func (q *sliceQueue) String() string { return q.name }
+
+func TestPopQueueOrder(t *testing.T) {
+	broken := &sliceQueue{name: "broken", err: errors.New("down")}
+	fifo := &sliceQueue{name: "fifo", messages: []string{"a"}}
+	redis := &sliceQueue{name: "redis", messages: []string{"b"}}
+	b := &bird{queues: []messageQueue{broken, fifo, redis}}
+	for _, want := range []string{"fifo", "redis"} {
+		m, ok := b.popQueue(context.Background())
+		if !ok || m.from.String() != want {
+			t.Fatalf("popQueue() = %v, %v; want a message from %s", m.from, ok, want)
+		}
+	}
+	if m, ok := b.popQueue(context.Background()); ok {
+		t.Fatalf("popQueue() on empty queues = %q; want none", m.text)
+	}
+}
+
+func TestPopQueuePriority(t *testing.T) {
+	fifo := &sliceQueue{name: "fifo", messages: []string{"a", "b"}}
+	redis := &sliceQueue{name: "redis", messages: []string{"#priority:5 urgent", "c"}}
+	b := &bird{queues: []messageQueue{fifo, redis}}
+	var got []string
+	for {
+		m, ok := b.popQueue(context.Background())
+		if !ok {
+			break
+		}
+		got = append(got, m.text)
+	}
+	want := []string{"urgent", "a", "b", "c"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("popQueue() order = %q; want %q", got, want)
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	cases := []struct {
+		message  string
+		priority int
+		text     string
+	}{
+		{"fix it", 0, "fix it"},
+		{"#priority:10 fix it", 10, "fix it"},
+		{"#priority:-1  later", -1, "later"},
+		{"#priority:high fix it", 0, "#priority:high fix it"},
+	}
+	for _, tc := range cases {
+		priority, text := parsePriority(tc.message)
+		if priority != tc.priority || text != tc.text {
+			t.Fatalf("parsePriority(%q) = %d, %q; want %d, %q", tc.message, priority, text, tc.priority, tc.text)
+		}
+	}
+}
+
+func TestInsertByPriority(t *testing.T) {
+	cases := []struct {
+		lines    []string
+		raw      string
+		returned bool
+		want     []string
+	}{
+		{nil, "a", false, []string{"a"}},
+		{[]string{"a", "b"}, "c", false, []string{"a", "b", "c"}},
+		{[]string{"a", "b"}, "c", true, []string{"c", "a", "b"}},
+		{[]string{"#priority:2 x", "a"}, "#priority:1 y", false, []string{"#priority:2 x", "#priority:1 y", "a"}},
+		{[]string{"#priority:2 x", "a"}, "#priority:2 y", false, []string{"#priority:2 x", "#priority:2 y", "a"}},
+		{[]string{"#priority:2 x", "a"}, "#priority:2 y", true, []string{"#priority:2 y", "#priority:2 x", "a"}},
+	}
+	for _, tc := range cases {
+		if got := insertByPriority(slices.Clone(tc.lines), tc.raw, tc.returned); !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("insertByPriority(%q, %q, %v) = %q; want %q", tc.lines, tc.raw, tc.returned, got, tc.want)
+		}
+	}
+}
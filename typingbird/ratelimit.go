@@ -0,0 +1,57 @@
+package typingbird
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sendLimiter staggers sends shared by several birds so that panes going
+// idle together do not all receive keystrokes at once. Each send reserves
+// the next free slot, at least interval after the previous one, and waits
+// for it. A nil limiter never waits.
+type sendLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newSendLimiter allows perMinute sends per minute, or returns nil when
+// perMinute is zero.
+func newSendLimiter(perMinute int) *sendLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &sendLimiter{interval: time.Minute / time.Duration(perMinute)}
+}
+
+// reserve claims the next slot and returns how long to wait for it.
+func (l *sendLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	slot := l.next
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next = slot.Add(l.interval)
+	return slot.Sub(now)
+}
+
+// wait blocks until the caller's slot comes up or ctx is done.
+func (l *sendLimiter) wait(ctx context.Context) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+	delay := l.reserve(time.Now())
+	if delay <= 0 {
+		return 0, nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return delay, ctx.Err()
+	case <-timer.C:
+		return delay, nil
+	}
+}
@@ -0,0 +1,45 @@
+package typingbird
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSendLimiterStaggersBursts(t *testing.T) {
+	l := newSendLimiter(60)
+	now := time.Unix(1000, 0)
+	var got []time.Duration
+	for i := 0; i < 3; i++ {
+		got = append(got, l.reserve(now))
+	}
+	want := []time.Duration{0, time.Second, 2 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("reserve(...) #%d = %s; want %s", i+1, got[i], want[i])
+		}
+	}
+	// After a quiet spell the next send goes out immediately.
+	if got := l.reserve(now.Add(time.Minute)); got != 0 {
+		t.Fatalf("reserve(...) after a quiet minute = %s; want 0", got)
+	}
+}
+
+func TestSendLimiterWait(t *testing.T) {
+	if l := newSendLimiter(0); l != nil {
+		t.Fatalf("newSendLimiter(0) = %v; want nil", l)
+	}
+	var unlimited *sendLimiter
+	if _, err := unlimited.wait(context.Background()); err != nil {
+		t.Fatalf("nil limiter wait() error: %v", err)
+	}
+	l := newSendLimiter(1)
+	if _, err := l.wait(context.Background()); err != nil {
+		t.Fatalf("first wait() error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.wait(ctx); err != context.Canceled {
+		t.Fatalf("wait() with a cancelled context = %v; want context.Canceled", err)
+	}
+}
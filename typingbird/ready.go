@@ -0,0 +1,126 @@
+package typingbird
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// readyPoll is how often --wait-port and --wait-http check again.
+	readyPoll = time.Second
+	// readyProbeTimeout bounds a single connection attempt or request.
+	readyProbeTimeout = 5 * time.Second
+)
+
+// validateWaitPort checks a --wait-port address: host:port, where host may
+// be left out for localhost.
+func validateWaitPort(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid wait-port %q: %w", addr, err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("invalid wait-port %q: port must be 1-65535", addr)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+func validateWaitHTTP(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid wait-http %q: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid wait-http %q: want an http:// or https:// URL", raw)
+	}
+	return nil
+}
+
+// gatesSend reports whether the readiness checks stand before this send:
+// the first one, or message --wait-before of the rotation.
+func (o options) gatesSend(index int, requested bool) bool {
+	if o.waitPort == "" && o.waitHTTP == "" {
+		return false
+	}
+	return o.waitBefore == 0 || !requested && index+1 == o.waitBefore
+}
+
+// waitReady blocks until the --wait-port address accepts connections and
+// --wait-http answers with a 2xx or 3xx status, giving up after
+// --wait-timeout when set.
+func (b *bird) waitReady(ctx context.Context, opts options) error {
+	if opts.waitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.waitTimeout)
+		defer cancel()
+	}
+	for _, check := range []struct {
+		what  string
+		probe func(context.Context) error
+	}{
+		{opts.waitPort, func(ctx context.Context) error { return probePort(ctx, opts.waitPort) }},
+		{opts.waitHTTP, func(ctx context.Context) error { return probeHTTP(ctx, opts.waitHTTP) }},
+	} {
+		if check.what == "" {
+			continue
+		}
+		logged := false
+		for {
+			err := check.probe(ctx)
+			if err == nil {
+				b.logf("%s is ready", check.what)
+				break
+			}
+			if !logged {
+				b.logf("waiting for %s to be ready: %v", check.what, err)
+				logged = true
+			} else {
+				b.debugf("%s not ready: %v", check.what, err)
+			}
+			if sleepWithContext(ctx, readyPoll) != nil {
+				if ctx.Err() == context.DeadlineExceeded {
+					return fmt.Errorf("%s not ready after %s: %v", check.what, opts.waitTimeout, err)
+				}
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+func probePort(ctx context.Context, addr string) error {
+	ctx, cancel := context.WithTimeout(ctx, readyProbeTimeout)
+	defer cancel()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func probeHTTP(ctx context.Context, raw string) error {
+	ctx, cancel := context.WithTimeout(ctx, readyProbeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, raw, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package typingbird
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateWaitPort(t *testing.T) {
+	cases := []struct {
+		addr    string
+		want    string
+		wantErr bool
+	}{
+		{"localhost:5432", "localhost:5432", false},
+		{":8080", "localhost:8080", false},
+		{"[::1]:80", "[::1]:80", false},
+		{"localhost", "", true},
+		{"localhost:0", "", true},
+		{"localhost:http", "", true},
+		{"localhost:70000", "", true},
+	}
+	for _, tc := range cases {
+		got, err := validateWaitPort(tc.addr)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Fatalf("validateWaitPort(%q) = %q, %v; want %q, error %v", tc.addr, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestValidateWaitHTTP(t *testing.T) {
+	cases := []struct {
+		raw     string
+		wantErr bool
+	}{
+		{"http://localhost:8080/health", false},
+		{"https://api.example.com/ready", false},
+		{"localhost:8080", true},
+		{"ftp://example.com/", true},
+		{"http://", true},
+	}
+	for _, tc := range cases {
+		if err := validateWaitHTTP(tc.raw); (err != nil) != tc.wantErr {
+			t.Fatalf("validateWaitHTTP(%q) = %v; want error %v", tc.raw, err, tc.wantErr)
+		}
+	}
+}
+
+func TestGatesSend(t *testing.T) {
+	cases := []struct {
+		opts      options
+		index     int
+		requested bool
+		want      bool
+	}{
+		{options{}, 0, false, false},
+		{options{waitPort: "localhost:1"}, 0, false, true},
+		{options{waitPort: "localhost:1"}, 2, true, true},
+		{options{waitHTTP: "http://x/", waitBefore: 3}, 0, false, false},
+		{options{waitHTTP: "http://x/", waitBefore: 3}, 2, false, true},
+		{options{waitHTTP: "http://x/", waitBefore: 3}, 2, true, false},
+	}
+	for _, tc := range cases {
+		if got := tc.opts.gatesSend(tc.index, tc.requested); got != tc.want {
+			t.Fatalf("gatesSend(%d, %v) with %+v = %v; want %v", tc.index, tc.requested, tc.opts, got, tc.want)
+		}
+	}
+}
+
+func TestProbePort(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	if err := probePort(context.Background(), addr); err != nil {
+		t.Fatalf("probePort(%q) = %v; want nil", addr, err)
+	}
+	ln.Close()
+	if err := probePort(context.Background(), addr); err == nil {
+		t.Fatalf("probePort(%q) after close = nil; want error", addr)
+	}
+}
+
+func TestProbeHTTP(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	if err := probeHTTP(context.Background(), srv.URL); err == nil {
+		t.Fatalf("probeHTTP(%q) with status 503 = nil; want error", srv.URL)
+	}
+	status = http.StatusOK
+	if err := probeHTTP(context.Background(), srv.URL); err != nil {
+		t.Fatalf("probeHTTP(%q) with status 200 = %v; want nil", srv.URL, err)
+	}
+}
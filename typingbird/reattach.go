@@ -0,0 +1,45 @@
+package typingbird
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// reattach waits up to opts.reattach for the bird's session to come back
+// after it, or the whole tmux server, went away, then re-resolves the target
+// pane. It reports false straight away without --reattach or while the
+// session still exists, so callers can fall back to --retarget.
+func (b *bird) reattach(ctx context.Context, opts options) (bool, error) {
+	if opts.reattach <= 0 || sessionExists(opts.session) == nil {
+		return false, nil
+	}
+	b.logf("session %q is gone; waiting up to %s for it to come back", opts.session, opts.reattach)
+	deadline := time.Now().Add(opts.reattach)
+	for sessionExists(opts.session) != nil {
+		if !time.Now().Before(deadline) {
+			return false, fmt.Errorf("session %q did not come back within %s", opts.session, opts.reattach)
+		}
+		if err := sleepWithContext(ctx, retargetPoll); err != nil {
+			return false, err
+		}
+	}
+	locate := b.locate
+	if locate == nil {
+		locate = func() (string, error) { return preferredSendPane(opts.session) }
+	}
+	pane, err := locate()
+	if err != nil {
+		return false, fmt.Errorf("resolving target pane for session %q: %w", opts.session, err)
+	}
+	// A restarted server has none of the hooks; setting them again is
+	// harmless when only the session was recreated.
+	if err := restoreTmuxHooks(); err != nil {
+		b.logf("WARNING: %v", err)
+	}
+	if err := b.switchTarget(pane); err != nil {
+		return false, err
+	}
+	b.logf("session %q is back; now sending to %q", opts.session, pane)
+	return true, nil
+}
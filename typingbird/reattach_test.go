@@ -0,0 +1,14 @@
+package typingbird
+
+import (
+	"context"
+	"testing"
+)
+
+func TestReattachDisabled(t *testing.T) {
+	b := &bird{target: "%1", events: &eventHub{}}
+	ok, err := b.reattach(context.Background(), options{session: "s"})
+	if ok || err != nil {
+		t.Fatalf("reattach(without --reattach) = %v, %v; want false, nil", ok, err)
+	}
+}
@@ -0,0 +1,81 @@
+package typingbird
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recordSettle is how long after a send the post-send snapshot is taken,
+// giving the pane time to echo the message.
+const recordSettle = time.Second
+
+// transcriptEntry is one send cycle in a --record transcript.
+type transcriptEntry struct {
+	Time    time.Time `json:"time"`
+	Bird    string    `json:"bird,omitempty"`
+	Session string    `json:"session"`
+	Target  string    `json:"target"`
+	// Index is the 1-based rotation position; zero for requested sends.
+	Index     int       `json:"index,omitempty"`
+	Total     int       `json:"total"`
+	Message   string    `json:"message"`
+	Before    string    `json:"before"`
+	After     string    `json:"after"`
+	AfterTime time.Time `json:"after_time"`
+}
+
+// transcript appends send cycles to a JSONL file.
+type transcript struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+func openTranscript(path string) (*transcript, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &transcript{f: f}, nil
+}
+
+// write appends e as one line. Message bodies follow --redact and resolved
+// secrets are masked in the snapshots.
+func (t *transcript) write(e transcriptEntry) error {
+	if redactingMessages() {
+		e.Message = logText(e.Message)
+	}
+	e.Before = secretRedactor.redact(e.Before)
+	e.After = secretRedactor.redact(e.After)
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, err = t.f.Write(append(line, '\n'))
+	return err
+}
+
+// recordCycle finishes e with the post-send snapshot and appends it to the
+// bird's transcript.
+func (b *bird) recordCycle(ctx context.Context, e transcriptEntry, requested bool) {
+	if requested {
+		e.Index = 0
+	}
+	e.Bird = b.name
+	_ = sleepWithContext(ctx, recordSettle)
+	after, _ := captureTarget(e.Target)
+	e.After = strings.TrimRight(string(after), "\n")
+	e.AfterTime = time.Now()
+	if err := b.record.write(e); err != nil {
+		b.logf("WARNING: writing transcript: %v", err)
+	}
+}
+
+func (t *transcript) Close() error {
+	return t.f.Close()
+}
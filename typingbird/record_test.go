@@ -0,0 +1,61 @@
+package typingbird
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscriptWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	tr, err := openTranscript(path)
+	if err != nil {
+		t.Fatalf("openTranscript(...) error: %v", err)
+	}
+	at := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	for i, msg := range []string{"continue", "run the tests"} {
+		if err := tr.write(transcriptEntry{Time: at, Session: "agent", Target: "%1", Index: i + 1, Total: 2, Message: msg, Before: "$ ", After: "$ " + msg}); err != nil {
+			t.Fatalf("write(...) error: %v", err)
+		}
+	}
+	if err := tr.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile(...) error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("transcript has %d lines; want 2", len(lines))
+	}
+	var e transcriptEntry
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil {
+		t.Fatalf("Unmarshal(%q) error: %v", lines[1], err)
+	}
+	if e.Index != 2 || e.Message != "run the tests" || e.After != "$ run the tests" || !e.Time.Equal(at) {
+		t.Fatalf("second entry = %+v; want index 2 with its message and snapshot", e)
+	}
+}
+
+func TestTranscriptWriteRedacts(t *testing.T) {
+	prev := messageRedaction
+	messageRedaction = redactLength
+	defer func() { messageRedaction = prev }()
+	path := filepath.Join(t.TempDir(), "transcript.jsonl")
+	tr, err := openTranscript(path)
+	if err != nil {
+		t.Fatalf("openTranscript(...) error: %v", err)
+	}
+	defer tr.Close()
+	if err := tr.write(transcriptEntry{Message: "continue"}); err != nil {
+		t.Fatalf("write(...) error: %v", err)
+	}
+	raw, _ := os.ReadFile(path)
+	if strings.Contains(string(raw), "continue") || !strings.Contains(string(raw), "[8 bytes]") {
+		t.Fatalf("transcript = %s; want the message redacted to its length", raw)
+	}
+}
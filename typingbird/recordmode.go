@@ -0,0 +1,292 @@
+package typingbird
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultRecordInterval is how often record looks at the cursor line. A
+// line is only seen once it has been on screen for a poll, so anything typed
+// faster than this after a prompt appears is taken for part of the prompt.
+const defaultRecordInterval = 100 * time.Millisecond
+
+// runRecordMode implements `typing-bird record <session>`: it watches the
+// lines typed into a pane and writes them out as a messages file or a config
+// with a messages list, ready to be replayed by a bird.
+func runRecordMode(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("record", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	duration := fs.Duration("duration", 0, "stop recording after this long (default: until Ctrl-C)")
+	interval := fs.Duration("interval", defaultRecordInterval, "how often the cursor line is captured")
+	format := fs.String("format", "messages", "output format: messages (a --messages-file) or config")
+	out := fs.String("out", "", "write to this file instead of stdout")
+	pane := fs.String("pane", "", "pane to watch (default: the pane a bird would type into)")
+	prompt := fs.String("prompt", "", "regexp matching the prompt at the start of a line (default: whatever the line held before typing began)")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird record [flags] <session>")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Records the lines you type into a pane and, when stopped, writes them out")
+		fmt.Fprintln(stderr, "once each in the order typed. Lines printed by programs and keys sent by")
+		fmt.Fprintln(stderr, "send-keys, including a bird's, are not recorded.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 1 || *interval <= 0 || *duration < 0 || (*format != "messages" && *format != "config") {
+		fs.Usage()
+		return 2
+	}
+	session := fs.Arg(0)
+	rec := &lineRecorder{}
+	if *prompt != "" {
+		re, err := regexp.Compile(*prompt)
+		if err != nil {
+			fmt.Fprintf(stderr, "ERROR: --prompt: %v\n", err)
+			return 2
+		}
+		rec.prompt = re
+	}
+	if err := lookTmux(); err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if err := sessionExists(session); err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	target := *pane
+	if target == "" {
+		var err error
+		if target, err = preferredSendPane(session); err != nil {
+			fmt.Fprintf(stderr, "ERROR: %v\n", err)
+			return 1
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+	fmt.Fprintf(stderr, "Recording lines typed into pane %s; press Ctrl-C to stop.\n", target)
+	lines, err := recordTypedLines(ctx, target, *interval, rec)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: recording pane %s: %v\n", target, err)
+		if len(lines) == 0 {
+			return 1
+		}
+	}
+	fmt.Fprintf(stderr, "Recorded %d line(s).\n", len(lines))
+
+	var text string
+	if *format == "config" {
+		text, err = recordedConfig(session, lines)
+	} else {
+		var skipped []string
+		text, skipped = recordedMessages(session, lines)
+		for _, line := range skipped {
+			fmt.Fprintf(stderr, "WARNING: %q would read as a comment in a messages file; use --format config to keep it\n", line)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if *out == "" {
+		_, _ = io.WriteString(stdout, text)
+		return 0
+	}
+	if err := os.WriteFile(*out, []byte(text), 0o600); err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// recordTypedLines polls target's cursor line until ctx is done and returns
+// the distinct lines typed, in the order first typed.
+func recordTypedLines(ctx context.Context, target string, interval time.Duration, rec *lineRecorder) ([]string, error) {
+	var lines []string
+	seen := map[string]bool{}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c, err := readCursorLine(target)
+		if err != nil {
+			return lines, err
+		}
+		lastKey, err := tmuxLastKeypress(target)
+		if err != nil {
+			return lines, err
+		}
+		if line, ok := rec.observe(c, lastKey); ok && !seen[line] {
+			seen[line] = true
+			lines = append(lines, line)
+			debugf("recorded %q", line)
+		}
+		select {
+		case <-ctx.Done():
+			return lines, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// cursorLine is the screen line holding target's cursor.
+type cursorLine struct {
+	// row counts from the top of the history, so it moves on when the
+	// screen scrolls even though the cursor stays on the bottom line.
+	row   int
+	width int
+	text  string
+	at    time.Time
+}
+
+func readCursorLine(target string) (cursorLine, error) {
+	out, err := tmuxOutput("display-message", "-p", "-t", target, "#{cursor_y} #{history_size} #{pane_width}")
+	if err != nil {
+		return cursorLine{}, err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 3 {
+		return cursorLine{}, fmt.Errorf("unexpected cursor position %q", strings.TrimSpace(string(out)))
+	}
+	var n [3]int
+	for i, f := range fields {
+		if n[i], err = strconv.Atoi(f); err != nil {
+			return cursorLine{}, fmt.Errorf("unexpected cursor position %q", strings.TrimSpace(string(out)))
+		}
+	}
+	y := strconv.Itoa(n[0])
+	text, err := tmuxOutput("capture-pane", "-p", "-t", target, "-S", y, "-E", y)
+	if err != nil {
+		return cursorLine{}, err
+	}
+	return cursorLine{row: n[0] + n[1], width: n[2], text: strings.TrimRight(string(text), "\n"), at: time.Now()}, nil
+}
+
+// lineRecorder follows the cursor line from poll to poll and reports a line
+// as typed when the cursor leaves it, provided it changed while the cursor
+// was on it and a client sent keys meanwhile. Output that scrolls past in one
+// go, or is printed without anyone typing, is never reported. A line that
+// fills the pane's width and continues on the next row is one line.
+//
+// Once the history limit is reached the row stops moving, so a line typed
+// then is only reported if the cursor reaches a different row.
+type lineRecorder struct {
+	prompt *regexp.Regexp
+
+	started bool
+	row     int
+	// first is the line as first seen, taken as its prompt without --prompt.
+	first   string
+	wrapped string
+	text    string
+	since   time.Time
+	changed bool
+}
+
+// observe takes the latest cursor line and the last time a client sent keys
+// and returns the line the cursor just left if it was typed.
+func (r *lineRecorder) observe(c cursorLine, lastKey time.Time) (string, bool) {
+	switch {
+	case !r.started:
+	case c.row == r.row:
+		if c.text != r.text {
+			r.text, r.changed = c.text, true
+		}
+		return "", false
+	case c.row == r.row+1 && len([]rune(r.text)) >= c.width:
+		r.wrapped += r.text
+		r.text, r.changed = c.text, true
+		r.row = c.row
+		return "", false
+	default:
+		line, ok := r.typed(lastKey)
+		r.start(c)
+		return line, ok
+	}
+	r.start(c)
+	return "", false
+}
+
+func (r *lineRecorder) start(c cursorLine) {
+	*r = lineRecorder{prompt: r.prompt, started: true, row: c.row, first: c.text, text: c.text, since: c.at}
+}
+
+// typed returns the current line without its prompt, if it was typed.
+func (r *lineRecorder) typed(lastKey time.Time) (string, bool) {
+	// client_activity has one-second resolution.
+	if !r.changed || lastKey.Before(r.since.Truncate(time.Second)) {
+		return "", false
+	}
+	line := r.wrapped + r.text
+	if r.prompt != nil {
+		loc := r.prompt.FindStringIndex(line)
+		if loc == nil || loc[0] != 0 {
+			return "", false
+		}
+		line = line[loc[1]:]
+	} else if rest, ok := strings.CutPrefix(line, r.first); ok {
+		line = rest
+	}
+	line = strings.TrimSpace(line)
+	return line, line != ""
+}
+
+// recordedMessages formats lines as a messages file. Lines the file would
+// read as comments or directives are left out and returned.
+func recordedMessages(session string, lines []string) (string, []string) {
+	var b strings.Builder
+	var skipped []string
+	fmt.Fprintf(&b, "# Recorded from session %s by typing-bird record.\n", session)
+	for _, line := range lines {
+		if strings.HasPrefix(line, "#") {
+			skipped = append(skipped, line)
+			continue
+		}
+		fmt.Fprintln(&b, line)
+	}
+	return b.String(), skipped
+}
+
+// recordedConfig formats lines as a config for session.
+func recordedConfig(session string, lines []string) (string, error) {
+	if len(lines) == 0 {
+		return "", errors.New("no lines were recorded")
+	}
+	cfg := struct {
+		Session  string   `yaml:"session"`
+		Messages []string `yaml:"messages"`
+	}{session, lines}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Recorded from session %s by typing-bird record.\n", session)
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,103 @@
+package typingbird
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLineRecorderObserve(t *testing.T) {
+	start := time.Unix(1000, 0)
+	typing := start.Add(2 * time.Second)
+	tests := []struct {
+		name    string
+		prompt  string
+		lines   []cursorLine
+		lastKey time.Time
+		want    []string
+	}{
+		{
+			name:    "typed then entered",
+			lines:   []cursorLine{{row: 3, text: "$ "}, {row: 3, text: "$ ls -l"}, {row: 5, text: "$ "}},
+			lastKey: typing,
+			want:    []string{"ls -l"},
+		},
+		{
+			name:    "output with nobody typing",
+			lines:   []cursorLine{{row: 3, text: "building"}, {row: 3, text: "building..."}, {row: 4, text: "done"}},
+			lastKey: start.Add(-time.Minute),
+		},
+		{
+			name:    "output that scrolls past at once",
+			lines:   []cursorLine{{row: 3, text: "$ "}, {row: 9, text: "$ "}, {row: 12, text: "$ "}},
+			lastKey: typing,
+		},
+		{
+			name:    "empty line entered",
+			lines:   []cursorLine{{row: 3, text: "$ "}, {row: 3, text: "$   "}, {row: 4, text: "$ "}},
+			lastKey: typing,
+		},
+		{
+			name:    "wrapped line",
+			lines:   []cursorLine{{row: 3, width: 8, text: "$ "}, {row: 3, width: 8, text: "$ echo h"}, {row: 4, width: 8, text: "ello"}, {row: 5, width: 8, text: "$ "}},
+			lastKey: typing,
+			want:    []string{"echo hello"},
+		},
+		{
+			name:    "prompt regexp",
+			prompt:  `^\w+> `,
+			lines:   []cursorLine{{row: 3, text: "db> sel"}, {row: 3, text: "db> select 1;"}, {row: 4, text: "db> "}},
+			lastKey: typing,
+			want:    []string{"select 1;"},
+		},
+		{
+			name:    "prompt regexp not matching",
+			prompt:  `^\w+> `,
+			lines:   []cursorLine{{row: 3, text: "pick:"}, {row: 3, text: "pick: 2"}, {row: 4, text: ""}},
+			lastKey: typing,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &lineRecorder{}
+			if tt.prompt != "" {
+				r.prompt = regexp.MustCompile(tt.prompt)
+			}
+			var got []string
+			for i, c := range tt.lines {
+				c.at = start.Add(time.Duration(i) * time.Second)
+				if c.width == 0 {
+					c.width = 80
+				}
+				if line, ok := r.observe(c, tt.lastKey); ok {
+					got = append(got, line)
+				}
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Fatalf("observe() recorded %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordedOutput(t *testing.T) {
+	text, skipped := recordedMessages("agent", []string{"continue", "#not a message", "run the tests"})
+	if want := "# Recorded from session agent by typing-bird record.\ncontinue\nrun the tests\n"; text != want {
+		t.Fatalf("recordedMessages() = %q; want %q", text, want)
+	}
+	if len(skipped) != 1 || skipped[0] != "#not a message" {
+		t.Fatalf("recordedMessages() skipped %q; want the comment-like line", skipped)
+	}
+
+	text, err := recordedConfig("agent", []string{"continue", "#kept"})
+	if err != nil {
+		t.Fatalf("recordedConfig() = %v", err)
+	}
+	if want := "# Recorded from session agent by typing-bird record.\nsession: agent\nmessages:\n  - continue\n  - '#kept'\n"; text != want {
+		t.Fatalf("recordedConfig() = %q; want %q", text, want)
+	}
+	if _, err := recordedConfig("agent", nil); err == nil {
+		t.Fatalf("recordedConfig() with no lines = nil error; want one")
+	}
+}
@@ -0,0 +1,63 @@
+package typingbird
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+const (
+	redactOff    = "off"
+	redactHash   = "hash"
+	redactLength = "length"
+)
+
+// messageRedaction controls how message bodies appear in log output.
+var messageRedaction = redactOff
+
+// redactMode is the --redact flag. It acts as a boolean flag so a bare
+// --redact selects hashes, while --redact=length selects lengths.
+type redactMode string
+
+func (m *redactMode) String() string {
+	if m == nil || *m == "" {
+		return redactOff
+	}
+	return string(*m)
+}
+
+func (m *redactMode) Set(value string) error {
+	switch value {
+	case "true", redactHash:
+		*m = redactHash
+	case "false", "", redactOff:
+		*m = redactOff
+	case redactLength:
+		*m = redactLength
+	default:
+		return fmt.Errorf("must be hash, length or off")
+	}
+	return nil
+}
+
+func (m *redactMode) IsBoolFlag() bool {
+	return true
+}
+
+func redactingMessages() bool {
+	return messageRedaction == redactHash || messageRedaction == redactLength
+}
+
+// logText formats s for a log line, quoting it as-is or replacing it with a
+// short hash or its length when redaction is on.
+func logText(s string) string {
+	switch messageRedaction {
+	case redactHash:
+		sum := sha256.Sum256([]byte(s))
+		return "[sha256:" + hex.EncodeToString(sum[:6]) + "]"
+	case redactLength:
+		return "[" + strconv.Itoa(len(s)) + " bytes]"
+	}
+	return strconv.Quote(s)
+}
@@ -0,0 +1,59 @@
+package typingbird
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRedactFlagForms(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: nil, want: redactOff},
+		{args: []string{"--redact"}, want: redactHash},
+		{args: []string{"--redact=length"}, want: redactLength},
+		{args: []string{"--redact=off"}, want: redactOff},
+	}
+	for _, tc := range tests {
+		var mode redactMode
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Var(&mode, "redact", "")
+		if err := fs.Parse(tc.args); err != nil {
+			t.Fatalf("Parse(%q) error = %v", tc.args, err)
+		}
+		if got := mode.String(); got != tc.want {
+			t.Fatalf("Parse(%q) redact = %q; want %q", tc.args, got, tc.want)
+		}
+	}
+	var mode redactMode
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&mode, "redact", "")
+	if err := fs.Parse([]string{"--redact=bogus"}); err == nil {
+		t.Fatalf("Parse(--redact=bogus) succeeded; want error")
+	}
+}
+
+func TestLogTextRedaction(t *testing.T) {
+	defer func(prev string) { messageRedaction = prev }(messageRedaction)
+
+	messageRedaction = redactOff
+	if got, want := logText("token abc"), `"token abc"`; got != want {
+		t.Fatalf("logText() off = %s; want %s", got, want)
+	}
+	messageRedaction = redactLength
+	if got, want := logText("token abc"), "[9 bytes]"; got != want {
+		t.Fatalf("logText() length = %s; want %s", got, want)
+	}
+	messageRedaction = redactHash
+	got := logText("token abc")
+	if !strings.HasPrefix(got, "[sha256:") || strings.Contains(got, "token") {
+		t.Fatalf("logText() hash = %s; want sha256 digest without the message", got)
+	}
+	if again := logText("token abc"); again != got {
+		t.Fatalf("logText() hash not stable: %s != %s", again, got)
+	}
+}
@@ -0,0 +1,89 @@
+package typingbird
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisQueueWait is how long each idle cycle blocks on an empty queue
+// before falling back to the rotation.
+const redisQueueWait = time.Second
+
+// redisQueue is a Redis list other services push messages onto; with
+// --redis-queue the bird takes the next one from its head on each idle
+// cycle.
+type redisQueue struct {
+	client *redis.Client
+	key    string
+	// name is the queue URL without its password, for logs.
+	name string
+}
+
+// parseRedisQueue splits redis://[user:pass@]host[:port]/db/key (rediss://
+// for TLS) into client options and the list key.
+func parseRedisQueue(raw string) (*redis.Options, string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid redis-queue %q: %v", raw, err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, "", fmt.Errorf("invalid redis-queue %q: scheme must be redis or rediss", u.Redacted())
+	}
+	db, key, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if db == "" || key == "" {
+		return nil, "", fmt.Errorf("invalid redis-queue %q: want %s://host/db/key", u.Redacted(), u.Scheme)
+	}
+	u.Path = "/" + db
+	opts, err := redis.ParseURL(u.String())
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid redis-queue %q: %v", u.Redacted(), err)
+	}
+	return opts, key, nil
+}
+
+func openRedisQueue(raw string) (*redisQueue, error) {
+	opts, key, err := parseRedisQueue(raw)
+	if err != nil {
+		return nil, err
+	}
+	// The blocking pop needs a read timeout longer than its own wait.
+	opts.ReadTimeout = redisQueueWait + 5*time.Second
+	u, _ := url.Parse(raw)
+	return &redisQueue{client: redis.NewClient(opts), key: key, name: u.Redacted()}, nil
+}
+
+// pop takes the next message, reporting false when none arrived within
+// redisQueueWait.
+func (q *redisQueue) pop(ctx context.Context) (string, bool, error) {
+	res, err := q.client.BLPop(ctx, redisQueueWait, q.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	// BLPOP answers with the key and the value.
+	return res[1], true, nil
+}
+
+// requeue puts a message that was taken but not sent back at the head of
+// the queue.
+func (q *redisQueue) requeue(message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return q.client.LPush(ctx, q.key, message).Err()
+}
+
+func (q *redisQueue) String() string {
+	return q.name
+}
+
+func (q *redisQueue) Close() error {
+	return q.client.Close()
+}
@@ -0,0 +1,36 @@
+package typingbird
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRedisQueue(t *testing.T) {
+	tests := []struct {
+		raw  string
+		addr string
+		db   int
+		key  string
+		err  string
+	}{
+		{raw: "redis://localhost/0/work", addr: "localhost:6379", key: "work"},
+		{raw: "redis://:secret@cache:6380/2/agent/inbox", addr: "cache:6380", db: 2, key: "agent/inbox"},
+		{raw: "rediss://cache/1/q", addr: "cache:6379", db: 1, key: "q"},
+		{raw: "redis://localhost/work", err: "want redis://host/db/key"},
+		{raw: "redis://localhost/x/work", err: "invalid redis-queue"},
+		{raw: "http://localhost/0/work", err: "scheme must be redis"},
+		{raw: "redis://:secret@localhost/0", err: "redis://:xxxxx@localhost/0"},
+	}
+	for _, tt := range tests {
+		opts, key, err := parseRedisQueue(tt.raw)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("parseRedisQueue(%q) error = %v; want %q", tt.raw, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || opts.Addr != tt.addr || opts.DB != tt.db || key != tt.key {
+			t.Fatalf("parseRedisQueue(%q) = %v, %v, %q, %v; want %v, %v, %q", tt.raw, opts.Addr, opts.DB, key, err, tt.addr, tt.db, tt.key)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package typingbird
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chzyer/readline"
+)
+
+// replHelp lists the REPL's commands; anything else is enqueued.
+const replHelp = `Lines are queued for the bird's next idle cycles, ahead of the rotation;
+start one with #priority:N to put it ahead of lower priorities and with //
+to queue a line starting with /.
+
+  /queue, /ls        list queued messages
+  /rm N              drop queued message N
+  /mv N M            move queued message N to position M
+  /send [text]       send text, or the next message, right away
+  /skip              skip the next message in rotation
+  /pause, /resume    pause or resume the bird
+  /reload            re-read the config and messages file
+  /status            show the bird's status
+  /dead              list recent dead letters
+  /watch             turn the live event feed off or on
+  /help              show this help
+  /quit              leave (or Ctrl-D)`
+
+// runREPL implements `typing-bird repl SESSION|SOCKET`, an interactive
+// prompt that manages a running bird's queue over its control socket.
+func runREPL(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	timeout := fs.Duration("timeout", 5*time.Second, "how long to wait for the bird to answer")
+	socketDir := fs.String("socket-dir", defaultSocketDir(), "directory of bird control sockets searched for the session")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird repl [--timeout 5s] [--socket-dir dir] session|control-socket")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Opens a prompt where each line typed is queued for the bird sending to the")
+		fmt.Fprintln(stderr, "session, with commands to list and reorder the queue, pause and resume, and a")
+		fmt.Fprintln(stderr, "live feed of the bird's events. Type /help at the prompt for the commands.")
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	c, err := dialREPL(*socketDir, fs.Arg(0), *timeout)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:          fs.Arg(0) + "> ",
+		HistoryFile:     replHistoryFile(),
+		AutoComplete:    replCompleter(),
+		InterruptPrompt: "^C",
+		EOFPrompt:       "/quit",
+		Stdout:          stdout,
+		Stderr:          stderr,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	defer rl.Close()
+	c.out = rl.Stdout()
+	fmt.Fprintf(c.out, "Connected to %s. Type /help for commands.\n", c)
+	c.watch(true)
+	defer c.watch(false)
+	for {
+		line, err := rl.Readline()
+		if errors.Is(err, readline.ErrInterrupt) {
+			continue
+		}
+		if err != nil {
+			return 0
+		}
+		if c.exec(line) {
+			return 0
+		}
+	}
+}
+
+// replHistoryFile keeps REPL history in the user cache directory, or
+// nowhere when there is none.
+func replHistoryFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	dir = filepath.Join(dir, "typing-bird")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "repl_history")
+}
+
+func replCompleter() *readline.PrefixCompleter {
+	var items []readline.PrefixCompleterInterface
+	for _, command := range []string{"/queue", "/ls", "/rm", "/mv", "/send", "/skip", "/pause", "/resume", "/reload", "/status", "/dead", "/watch", "/help", "/quit"} {
+		items = append(items, readline.PcItem(command))
+	}
+	return readline.NewPrefixCompleter(items...)
+}
+
+// replClient talks to one bird: the one behind socket, or its bird called
+// name in a fleet.
+type replClient struct {
+	socket  string
+	name    string
+	timeout time.Duration
+	out     io.Writer
+
+	mu      sync.Mutex
+	watcher net.Conn
+}
+
+// dialREPL finds the bird for target, a control socket path or a session
+// name looked up in socketDir.
+func dialREPL(socketDir, target string, timeout time.Duration) (*replClient, error) {
+	if info, err := os.Stat(target); err == nil && info.Mode()&os.ModeSocket != 0 {
+		return &replClient{socket: target, timeout: timeout}, nil
+	}
+	birds, err := findSessionBirds(socketDir, target, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if len(birds) == 0 {
+		return nil, fmt.Errorf("no bird is sending to session %q", target)
+	}
+	if len(birds) > 1 {
+		var names []string
+		for _, b := range birds {
+			names = append(names, (&replClient{socket: b.Socket, name: b.Name}).String())
+		}
+		return nil, fmt.Errorf("several birds send to session %q (%s); pass the control socket of one", target, strings.Join(names, ", "))
+	}
+	return &replClient{socket: birds[0].Socket, name: birds[0].Name, timeout: timeout}, nil
+}
+
+func (c *replClient) String() string {
+	if c.name != "" {
+		return fmt.Sprintf("bird %q at %s", c.name, c.socket)
+	}
+	return c.socket
+}
+
+// line builds a control command line, naming the bird for a fleet.
+func (c *replClient) line(command string, args []string) string {
+	fields := []string{command}
+	if c.name != "" {
+		fields = append(fields, c.name)
+	}
+	return strings.Join(append(fields, args...), " ")
+}
+
+// parseREPLLine turns a line typed at the prompt into a control command:
+// /commands map to theirs and anything else is enqueued.
+func parseREPLLine(line string) (command string, args []string, err error) {
+	line = strings.TrimSpace(line)
+	switch {
+	case line == "":
+		return "", nil, nil
+	case strings.HasPrefix(line, "//"):
+		return "enqueue", []string{line[1:]}, nil
+	case !strings.HasPrefix(line, "/"):
+		return "enqueue", []string{line}, nil
+	}
+	fields := strings.Fields(line[1:])
+	if len(fields) == 0 {
+		return "", nil, fmt.Errorf("unknown command %q; type /help", line)
+	}
+	name, args := fields[0], fields[1:]
+	switch name {
+	case "queue", "ls":
+		return "queue", nil, nil
+	case "rm":
+		return "unqueue", args, nil
+	case "mv":
+		return "move", args, nil
+	case "dead":
+		return "dead-letters", nil, nil
+	case "send", "skip", "pause", "resume", "reload", "status", "watch", "help", "quit":
+		return name, args, nil
+	case "exit", "q":
+		return "quit", nil, nil
+	}
+	return "", nil, fmt.Errorf("unknown command %q; type /help", line)
+}
+
+// exec runs one line typed at the prompt, reporting whether to quit.
+func (c *replClient) exec(line string) bool {
+	command, args, err := parseREPLLine(line)
+	switch {
+	case err != nil:
+		fmt.Fprintln(c.out, err)
+		return false
+	case command == "":
+		return false
+	case command == "quit":
+		return true
+	case command == "help":
+		fmt.Fprintln(c.out, replHelp)
+		return false
+	case command == "watch":
+		c.mu.Lock()
+		on := c.watcher == nil
+		c.mu.Unlock()
+		c.watch(on)
+		return false
+	}
+	reply, err := controlCall(c.socket, c.line(command, args), c.timeout)
+	if err != nil {
+		fmt.Fprintf(c.out, "ERROR: %v\n", err)
+		return false
+	}
+	if !reply.OK {
+		fmt.Fprintf(c.out, "ERROR: %s\n", reply.Error)
+		return false
+	}
+	switch command {
+	case "enqueue", "queue", "unqueue", "move":
+		var queued []string
+		if err := json.Unmarshal(reply.Data, &queued); err != nil {
+			fmt.Fprintf(c.out, "ERROR: decoding queue: %v\n", err)
+			return false
+		}
+		printQueue(c.out, queued)
+	case "status", "dead-letters":
+		fmt.Fprintln(c.out, string(reply.Data))
+	default:
+		fmt.Fprintln(c.out, "ok")
+	}
+	return false
+}
+
+func printQueue(w io.Writer, queued []string) {
+	if len(queued) == 0 {
+		fmt.Fprintln(w, "(queue empty)")
+		return
+	}
+	for i, message := range queued {
+		fmt.Fprintf(w, "%3d  %s\n", i+1, message)
+	}
+}
+
+// watch starts or stops printing the bird's events as they happen.
+func (c *replClient) watch(on bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !on {
+		if c.watcher != nil {
+			_ = c.watcher.Close()
+			c.watcher = nil
+		}
+		return
+	}
+	if c.watcher != nil {
+		return
+	}
+	conn, err := net.DialTimeout("unix", c.socket, c.timeout)
+	if err != nil {
+		fmt.Fprintf(c.out, "ERROR: watching events: %v\n", err)
+		return
+	}
+	if _, err := fmt.Fprintln(conn, c.line("watch", nil)); err != nil {
+		_ = conn.Close()
+		fmt.Fprintf(c.out, "ERROR: watching events: %v\n", err)
+		return
+	}
+	c.watcher = conn
+	go func() {
+		sc := bufio.NewScanner(conn)
+		sc.Buffer(make([]byte, 64*1024), maxAPIBody)
+		// The first line acknowledges the watch.
+		if sc.Scan() {
+			var reply controlReply
+			if json.Unmarshal(sc.Bytes(), &reply) == nil && !reply.OK {
+				fmt.Fprintf(c.out, "ERROR: watching events: %s\n", reply.Error)
+				return
+			}
+		}
+		for sc.Scan() {
+			var ev birdEvent
+			if json.Unmarshal(sc.Bytes(), &ev) == nil {
+				fmt.Fprintln(c.out, formatEvent(ev))
+			}
+		}
+	}()
+}
+
+// formatEvent renders an event as one line for the REPL.
+func formatEvent(ev birdEvent) string {
+	var b strings.Builder
+	b.WriteString(ev.Time.Local().Format("15:04:05"))
+	if ev.Bird != "" {
+		fmt.Fprintf(&b, " [%s]", ev.Bird)
+	}
+	b.WriteString(" " + ev.Type)
+	if ev.Index > 0 {
+		fmt.Fprintf(&b, " %d/%d", ev.Index, ev.Total)
+	}
+	if ev.Message != "" {
+		fmt.Fprintf(&b, ": %s", ev.Message)
+	}
+	if ev.Error != "" {
+		fmt.Fprintf(&b, ": %s", ev.Error)
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseREPLLine(t *testing.T) {
+	cases := []struct {
+		line    string
+		command string
+		args    []string
+		wantErr bool
+	}{
+		{"", "", nil, false},
+		{"  run the tests ", "enqueue", []string{"run the tests"}, false},
+		{"//etc/hosts", "enqueue", []string{"/etc/hosts"}, false},
+		{"/ls", "queue", nil, false},
+		{"/rm 2", "unqueue", []string{"2"}, false},
+		{"/mv 3 1", "move", []string{"3", "1"}, false},
+		{"/send now please", "send", []string{"now", "please"}, false},
+		{"/dead", "dead-letters", nil, false},
+		{"/q", "quit", nil, false},
+		{"/", "", nil, true},
+		{"/bogus", "", nil, true},
+	}
+	for _, tc := range cases {
+		command, args, err := parseREPLLine(tc.line)
+		if command != tc.command || !reflect.DeepEqual(args, tc.args) || (err != nil) != tc.wantErr {
+			t.Fatalf("parseREPLLine(%q) = %q, %q, %v; want %q, %q, error %v", tc.line, command, args, err, tc.command, tc.args, tc.wantErr)
+		}
+	}
+}
+
+func TestFormatEvent(t *testing.T) {
+	at := time.Date(2026, 1, 2, 15, 4, 5, 0, time.Local)
+	cases := []struct {
+		ev   birdEvent
+		want string
+	}{
+		{birdEvent{Time: at, Type: eventPaused}, "15:04:05 paused"},
+		{birdEvent{Time: at, Type: eventSent, Index: 2, Total: 3, Message: "hi"}, "15:04:05 sent 2/3: hi"},
+		{birdEvent{Time: at, Bird: "a", Type: eventSkipped, Index: 1, Total: 3, Error: "busy"}, "15:04:05 [a] skipped 1/3: busy"},
+	}
+	for _, tc := range cases {
+		if got := formatEvent(tc.ev); got != tc.want {
+			t.Fatalf("formatEvent(%+v) = %q; want %q", tc.ev, got, tc.want)
+		}
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the REPL's watcher goroutine.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestREPLClient(t *testing.T) {
+	b, err := newBird(options{timeout: time.Second, session: "s", messages: []string{"m"}}, "%1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "bird.sock")
+	server, err := startControlServer(path, b.controlCommand)
+	if err != nil {
+		t.Fatalf("startControlServer(...) error: %v", err)
+	}
+	defer server.Close()
+
+	var out syncBuffer
+	c := &replClient{socket: path, timeout: time.Second, out: &out}
+	for _, line := range []string{"run the tests", "lint", "/mv 2 1"} {
+		if c.exec(line) {
+			t.Fatalf("exec(%q) quit; want to carry on", line)
+		}
+	}
+	if got := b.inbox.list(); !reflect.DeepEqual(got, []string{"lint", "run the tests"}) {
+		t.Fatalf("inbox after the REPL = %q; want lint, run the tests", got)
+	}
+	if !strings.HasSuffix(out.String(), "  1  lint\n  2  run the tests\n") {
+		t.Fatalf("REPL printed %q; want the numbered queue", out.String())
+	}
+	if c.exec("/rm 5"); !strings.Contains(out.String(), "ERROR: no queued message 5") {
+		t.Fatalf("REPL printed %q; want the unqueue error", out.String())
+	}
+
+	c.watch(true)
+	defer c.watch(false)
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(out.String(), "paused") {
+		if time.Now().After(deadline) {
+			t.Fatalf("REPL printed %q; want the paused event", out.String())
+		}
+		b.publish(birdEvent{Type: eventPaused})
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !c.exec("/quit") {
+		t.Fatalf("exec(/quit) = false; want true")
+	}
+}
@@ -0,0 +1,146 @@
+package typingbird
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// retargetPoll is how often a bird with --retarget looks for a new pane.
+const retargetPoll = time.Second
+
+// parsePaneInfo parses tmux list-panes lines of pane id, active flag,
+// injected flag and title.
+func parsePaneInfo(raw string) []Pane {
+	var panes []Pane
+	for _, line := range strings.Split(raw, "\n") {
+		parts := strings.SplitN(line, "\t", 4)
+		if len(parts) < 4 || strings.TrimSpace(parts[0]) == "" {
+			continue
+		}
+		panes = append(panes, Pane{
+			ID:       strings.TrimSpace(parts[0]),
+			Active:   strings.TrimSpace(parts[1]) == "1",
+			Injected: strings.TrimSpace(parts[2]) == "1",
+			Title:    parts[3],
+		})
+	}
+	return panes
+}
+
+// pickRetargetPane chooses the pane to follow: a non-injected pane whose
+// title matches title, when given, preferring the active one.
+func pickRetargetPane(panes []Pane, title *regexp.Regexp) string {
+	first := ""
+	for _, p := range panes {
+		if p.Injected || (title != nil && !title.MatchString(p.Title)) {
+			continue
+		}
+		if p.Active {
+			return p.ID
+		}
+		if first == "" {
+			first = p.ID
+		}
+	}
+	return first
+}
+
+// followTarget waits for a pane to take over from a target that went away
+// and switches the bird to it. It gives up when the session is gone or ctx
+// ends.
+func (b *bird) followTarget(ctx context.Context) error {
+	opts := b.options()
+	b.mu.Lock()
+	old := b.target
+	b.mu.Unlock()
+	waiting := false
+	for {
+		if err := sessionExists(opts.session); err != nil {
+			return fmt.Errorf("session %q is gone", opts.session)
+		}
+		panes, err := sessionPanes(opts.session)
+		if err != nil {
+			debugf("listing panes of session %q: %v", opts.session, err)
+		}
+		if pane := pickRetargetPane(panes, opts.retargetTitle); pane != "" && pane != old {
+			if err := b.switchTarget(pane); err != nil {
+				return err
+			}
+			b.logf("target %q is gone; now sending to %q", old, pane)
+			return nil
+		}
+		if !waiting {
+			waiting = true
+			if opts.retargetTitle != nil {
+				b.logf("target %q is gone; waiting for a pane titled /%s/ in session %q", old, opts.retargetTitle, opts.session)
+			} else {
+				b.logf("target %q is gone; waiting for a new pane in session %q", old, opts.session)
+			}
+		}
+		if err := sleepWithContext(ctx, retargetPoll); err != nil {
+			return err
+		}
+	}
+}
+
+// switchTarget points the bird, and its pipe-pane monitor if any, at pane.
+func (b *bird) switchTarget(pane string) error {
+	if err := b.lockTarget(pane); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	monitor := b.monitor
+	b.mu.Unlock()
+	if monitor != nil {
+		_ = monitor.Close()
+		m, err := startPipeMonitor(pane, monitor.prompts != nil)
+		if err != nil {
+			return fmt.Errorf("attaching pipe-pane monitor to %q: %w", pane, err)
+		}
+		monitor = m
+	}
+	b.mu.Lock()
+	b.target = pane
+	b.monitor = monitor
+	b.lost = ""
+	b.mu.Unlock()
+	return nil
+}
+
+// closeMonitor detaches the pipe-pane monitor of whichever pane the bird
+// ended up on.
+func (b *bird) closeMonitor() {
+	b.mu.Lock()
+	m := b.monitor
+	b.monitor = nil
+	b.mu.Unlock()
+	if m != nil {
+		_ = m.Close()
+	}
+}
+
+// followActive moves the bird to the session's active non-injected pane when
+// that is no longer its target, for --follow-active. It reports whether the
+// target changed.
+func (b *bird) followActive(opts options) bool {
+	pane, err := preferredSendPane(opts.session)
+	if err != nil {
+		b.debugf("resolving the active pane of session %q: %v", opts.session, err)
+		return false
+	}
+	b.mu.Lock()
+	old := b.target
+	b.mu.Unlock()
+	if pane == old {
+		return false
+	}
+	if err := b.switchTarget(pane); err != nil {
+		b.logf("WARNING: cannot follow the active pane: %v", err)
+		return false
+	}
+	b.logf("active pane is now %q; sending there instead of %q", pane, old)
+	return true
+}
@@ -0,0 +1,32 @@
+package typingbird
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPickRetargetPane(t *testing.T) {
+	panes := parsePaneInfo("%1\t0\t\tbash\n%2\t0\t1\ttyping-bird\n%3\t1\t\tagent: claude\n%4\t0\t\tagent: aider\n")
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"", "%3"},
+		{"aider", "%4"},
+		{"^agent", "%3"},
+		{"typing-bird", ""},
+		{"nothing", ""},
+	}
+	for _, tt := range tests {
+		var re *regexp.Regexp
+		if tt.title != "" {
+			re = regexp.MustCompile(tt.title)
+		}
+		if got := pickRetargetPane(panes, re); got != tt.want {
+			t.Fatalf("pickRetargetPane(..., %q) = %q; want %q", tt.title, got, tt.want)
+		}
+	}
+	if got := pickRetargetPane(parsePaneInfo("%1\t0\t\tbash\n%2\t0\t\tvim\n"), nil); got != "%1" {
+		t.Fatalf("pickRetargetPane(no active pane) = %q; want the first pane", got)
+	}
+}
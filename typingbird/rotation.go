@@ -0,0 +1,165 @@
+package typingbird
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+const (
+	orderRoundRobin = "round-robin"
+	orderRandom     = "random"
+	orderShuffle    = "shuffle"
+)
+
+// rotation decides which message is sent on each idle cycle. The chosen
+// index stays pending until advance is called, so a skipped send retries the
+// same message.
+type rotation struct {
+	order   string
+	count   int
+	weights []float64
+	rng     *rand.Rand
+	next    int
+	pending int
+	perm    []int
+	pos     int
+	last    int
+	sent    int
+}
+
+func newRotation(order string, count int, weights []float64, rng *rand.Rand) *rotation {
+	return &rotation{
+		order:   order,
+		count:   count,
+		weights: weights,
+		rng:     rng,
+		pending: -1,
+		last:    -1,
+	}
+}
+
+// current returns the index of the message to send next.
+func (r *rotation) current() int {
+	if r.pending < 0 {
+		r.pending = r.pick()
+	}
+	return r.pending
+}
+
+// advance marks the current message as sent.
+func (r *rotation) advance() {
+	r.last = r.current()
+	r.next = (r.last + 1) % r.count
+	r.pending = -1
+	r.sent++
+	if r.order == orderShuffle {
+		r.pos++
+	}
+}
+
+// restore continues the rotation from index next with sent messages
+// already sent, as after importing a bird's state. A shuffle starts a new
+// pass.
+func (r *rotation) restore(next, sent int) {
+	r.next = next
+	r.sent = sent
+	r.pending = -1
+	r.perm = nil
+	r.pos = 0
+}
+
+// passComplete reports whether a full pass worth of messages has been sent,
+// which is where --no-loop stops.
+func (r *rotation) passComplete() bool {
+	return r.sent >= r.count
+}
+
+func (r *rotation) pick() int {
+	if r.count < 2 {
+		return r.next
+	}
+	switch r.order {
+	case orderRandom:
+		return r.weightedIndex()
+	case orderShuffle:
+		if r.pos >= len(r.perm) {
+			r.reshuffle()
+		}
+		return r.perm[r.pos]
+	}
+	return r.next
+}
+
+// reshuffle starts a new pass with a fresh permutation whose first entry
+// differs from the last message sent, so passes never repeat back to back.
+func (r *rotation) reshuffle() {
+	r.perm = r.rng.Perm(r.count)
+	r.pos = 0
+	if r.perm[0] == r.last {
+		j := 1 + r.rng.IntN(r.count-1)
+		r.perm[0], r.perm[j] = r.perm[j], r.perm[0]
+	}
+}
+
+func (r *rotation) weightedIndex() int {
+	if len(r.weights) != r.count {
+		return r.rng.IntN(r.count)
+	}
+	total := 0.0
+	for _, w := range r.weights {
+		total += w
+	}
+	x := r.rng.Float64() * total
+	for i, w := range r.weights {
+		if x < w {
+			return i
+		}
+		x -= w
+	}
+	// Floating point leftovers land on the last positively weighted entry.
+	for i := len(r.weights) - 1; i >= 0; i-- {
+		if r.weights[i] > 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+func validateOrder(order string) error {
+	switch order {
+	case orderRoundRobin, orderRandom, orderShuffle:
+		return nil
+	}
+	return fmt.Errorf("invalid order %q: must be %q, %q or %q", order, orderRoundRobin, orderRandom, orderShuffle)
+}
+
+// parseWeights parses a comma-separated list of non-negative weights, one per
+// message.
+func parseWeights(raw string, count int) ([]float64, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	parts := strings.Split(raw, ",")
+	if len(parts) != count {
+		return nil, fmt.Errorf("weights: got %d values for %d messages", len(parts), count)
+	}
+	weights := make([]float64, 0, len(parts))
+	total := 0.0
+	for _, part := range parts {
+		w, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("weights: invalid value %q: %w", part, err)
+		}
+		if w < 0 {
+			return nil, fmt.Errorf("weights: %q must be >= 0", part)
+		}
+		total += w
+		weights = append(weights, w)
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("weights: at least one weight must be greater than 0")
+	}
+	return weights, nil
+}
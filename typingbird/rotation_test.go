@@ -0,0 +1,95 @@
+package typingbird
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestRotationRoundRobin(t *testing.T) {
+	r := newRotation(orderRoundRobin, 3, nil, nil)
+	got := make([]int, 0, 5)
+	for i := 0; i < 5; i++ {
+		got = append(got, r.current())
+		r.advance()
+	}
+	want := []int{0, 1, 2, 0, 1}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round-robin indexes = %v; want %v", got, want)
+	}
+}
+
+func TestRotationCurrentIsStableUntilAdvance(t *testing.T) {
+	r := newRotation(orderRandom, 10, nil, rand.New(rand.NewPCG(1, 2)))
+	first := r.current()
+	for i := 0; i < 5; i++ {
+		if got := r.current(); got != first {
+			t.Fatalf("current() = %d after repeat; want %d", got, first)
+		}
+	}
+}
+
+func TestRotationRandomHonoursWeights(t *testing.T) {
+	r := newRotation(orderRandom, 3, []float64{0, 1, 3}, rand.New(rand.NewPCG(1, 2)))
+	counts := make([]int, 3)
+	for i := 0; i < 4000; i++ {
+		counts[r.current()]++
+		r.advance()
+	}
+	if counts[0] != 0 {
+		t.Fatalf("zero-weight message picked %d times", counts[0])
+	}
+	if counts[2] < 2*counts[1] {
+		t.Fatalf("weights 1:3 produced counts %v", counts)
+	}
+}
+
+func TestParseWeights(t *testing.T) {
+	got, err := parseWeights("1, 2.5,0", 3)
+	if err != nil {
+		t.Fatalf("parseWeights(...) error: %v", err)
+	}
+	if want := []float64{1, 2.5, 0}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseWeights(...) = %v; want %v", got, want)
+	}
+	for _, raw := range []string{"1,2", "1,-1,1", "0,0,0", "1,x,1"} {
+		if _, err := parseWeights(raw, 3); err == nil {
+			t.Fatalf("parseWeights(%q, 3) = nil error; want error", raw)
+		}
+	}
+}
+
+func TestRotationShuffleCoversEachPassWithoutImmediateRepeats(t *testing.T) {
+	const count = 4
+	r := newRotation(orderShuffle, count, nil, rand.New(rand.NewPCG(3, 4)))
+	prev := -1
+	for pass := 0; pass < 50; pass++ {
+		seen := make(map[int]bool, count)
+		for i := 0; i < count; i++ {
+			idx := r.current()
+			if idx == prev {
+				t.Fatalf("pass %d: index %d repeated immediately", pass, idx)
+			}
+			seen[idx] = true
+			prev = idx
+			r.advance()
+		}
+		if len(seen) != count {
+			t.Fatalf("pass %d covered %d distinct messages; want %d", pass, len(seen), count)
+		}
+	}
+}
+
+func TestRotationPassComplete(t *testing.T) {
+	r := newRotation(orderRoundRobin, 2, nil, nil)
+	for i := 0; i < 2; i++ {
+		if r.passComplete() {
+			t.Fatalf("passComplete() = true after %d sends; want false", i)
+		}
+		r.current()
+		r.advance()
+	}
+	if !r.passComplete() {
+		t.Fatalf("passComplete() = false after 2 sends; want true")
+	}
+}
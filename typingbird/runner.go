@@ -0,0 +1,179 @@
+package typingbird
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Runner runs a bird from a Go program: it sends messages to a tmux
+// session whenever it goes idle, as the typing-bird command does, until
+// its context ends.
+//
+//	r := typingbird.New(
+//		typingbird.WithSession("agent"),
+//		typingbird.WithTimeout(time.Minute),
+//		typingbird.WithMessages("continue", "run the tests"),
+//	)
+//	err := r.Run(ctx)
+//
+// A Runner logs to stderr like the command. Logging, tracing and
+// redaction settings are process-wide, so the last Runner started wins.
+type Runner struct {
+	session  string
+	messages []string
+	args     []string
+	detector IdleDetector
+}
+
+// Option configures a Runner.
+type Option func(*Runner)
+
+// New returns a Runner configured by opts.
+func New(opts ...Option) *Runner {
+	r := &Runner{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// WithSession sets the tmux session to send to. It may be left out when a
+// config passed through WithArgs names one.
+func WithSession(name string) Option {
+	return func(r *Runner) { r.session = name }
+}
+
+// WithTargetPane sends to the pane with this id, such as "%3", instead of
+// the session's preferred pane.
+func WithTargetPane(paneID string) Option {
+	return WithArgs("--target-pane", paneID)
+}
+
+// WithMessages sets the messages sent in turn. Without any, each send is
+// just Enter.
+func WithMessages(messages ...string) Option {
+	return func(r *Runner) { r.messages = append(r.messages, messages...) }
+}
+
+// WithTimeout sets how long the pane must stay unchanged to count as idle.
+func WithTimeout(d time.Duration) Option {
+	return WithArgs("--timeout", d.String())
+}
+
+// WithDelay sets the pause between keys when typing.
+func WithDelay(d time.Duration) Option {
+	return WithArgs("--delay", d.String())
+}
+
+// WithNoLoop stops the Runner once every message was sent, instead of
+// starting over.
+func WithNoLoop() Option {
+	return WithArgs("--no-loop")
+}
+
+// WithArgs passes command-line flags, for the settings without an Option
+// of their own: WithArgs("--busy-regex", "Compiling", "--config",
+// "bird.yaml").
+func WithArgs(args ...string) Option {
+	return func(r *Runner) { r.args = append(r.args, args...) }
+}
+
+// WithIdleDetector makes the Runner wait for d instead of watching the
+// screen; --timeout and --idle-mode then no longer apply.
+func WithIdleDetector(d IdleDetector) Option {
+	return func(r *Runner) { r.detector = d }
+}
+
+// ExitError is returned by Run when the bird stops with the non-zero exit
+// code the command would have exited with, e.g. because --fail-on matched.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("typing-bird stopped with exit code %d", e.Code)
+}
+
+// commandLine returns the Runner's settings as typing-bird arguments.
+func (r *Runner) commandLine() ([]string, error) {
+	args := append([]string(nil), r.args...)
+	if r.session == "" {
+		if len(r.messages) > 0 {
+			return nil, errors.New("typingbird: WithMessages needs WithSession")
+		}
+		return args, nil
+	}
+	return append(append(args, "--", r.session), r.messages...), nil
+}
+
+// Run sends until ctx ends, returning nil then. Settings are checked as on
+// the command line; --inject and session patterns are not supported since
+// they start birds of their own.
+func (r *Runner) Run(ctx context.Context) error {
+	args, err := r.commandLine()
+	if err != nil {
+		return err
+	}
+	cli := newCLIFlags()
+	fs := flag.NewFlagSet("typingbird", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	cli.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("typingbird: %w", err)
+	}
+	explicit := explicitFlags(fs)
+	resolveOptions := func() (options, error) {
+		resetFlags(fs, explicit)
+		config, loadedConfig, err := cli.loadConfig(fs, explicit)
+		if err != nil {
+			return options{}, err
+		}
+		opts, err := cli.options(fs.Args(), config, loadedConfig)
+		opts.detector = r.detector
+		return opts, err
+	}
+	opts, err := resolveOptions()
+	if err == errUsage {
+		return errors.New("typingbird: no session given")
+	}
+	if err != nil {
+		return fmt.Errorf("typingbird: %w", err)
+	}
+	if cli.inject || opts.sessions != nil {
+		return errors.New("typingbird: --inject and session patterns are not supported by Runner")
+	}
+	applyLogging(opts)
+	if opts.script != "" {
+		if _, err := loadScript(opts.script, nil); err != nil {
+			return fmt.Errorf("typingbird: %w", err)
+		}
+	}
+	if err := useBackend(opts.backend); err != nil {
+		return fmt.Errorf("typingbird: %w", err)
+	}
+	if usingTmux() {
+		if err := opts.tmuxFeatures(); err != nil {
+			return fmt.Errorf("typingbird: %w", err)
+		}
+	}
+	created, err := tmuxEnsureSession(opts.session, opts.create)
+	if err != nil {
+		return fmt.Errorf("typingbird: tmux session %q not available: %w", opts.session, err)
+	}
+	if created {
+		logf("created session %q running %q", opts.session, opts.create)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var interruptCode atomic.Int32
+	if code := runBird(ctx, cancel, opts, strings.TrimSpace(cli.targetPane), resolveOptions, &flock{}, &interruptCode, false); code != 0 {
+		return &ExitError{Code: code}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package typingbird
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunnerCommandLine(t *testing.T) {
+	r := New(
+		WithTimeout(time.Minute),
+		WithSession("-agent"),
+		WithMessages("continue", "run the tests"),
+		WithTargetPane("%3"),
+		WithNoLoop(),
+		WithArgs("--busy-regex", "Compiling"),
+	)
+	got, err := r.commandLine()
+	if err != nil {
+		t.Fatalf("commandLine() error: %v", err)
+	}
+	want := []string{"--timeout", "1m0s", "--target-pane", "%3", "--no-loop", "--busy-regex", "Compiling", "--", "-agent", "continue", "run the tests"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("commandLine() = %q; want %q", got, want)
+	}
+	if _, err := New(WithMessages("continue")).commandLine(); err == nil {
+		t.Fatalf("commandLine() with messages but no session = nil error; want error")
+	}
+}
+
+func TestRunnerRunRejectsBadSettings(t *testing.T) {
+	tests := []struct {
+		runner *Runner
+		want   string
+	}{
+		{runner: New(), want: "no session given"},
+		{runner: New(WithSession("s"), WithArgs("--inject")), want: "not supported by Runner"},
+		{runner: New(WithSession("agent-*")), want: "not supported by Runner"},
+		{runner: New(WithSession("s"), WithArgs("--no-such-flag")), want: "not defined"},
+		{runner: New(WithSession("s"), WithTimeout(-time.Second)), want: "timeout"},
+	}
+	for _, tt := range tests {
+		err := tt.runner.Run(context.Background())
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("Run() with %q error = %v; want %q", tt.runner.args, err, tt.want)
+		}
+	}
+}
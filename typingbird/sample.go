@@ -0,0 +1,56 @@
+package typingbird
+
+import (
+	"bytes"
+	"hash/fnv"
+)
+
+// paneSample is one capture of a pane as idle detection keeps it: a hash
+// and the length, so comparing big scrollback captures costs no more than
+// comparing two numbers. The text itself is only kept with --verbose, to
+// count how many bytes changed in the debug log.
+//
+// With --ignore-whitespace the capture is reduced to its words first, so a
+// TUI that redraws with different padding, blank lines or line breaks each
+// frame still compares equal.
+type paneSample struct {
+	sum  uint64
+	size int
+	text []byte
+}
+
+func newPaneSample(capture []byte, ignoreSpace bool) paneSample {
+	if ignoreSpace {
+		capture = squashWhitespace(capture)
+	}
+	h := fnv.New64a()
+	h.Write(capture)
+	s := paneSample{sum: h.Sum64(), size: len(capture)}
+	if verboseLogging {
+		s.text = capture
+	}
+	return s
+}
+
+func (s paneSample) equal(o paneSample) bool {
+	return s.sum == o.sum && s.size == o.size
+}
+
+// diff counts the bytes that differ between s and o. Without the text of
+// both it can only tell the change in length, or 1 for a change that kept
+// it.
+func (s paneSample) diff(o paneSample) int {
+	if s.equal(o) {
+		return 0
+	}
+	if s.text != nil && o.text != nil {
+		return byteDiffCount(s.text, o.text)
+	}
+	return max(abs(s.size-o.size), 1)
+}
+
+// squashWhitespace turns every run of whitespace in b, line breaks
+// included, into one space and drops it at either end.
+func squashWhitespace(b []byte) []byte {
+	return bytes.Join(bytes.Fields(b), []byte{' '})
+}
@@ -0,0 +1,52 @@
+package typingbird
+
+import "testing"
+
+func TestPaneSample(t *testing.T) {
+	defer func(v bool) { verboseLogging = v }(verboseLogging)
+	cases := []struct {
+		a, b    string
+		verbose bool
+		equal   bool
+		diff    int
+	}{
+		{"prompt $ ", "prompt $ ", false, true, 0},
+		{"prompt $ ", "prompt # ", false, false, 1},
+		{"prompt $ ", "prompt $ ls", false, false, 2},
+		{"abcdef", "abXdYf", true, false, 2},
+		{"abc", "abcdef", true, false, 3},
+	}
+	for _, tc := range cases {
+		verboseLogging = tc.verbose
+		a, b := newPaneSample([]byte(tc.a), false), newPaneSample([]byte(tc.b), false)
+		if (a.text != nil) != tc.verbose {
+			t.Fatalf("newPaneSample(%q) kept text = %v with verbose %v", tc.a, a.text != nil, tc.verbose)
+		}
+		if got := a.equal(b); got != tc.equal {
+			t.Fatalf("%q.equal(%q) = %v; want %v", tc.a, tc.b, got, tc.equal)
+		}
+		if got := a.diff(b); got != tc.diff {
+			t.Fatalf("%q.diff(%q) = %d; want %d", tc.a, tc.b, got, tc.diff)
+		}
+	}
+}
+
+func TestPaneSampleIgnoreWhitespace(t *testing.T) {
+	cases := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"prompt $   \n\n\n", "prompt $\n", true},
+		{"one\n\n\n\ntwo", "one\n\ntwo  ", true},
+		{"a long line that\nwraps", "a long line that wraps", true},
+		{"  indented\tcell |", "indented cell |", true},
+		{"prompt $", "prompt $ ls", false},
+		{"ab", "a b", false},
+	}
+	for _, tc := range cases {
+		a, b := newPaneSample([]byte(tc.a), true), newPaneSample([]byte(tc.b), true)
+		if got := a.equal(b); got != tc.equal {
+			t.Fatalf("%q.equal(%q) ignoring whitespace = %v; want %v", tc.a, tc.b, got, tc.equal)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package typingbird
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// activeSchedule limits sends to daily time windows on selected weekdays.
+// A window whose end is before its start runs past midnight; the part after
+// midnight belongs to the day the window started on. A nil schedule is
+// always active.
+type activeSchedule struct {
+	windows []timeWindow
+	// days is indexed by time.Weekday.
+	days [7]bool
+	loc  *time.Location
+}
+
+// timeWindow is a span of wall-clock time in minutes after midnight.
+type timeWindow struct {
+	start, end int
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseSchedule builds a schedule from --active-hours, --active-days and
+// --timezone. It returns nil when neither hours nor days are set.
+func parseSchedule(hours, days, timezone string) (*activeSchedule, error) {
+	loc := time.Local
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+	}
+	if strings.TrimSpace(hours) == "" && strings.TrimSpace(days) == "" {
+		if timezone != "" {
+			return nil, fmt.Errorf("--timezone requires --active-hours, --active-days or --until")
+		}
+		return nil, nil
+	}
+	s := &activeSchedule{loc: loc}
+	if strings.TrimSpace(hours) == "" {
+		s.windows = []timeWindow{{start: 0, end: 24 * 60}}
+	}
+	for _, raw := range strings.Split(hours, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(raw, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid active hours %q: want HH:MM-HH:MM", raw)
+		}
+		start, err := parseClock(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid active hours %q: %w", raw, err)
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid active hours %q: %w", raw, err)
+		}
+		if start == end {
+			return nil, fmt.Errorf("invalid active hours %q: window is empty", raw)
+		}
+		s.windows = append(s.windows, timeWindow{start: start, end: end})
+	}
+	if strings.TrimSpace(days) == "" {
+		for i := range s.days {
+			s.days[i] = true
+		}
+		return s, nil
+	}
+	for _, raw := range strings.Split(days, ",") {
+		raw = strings.ToLower(strings.TrimSpace(raw))
+		switch raw {
+		case "":
+			continue
+		case "weekdays":
+			raw = "mon-fri"
+		case "weekends":
+			raw = "sat-sun"
+		}
+		from, to, isRange := strings.Cut(raw, "-")
+		first, ok := weekdayNames[from]
+		if !ok {
+			return nil, fmt.Errorf("invalid active day %q: want mon, tue, ... sun", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[to]; !ok {
+				return nil, fmt.Errorf("invalid active day %q: want mon, tue, ... sun", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			s.days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	if s.days == [7]bool{} {
+		return nil, fmt.Errorf("invalid active days %q: no days selected", days)
+	}
+	return s, nil
+}
+
+// parseClock parses HH:MM into minutes after midnight; 24:00 is accepted as
+// the end of the day.
+func parseClock(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	var h, m int
+	if n, err := fmt.Sscanf(raw, "%d:%d", &h, &m); err != nil || n != 2 || len(raw) < 4 {
+		return 0, fmt.Errorf("invalid time %q: want HH:MM", raw)
+	}
+	if h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q: want HH:MM", raw)
+	}
+	return h*60 + m, nil
+}
+
+// active reports whether t falls inside the schedule.
+func (s *activeSchedule) active(t time.Time) bool {
+	if s == nil {
+		return true
+	}
+	t = t.In(s.loc)
+	minute := t.Hour()*60 + t.Minute()
+	today := s.days[t.Weekday()]
+	yesterday := s.days[(t.Weekday()+6)%7]
+	for _, w := range s.windows {
+		if w.start < w.end {
+			if today && minute >= w.start && minute < w.end {
+				return true
+			}
+			continue
+		}
+		if (today && minute >= w.start) || (yesterday && minute < w.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// next returns the start of the next active window after t, or the zero
+// time when the schedule never becomes active.
+func (s *activeSchedule) next(t time.Time) time.Time {
+	local := t.In(s.loc)
+	var best time.Time
+	for offset := 0; offset <= 7; offset++ {
+		day := local.AddDate(0, 0, offset)
+		for _, w := range s.windows {
+			start := time.Date(day.Year(), day.Month(), day.Day(), w.start/60, w.start%60, 0, 0, s.loc)
+			if !start.After(t) || !s.active(start) {
+				continue
+			}
+			if best.IsZero() || start.Before(best) {
+				best = start
+			}
+		}
+		if !best.IsZero() {
+			return best
+		}
+	}
+	return best
+}
+
+// stopClock is --until: a time of day at which the bird exits.
+type stopClock struct {
+	minute int
+	loc    *time.Location
+}
+
+// parseStopClock parses --until. It returns nil when raw is empty.
+func parseStopClock(raw, timezone string) (*stopClock, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	minute, err := parseClock(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid until: %w", err)
+	}
+	loc := time.Local
+	if timezone != "" {
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+	}
+	return &stopClock{minute: minute % (24 * 60), loc: loc}, nil
+}
+
+// after returns the first time after t showing the stop time on the clock.
+func (c *stopClock) after(t time.Time) time.Time {
+	local := t.In(c.loc)
+	for offset := 0; ; offset++ {
+		day := local.AddDate(0, 0, offset)
+		stop := time.Date(day.Year(), day.Month(), day.Day(), c.minute/60, c.minute%60, 0, 0, c.loc)
+		if stop.After(t) {
+			return stop
+		}
+	}
+}
+
+// deadline returns when a bird started at start has to stop under
+// --max-runtime and --until, whichever comes first, and which of them that
+// is. It returns the zero time when neither is set.
+func (o options) deadline(start time.Time) (time.Time, string) {
+	var at time.Time
+	var why string
+	if o.maxRuntime > 0 {
+		at, why = start.Add(o.maxRuntime), fmt.Sprintf("--max-runtime %s reached", o.maxRuntime)
+	}
+	if o.untilClock != nil {
+		if stop := o.untilClock.after(start); at.IsZero() || stop.Before(at) {
+			at, why = stop, fmt.Sprintf("--until %s reached", o.until)
+		}
+	}
+	return at, why
+}
@@ -0,0 +1,128 @@
+package typingbird
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleActive(t *testing.T) {
+	// 2024-01-05 is a Friday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		hours, days string
+		t           time.Time
+		want        bool
+	}{
+		{"09:00-18:00", "", at(5, 9, 0), true},
+		{"09:00-18:00", "", at(5, 17, 59), true},
+		{"09:00-18:00", "", at(5, 18, 0), false},
+		{"09:00-18:00", "", at(5, 8, 59), false},
+		{"09:00-12:00,13:00-17:00", "", at(5, 12, 30), false},
+		{"09:00-12:00,13:00-17:00", "", at(5, 13, 30), true},
+		{"22:00-06:00", "", at(5, 23, 0), true},
+		{"22:00-06:00", "", at(6, 5, 59), true},
+		{"22:00-06:00", "", at(6, 6, 0), false},
+		// Past midnight belongs to the day the window opened.
+		{"22:00-06:00", "mon-fri", at(6, 3, 0), true},
+		{"22:00-06:00", "mon-fri", at(6, 23, 0), false},
+		{"", "mon-fri", at(6, 12, 0), false},
+		{"", "weekends", at(6, 12, 0), true},
+		{"", "fri-mon", at(8, 12, 0), true},
+		{"", "fri-mon", at(9, 12, 0), false},
+		{"09:00-24:00", "sat,sun", at(7, 23, 59), true},
+	}
+	for _, tt := range tests {
+		s, err := parseSchedule(tt.hours, tt.days, "UTC")
+		if err != nil {
+			t.Fatalf("parseSchedule(%q, %q) error: %v", tt.hours, tt.days, err)
+		}
+		if got := s.active(tt.t); got != tt.want {
+			t.Fatalf("parseSchedule(%q, %q).active(%s) = %v; want %v", tt.hours, tt.days, tt.t.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	s, err := parseSchedule("09:00-18:00", "weekdays", "UTC")
+	if err != nil {
+		t.Fatalf("parseSchedule(...) error: %v", err)
+	}
+	friday := time.Date(2024, 1, 5, 19, 0, 0, 0, time.UTC)
+	if got, want := s.next(friday), time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("next(Friday evening) = %s; want %s", got, want)
+	}
+	early := time.Date(2024, 1, 8, 7, 30, 0, 0, time.UTC)
+	if got, want := s.next(early), time.Date(2024, 1, 8, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("next(Monday morning) = %s; want %s", got, want)
+	}
+
+	berlin, err := parseSchedule("09:00-18:00", "", "Europe/Berlin")
+	if err != nil {
+		t.Fatalf("parseSchedule(..., Europe/Berlin) error: %v", err)
+	}
+	// 08:30 UTC is 09:30 in Berlin in winter.
+	if !berlin.active(time.Date(2024, 1, 5, 8, 30, 0, 0, time.UTC)) {
+		t.Fatalf("Europe/Berlin schedule inactive at 09:30 local; want active")
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	if s, err := parseSchedule("", "", ""); s != nil || err != nil {
+		t.Fatalf("parseSchedule(empty) = %v, %v; want nil, nil", s, err)
+	}
+	tests := []struct{ hours, days, tz string }{
+		{"9-18", "", ""},
+		{"09:00", "", ""},
+		{"09:00-09:00", "", ""},
+		{"25:00-26:00", "", ""},
+		{"09:60-10:00", "", ""},
+		{"", "funday", ""},
+		{"", "mon-xyz", ""},
+		{"09:00-18:00", "", "Mars/Olympus"},
+		{"", "", "UTC"},
+	}
+	for _, tt := range tests {
+		if _, err := parseSchedule(tt.hours, tt.days, tt.tz); err == nil {
+			t.Fatalf("parseSchedule(%q, %q, %q) = nil error; want error", tt.hours, tt.days, tt.tz)
+		}
+	}
+}
+
+func TestOptionsDeadline(t *testing.T) {
+	utc := time.UTC
+	start := time.Date(2026, 3, 2, 16, 0, 0, 0, utc)
+	clock := func(raw string) *stopClock {
+		c, err := parseStopClock(raw, "UTC")
+		if err != nil {
+			t.Fatalf("parseStopClock(%q) error: %v", raw, err)
+		}
+		return c
+	}
+	tests := []struct {
+		name string
+		opts options
+		want time.Time
+		why  string
+	}{
+		{"none", options{}, time.Time{}, ""},
+		{"max runtime", options{maxRuntime: 8 * time.Hour}, start.Add(8 * time.Hour), "--max-runtime 8h0m0s reached"},
+		{"until later today", options{until: "17:30", untilClock: clock("17:30")}, time.Date(2026, 3, 2, 17, 30, 0, 0, utc), "--until 17:30 reached"},
+		{"until already passed", options{until: "09:00", untilClock: clock("09:00")}, time.Date(2026, 3, 3, 9, 0, 0, 0, utc), "--until 09:00 reached"},
+		{"until now means tomorrow", options{until: "16:00", untilClock: clock("16:00")}, time.Date(2026, 3, 3, 16, 0, 0, 0, utc), "--until 16:00 reached"},
+		{"midnight", options{until: "24:00", untilClock: clock("24:00")}, time.Date(2026, 3, 3, 0, 0, 0, 0, utc), "--until 24:00 reached"},
+		{"earliest wins", options{maxRuntime: time.Hour, until: "16:30", untilClock: clock("16:30")}, time.Date(2026, 3, 2, 16, 30, 0, 0, utc), "--until 16:30 reached"},
+	}
+	for _, tt := range tests {
+		got, why := tt.opts.deadline(start)
+		if !got.Equal(tt.want) || why != tt.why {
+			t.Fatalf("%s: deadline(...) = %v, %q; want %v, %q", tt.name, got, why, tt.want, tt.why)
+		}
+	}
+	for _, raw := range []string{"5pm", "17:75", "-1:00"} {
+		if _, err := parseStopClock(raw, ""); err == nil {
+			t.Fatalf("parseStopClock(%q) = nil error; want error", raw)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package typingbird
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+const (
+	scriptShouldSend  = "should_send"
+	scriptNextMessage = "next_message"
+)
+
+// birdScript wraps a user Starlark script whose callbacks can veto a send
+// and pick the message to type. Both callbacks receive the current pane
+// capture and a state dict; state["store"] persists between calls so
+// scripts can keep their own bookkeeping.
+type birdScript struct {
+	path        string
+	thread      *starlark.Thread
+	shouldSend  starlark.Callable
+	nextMessage starlark.Callable
+	store       *starlark.Dict
+}
+
+// scriptState is the loop state exposed to script callbacks.
+type scriptState struct {
+	session  string
+	target   string
+	index    int
+	messages []string
+	sends    int
+}
+
+func loadScript(path string, src any) (*birdScript, error) {
+	thread := &starlark.Thread{
+		Name: "typing-bird",
+		Print: func(_ *starlark.Thread, msg string) {
+			// Script output often echoes the capture, so it is redacted
+			// like a message body.
+			if redactingMessages() {
+				msg = logText(msg)
+			}
+			logf("script: %s", msg)
+		},
+	}
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, path, src, nil)
+	if err != nil {
+		return nil, fmt.Errorf("loading script %q: %w", path, err)
+	}
+	s := &birdScript{path: path, thread: thread, store: starlark.NewDict(0)}
+	for name, dst := range map[string]*starlark.Callable{
+		scriptShouldSend:  &s.shouldSend,
+		scriptNextMessage: &s.nextMessage,
+	} {
+		v, ok := globals[name]
+		if !ok {
+			continue
+		}
+		fn, ok := v.(starlark.Callable)
+		if !ok {
+			return nil, fmt.Errorf("script %q: %s must be a function, got %s", path, name, v.Type())
+		}
+		*dst = fn
+	}
+	if s.shouldSend == nil && s.nextMessage == nil {
+		return nil, fmt.Errorf("script %q defines neither %s nor %s", path, scriptShouldSend, scriptNextMessage)
+	}
+	return s, nil
+}
+
+// ShouldSend reports whether the script allows a send; scripts without a
+// should_send callback always allow it.
+func (s *birdScript) ShouldSend(capture string, st scriptState) (bool, error) {
+	if s.shouldSend == nil {
+		return true, nil
+	}
+	v, err := s.call(s.shouldSend, capture, st)
+	if err != nil {
+		return false, err
+	}
+	return bool(v.Truth()), nil
+}
+
+// NextMessage returns the script's chosen message; ok is false when the
+// script has no next_message callback or it returned None, in which case the
+// regular rotation applies.
+func (s *birdScript) NextMessage(capture string, st scriptState) (message string, ok bool, err error) {
+	if s.nextMessage == nil {
+		return "", false, nil
+	}
+	v, err := s.call(s.nextMessage, capture, st)
+	if err != nil {
+		return "", false, err
+	}
+	if v == starlark.None {
+		return "", false, nil
+	}
+	str, isString := starlark.AsString(v)
+	if !isString {
+		return "", false, fmt.Errorf("%s returned %s, want string or None", scriptNextMessage, v.Type())
+	}
+	return str, true, nil
+}
+
+func (s *birdScript) call(fn starlark.Callable, capture string, st scriptState) (starlark.Value, error) {
+	state, err := s.stateDict(st)
+	if err != nil {
+		return nil, err
+	}
+	v, err := starlark.Call(s.thread, fn, starlark.Tuple{starlark.String(capture), state}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("script %q: %w", s.path, err)
+	}
+	return v, nil
+}
+
+func (s *birdScript) stateDict(st scriptState) (*starlark.Dict, error) {
+	messages := make([]starlark.Value, 0, len(st.messages))
+	for _, m := range st.messages {
+		messages = append(messages, starlark.String(m))
+	}
+	d := starlark.NewDict(6)
+	for k, v := range map[string]starlark.Value{
+		"session":  starlark.String(st.session),
+		"target":   starlark.String(st.target),
+		"index":    starlark.MakeInt(st.index),
+		"messages": starlark.NewList(messages),
+		"sends":    starlark.MakeInt(st.sends),
+		"store":    s.store,
+	} {
+		if err := d.SetKey(starlark.String(k), v); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
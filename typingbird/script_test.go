@@ -0,0 +1,42 @@
+package typingbird
+
+import "testing"
+
+func TestBirdScriptCallbacks(t *testing.T) {
+	src := `
+def should_send(capture, state):
+    return "$ " in capture
+
+def next_message(capture, state):
+    state["store"]["calls"] = state["store"].get("calls", 0) + 1
+    if state["store"]["calls"] > 1:
+        return None
+    return "%s:%d" % (state["session"], state["index"])
+`
+	s, err := loadScript("test.star", src)
+	if err != nil {
+		t.Fatalf("loadScript(...) error: %v", err)
+	}
+	st := scriptState{session: "foobar", index: 1, messages: []string{"a", "b"}}
+
+	if ok, err := s.ShouldSend("building...", st); err != nil || ok {
+		t.Fatalf("ShouldSend(busy) = %t, %v; want false, nil", ok, err)
+	}
+	if ok, err := s.ShouldSend("done\n$ ", st); err != nil || !ok {
+		t.Fatalf("ShouldSend(prompt) = %t, %v; want true, nil", ok, err)
+	}
+
+	msg, ok, err := s.NextMessage("", st)
+	if err != nil || !ok || msg != "foobar:1" {
+		t.Fatalf("NextMessage(...) = %q, %t, %v; want %q, true, nil", msg, ok, err, "foobar:1")
+	}
+	if _, ok, err := s.NextMessage("", st); err != nil || ok {
+		t.Fatalf("NextMessage(...) second call ok = %t, %v; want false, nil", ok, err)
+	}
+}
+
+func TestLoadScriptRequiresCallback(t *testing.T) {
+	if _, err := loadScript("empty.star", "x = 1\n"); err == nil {
+		t.Fatalf("loadScript(...) = nil error; want error for script without callbacks")
+	}
+}
@@ -0,0 +1,225 @@
+package typingbird
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	secretSourceEnv      = "env"
+	secretSourceFile     = "file"
+	secretSourceKeychain = "keychain"
+
+	redactedText = "[REDACTED]"
+)
+
+// secretPattern matches {{secret "name"}} placeholders.
+var secretPattern = regexp.MustCompile(`\{\{\s*secret\s+"([^"]+)"\s*\}\}`)
+
+// secretSource is one place secrets are looked up, e.g. env:/path/to/.env,
+// file:/path/to/secrets.env.gpg or keychain:service-name.
+type secretSource struct {
+	kind  string
+	value string
+}
+
+// parseSecretSources parses a comma-separated list of sources, tried in
+// order. File paths are made absolute so the injected child finds them.
+func parseSecretSources(raw string) ([]secretSource, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var sources []secretSource
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		kind, value, ok := strings.Cut(part, ":")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid secrets source %q (want env:PATH, file:PATH or keychain:SERVICE)", part)
+		}
+		switch kind {
+		case secretSourceEnv, secretSourceFile:
+			path, err := absPath(value)
+			if err != nil {
+				return nil, err
+			}
+			value = path
+		case secretSourceKeychain:
+		default:
+			return nil, fmt.Errorf("invalid secrets source %q (want env:PATH, file:PATH or keychain:SERVICE)", part)
+		}
+		sources = append(sources, secretSource{kind: kind, value: value})
+	}
+	return sources, nil
+}
+
+func (s secretSource) String() string {
+	return s.kind + ":" + s.value
+}
+
+// lookup returns the named secret and whether this source has it. Sources
+// are re-read on every call so rotated secrets are picked up.
+func (s secretSource) lookup(name string) (string, bool, error) {
+	switch s.kind {
+	case secretSourceEnv:
+		data, err := os.ReadFile(s.value)
+		if err != nil {
+			return "", false, err
+		}
+		value, ok := parseEnvFile(data)[name]
+		return value, ok, nil
+	case secretSourceFile:
+		data, err := decryptFile(s.value)
+		if err != nil {
+			return "", false, err
+		}
+		value, ok := parseEnvFile(data)[name]
+		return value, ok, nil
+	case secretSourceKeychain:
+		return keychainLookup(s.value, name)
+	}
+	return "", false, fmt.Errorf("unknown secrets source %q", s.kind)
+}
+
+// expandSecrets replaces every placeholder in message. It returns the
+// resolved text and the secret values used so they can be redacted.
+func expandSecrets(message string, sources []secretSource) (string, []string, error) {
+	matches := secretPattern.FindAllStringSubmatchIndex(message, -1)
+	if len(matches) == 0 {
+		return message, nil, nil
+	}
+	if len(sources) == 0 {
+		return "", nil, fmt.Errorf("message references secrets but no --secrets source is configured")
+	}
+	var out strings.Builder
+	var values []string
+	last := 0
+	for _, m := range matches {
+		name := message[m[2]:m[3]]
+		value, err := lookupSecret(name, sources)
+		if err != nil {
+			return "", nil, err
+		}
+		out.WriteString(message[last:m[0]])
+		out.WriteString(value)
+		values = append(values, value)
+		last = m[1]
+	}
+	out.WriteString(message[last:])
+	return out.String(), values, nil
+}
+
+func lookupSecret(name string, sources []secretSource) (string, error) {
+	for _, source := range sources {
+		value, ok, err := source.lookup(name)
+		if err != nil {
+			return "", fmt.Errorf("secret %q: %s: %w", name, source, err)
+		}
+		if ok {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("secret %q not found", name)
+}
+
+// parseEnvFile reads NAME=value lines, allowing an export prefix, quoted
+// values, blank lines and # comments.
+func parseEnvFile(data []byte) map[string]string {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			}
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(name)] = value
+	}
+	return values
+}
+
+// keychainLookup reads a generic password from the macOS keychain or, on
+// other systems, the freedesktop secret service via secret-tool.
+func keychainLookup(service, name string) (string, bool, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", name, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "account", name)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return strings.TrimRight(string(out), "\r\n"), true, nil
+}
+
+// redactor scrubs known secret values from log output.
+type redactor struct {
+	mu       sync.Mutex
+	values   map[string]bool
+	replacer *strings.Replacer
+}
+
+var secretRedactor = &redactor{}
+
+func (r *redactor) add(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.values == nil {
+		r.values = map[string]bool{}
+	}
+	changed := false
+	for _, v := range values {
+		if v != "" && !r.values[v] {
+			r.values[v] = true
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	// Longest first so a secret containing another is fully replaced.
+	sorted := make([]string, 0, len(r.values))
+	for v := range r.values {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, v := range sorted {
+		pairs = append(pairs, v, redactedText)
+	}
+	r.replacer = strings.NewReplacer(pairs...)
+}
+
+func (r *redactor) redact(s string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.replacer == nil {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
@@ -0,0 +1,81 @@
+package typingbird
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandSecretsFromEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.env")
+	data := "# tokens\nexport API_TOKEN=\"abc 123\"\nOTHER='xyz'\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := parseSecretSources("env:" + path)
+	if err != nil {
+		t.Fatalf("parseSecretSources() error = %v", err)
+	}
+	got, values, err := expandSecrets(`login {{secret "API_TOKEN"}} {{ secret "OTHER" }}`, sources)
+	if err != nil {
+		t.Fatalf("expandSecrets() error = %v", err)
+	}
+	if want := "login abc 123 xyz"; got != want {
+		t.Fatalf("expandSecrets() = %q; want %q", got, want)
+	}
+	if len(values) != 2 {
+		t.Fatalf("expandSecrets() values = %q; want 2 values", values)
+	}
+	if _, _, err := expandSecrets(`{{secret "MISSING"}}`, sources); err == nil {
+		t.Fatalf("expandSecrets() with missing secret succeeded; want error")
+	}
+}
+
+func TestExpandSecretsWithoutPlaceholders(t *testing.T) {
+	got, values, err := expandSecrets("plain {{ not a secret }}", nil)
+	if err != nil || got != "plain {{ not a secret }}" || values != nil {
+		t.Fatalf("expandSecrets() = %q, %q, %v; want message unchanged", got, values, err)
+	}
+	if _, _, err := expandSecrets(`{{secret "x"}}`, nil); err == nil {
+		t.Fatalf("expandSecrets() without sources succeeded; want error")
+	}
+}
+
+func TestParseSecretSources(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{raw: "", want: 0},
+		{raw: "keychain:typing-bird", want: 1},
+		{raw: "env:/tmp/a.env, file:/tmp/b.env.gpg", want: 2},
+		{raw: "vault:foo", wantErr: true},
+		{raw: "env:", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := parseSecretSources(tc.raw)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("parseSecretSources(%q) error = %v; wantErr %v", tc.raw, err, tc.wantErr)
+		}
+		if len(got) != tc.want {
+			t.Fatalf("parseSecretSources(%q) = %v; want %d sources", tc.raw, got, tc.want)
+		}
+	}
+}
+
+func TestRedactorReplacesSecretValues(t *testing.T) {
+	r := &redactor{}
+	if got := r.redact("token abc"); got != "token abc" {
+		t.Fatalf("redact() before add = %q; want unchanged", got)
+	}
+	r.add("abc", "abcdef", "")
+	got := r.redact("sent abcdef and abc")
+	if strings.Contains(got, "abc") {
+		t.Fatalf("redact() = %q; still contains a secret", got)
+	}
+	if want := "sent [REDACTED] and [REDACTED]"; got != want {
+		t.Fatalf("redact() = %q; want %q", got, want)
+	}
+}
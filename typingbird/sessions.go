@@ -0,0 +1,288 @@
+package typingbird
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// sessionPattern matches session names for a session argument written as a
+// glob (agent-*) or as a regexp between slashes (/^agent-[0-9]+$/).
+type sessionPattern struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+// parseSessionPattern returns nil for an argument that names one session.
+func parseSessionPattern(arg string) (*sessionPattern, error) {
+	if len(arg) > 2 && strings.HasPrefix(arg, "/") && strings.HasSuffix(arg, "/") {
+		re, err := regexp.Compile(arg[1 : len(arg)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid session regexp %s: %w", arg, err)
+		}
+		return &sessionPattern{re: re}, nil
+	}
+	if !strings.ContainsAny(arg, "*?[") {
+		return nil, nil
+	}
+	if _, err := path.Match(arg, ""); err != nil {
+		return nil, fmt.Errorf("invalid session glob %q: %w", arg, err)
+	}
+	return &sessionPattern{glob: arg}, nil
+}
+
+func (p *sessionPattern) match(name string) bool {
+	if p.re != nil {
+		return p.re.MatchString(name)
+	}
+	ok, _ := path.Match(p.glob, name)
+	return ok
+}
+
+func (p *sessionPattern) String() string {
+	if p.re != nil {
+		return "/" + p.re.String() + "/"
+	}
+	return p.glob
+}
+
+func tmuxSessionNames() ([]string, error) {
+	out, err := tmuxOutput("list-sessions", "-F", "#{session_name}")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" {
+			names = append(names, line)
+		}
+	}
+	return names, nil
+}
+
+// remove drops a bird whose run ended, so the APIs stop listing it.
+func (f *flock) remove(b *bird) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, other := range f.birds {
+		if other == b {
+			f.birds = append(f.birds[:i:i], f.birds[i+1:]...)
+			return
+		}
+	}
+}
+
+// runSessionPattern runs one bird, named after its session, for every
+// session matching opts.sessions, like a fleet. With --rescan it keeps
+// looking for new matches and runs until interrupted; otherwise it ends once
+// the birds found at start have. A bird whose session closed ends quietly.
+func runSessionPattern(opts options, resolveOptions func() (options, error)) int {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interruptCode := atomic.Int32{}
+	f := &flock{hub: &eventHub{}}
+	stopInterrupts := installInterruptHandlers(cancel, buildLaunchCommand(os.Args), opts.interrupts(f), &interruptCode)
+	defer stopInterrupts()
+
+	var record *transcript
+	if opts.record != "" {
+		t, err := openTranscript(opts.record)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: opening transcript: %v\n", err)
+			return 1
+		}
+		defer t.Close()
+		record = t
+	}
+	var audit *auditLog
+	if opts.auditLog != "" {
+		a, err := openAuditLog(opts.auditLog, opts.auditMaxSize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: opening audit log: %v\n", err)
+			return 1
+		}
+		defer a.Close()
+		audit = a
+	}
+	var dead *deadLetterFile
+	if opts.deadLetter != "" {
+		dl, err := openDeadLetterFile(opts.deadLetter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: opening dead-letter file: %v\n", err)
+			return 1
+		}
+		defer dl.Close()
+		dead = dl
+	}
+	// Birds share the queue, each taking the next message when it goes
+	// idle.
+	var queue *redisQueue
+	if opts.redisQueue != "" {
+		q, err := openRedisQueue(opts.redisQueue)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 2
+		}
+		defer q.Close()
+		queue = q
+	}
+
+	type birdExit struct {
+		b    *bird
+		code int
+	}
+	exits := make(chan birdExit)
+	running := map[string]bool{}
+	var stops []func()
+	defer func() {
+		for _, stop := range stops {
+			stop()
+		}
+	}()
+	start := func(session string) {
+		bopts := opts
+		bopts.session = session
+		target, err := preferredSendPane(session)
+		if err != nil {
+			logf("WARNING: skipping session %q: failed resolving target pane: %v", session, err)
+			return
+		}
+		b, err := newBird(bopts, target)
+		if err != nil {
+			logf("WARNING: skipping session %q: %v", session, err)
+			return
+		}
+		b.name = session
+		b.events = f.hub
+		b.record = record
+		b.audit = audit
+		b.dead = dead
+		if queue != nil {
+			b.queues = append(b.queues, queue)
+		}
+		b.resolve = func() (options, error) {
+			o, err := resolveOptions()
+			o.session = session
+			return o, err
+		}
+		if opts.idleMode == idleModePipe || opts.idleMode == idleModePrompt {
+			m, err := startPipeMonitor(target, opts.idleMode == idleModePrompt)
+			if err != nil {
+				logf("WARNING: skipping session %q: failed attaching pipe-pane monitor to target %q: %v", session, target, err)
+				return
+			}
+			b.monitor = m
+		}
+		if opts.tmuxControl && tmuxControl.Load() == nil {
+			// One client serves every bird; targets are always explicit.
+			if stop, err := useControlClient(session); err != nil {
+				logf("WARNING: not using a tmux control-mode client: %v", err)
+			} else {
+				stops = append(stops, stop)
+			}
+		}
+		running[session] = true
+		f.add(b)
+		b.logf(
+			"session=%q send-target=%q idle-mode=%s idle-timeout=%s delay=%s messages=%d",
+			session, target, opts.idleMode, opts.timeout, opts.delay, len(opts.messages),
+		)
+		go func() {
+			code := b.run(ctx, &interruptCode)
+			b.closeMonitor()
+			exits <- birdExit{b: b, code: code}
+		}()
+	}
+	scan := func() {
+		names, err := tmuxSessionNames()
+		if err != nil {
+			debugf("listing sessions: %v", err)
+			return
+		}
+		for _, name := range names {
+			if opts.sessions.match(name) && !running[name] {
+				start(name)
+			}
+		}
+	}
+
+	stopReload := installReloadHandler(cancel, &interruptCode, func() {
+		if err := f.reload(""); err != nil {
+			logf("WARNING: reload failed: %v", err)
+		}
+	})
+	defer stopReload()
+	defer installUserSignals(f)()
+	if opts.watch {
+		watcher, err := startFileWatcher(opts.messagesFile, watchDebounce, func() {
+			logf("messages file %q changed; reloading", opts.messagesFile)
+			if err := f.reload(""); err != nil {
+				logf("WARNING: reload failed: %v", err)
+			}
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: failed watching messages file %q: %v\n", opts.messagesFile, err)
+			return 1
+		}
+		defer watcher.Close()
+	}
+	stopServers, code := startFlockServers(f, flockServers{
+		controlSocket: opts.controlSocket,
+		grpcListen:    opts.grpcListen,
+		apiListen:     opts.apiListen,
+		apiTokenFile:  opts.apiTokenFile,
+		mqttBroker:    opts.mqttBroker,
+		mqttTopic:     opts.mqttTopic,
+		natsURL:       opts.natsURL,
+		natsSubject:   opts.natsSubject,
+		tmuxHooks:     opts.tmuxHooks,
+	})
+	if code != 0 {
+		return code
+	}
+	defer stopServers()
+
+	scan()
+	if len(running) == 0 {
+		if opts.rescan == 0 {
+			fmt.Fprintf(os.Stderr, "ERROR: no tmux session matches %s\n", opts.sessions)
+			return 1
+		}
+		logf("no tmux session matches %s yet; checking every %s", opts.sessions, opts.rescan)
+	}
+
+	var codes []int
+	var rescan <-chan time.Time
+	if opts.rescan > 0 {
+		ticker := time.NewTicker(opts.rescan)
+		defer ticker.Stop()
+		rescan = ticker.C
+	}
+	done := ctx.Done()
+	for len(running) > 0 || (rescan != nil && done != nil) {
+		select {
+		case exit := <-exits:
+			session := exit.b.options().session
+			delete(running, session)
+			f.remove(exit.b)
+			if exit.code != 0 && ctx.Err() == nil && sessionExists(session) != nil {
+				exit.b.logf("session %q closed", session)
+				continue
+			}
+			if ctx.Err() == nil {
+				exit.b.logf("finished with exit code %d", exit.code)
+			}
+			codes = append(codes, exit.code)
+		case <-rescan:
+			scan()
+		case <-done:
+			done, rescan = nil, nil
+		}
+	}
+	return firstNonZero(codes)
+}
@@ -0,0 +1,70 @@
+package typingbird
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSessionPattern(t *testing.T) {
+	tests := []struct {
+		arg     string
+		pattern bool
+		matches []string
+	}{
+		{arg: "agent-1", pattern: false},
+		{arg: "agent-*", pattern: true, matches: []string{"agent-1", "agent-12"}},
+		{arg: "agent-?", pattern: true, matches: []string{"agent-1"}},
+		{arg: "/^agent-[0-9]$/", pattern: true, matches: []string{"agent-1"}},
+		{arg: "/ent-1/", pattern: true, matches: []string{"agent-1", "agent-12"}},
+	}
+	names := []string{"agent-1", "agent-12", "main"}
+	for _, tt := range tests {
+		p, err := parseSessionPattern(tt.arg)
+		if err != nil {
+			t.Fatalf("parseSessionPattern(%q) error: %v", tt.arg, err)
+		}
+		if (p != nil) != tt.pattern {
+			t.Fatalf("parseSessionPattern(%q) = %v; want pattern %v", tt.arg, p, tt.pattern)
+		}
+		if p == nil {
+			continue
+		}
+		var got []string
+		for _, name := range names {
+			if p.match(name) {
+				got = append(got, name)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.matches) {
+			t.Fatalf("%s matches %q; want %q", p, got, tt.matches)
+		}
+	}
+}
+
+func TestSessionPatternErrors(t *testing.T) {
+	for arg, want := range map[string]string{
+		"agent-[":  "invalid session glob",
+		"/agent(/": "invalid session regexp",
+	} {
+		_, err := parseSessionPattern(arg)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("parseSessionPattern(%q) error = %v; want %q", arg, err, want)
+		}
+	}
+}
+
+func TestFlockRemove(t *testing.T) {
+	a, b := &bird{name: "a"}, &bird{name: "b"}
+	f := &flock{}
+	f.add(a)
+	f.add(b)
+	before := f.list()
+	f.remove(a)
+	if got := f.names(); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Fatalf("names() after remove = %q; want [b]", got)
+	}
+	if before[0] != a {
+		t.Fatalf("remove changed an earlier list()")
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package typingbird
+
+import "os"
+
+// Platforms without SIGUSR1 and SIGUSR2 get no user signals.
+var sendNowSignal, skipSignal os.Signal
@@ -0,0 +1,14 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"os"
+	"syscall"
+)
+
+// sendNowSignal and skipSignal drive installUserSignals.
+var (
+	sendNowSignal os.Signal = syscall.SIGUSR1
+	skipSignal    os.Signal = syscall.SIGUSR2
+)
@@ -0,0 +1,231 @@
+package typingbird
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func defaultSnippetsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "typing-bird", "snippets.yaml")
+}
+
+// loadSnippets reads a YAML mapping of snippet names to message bodies:
+//
+//	review: please review the diff above
+//	ship: |
+//	  run the tests
+//	  then open a PR
+func loadSnippets(path string) (map[string]string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading snippets file: %w", err)
+	}
+	snippets, err := parseSnippets(raw)
+	if err != nil {
+		return nil, fmt.Errorf("snippets file %q: %w", path, err)
+	}
+	return snippets, nil
+}
+
+func parseSnippets(raw []byte) (map[string]string, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	snippets := make(map[string]string, len(doc))
+	for name, value := range doc {
+		switch value.(type) {
+		case nil, []any, map[string]any:
+			return nil, fmt.Errorf("snippet %q must be a string", name)
+		}
+		// Block scalars keep their final newline, which would press Enter
+		// twice.
+		snippets[name] = strings.TrimSuffix(fmt.Sprint(value), "\n")
+	}
+	return snippets, nil
+}
+
+// runSend implements `typing-bird send <session> <snippet>`: the snippet is
+// typed into the session's preferred pane straight away, without waiting
+// for idle.
+func runSend(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("send", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	path := fs.String("snippets", defaultSnippetsPath(), "YAML file mapping snippet names to messages")
+	delay := fs.Duration("delay", defaultDelay, "key input delay")
+	list := fs.Bool("list", false, "list the snippet names and exit")
+	fromClipboard := fs.Bool("from-clipboard", false, "type the clipboard's contents instead of a snippet")
+	denyRegex := fs.String("deny-regex", "", "refuse messages matching this regexp, on top of the built-in list of destructive commands")
+	allowRegex := fs.String("allow-regex", "", "only send messages matching this regexp")
+	force := fs.Bool("force", false, "send the message even when it matches a deny pattern or misses --allow-regex")
+	passwordRegex := fs.String("password-regex", "", "also treat prompts matching this regexp as password prompts")
+	passwordGuard := fs.Bool("password-guard", true, "refuse to send while the pane is waiting at a password, passphrase or PIN prompt")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird send [--snippets FILE] [--delay D] <session|pane-id> <snippet>")
+		fmt.Fprintln(stderr, "       typing-bird send [--delay D] --from-clipboard <session|pane-id>")
+		fmt.Fprintln(stderr, "       typing-bird send [--snippets FILE] --list")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Types a named snippet (or the clipboard) into the session's pane now,")
+		fmt.Fprintln(stderr, "followed by Enter. Like the bird's own sends, it refuses messages matching")
+		fmt.Fprintln(stderr, "a deny pattern and panes waiting at a password prompt.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	want := 2
+	switch {
+	case *list && *fromClipboard:
+		fs.Usage()
+		return 2
+	case *list:
+		want = 0
+	case *fromClipboard:
+		want = 1
+	}
+	if fs.NArg() != want {
+		fs.Usage()
+		return 2
+	}
+	if *delay < 0 {
+		fmt.Fprintf(stderr, "ERROR: delay must be >= 0 (got %s)\n", *delay)
+		return 2
+	}
+	guard, err := newSendGuard(*denyRegex, *allowRegex, *passwordRegex, *force, !*passwordGuard)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 2
+	}
+	if *fromClipboard {
+		return sendTo(fs.Arg(0), "clipboard", clipboardMessage, *delay, guard, stderr)
+	}
+	snippets, err := loadSnippets(*path)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	if *list {
+		names := make([]string, 0, len(snippets))
+		for name := range snippets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(stdout, name)
+		}
+		return 0
+	}
+	name := fs.Arg(1)
+	message, ok := snippets[name]
+	if !ok {
+		fmt.Fprintf(stderr, "ERROR: no snippet %q in %s\n", name, *path)
+		return 1
+	}
+	return sendTo(fs.Arg(0), fmt.Sprintf("snippet %q", name), message, *delay, guard, stderr)
+}
+
+// sendGuard holds the checks `typing-bird send` makes before typing, the
+// same ones the bird makes before every send.
+type sendGuard struct {
+	policy        messagePolicy
+	passwordRegex *regexp.Regexp
+	allowPassword bool
+}
+
+func newSendGuard(deny, allow, passwordRegex string, force, allowPassword bool) (sendGuard, error) {
+	g := sendGuard{policy: messagePolicy{force: force}, allowPassword: allowPassword}
+	var err error
+	if deny != "" {
+		if g.policy.deny, err = regexp.Compile(deny); err != nil {
+			return sendGuard{}, fmt.Errorf("invalid deny-regex: %w", err)
+		}
+	}
+	if allow != "" {
+		if g.policy.allow, err = regexp.Compile(allow); err != nil {
+			return sendGuard{}, fmt.Errorf("invalid allow-regex: %w", err)
+		}
+	}
+	if passwordRegex != "" {
+		if g.passwordRegex, err = regexp.Compile(passwordRegex); err != nil {
+			return sendGuard{}, fmt.Errorf("invalid password-regex: %w", err)
+		}
+	}
+	return g, nil
+}
+
+// check screens text and refuses a target waiting at a password prompt.
+func (g sendGuard) check(target, text string) error {
+	if err := g.policy.screen(text); err != nil {
+		return err
+	}
+	if g.allowPassword {
+		return nil
+	}
+	if screen, err := captureTarget(target); err == nil {
+		if prompt, ok := passwordPrompt(screen, g.passwordRegex); ok {
+			return fmt.Errorf("the pane is waiting at a password prompt (%q; use --password-guard=false to send anyway)", prompt)
+		}
+	}
+	return nil
+}
+
+// sendTo types message into session (or pane id) for `typing-bird send`;
+// what names the message in errors.
+func sendTo(session, what, message string, delay time.Duration, guard sendGuard, stderr io.Writer) int {
+	if err := lookTmux(); err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	text, err := expandClipboard(message)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	target := session
+	if !strings.HasPrefix(session, "%") {
+		if target, err = preferredSendPane(session); err != nil {
+			fmt.Fprintf(stderr, "ERROR: failed resolving target pane for session %q: %v\n", session, err)
+			return 1
+		}
+	}
+	path, paste := fileMessagePath(text)
+	if paste {
+		if text, err = readFileMessage(path); err != nil {
+			fmt.Fprintf(stderr, "ERROR: sending %s to %s: %v\n", what, target, err)
+			return 1
+		}
+	} else {
+		text, paste = pastedText(text, false)
+	}
+	if err := guard.check(target, text); err != nil {
+		fmt.Fprintf(stderr, "ERROR: not sending %s to %s: %v\n", what, target, err)
+		return 1
+	}
+	if paste {
+		err = tmuxPaste(target, text, chunking{})
+	} else {
+		err = tmuxSendMessage(target, text, delay, chunking{}, typing{})
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: sending %s to %s: %v\n", what, target, err)
+		return 1
+	}
+	return 0
+}
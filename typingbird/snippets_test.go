@@ -0,0 +1,54 @@
+package typingbird
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSnippets(t *testing.T) {
+	raw := "review: please review the diff above\nship: |\n  run the tests\n  then open a PR\ncount: 3\n"
+	got, err := parseSnippets([]byte(raw))
+	if err != nil {
+		t.Fatalf("parseSnippets(...) error: %v", err)
+	}
+	want := map[string]string{
+		"review": "please review the diff above",
+		"ship":   "run the tests\nthen open a PR",
+		"count":  "3",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseSnippets(...) = %q; want %q", got, want)
+	}
+}
+
+func TestParseSnippetsErrors(t *testing.T) {
+	tests := map[string]string{
+		"a: [x, y]\n": `snippet "a" must be a string`,
+		"a:\n":        `snippet "a" must be a string`,
+		"- a\n":       "cannot unmarshal",
+	}
+	for raw, want := range tests {
+		if _, err := parseSnippets([]byte(raw)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("parseSnippets(%q) error = %v; want it to mention %q", raw, err, want)
+		}
+	}
+}
+
+func TestNewSendGuard(t *testing.T) {
+	for _, args := range [][3]string{{"(", "", ""}, {"", "(", ""}, {"", "", "("}} {
+		if _, err := newSendGuard(args[0], args[1], args[2], false, false); err == nil {
+			t.Fatalf("newSendGuard(%q) error = nil; want an invalid regexp", args)
+		}
+	}
+	g, err := newSendGuard("", "^git ", "", false, true)
+	if err != nil {
+		t.Fatalf("newSendGuard(...) error: %v", err)
+	}
+	if err := g.check("%0", "git status"); err != nil {
+		t.Fatalf("check(git status) = %v; want nil", err)
+	}
+	if err := g.check("%0", "ls"); err == nil {
+		t.Fatalf("check(ls) = nil; want --allow-regex to refuse it")
+	}
+}
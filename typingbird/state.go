@@ -0,0 +1,194 @@
+package typingbird
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// birdState is a bird's runtime state as `state export` writes it and
+// `state import` reads it back, e.g. on another host or to seed a test.
+// Name, Session and Exported describe where it came from and are not
+// restored.
+type birdState struct {
+	Name     string    `json:"name,omitempty"`
+	Session  string    `json:"session,omitempty"`
+	Exported time.Time `json:"exported"`
+	// Messages is the length of the rotation Next points into.
+	Messages int `json:"messages"`
+	// Next is the 1-based message sent next, as in the status report.
+	Next int `json:"next"`
+	// Sent counts the sends of the rotation towards --no-loop's pass.
+	Sent   int  `json:"sent"`
+	Sends  int  `json:"sends"`
+	Paused bool `json:"paused"`
+	// FlowState names the current state of a config-defined flow.
+	FlowState   string   `json:"flow_state,omitempty"`
+	DeadLetters int      `json:"dead_letters,omitempty"`
+	Queue       []string `json:"queue"`
+}
+
+// stateCommand handles `state export` and `state import JSON`.
+func (b *bird) stateCommand(args []string) controlResponse {
+	if len(args) == 0 {
+		return controlError(fmt.Errorf("usage: state export|import JSON"))
+	}
+	switch args[0] {
+	case "export":
+		return controlOK(b.exportState())
+	case "import":
+		var st birdState
+		if err := json.Unmarshal([]byte(strings.Join(args[1:], " ")), &st); err != nil {
+			return controlError(fmt.Errorf("decoding state: %w", err))
+		}
+		if err := b.importState(st); err != nil {
+			return controlError(err)
+		}
+		return controlOK(b.status())
+	}
+	return controlError(fmt.Errorf("unknown state command %q: must be export or import", args[0]))
+}
+
+func (b *bird) exportState() birdState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st := birdState{
+		Name:        b.name,
+		Session:     b.opts.session,
+		Exported:    time.Now(),
+		Messages:    len(b.opts.messages),
+		Next:        b.rot.next + 1,
+		Sent:        b.rot.sent,
+		Sends:       b.sends,
+		Paused:      b.paused,
+		DeadLetters: b.deadCount,
+		Queue:       b.inbox.list(),
+	}
+	if b.opts.flow != nil {
+		st.Next = b.flowStep + 1
+		st.FlowState = b.opts.flow.steps[b.flowStep].name
+	}
+	return st
+}
+
+// importState replaces the bird's state with st. The rotation position
+// only carries over to a rotation of the same length, and a flow state to
+// a flow that has it.
+func (b *bird) importState(st birdState) error {
+	if st.Sends < 0 || st.Sent < 0 || st.DeadLetters < 0 {
+		return fmt.Errorf("invalid state: counters must be >= 0")
+	}
+	b.mu.Lock()
+	flowStep := -1
+	switch {
+	case b.opts.flow != nil:
+		if st.FlowState == "" {
+			b.mu.Unlock()
+			return fmt.Errorf("invalid state: the bird runs a flow but the state has no flow_state")
+		}
+		if flowStep = b.opts.flow.index(st.FlowState); flowStep < 0 {
+			b.mu.Unlock()
+			return fmt.Errorf("invalid state: the bird's flow has no state %q", st.FlowState)
+		}
+	case st.Messages != len(b.opts.messages):
+		b.mu.Unlock()
+		return fmt.Errorf("invalid state: it is for %d messages but the bird has %d", st.Messages, len(b.opts.messages))
+	case st.Next < 1 || st.Next > st.Messages:
+		b.mu.Unlock()
+		return fmt.Errorf("invalid state: next %d is not between 1 and %d", st.Next, st.Messages)
+	}
+	if flowStep >= 0 {
+		b.flowStep = flowStep
+	} else {
+		b.rot.restore(st.Next-1, st.Sent)
+	}
+	b.sends = st.Sends
+	b.deadCount = st.DeadLetters
+	b.inbox.replace(st.Queue)
+	b.interruptWait()
+	b.mu.Unlock()
+	b.logf("state imported: next=%d sends=%d queued=%d", st.Next, st.Sends, len(st.Queue))
+	if st.Paused {
+		b.pause()
+	} else {
+		b.resume()
+	}
+	return nil
+}
+
+// saveStateFile writes the bird's state to path, --state-file, so the
+// next start picks up where this one stopped, queued messages included.
+func (b *bird) saveStateFile(path string) error {
+	st := b.exportState()
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+	b.logf("state saved to %s: next=%d queued=%d", path, st.Next, len(st.Queue))
+	return nil
+}
+
+// loadStateFile restores the state saved at the last shutdown; a missing
+// file is a first start.
+func (b *bird) loadStateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("loading state: %w", err)
+	}
+	var st birdState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("loading state from %s: %w", path, err)
+	}
+	if err := b.importState(st); err != nil {
+		return fmt.Errorf("loading state from %s: %w", path, err)
+	}
+	return nil
+}
+
+// stateImportArgs inlines the state given to `ctl ... state import` from
+// a file, or standard input for -, unless it is JSON already. Control
+// commands are split on whitespace, so spaces are escaped; outside strings
+// compacted JSON has none.
+func stateImportArgs(command []string, stdin io.Reader) ([]string, error) {
+	i := slices.Index(command, "import")
+	if len(command) == 0 || command[0] != "state" || i < 0 || i != len(command)-2 {
+		return command, nil
+	}
+	source := command[i+1]
+	var data []byte
+	var err error
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(source), "{"):
+		data = []byte(source)
+	case source == "-":
+		data, err = io.ReadAll(stdin)
+	default:
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading state: %w", err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return nil, fmt.Errorf("decoding state: %w", err)
+	}
+	inline := strings.ReplaceAll(compact.String(), " ", `\u0020`)
+	return append(slices.Clone(command[:i+1]), inline), nil
+}
@@ -0,0 +1,148 @@
+package typingbird
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	opts := options{timeout: 1, session: "s", messages: []string{"a", "b", "c"}}
+	from, err := newBird(opts, "%1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from.rot.advance()
+	from.sends = 4
+	from.inbox.push("run  the tests")
+	from.inbox.push("#priority:1 lint")
+	from.pause()
+
+	resp := from.controlCommand("state", []string{"export"})
+	if !resp.OK {
+		t.Fatalf("state export: %s", resp.Error)
+	}
+	data, err := json.Marshal(resp.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := stateImportArgs([]string{"state", "import", string(data)}, nil)
+	if err != nil {
+		t.Fatalf("stateImportArgs() = %v", err)
+	}
+	if strings.ContainsAny(args[2], " \t\n") {
+		t.Fatalf("stateImportArgs() = %q; want no whitespace", args[2])
+	}
+
+	to, err := newBird(opts, "%2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := to.controlCommand(args[0], strings.Fields(strings.Join(args[1:], " "))); !resp.OK {
+		t.Fatalf("state import: %s", resp.Error)
+	}
+	st := to.status()
+	if st.Next != 2 || st.Sends != 4 || !st.Paused || st.Queued != 2 {
+		t.Fatalf("status after import = next %d, sends %d, paused %v, queued %d; want 2, 4, true, 2", st.Next, st.Sends, st.Paused, st.Queued)
+	}
+	if got, want := to.inbox.list(), []string{"#priority:1 lint", "run  the tests"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("queue after import = %q; want %q", got, want)
+	}
+	if got := to.rot.current(); got != 1 {
+		t.Fatalf("rot.current() after import = %d; want 1", got)
+	}
+}
+
+func TestImportStateRejects(t *testing.T) {
+	b, err := newBird(options{timeout: 1, session: "s", messages: []string{"a", "b"}}, "%1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []birdState{
+		{Messages: 3, Next: 1},
+		{Messages: 2, Next: 0},
+		{Messages: 2, Next: 3},
+		{Messages: 2, Next: 1, Sends: -1},
+		{Messages: 2, Next: 1, FlowState: "x"},
+	}
+	for _, st := range cases[:4] {
+		if err := b.importState(st); err == nil {
+			t.Fatalf("importState(%+v) = nil; want error", st)
+		}
+	}
+	// A flow state is ignored without a flow.
+	if err := b.importState(cases[4]); err != nil {
+		t.Fatalf("importState(%+v) = %v; want nil", cases[4], err)
+	}
+	if resp := b.controlCommand("state", []string{"import", "{"}); resp.OK {
+		t.Fatalf("state import of bad JSON succeeded; want error")
+	}
+	if resp := b.controlCommand("state", []string{"bogus"}); resp.OK {
+		t.Fatalf("state bogus succeeded; want error")
+	}
+}
+
+func TestStateImportArgs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{\n  \"next\": 2,\n  \"queue\": [\"a b\"]\n}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"next":2,"queue":["a\u0020b"]}`
+	cases := []struct {
+		command []string
+		stdin   string
+		want    []string
+	}{
+		{[]string{"status"}, "", []string{"status"}},
+		{[]string{"state", "export"}, "", []string{"state", "export"}},
+		{[]string{"state", "import", path}, "", []string{"state", "import", want}},
+		{[]string{"state", "b1", "import", "-"}, `{"next": 2, "queue": ["a b"]}`, []string{"state", "b1", "import", want}},
+	}
+	for _, tc := range cases {
+		got, err := stateImportArgs(tc.command, strings.NewReader(tc.stdin))
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Fatalf("stateImportArgs(%q) = %q, %v; want %q", tc.command, got, err, tc.want)
+		}
+	}
+	if _, err := stateImportArgs([]string{"state", "import", "-"}, strings.NewReader("nope")); err == nil {
+		t.Fatalf("stateImportArgs(bad JSON) = nil error; want error")
+	}
+}
+
+func TestStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	opts := options{timeout: 1, session: "s", messages: []string{"a", "b", "c"}, stateFile: path}
+	b, err := newBird(opts, "%1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.loadStateFile(path); err != nil {
+		t.Fatalf("loadStateFile() without a file = %v; want nil", err)
+	}
+	b.rot.advance()
+	b.inbox.push("left over")
+	if err := b.saveStateFile(path); err != nil {
+		t.Fatalf("saveStateFile() = %v", err)
+	}
+
+	restarted, err := newBird(opts, "%1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.loadStateFile(path); err != nil {
+		t.Fatalf("loadStateFile() = %v", err)
+	}
+	if st := restarted.exportState(); st.Next != 2 || !reflect.DeepEqual(st.Queue, []string{"left over"}) {
+		t.Fatalf("state after restart: next=%d queue=%q; want next=2 queue=[left over]", st.Next, st.Queue)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.loadStateFile(path); err == nil {
+		t.Fatalf("loadStateFile() with a broken file = nil; want an error")
+	}
+}
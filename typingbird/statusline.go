@@ -0,0 +1,201 @@
+package typingbird
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// statusLineOption is the session user option birds keep up to date, for
+	// use as #{@typing_bird_next_send} in status-right.
+	statusLineOption   = "@typing_bird_next_send"
+	statusLineInterval = time.Second
+)
+
+// statusLine renders st as a short string for the tmux status bar.
+func statusLine(st birdStatus, now time.Time) string {
+	var text string
+	switch st.State {
+	case statePaused:
+		text = "paused"
+	case stateQueued:
+		text = "queued"
+	case stateSending:
+		text = "sending"
+	case stateExpecting:
+		text = "awaiting reply"
+	case stateInactive:
+		text = "inactive"
+		if st.ActiveAt != nil {
+			text += " until " + st.ActiveAt.Local().Format("Mon 15:04")
+		}
+	case stateWaiting:
+		if st.WaitStarted == nil {
+			return ""
+		}
+		left := time.Duration(st.TimeoutMS)*time.Millisecond - now.Sub(*st.WaitStarted)
+		if left > 0 {
+			text = "next " + formatCountdown(left)
+		} else {
+			// Output kept coming after the window, so the send waits for
+			// the pane to go quiet.
+			text = "next when quiet"
+		}
+	default:
+		return ""
+	}
+	if st.Name != "" {
+		text = st.Name + ": " + text
+	}
+	return text
+}
+
+// formatCountdown renders d as m:ss, or h:mm:ss from an hour up.
+func formatCountdown(d time.Duration) string {
+	s := int((d + time.Second - 1) / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// startIndicators keeps statusLineOption on the bird's session, the title
+// of the pane the bird runs in when that isn't its target (an injected
+// pane, say), and with --border-status and --window-countdown the target
+// pane's border and window name, in step with the bird's state until ctx
+// ends or stop is called. All are put back on the way out. Other backends
+// have none.
+func (b *bird) startIndicators(ctx context.Context) (stop func()) {
+	opts := b.options()
+	ownPane := ""
+	if !opts.noPaneTitle {
+		ownPane = strings.TrimSpace(os.Getenv("TMUX_PANE"))
+	}
+	if (opts.noStatusLine && !opts.borderStatus && ownPane == "" && !opts.windowCount) || !usingTmux() {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(statusLineInterval)
+		defer ticker.Stop()
+		last := ""
+		var border *paneBorder
+		var title *paneTitler
+		var window *windowCountdown
+		for {
+			st := b.status()
+			if text := statusLine(st, time.Now()); !opts.noStatusLine && text != last {
+				if err := tmuxRun("set-option", "-q", "-t", st.Session, statusLineOption, text); err != nil {
+					debugf("setting %s on session %q: %v", statusLineOption, st.Session, err)
+				}
+				last = text
+			}
+			if opts.borderStatus {
+				// Follow the bird to a new pane after --retarget.
+				if border != nil && border.pane != st.Target {
+					border.restore()
+					border = nil
+				}
+				if border == nil {
+					border = newPaneBorder(st.Target)
+				}
+				border.set(borderStyle(st))
+			}
+			if ownPane != "" && ownPane != st.Target {
+				if title == nil {
+					title = newPaneTitler(ownPane)
+				}
+				title.set(paneTitle(st, time.Now()))
+			}
+			if opts.windowCount {
+				if window != nil && window.pane != st.Target {
+					window.restore()
+					window = nil
+				}
+				if window == nil {
+					window = newWindowCountdown(st.Target)
+				}
+				window.set(statusLine(st, time.Now()))
+			}
+			select {
+			case <-ctx.Done():
+				if !opts.noStatusLine {
+					_ = tmuxRun("set-option", "-q", "-u", "-t", st.Session, statusLineOption)
+				}
+				if border != nil {
+					border.restore()
+				}
+				if title != nil {
+					title.restore()
+				}
+				if window != nil {
+					window.restore()
+				}
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// runStatusLine implements `typing-bird statusline [session]`, printing the
+// status of every running bird (optionally only those on session) for use
+// in #(...) status-line commands.
+func runStatusLine(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("statusline", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	socketDir := fs.String("socket-dir", defaultSocketDir(), "directory of bird control sockets")
+	timeout := fs.Duration("timeout", 500*time.Millisecond, "how long to wait for each bird to answer")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird statusline [--socket-dir DIR] [session]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Prints a one-line summary of running birds, e.g. for")
+		fmt.Fprintln(stderr, "set -g status-right '#(typing-bird statusline #{session_name})'.")
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	sockets, err := discoverSockets(*socketDir)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	var parts []string
+	now := time.Now()
+	for _, socket := range sockets {
+		reply, err := controlCall(socket, "status", *timeout)
+		if err != nil || !reply.OK {
+			continue
+		}
+		for _, wb := range splitFleetStatus(socket, reply.Data) {
+			var st birdStatus
+			if json.Unmarshal(wb.Status, &st) != nil || (fs.NArg() == 1 && st.Session != fs.Arg(0)) {
+				continue
+			}
+			if text := statusLine(st, now); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	fmt.Fprintln(stdout, strings.Join(parts, " | "))
+	return 0
+}
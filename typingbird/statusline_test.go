@@ -0,0 +1,45 @@
+package typingbird
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusLine(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	started := now.Add(-20 * time.Second)
+	opens := time.Date(2026, 3, 3, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		st   birdStatus
+		want string
+	}{
+		{birdStatus{State: stateWaiting, TimeoutMS: 90000, WaitStarted: &started}, "next 1:10"},
+		{birdStatus{State: stateWaiting, TimeoutMS: 10000, WaitStarted: &started}, "next when quiet"},
+		{birdStatus{State: stateWaiting}, ""},
+		{birdStatus{Name: "beta", State: statePaused}, "beta: paused"},
+		{birdStatus{State: stateInactive, ActiveAt: &opens}, "inactive until Tue 09:00"},
+		{birdStatus{State: stateSending}, "sending"},
+	}
+	for _, tt := range tests {
+		if got := statusLine(tt.st, now); got != tt.want {
+			t.Fatalf("statusLine(%+v) = %q; want %q", tt.st, got, tt.want)
+		}
+	}
+}
+
+func TestFormatCountdown(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{500 * time.Millisecond, "0:01"},
+		{59 * time.Second, "0:59"},
+		{2*time.Minute + 5*time.Second, "2:05"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1:02:03"},
+	}
+	for _, tt := range tests {
+		if got := formatCountdown(tt.d); got != tt.want {
+			t.Fatalf("formatCountdown(%s) = %q; want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package typingbird
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const (
+	// A crashed bird is respawned after respawnDelay, doubling up to
+	// respawnMaxDelay while it keeps crashing.
+	respawnDelay    = time.Second
+	respawnMaxDelay = time.Minute
+	// A bird that crashes respawnQuickLimit times in a row, each time
+	// within respawnMinUptime of starting, is not coming up and is left
+	// alone.
+	respawnMinUptime  = 10 * time.Second
+	respawnQuickLimit = 5
+)
+
+// runSupervise implements `typing-bird supervise [flags] session
+// [messages...]`: it runs the bird with those arguments and starts it again
+// whenever it crashes, as `--inject --respawn` does in the injected pane.
+func runSupervise(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprintln(stderr, "Usage: typing-bird supervise [flags] session [messages...]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Runs typing-bird with the given flags and arguments, starting it again")
+		fmt.Fprintln(stderr, "when it crashes: a panic or a signal other than Ctrl-C, SIGHUP or")
+		fmt.Fprintln(stderr, "SIGTERM. A bird that exits, with or without an error, is not restarted.")
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	exe, err := birdExecutable()
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: locating executable: %v\n", err)
+		return 1
+	}
+	s := &supervisor{
+		start: func() *exec.Cmd {
+			cmd := exec.Command(exe, args...)
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, stdout, stderr
+			return cmd
+		},
+		delay:      respawnDelay,
+		maxDelay:   respawnMaxDelay,
+		minUptime:  respawnMinUptime,
+		quickLimit: respawnQuickLimit,
+		hadTTY:     canOpenTTY(),
+	}
+	signals := []os.Signal{os.Interrupt, syscall.SIGHUP, syscall.SIGTERM}
+	if sendNowSignal != nil {
+		signals = append(signals, sendNowSignal, skipSignal)
+	}
+	c := make(chan os.Signal, 4)
+	signal.Notify(c, signals...)
+	defer signal.Stop(c)
+	return s.run(c)
+}
+
+// supervisor runs a bird and respawns it when it crashes.
+type supervisor struct {
+	start      func() *exec.Cmd
+	delay      time.Duration
+	maxDelay   time.Duration
+	minUptime  time.Duration
+	quickLimit int
+	// hadTTY is whether the supervisor started with a terminal, for
+	// telling that its pane went away.
+	hadTTY bool
+}
+
+// run starts the bird until it exits without crashing, returning its exit
+// code. Ctrl-C and SIGHUP reach the bird from the terminal by themselves;
+// SIGTERM and the user signals are passed on.
+func (s *supervisor) run(signals <-chan os.Signal) int {
+	delay := s.delay
+	quick := 0
+	for {
+		cmd := s.start()
+		started := time.Now()
+		if err := cmd.Start(); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: starting bird: %v\n", err)
+			return 1
+		}
+		done := make(chan error, 1)
+		go func() { done <- cmd.Wait() }()
+		stopping := false
+		var err error
+	wait:
+		for {
+			select {
+			case err = <-done:
+				break wait
+			case sig := <-signals:
+				switch sig {
+				case os.Interrupt, syscall.SIGHUP:
+				case syscall.SIGTERM:
+					stopping = true
+					_ = cmd.Process.Signal(sig)
+				default:
+					_ = cmd.Process.Signal(sig)
+				}
+			}
+		}
+		code, crashed := birdExit(err)
+		if !crashed || stopping || terminalHungUp(s.hadTTY) {
+			return code
+		}
+		if time.Since(started) < s.minUptime {
+			quick++
+		} else {
+			quick = 0
+			delay = s.delay
+		}
+		if quick >= s.quickLimit {
+			fmt.Fprintf(os.Stderr, "ERROR: bird crashed %d times in a row right after starting (%v); not respawning\n", quick, err)
+			return code
+		}
+		logf("WARNING: bird crashed (%v); respawning in %s", err, delay)
+		time.Sleep(delay)
+		delay = min(2*delay, s.maxDelay)
+	}
+}
+
+// birdExit turns how a bird process ended into an exit code, and whether
+// it crashed: panicked (exit status 2, as for any fatal Go error) or died
+// from a signal other than the ones used to stop it.
+func birdExit(err error) (code int, crashed bool) {
+	if err == nil {
+		return 0, false
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return 1, false
+	}
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		switch ws.Signal() {
+		case syscall.SIGINT, syscall.SIGHUP, syscall.SIGTERM:
+			return 128 + int(ws.Signal()), false
+		}
+		return 128 + int(ws.Signal()), true
+	}
+	return exitErr.ExitCode(), exitErr.ExitCode() == 2
+}
@@ -0,0 +1,94 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBirdExit(t *testing.T) {
+	cases := []struct {
+		script  string
+		code    int
+		crashed bool
+	}{
+		{"exit 0", 0, false},
+		{"exit 1", 1, false},
+		{"exit 2", 2, true},
+		{"exit 130", 130, false},
+		{"kill -TERM $$", 143, false},
+		{"kill -INT $$", 130, false},
+		{"kill -KILL $$", 137, true},
+		{"kill -SEGV $$", 139, true},
+	}
+	for _, tc := range cases {
+		code, crashed := birdExit(exec.Command("sh", "-c", tc.script).Run())
+		if code != tc.code || crashed != tc.crashed {
+			t.Fatalf("birdExit(%q) = %d, %v; want %d, %v", tc.script, code, crashed, tc.code, tc.crashed)
+		}
+	}
+}
+
+// countingSupervisor runs script under a supervisor with short delays,
+// returning its exit code and how many times script ran.
+func countingSupervisor(t *testing.T, script string, minUptime time.Duration) (int, int) {
+	t.Helper()
+	runs := filepath.Join(t.TempDir(), "runs")
+	s := &supervisor{
+		start: func() *exec.Cmd {
+			return exec.Command("sh", "-c", `echo run >> "$1"; `+script, "sh", runs)
+		},
+		delay:      time.Millisecond,
+		maxDelay:   4 * time.Millisecond,
+		minUptime:  minUptime,
+		quickLimit: 3,
+	}
+	code := s.run(make(chan os.Signal))
+	data, err := os.ReadFile(runs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code, strings.Count(string(data), "run")
+}
+
+func TestSupervisorRespawnsCrashes(t *testing.T) {
+	// The third run exits cleanly.
+	runs := filepath.Join(t.TempDir(), "runs")
+	s := &supervisor{
+		start: func() *exec.Cmd {
+			return exec.Command("sh", "-c", `echo run >> "$1"; [ "$(wc -l < "$1")" -ge 3 ] && exit 0; exit 2`, "sh", runs)
+		},
+		delay:      time.Millisecond,
+		maxDelay:   4 * time.Millisecond,
+		minUptime:  0,
+		quickLimit: 3,
+	}
+	if code := s.run(make(chan os.Signal)); code != 0 {
+		t.Fatalf("run() = %d; want 0 once the bird exits cleanly", code)
+	}
+	data, _ := os.ReadFile(runs)
+	if n := strings.Count(string(data), "run"); n != 3 {
+		t.Fatalf("bird ran %d times; want 3", n)
+	}
+}
+
+func TestSupervisorLeavesExitsAlone(t *testing.T) {
+	for _, script := range []string{"exit 0", "exit 1", "kill -TERM $$"} {
+		code, runs := countingSupervisor(t, script, 0)
+		if runs != 1 {
+			t.Fatalf("%q: bird ran %d times (exit %d); want 1", script, runs, code)
+		}
+	}
+}
+
+func TestSupervisorGivesUpOnQuickCrashes(t *testing.T) {
+	code, runs := countingSupervisor(t, "exit 2", time.Hour)
+	if code != 2 || runs != 3 {
+		t.Fatalf("run() = %d after %d runs; want 2 after 3", code, runs)
+	}
+}
@@ -0,0 +1,109 @@
+package typingbird
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// paneTitle renders st for the title of the pane the bird runs in, e.g.
+// "next send in 4:32 (msg 2/5)".
+func paneTitle(st birdStatus, now time.Time) string {
+	name := st.Name
+	st.Name = ""
+	var text string
+	if st.State == stateWaiting && st.WaitStarted != nil {
+		text = "next send when quiet"
+		if left := time.Duration(st.TimeoutMS)*time.Millisecond - now.Sub(*st.WaitStarted); left > 0 {
+			text = "next send in " + formatCountdown(left)
+		}
+	} else if text = statusLine(st, now); text == "" {
+		return ""
+	}
+	if st.Messages > 0 {
+		text += fmt.Sprintf(" (msg %d/%d)", st.Next, st.Messages)
+	}
+	if name != "" {
+		text = name + ": " + text
+	}
+	return text
+}
+
+// paneTitler keeps one pane's title and puts the original back on
+// restore.
+type paneTitler struct {
+	pane     string
+	original string
+	current  string
+}
+
+func newPaneTitler(pane string) *paneTitler {
+	p := &paneTitler{pane: pane}
+	if out, err := tmuxOutput("display-message", "-p", "-t", pane, "#{pane_title}"); err == nil {
+		p.original = strings.TrimRight(string(out), "\n")
+	}
+	return p
+}
+
+func (p *paneTitler) set(title string) {
+	if title == "" || title == p.current {
+		return
+	}
+	p.current = title
+	if err := tmuxRun("select-pane", "-t", p.pane, "-T", title); err != nil {
+		debugf("setting the title of pane %q: %v", p.pane, err)
+	}
+}
+
+func (p *paneTitler) restore() {
+	if p.current != "" {
+		_ = tmuxRun("select-pane", "-t", p.pane, "-T", p.original)
+	}
+}
+
+// windowCountdown appends the bird's status to the name of the window
+// holding its target with --window-countdown. Renaming turns tmux's
+// automatic-rename off for the window, so restore puts back both the name
+// and the window-level option.
+type windowCountdown struct {
+	pane       string
+	name       string
+	autoRename string
+	current    string
+}
+
+func newWindowCountdown(pane string) *windowCountdown {
+	w := &windowCountdown{pane: pane}
+	if out, err := tmuxOutput("display-message", "-p", "-t", pane, "#{window_name}"); err == nil {
+		w.name = strings.TrimRight(string(out), "\n")
+	}
+	if out, err := tmuxOutput("show-options", "-wqv", "-t", pane, "automatic-rename"); err == nil {
+		w.autoRename = strings.TrimSpace(string(out))
+	}
+	return w
+}
+
+func (w *windowCountdown) set(status string) {
+	name := w.name
+	if status != "" {
+		name += " [" + status + "]"
+	}
+	if name == w.current {
+		return
+	}
+	w.current = name
+	if err := tmuxRun("rename-window", "-t", w.pane, name); err != nil {
+		debugf("renaming the window of pane %q: %v", w.pane, err)
+	}
+}
+
+func (w *windowCountdown) restore() {
+	if w.current == "" {
+		return
+	}
+	restoreAuto := []string{"set-option", "-wqu", "-t", w.pane, "automatic-rename"}
+	if w.autoRename != "" {
+		restoreAuto = []string{"set-option", "-wq", "-t", w.pane, "automatic-rename", w.autoRename}
+	}
+	_ = tmuxBatch([]string{"rename-window", "-t", w.pane, w.name}, restoreAuto)
+}
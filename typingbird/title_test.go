@@ -0,0 +1,26 @@
+package typingbird
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPaneTitle(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	started := now.Add(-28 * time.Second)
+	tests := []struct {
+		st   birdStatus
+		want string
+	}{
+		{birdStatus{State: stateWaiting, TimeoutMS: 300000, WaitStarted: &started, Next: 2, Messages: 5}, "next send in 4:32 (msg 2/5)"},
+		{birdStatus{State: stateWaiting, TimeoutMS: 10000, WaitStarted: &started, Next: 1, Messages: 1}, "next send when quiet (msg 1/1)"},
+		{birdStatus{State: stateWaiting, Next: 1, Messages: 1}, ""},
+		{birdStatus{Name: "beta", State: statePaused, Next: 3, Messages: 3}, "beta: paused (msg 3/3)"},
+		{birdStatus{State: stateSending}, "sending"},
+	}
+	for _, tt := range tests {
+		if got := paneTitle(tt.st, now); got != tt.want {
+			t.Fatalf("paneTitle(%+v) = %q; want %q", tt.st, got, tt.want)
+		}
+	}
+}
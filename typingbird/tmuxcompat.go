@@ -0,0 +1,126 @@
+package typingbird
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// tmuxVersion is a tmux release as tmux -V reports it; 3.3a is {3, 3}. The
+// zero value is a version that couldn't be told, such as a build from
+// master or OpenBSD's own tmux, and is taken to support everything.
+type tmuxVersion struct {
+	major, minor int
+}
+
+func (v tmuxVersion) String() string {
+	if v == (tmuxVersion{}) {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+func (v tmuxVersion) atLeast(o tmuxVersion) bool {
+	if v == (tmuxVersion{}) {
+		return true
+	}
+	return v.major > o.major || (v.major == o.major && v.minor >= o.minor)
+}
+
+// parseTmuxVersion reads tmux -V output such as "tmux 3.3a", "tmux 3.0-rc5"
+// or "tmux next-3.4".
+func parseTmuxVersion(out string) (tmuxVersion, bool) {
+	fields := strings.Fields(out)
+	if len(fields) != 2 || fields[0] != "tmux" {
+		return tmuxVersion{}, false
+	}
+	raw := strings.TrimPrefix(fields[1], "next-")
+	major, rest, ok := strings.Cut(raw, ".")
+	if !ok {
+		return tmuxVersion{}, false
+	}
+	end := 0
+	for end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+		end++
+	}
+	v := tmuxVersion{}
+	var err error
+	if v.major, err = strconv.Atoi(major); err != nil || end == 0 {
+		return tmuxVersion{}, false
+	}
+	v.minor, _ = strconv.Atoi(rest[:end])
+	return v, true
+}
+
+// detectedTmux caches runningTmux for the life of the process.
+var detectedTmux atomic.Pointer[tmuxVersion]
+
+// runningTmux returns the version of the tmux the bird drives, asking it
+// once.
+func runningTmux() tmuxVersion {
+	if v := detectedTmux.Load(); v != nil {
+		return *v
+	}
+	var v tmuxVersion
+	if out, err := tmuxCommand("-V").Output(); err == nil {
+		v, _ = parseTmuxVersion(string(out))
+	}
+	if v == (tmuxVersion{}) {
+		debugf("could not tell the tmux version; assuming a current one")
+	}
+	detectedTmux.Store(&v)
+	return v
+}
+
+// tmuxFeature is something the bird asks of tmux that older releases lack.
+type tmuxFeature struct {
+	name  string
+	since tmuxVersion
+}
+
+var (
+	tmuxPaneOptions  = tmuxFeature{"pane options (set-option -p)", tmuxVersion{3, 0}}
+	tmuxMenus        = tmuxFeature{"menus (display-menu)", tmuxVersion{3, 0}}
+	tmuxSplitPercent = tmuxFeature{"percentage split sizes (split-window -l N%)", tmuxVersion{3, 1}}
+	tmuxPopups       = tmuxFeature{"popups (display-popup)", tmuxVersion{3, 2}}
+)
+
+func (f tmuxFeature) supportedBy(v tmuxVersion) bool {
+	return v.atLeast(f.since)
+}
+
+// require explains, for what, that the running tmux is too old for f.
+func (f tmuxFeature) require(what string) error {
+	if v := runningTmux(); !f.supportedBy(v) {
+		return fmt.Errorf("%s needs tmux %s or newer for %s; this is tmux %s", what, f.since, f.name, v)
+	}
+	return nil
+}
+
+// tmuxFeatures checks up front that the running tmux has what opts need,
+// rather than have tmux fail mid-run with its own message. Features that
+// have a fallback, such as percentage splits and the pane options marking
+// injected birds, are not checked.
+func (o options) tmuxFeatures() error {
+	if o.popup != "" && o.popup != popupOff {
+		if err := tmuxPopups.require("--inject-popup"); err != nil {
+			return err
+		}
+	}
+	if o.borderStatus {
+		if err := tmuxPaneOptions.require("--border-status"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tmuxSplitSizeArgs sizes a split as size lines or columns, or a
+// percentage such as "20%", which tmux before 3.1 only takes as -p.
+func tmuxSplitSizeArgs(size string) []string {
+	if percent, ok := strings.CutSuffix(size, "%"); ok && !tmuxSplitPercent.supportedBy(runningTmux()) {
+		return []string{"-p", percent}
+	}
+	return []string{"-l", size}
+}
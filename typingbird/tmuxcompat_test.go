@@ -0,0 +1,90 @@
+package typingbird
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// withTmuxVersion makes runningTmux report v for the rest of the test.
+func withTmuxVersion(t *testing.T, v tmuxVersion) {
+	t.Helper()
+	prev := detectedTmux.Load()
+	detectedTmux.Store(&v)
+	t.Cleanup(func() { detectedTmux.Store(prev) })
+}
+
+func TestParseTmuxVersion(t *testing.T) {
+	cases := []struct {
+		out  string
+		want tmuxVersion
+		ok   bool
+	}{
+		{"tmux 3.3a\n", tmuxVersion{3, 3}, true},
+		{"tmux 2.9", tmuxVersion{2, 9}, true},
+		{"tmux 3.0-rc5", tmuxVersion{3, 0}, true},
+		{"tmux next-3.4", tmuxVersion{3, 4}, true},
+		{"tmux master", tmuxVersion{}, false},
+		{"tmux openbsd-7.4", tmuxVersion{}, false},
+		{"screen 4.09", tmuxVersion{}, false},
+	}
+	for _, tc := range cases {
+		got, ok := parseTmuxVersion(tc.out)
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("parseTmuxVersion(%q) = %v, %v; want %v, %v", tc.out, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestTmuxFeatureSupport(t *testing.T) {
+	cases := []struct {
+		v    tmuxVersion
+		f    tmuxFeature
+		want bool
+	}{
+		{tmuxVersion{2, 9}, tmuxPaneOptions, false},
+		{tmuxVersion{3, 0}, tmuxPaneOptions, true},
+		{tmuxVersion{3, 0}, tmuxSplitPercent, false},
+		{tmuxVersion{3, 1}, tmuxPopups, false},
+		{tmuxVersion{3, 2}, tmuxPopups, true},
+		{tmuxVersion{4, 0}, tmuxPopups, true},
+		{tmuxVersion{}, tmuxPopups, true},
+	}
+	for _, tc := range cases {
+		if got := tc.f.supportedBy(tc.v); got != tc.want {
+			t.Fatalf("%s supportedBy(%s) = %v; want %v", tc.f.name, tc.v, got, tc.want)
+		}
+	}
+}
+
+func TestTmuxSplitSizeArgs(t *testing.T) {
+	withTmuxVersion(t, tmuxVersion{3, 0})
+	if got, want := tmuxSplitSizeArgs("30%"), []string{"-p", "30"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("tmuxSplitSizeArgs(30%%) on tmux 3.0 = %q; want %q", got, want)
+	}
+	if got, want := tmuxSplitSizeArgs("5"), []string{"-l", "5"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("tmuxSplitSizeArgs(5) on tmux 3.0 = %q; want %q", got, want)
+	}
+	withTmuxVersion(t, tmuxVersion{3, 1})
+	if got, want := tmuxSplitSizeArgs("30%"), []string{"-l", "30%"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("tmuxSplitSizeArgs(30%%) on tmux 3.1 = %q; want %q", got, want)
+	}
+}
+
+func TestOptionsTmuxFeatures(t *testing.T) {
+	withTmuxVersion(t, tmuxVersion{2, 8})
+	err := options{popup: popupShow}.tmuxFeatures()
+	if err == nil || !strings.Contains(err.Error(), "--inject-popup needs tmux 3.2 or newer") || !strings.Contains(err.Error(), "this is tmux 2.8") {
+		t.Fatalf("tmuxFeatures() with --inject-popup on tmux 2.8 = %v; want an error naming both versions", err)
+	}
+	if err := (options{borderStatus: true}).tmuxFeatures(); err == nil {
+		t.Fatalf("tmuxFeatures() with --border-status on tmux 2.8 = nil; want error")
+	}
+	if err := (options{popup: popupOff}).tmuxFeatures(); err != nil {
+		t.Fatalf("tmuxFeatures() without features = %v; want nil", err)
+	}
+	withTmuxVersion(t, tmuxVersion{3, 2})
+	if err := (options{popup: popupShow, borderStatus: true}).tmuxFeatures(); err != nil {
+		t.Fatalf("tmuxFeatures() on tmux 3.2 = %v; want nil", err)
+	}
+}
@@ -0,0 +1,200 @@
+package typingbird
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// controlStartTimeout bounds how long the control-mode client may take to
+// attach.
+const controlStartTimeout = 5 * time.Second
+
+// errControlClosed reports that the control-mode client went away; callers
+// fall back to running tmux directly.
+var errControlClosed = errors.New("tmux control-mode client closed")
+
+// tmuxControl is the --tmux-control client every tmux command goes through
+// while it is running, or nil.
+var tmuxControl atomic.Pointer[controlClient]
+
+// controlClient runs tmux commands through one long-lived control-mode
+// client (tmux -C) instead of starting a process per command. Commands are
+// sent one per line; tmux answers each with a %begin ... %end (or %error)
+// block whose flags are 1 for commands the client sent.
+type controlClient struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	blocks chan controlBlock
+	done   chan struct{}
+}
+
+type controlBlock struct {
+	output []byte
+	failed bool
+}
+
+// startControlClient attaches a control-mode client to session. It asks
+// tmux not to send pane output and not to size windows after it, so the
+// client is invisible apart from showing up in list-clients.
+func startControlClient(session string) (*controlClient, error) {
+	cmd := tmuxCommand("-C", "attach-session", "-t", session, "-f", "no-output,ignore-size")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	c := &controlClient{
+		cmd:    cmd,
+		stdin:  stdin,
+		blocks: make(chan controlBlock, 1),
+		done:   make(chan struct{}),
+	}
+	go c.read(stdout)
+	// attach-session answers first, like any other command.
+	timer := time.NewTimer(controlStartTimeout)
+	defer timer.Stop()
+	select {
+	case b := <-c.blocks:
+		if b.failed {
+			c.Close()
+			return nil, fmt.Errorf("attaching control-mode client: %s", strings.TrimSpace(string(b.output)))
+		}
+		return c, nil
+	case <-c.done:
+		c.Close()
+		return nil, fmt.Errorf("control-mode client exited while attaching to %q", session)
+	case <-timer.C:
+		c.Close()
+		return nil, fmt.Errorf("control-mode client did not attach to %q within %s", session, controlStartTimeout)
+	}
+}
+
+func (c *controlClient) read(r io.Reader) {
+	defer close(c.done)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16<<20)
+	var block *controlBlock
+	guard := ""
+	attached := false
+	for sc.Scan() {
+		line := sc.Text()
+		if block == nil {
+			// Anything outside a block is a notification.
+			if rest, ok := strings.CutPrefix(line, "%begin "); ok {
+				block, guard = &controlBlock{}, rest
+			}
+			continue
+		}
+		if line == "%end "+guard || line == "%error "+guard {
+			block.failed = strings.HasPrefix(line, "%error ")
+			// After attach-session's own block, only answers to this
+			// client's commands are passed on.
+			if !attached || strings.HasSuffix(guard, " 1") {
+				attached = true
+				c.blocks <- *block
+			}
+			block = nil
+			continue
+		}
+		block.output = append(block.output, line...)
+		block.output = append(block.output, '\n')
+	}
+}
+
+// run sends commands one by one, stopping at the first that fails, and
+// returns their combined output and how many commands completed.
+func (c *controlClient) run(commands [][]string) ([]byte, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []byte
+	for i, args := range commands {
+		if _, err := io.WriteString(c.stdin, controlCommandLine(args)+"\n"); err != nil {
+			return out, i, errControlClosed
+		}
+		select {
+		case b := <-c.blocks:
+			out = append(out, b.output...)
+			if b.failed {
+				return out, i, fmt.Errorf("tmux %s: %s", args[0], strings.TrimSpace(string(b.output)))
+			}
+		case <-c.done:
+			return out, i, errControlClosed
+		}
+	}
+	return out, len(commands), nil
+}
+
+// Close detaches the client.
+func (c *controlClient) Close() error {
+	_ = c.stdin.Close()
+	timer := time.NewTimer(time.Second)
+	defer timer.Stop()
+	select {
+	case <-c.done:
+	case <-timer.C:
+		_ = c.cmd.Process.Kill()
+	}
+	return c.cmd.Wait()
+}
+
+// controlCommandLine quotes args for tmux's command parser, which reads
+// control-mode input like a config file line.
+func controlCommandLine(args []string) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = controlQuote(arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+func controlQuote(arg string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range arg {
+		switch {
+		case r == '\\' || r == '"' || r == '$':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\%03o`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// useControlClient starts a control-mode client on session and routes every
+// tmux command through it until the returned stop is called.
+func useControlClient(session string) (stop func(), err error) {
+	c, err := startControlClient(session)
+	if err != nil {
+		return nil, err
+	}
+	tmuxControl.Store(c)
+	return func() {
+		tmuxControl.CompareAndSwap(c, nil)
+		_ = c.Close()
+	}, nil
+}
@@ -0,0 +1,65 @@
+package typingbird
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestControlQuote(t *testing.T) {
+	tests := map[string]string{
+		"":               `""`,
+		"plain":          `"plain"`,
+		`say "hi" $HOME`: `"say \"hi\" \$HOME"`,
+		`a\b;c #d`:       `"a\\b;c #d"`,
+		"tab\tnl\n\x1b":  `"tab\tnl\n\033"`,
+	}
+	for in, want := range tests {
+		if got := controlQuote(in); got != want {
+			t.Fatalf("controlQuote(%q) = %s; want %s", in, got, want)
+		}
+	}
+}
+
+func TestTmuxArgvEscapesTrailingSemicolons(t *testing.T) {
+	got := tmuxArgv([][]string{
+		{"send-keys", "-t", "%1", "-l", "--", "echo a;"},
+		{"send-keys", "-t", "%1", "Enter"},
+	})
+	want := []string{"send-keys", "-t", "%1", "-l", "--", `echo a\;`, ";", "send-keys", "-t", "%1", "Enter"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tmuxArgv(...) = %q; want %q", got, want)
+	}
+}
+
+func TestControlClientReadsBlocks(t *testing.T) {
+	c := &controlClient{blocks: make(chan controlBlock, 4), done: make(chan struct{})}
+	c.read(strings.NewReader(strings.Join([]string{
+		"%begin 100 1 0",
+		"%end 100 1 0",
+		"%session-changed $1 agent",
+		"%begin 100 2 0",
+		"%end 100 2 0",
+		"%begin 100 3 1",
+		"%end looks like a guard but isn't",
+		"line two",
+		"%end 100 3 1",
+		"%begin 100 4 1",
+		"can't find pane: %9",
+		"%error 100 4 1",
+		"%exit",
+	}, "\n")))
+	close(c.blocks)
+	var got []controlBlock
+	for b := range c.blocks {
+		got = append(got, b)
+	}
+	want := []controlBlock{
+		{},
+		{output: []byte("%end looks like a guard but isn't\nline two\n")},
+		{output: []byte("can't find pane: %9\n"), failed: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("blocks = %+v; want %+v", got, want)
+	}
+}
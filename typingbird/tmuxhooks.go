@@ -0,0 +1,130 @@
+package typingbird
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Hooks registered by --tmux-hooks. Each notifies the bird over its control
+// socket with "tmux-event <hook> <subject>".
+const (
+	tmuxHookPaneDied       = "pane-died"
+	tmuxHookPaneExited     = "pane-exited"
+	tmuxHookAfterKillPane  = "after-kill-pane"
+	tmuxHookWindowUnlinked = "window-unlinked"
+	tmuxHookSessionClosed  = "session-closed"
+)
+
+// tmuxHookSpec is one hook: its option scope flags and the format naming
+// what it is about. kill-pane and kill-window do not say which panes went,
+// so their hooks only prompt the bird to check its target.
+type tmuxHookSpec struct {
+	hook    string
+	scope   string
+	subject string
+}
+
+var tmuxHookSpecs = []tmuxHookSpec{
+	{tmuxHookPaneDied, "-gw", "#{hook_pane}"},
+	{tmuxHookPaneExited, "-gw", "#{hook_pane}"},
+	{tmuxHookAfterKillPane, "-g", "-"},
+	{tmuxHookWindowUnlinked, "-g", "#{q:hook_session_name}"},
+	{tmuxHookSessionClosed, "-g", "#{q:hook_session_name}"},
+}
+
+// tmuxHooks tracks the global hooks a process registered so they can be
+// removed on exit. Hooks are indexed by pid so several birds, and the
+// user's own hooks, can coexist.
+type tmuxHooks struct {
+	index      string
+	exe        string
+	socket     string
+	registered []tmuxHookSpec
+}
+
+// activeTmuxHooks are the hooks this process registered, set again by
+// restoreTmuxHooks after a tmux server restart.
+var activeTmuxHooks struct {
+	sync.Mutex
+	hooks *tmuxHooks
+}
+
+// registerTmuxHooks installs the hooks, all pointing at socket.
+func registerTmuxHooks(socket string) (*tmuxHooks, error) {
+	exe, err := birdExecutable()
+	if err != nil {
+		return nil, fmt.Errorf("locating executable: %w", err)
+	}
+	h := &tmuxHooks{index: strconv.Itoa(os.Getpid()), exe: exe, socket: socket}
+	if err := h.set(); err != nil {
+		_ = h.Close()
+		return nil, err
+	}
+	activeTmuxHooks.Lock()
+	activeTmuxHooks.hooks = h
+	activeTmuxHooks.Unlock()
+	return h, nil
+}
+
+// set (re)installs every hook not yet recorded as registered.
+func (h *tmuxHooks) set() error {
+	for _, spec := range tmuxHookSpecs[len(h.registered):] {
+		command := tmuxHookCommand(h.exe, h.socket, spec.hook, spec.subject)
+		if err := tmuxRun("set-hook", spec.scope, h.name(spec.hook), command); err != nil {
+			return fmt.Errorf("setting %s hook: %w", spec.hook, err)
+		}
+		h.registered = append(h.registered, spec)
+	}
+	return nil
+}
+
+// restoreTmuxHooks registers the process's hooks again, for a tmux server
+// that restarted without them. It does nothing without --tmux-hooks.
+func restoreTmuxHooks() error {
+	activeTmuxHooks.Lock()
+	defer activeTmuxHooks.Unlock()
+	h := activeTmuxHooks.hooks
+	if h == nil {
+		return nil
+	}
+	h.registered = nil
+	if err := h.set(); err != nil {
+		return fmt.Errorf("restoring tmux hooks: %w", err)
+	}
+	return nil
+}
+
+func (h *tmuxHooks) name(hook string) string {
+	return hook + "[" + h.index + "]"
+}
+
+// Close removes the hooks. Failures are ignored: the server may be gone.
+func (h *tmuxHooks) Close() error {
+	activeTmuxHooks.Lock()
+	defer activeTmuxHooks.Unlock()
+	if activeTmuxHooks.hooks == h {
+		activeTmuxHooks.hooks = nil
+	}
+	for _, spec := range h.registered {
+		_ = tmuxRun("set-hook", spec.scope+"u", h.name(spec.hook))
+	}
+	h.registered = nil
+	return nil
+}
+
+// tmuxHookCommand builds the tmux command run by a hook: a background
+// run-shell of `typing-bird ctl`, with subject left as a format for
+// run-shell to expand.
+func tmuxHookCommand(exe, socket, hook, subject string) string {
+	command := shellCommandForExec(exe, []string{"ctl", socket, "tmux-event", hook})
+	return `run-shell -b "` + tmuxEscape(command) + " " + subject + ` >/dev/null 2>&1"`
+}
+
+// tmuxEscape escapes s for a double-quoted tmux string whose contents
+// run-shell will format-expand: literal # are doubled so they stay literal.
+func tmuxEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, `#`, `##`).Replace(s)
+}
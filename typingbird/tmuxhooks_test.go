@@ -0,0 +1,11 @@
+package typingbird
+
+import "testing"
+
+func TestTmuxHookCommand(t *testing.T) {
+	got := tmuxHookCommand("/opt/typing-bird", "/tmp/typing-bird-0/#1 $x.sock", tmuxHookPaneExited, "#{hook_pane}")
+	want := `run-shell -b "'/opt/typing-bird' 'ctl' '/tmp/typing-bird-0/##1 \$x.sock' 'tmux-event' 'pane-exited' #{hook_pane} >/dev/null 2>&1"`
+	if got != want {
+		t.Fatalf("tmuxHookCommand(...) = %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+package typingbird
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// traceOutputLimit caps how much command output a trace line shows.
+const traceOutputLimit = 160
+
+func tmuxRun(args ...string) error {
+	_, err := tmuxExec([][]string{args}, runOnly)
+	return err
+}
+
+func tmuxOutput(args ...string) ([]byte, error) {
+	return tmuxExec([][]string{args}, (*exec.Cmd).Output)
+}
+
+func tmuxCombinedOutput(args ...string) ([]byte, error) {
+	return tmuxExec([][]string{args}, (*exec.Cmd).CombinedOutput)
+}
+
+// tmuxBatch runs several tmux commands in a single invocation, stopping at
+// the first that fails.
+func tmuxBatch(commands ...[]string) error {
+	_, err := tmuxExec(commands, runOnly)
+	return err
+}
+
+// tmuxExec runs commands through the --tmux-control client when there is
+// one and as a tmux process otherwise.
+func tmuxExec(commands [][]string, run func(*exec.Cmd) ([]byte, error)) ([]byte, error) {
+	if c := tmuxControl.Load(); c != nil {
+		out, done, err := runControlTraced(c, commands)
+		if err != errControlClosed {
+			return out, err
+		}
+		if tmuxControl.CompareAndSwap(c, nil) {
+			logf("WARNING: tmux control-mode client exited; running tmux commands directly")
+		}
+		// Only the commands the client didn't get to are run again.
+		commands = commands[done:]
+	}
+	return runTraced(tmuxCommand(tmuxArgv(commands)...), run)
+}
+
+// tmuxArgv joins commands with ";" arguments. tmux also treats a trailing
+// ";" inside an argument as a separator, so those are escaped.
+func tmuxArgv(commands [][]string) []string {
+	var argv []string
+	for i, args := range commands {
+		if i > 0 {
+			argv = append(argv, ";")
+		}
+		for _, arg := range args {
+			if strings.HasSuffix(arg, ";") {
+				arg = arg[:len(arg)-1] + `\;`
+			}
+			argv = append(argv, arg)
+		}
+	}
+	return argv
+}
+
+func runControlTraced(c *controlClient, commands [][]string) ([]byte, int, error) {
+	if !traceLogging {
+		return c.run(commands)
+	}
+	start := time.Now()
+	out, done, err := c.run(commands)
+	status := "ok"
+	if err != nil {
+		status = err.Error()
+	}
+	line := []string{"tmux"}
+	for i, args := range commands {
+		if i > 0 {
+			line = append(line, ";")
+		}
+		line = append(line, args...)
+	}
+	tracef("%s (%s, control, %s)%s", traceCommandLine(line), time.Since(start).Round(time.Microsecond), status, traceOutput(out))
+	return out, done, err
+}
+
+// runOnly runs cmd like (*exec.Cmd).Run, keeping its output only when it
+// will be traced.
+func runOnly(cmd *exec.Cmd) ([]byte, error) {
+	if traceLogging {
+		return cmd.CombinedOutput()
+	}
+	return nil, cmd.Run()
+}
+
+// runTraced runs cmd with run and, at -vv, logs the command line, how long
+// it took, its exit status and the start of its output.
+func runTraced(cmd *exec.Cmd, run func(*exec.Cmd) ([]byte, error)) ([]byte, error) {
+	if !traceLogging {
+		return run(cmd)
+	}
+	start := time.Now()
+	out, err := run(cmd)
+	tracef("%s (%s, %s)%s", traceCommandLine(cmd.Args), time.Since(start).Round(time.Microsecond), exitStatus(err), traceOutput(out))
+	return out, err
+}
+
+// traceCommandLine quotes args for a trace line. With --redact, literal
+// text sent by send-keys -l is logged like any other message body.
+func traceCommandLine(args []string) string {
+	parts := make([]string, len(args))
+	literal := false
+	for i, arg := range args {
+		if arg == ";" {
+			literal = false
+		}
+		switch {
+		case literal && redactingMessages():
+			parts[i] = logText(arg)
+		case arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$#;&|<>()*?`"):
+			parts[i] = arg
+		default:
+			parts[i] = shellQuoteSingle(arg)
+		}
+		if i > 0 && args[i-1] == "-l" && arg == "--" {
+			literal = true
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func exitStatus(err error) string {
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return "exit 0"
+	case errors.As(err, &exitErr):
+		return fmt.Sprintf("exit %d", exitErr.ExitCode())
+	}
+	return err.Error()
+}
+
+func traceOutput(out []byte) string {
+	text := strings.TrimSpace(string(out))
+	if text == "" {
+		return ""
+	}
+	if redactingMessages() {
+		// Pane captures can hold anything the messages could.
+		return fmt.Sprintf(": [%d bytes]", len(out))
+	}
+	if len(text) > traceOutputLimit {
+		text = text[:traceOutputLimit] + "..."
+	}
+	return fmt.Sprintf(": %q", text)
+}
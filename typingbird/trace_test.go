@@ -0,0 +1,39 @@
+package typingbird
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestTraceCommandLine(t *testing.T) {
+	args := []string{"tmux", "send-keys", "-t", "%3", "-l", "--", "keep going; it's fine"}
+	got := traceCommandLine(args)
+	want := `tmux send-keys -t %3 -l -- 'keep going; it'\''s fine'`
+	if got != want {
+		t.Fatalf("traceCommandLine(...) = %q; want %q", got, want)
+	}
+
+	prev := messageRedaction
+	messageRedaction = redactLength
+	defer func() { messageRedaction = prev }()
+	if got, want := traceCommandLine(args), "tmux send-keys -t %3 -l -- [21 bytes]"; got != want {
+		t.Fatalf("traceCommandLine(redacted) = %q; want %q", got, want)
+	}
+	if got, want := traceOutput([]byte("secret screen\n")), ": [14 bytes]"; got != want {
+		t.Fatalf("traceOutput(redacted) = %q; want %q", got, want)
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	if got := exitStatus(nil); got != "exit 0" {
+		t.Fatalf("exitStatus(nil) = %q; want %q", got, "exit 0")
+	}
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	if got := exitStatus(err); got != "exit 3" {
+		t.Fatalf("exitStatus(exit 3) = %q; want %q", got, "exit 3")
+	}
+	if got := exitStatus(errors.New("not found")); got != "not found" {
+		t.Fatalf("exitStatus(other) = %q; want %q", got, "not found")
+	}
+}
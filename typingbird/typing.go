@@ -0,0 +1,113 @@
+package typingbird
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// backspaceKey is the tmux key that takes back a typo.
+	backspaceKey = "BSpace"
+	// charsPerWord is the standard word length behind words per minute.
+	charsPerWord = 5
+	// wpmVariance is how far each keystroke may stray from the average
+	// --wpm pace, either way.
+	wpmVariance = 0.4
+)
+
+// keyboardRows are the rows of a US QWERTY keyboard; a typo hits a key
+// next to the intended one on the same row.
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// typing makes a bird type like a person rather than a paste. With --wpm
+// every keystroke is paced at that many words per minute, give or take
+// wpmVariance. With --typo-rate each letter or digit is mistyped as a
+// neighbouring key with that probability and corrected with Backspace
+// right away. Either one types literal text a character at a time. The
+// zero value types text as it is.
+type typing struct {
+	typos float64
+	wpm   int
+	// rnd is for tests; nil uses the global source.
+	rnd *rand.Rand
+}
+
+// perChar reports whether literal text is typed a character at a time.
+func (t typing) perChar() bool {
+	return t.typos > 0 || t.wpm > 0
+}
+
+// delay returns how long to wait before the next keystroke: keyDelay
+// unless --wpm sets the pace.
+func (t typing) delay(keyDelay time.Duration) time.Duration {
+	if t.wpm <= 0 {
+		return keyDelay
+	}
+	base := float64(time.Minute) / float64(t.wpm*charsPerWord)
+	return time.Duration(base * (1 - wpmVariance + 2*wpmVariance*t.float()))
+}
+
+func (t typing) float() float64 {
+	if t.rnd != nil {
+		return t.rnd.Float64()
+	}
+	return rand.Float64()
+}
+
+func (t typing) intN(n int) int {
+	if t.rnd != nil {
+		return t.rnd.IntN(n)
+	}
+	return rand.IntN(n)
+}
+
+// apply splits literal actions into one action per character and slips in
+// the typos with their corrections.
+func (t typing) apply(actions []sendAction) []sendAction {
+	if !t.perChar() {
+		return actions
+	}
+	out := make([]sendAction, 0, len(actions))
+	for _, action := range actions {
+		if !action.literal {
+			out = append(out, action)
+			continue
+		}
+		for _, r := range action.value {
+			if t.typos > 0 && t.float() < t.typos {
+				if wrong, ok := t.neighbour(r); ok {
+					out = append(out, sendAction{value: string(wrong), literal: true}, sendAction{value: backspaceKey})
+				}
+			}
+			out = append(out, sendAction{value: string(r), literal: true})
+		}
+	}
+	return out
+}
+
+// neighbour picks a key next to r, keeping its case. Only letters and
+// digits have neighbours.
+func (t typing) neighbour(r rune) (rune, bool) {
+	lower := unicode.ToLower(r)
+	for _, row := range keyboardRows {
+		i := strings.IndexRune(row, lower)
+		if i < 0 {
+			continue
+		}
+		var near []rune
+		if i > 0 {
+			near = append(near, rune(row[i-1]))
+		}
+		if i < len(row)-1 {
+			near = append(near, rune(row[i+1]))
+		}
+		wrong := near[t.intN(len(near))]
+		if unicode.IsUpper(r) {
+			wrong = unicode.ToUpper(wrong)
+		}
+		return wrong, true
+	}
+	return 0, false
+}
@@ -0,0 +1,103 @@
+package typingbird
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// typedText replays actions as a line editor would.
+func typedText(actions []sendAction) string {
+	var typed []rune
+	for _, a := range actions {
+		switch {
+		case a.literal:
+			typed = append(typed, []rune(a.value)...)
+		case a.value == backspaceKey && len(typed) > 0:
+			typed = typed[:len(typed)-1]
+		}
+	}
+	return string(typed)
+}
+
+func TestTyposApply(t *testing.T) {
+	actions := messageSendActions("Hello, w0rld!", enterKey)
+	if got := (typing{}).apply(actions); !reflect.DeepEqual(got, actions) {
+		t.Fatalf("typing{}.apply(...) = %#v; want the actions unchanged", got)
+	}
+
+	slips := typing{typos: 0.999, rnd: rand.New(rand.NewPCG(1, 2))}
+	got := slips.apply(actions)
+	if text := typedText(got); text != "Hello, w0rld!" {
+		t.Fatalf("text typed with typos = %q; want %q", text, "Hello, w0rld!")
+	}
+	// Ten letters and digits each get a typo; punctuation and spaces don't.
+	var backspaces int
+	for i, a := range got {
+		if a.literal && len([]rune(a.value)) != 1 {
+			t.Fatalf("action %d = %#v; want one character at a time", i, a)
+		}
+		if a.value == backspaceKey {
+			backspaces++
+			if wrong := got[i-1].value; strings.ContainsAny(wrong, " ,!") {
+				t.Fatalf("typo %q before backspace %d; want a neighbouring letter or digit", wrong, backspaces)
+			}
+		}
+	}
+	if backspaces != 10 {
+		t.Fatalf("backspaces = %d; want 10", backspaces)
+	}
+	if last := got[len(got)-1]; last.value != enterKey || last.literal {
+		t.Fatalf("last action = %#v; want Enter", last)
+	}
+}
+
+func TestTyposNeighbour(t *testing.T) {
+	slips := typing{rnd: rand.New(rand.NewPCG(3, 4))}
+	tests := map[rune]string{'q': "w", 'P': "O", '5': "46", 'g': "fh", 'm': "n"}
+	for r, want := range tests {
+		got, ok := slips.neighbour(r)
+		if !ok || !strings.ContainsRune(want, got) {
+			t.Fatalf("neighbour(%q) = %q, %t; want one of %q", r, got, ok, want)
+		}
+	}
+	for _, r := range []rune{' ', '-', 'é'} {
+		if got, ok := slips.neighbour(r); ok {
+			t.Fatalf("neighbour(%q) = %q; want none", r, got)
+		}
+	}
+}
+
+func TestOptionsValidatesTypoRate(t *testing.T) {
+	for rate, ok := range map[float64]bool{0: true, 0.05: true, 1: false, -0.1: false} {
+		cli := newCLIFlags()
+		cli.typoRate = rate
+		if _, err := cli.options([]string{"s", "m"}, resolvedConfig{}, ""); (err == nil) != ok {
+			t.Fatalf("options() with typo-rate %g = %v; want ok=%t", rate, err, ok)
+		}
+	}
+}
+
+func TestTypingDelay(t *testing.T) {
+	if got := (typing{}).delay(15 * time.Millisecond); got != 15*time.Millisecond {
+		t.Fatalf("typing{}.delay(15ms) = %s; want the key delay", got)
+	}
+	// 60 words of 5 characters a minute is one keystroke every 200ms.
+	human := typing{wpm: 60, rnd: rand.New(rand.NewPCG(5, 6))}
+	var total time.Duration
+	for i := 0; i < 1000; i++ {
+		d := human.delay(15 * time.Millisecond)
+		if d < 120*time.Millisecond || d > 280*time.Millisecond {
+			t.Fatalf("delay at 60 wpm = %s; want 200ms give or take 40%%", d)
+		}
+		total += d
+	}
+	if avg := total / 1000; avg < 190*time.Millisecond || avg > 210*time.Millisecond {
+		t.Fatalf("average delay at 60 wpm = %s; want about 200ms", avg)
+	}
+	if got := human.apply([]sendAction{{value: "ab", literal: true}}); len(got) != 2 {
+		t.Fatalf("apply() at 60 wpm = %#v; want a character at a time", got)
+	}
+}
@@ -0,0 +1,83 @@
+package typingbird
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// verifyTimeout is how long --verify waits for sent text to show up.
+	verifyTimeout = 3 * time.Second
+	verifyPoll    = 100 * time.Millisecond
+	// verifyTail is how much of the end of a message --verify looks for.
+	verifyTail = 40
+)
+
+// verifyNeedle is what --verify looks for in the pane after text was sent:
+// its end with all whitespace removed, so the pane wrapping or padding the
+// text doesn't matter. It is empty when there is nothing to check.
+func verifyNeedle(text string) string {
+	needle := squeezeSpace(text)
+	if n := utf8.RuneCountInString(needle); n > verifyTail {
+		needle = string([]rune(needle)[n-verifyTail:])
+	}
+	return needle
+}
+
+func squeezeSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// awaitEcho polls target until needle shows up in its output since mark,
+// ignoring whitespace on both sides, and reports whether it did within
+// timeout.
+func awaitEcho(ctx context.Context, target, needle string, mark paneMark, timeout time.Duration) (bool, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		output, err := tmuxOutputSince(target, mark)
+		if err != nil {
+			return false, err
+		}
+		if strings.Contains(squeezeSpace(output), needle) {
+			return true, nil
+		}
+		if time.Now().After(deadline) {
+			return false, nil
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(verifyPoll):
+		}
+	}
+}
+
+// verifySent waits for needle to show up in the pane after mark, sending
+// the message again with resend up to retries times when it doesn't.
+func (b *bird) verifySent(ctx context.Context, needle string, mark paneMark, retries int, resend func() error) error {
+	for attempt := 0; ; attempt++ {
+		seen, err := awaitEcho(ctx, b.target, needle, mark, verifyTimeout)
+		if err != nil || seen {
+			return err
+		}
+		if attempt == retries {
+			return fmt.Errorf("sent text never showed up in the pane (sends: %d)", retries+1)
+		}
+		b.logf("WARNING: sent text did not show up in the pane; sending it again (retry %d/%d)", attempt+1, retries)
+		if mark, err = tmuxPaneMark(b.target); err != nil {
+			return err
+		}
+		if err := resend(); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package typingbird
+
+import "testing"
+
+func TestVerifyNeedle(t *testing.T) {
+	tests := map[string]string{
+		"continue":                 "continue",
+		"please  review\nthe diff": "pleasereviewthediff",
+		" \n\t":                    "",
+		"the quick brown fox jumps over the lazy dog and keeps running": "ownfoxjumpsoverthelazydogandkeepsrunning",
+	}
+	for text, want := range tests {
+		if got := verifyNeedle(text); got != want {
+			t.Fatalf("verifyNeedle(%q) = %q; want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package typingbird
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, set at build time with e.g.
+//
+//	go build -ldflags "-X typing-bird/typingbird.version=1.2.3 -X typing-bird/typingbird.commit=$(git rev-parse HEAD) -X typing-bird/typingbird.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Anything left empty is filled in from the module and VCS information the
+// Go toolchain embeds.
+var (
+	version   = ""
+	commit    = ""
+	buildDate = ""
+)
+
+// buildInfo is printed by the version subcommand.
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+func currentBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info.withDefaults()
+	}
+	if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	dirty := false
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = s.Value
+			}
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	if dirty && commit == "" && info.Commit != "" {
+		info.Commit += "-dirty"
+	}
+	return info.withDefaults()
+}
+
+func (info buildInfo) withDefaults() buildInfo {
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
+
+// runVersion implements `typing-bird version [--json]`.
+func runVersion(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "print build metadata as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird version [--json]")
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	info := currentBuildInfo()
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(info); err != nil {
+			fmt.Fprintf(stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stdout, "typing-bird %s\n", info.Version)
+	fmt.Fprintf(stdout, "  commit:     %s\n", info.Commit)
+	fmt.Fprintf(stdout, "  built:      %s\n", info.BuildDate)
+	fmt.Fprintf(stdout, "  go version: %s\n", info.GoVersion)
+	fmt.Fprintf(stdout, "  platform:   %s\n", info.Platform)
+	return 0
+}
@@ -0,0 +1,46 @@
+package typingbird
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRunVersionJSON(t *testing.T) {
+	defer func(v, c, d string) { version, commit, buildDate = v, c, d }(version, commit, buildDate)
+	version, commit, buildDate = "1.2.3", "abc123", "2024-05-01T00:00:00Z"
+
+	var stdout, stderr bytes.Buffer
+	if code := runVersion([]string{"--json"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("runVersion(--json) = %d; want 0 (stderr %q)", code, stderr.String())
+	}
+	var got buildInfo
+	if err := json.Unmarshal(stdout.Bytes(), &got); err != nil {
+		t.Fatalf("runVersion(--json) output %q is not JSON: %v", stdout.String(), err)
+	}
+	want := buildInfo{
+		Version:   "1.2.3",
+		Commit:    "abc123",
+		BuildDate: "2024-05-01T00:00:00Z",
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if got != want {
+		t.Fatalf("runVersion(--json) = %+v; want %+v", got, want)
+	}
+}
+
+func TestRunVersionText(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runVersion(nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("runVersion() = %d; want 0", code)
+	}
+	if !strings.HasPrefix(stdout.String(), "typing-bird ") || !strings.Contains(stdout.String(), runtime.Version()) {
+		t.Fatalf("runVersion() output = %q; want version header and Go version", stdout.String())
+	}
+	if code := runVersion([]string{"extra"}, &stdout, &stderr); code != 2 {
+		t.Fatalf("runVersion(extra) = %d; want 2", code)
+	}
+}
@@ -0,0 +1,78 @@
+package typingbird
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce coalesces the burst of events a single save produces.
+const watchDebounce = 200 * time.Millisecond
+
+// fileWatcher calls onChange after a file is edited. It watches the parent
+// directory so editors that save by renaming a temp file over the original
+// are still seen.
+type fileWatcher struct {
+	w    *fsnotify.Watcher
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func startFileWatcher(path string, debounce time.Duration, onChange func()) (*fileWatcher, error) {
+	path = filepath.Clean(path)
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	fw := &fileWatcher{w: w, done: make(chan struct{})}
+	fw.wg.Add(1)
+	go fw.loop(path, debounce, onChange)
+	return fw, nil
+}
+
+func (fw *fileWatcher) loop(path string, debounce time.Duration, onChange func()) {
+	defer fw.wg.Done()
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		select {
+		case <-fw.done:
+			return
+		case ev, ok := <-fw.w.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(ev.Name) != path || ev.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+			debugf("watch: %s", ev)
+			if timer == nil {
+				timer = time.AfterFunc(debounce, onChange)
+			} else {
+				timer.Reset(debounce)
+			}
+		case err, ok := <-fw.w.Errors:
+			if !ok {
+				return
+			}
+			logf("WARNING: watching %q: %v", path, err)
+		}
+	}
+}
+
+func (fw *fileWatcher) Close() error {
+	close(fw.done)
+	err := fw.w.Close()
+	fw.wg.Wait()
+	return err
+}
@@ -0,0 +1,54 @@
+package typingbird
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileWatcherSeesWritesAndRenames(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "messages.txt")
+	if err := os.WriteFile(path, []byte("one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changed := make(chan struct{}, 8)
+	w, err := startFileWatcher(path, 10*time.Millisecond, func() { changed <- struct{}{} })
+	if err != nil {
+		t.Fatalf("startFileWatcher() error = %v", err)
+	}
+	defer w.Close()
+
+	wait := func(what string) {
+		t.Helper()
+		select {
+		case <-changed:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no change reported after %s", what)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("two\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wait("write")
+
+	tmp := filepath.Join(dir, ".messages.txt.swp")
+	if err := os.WriteFile(tmp, []byte("three\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	wait("rename")
+
+	if err := os.WriteFile(filepath.Join(dir, "other.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+		t.Fatalf("change reported for unrelated file")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
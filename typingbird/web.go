@@ -0,0 +1,272 @@
+package typingbird
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultWebListen = "127.0.0.1:8788"
+	webCallTimeout   = 2 * time.Second
+	// webTokenHeader carries the per-run token the page is served with.
+	webTokenHeader = "X-Typing-Bird-Token"
+	// webTokenMarker in index.html is replaced by the token.
+	webTokenMarker = "{{TOKEN}}"
+)
+
+//go:embed web/index.html
+var webIndex []byte
+
+// webBird is one instance as reported to the dashboard. Birds of a fleet
+// share a socket and are told apart by name.
+type webBird struct {
+	Socket string          `json:"socket"`
+	Name   string          `json:"name,omitempty"`
+	Status json.RawMessage `json:"status,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// webDashboard aggregates birds reachable through control sockets: those
+// registered in socketDir plus any given explicitly.
+type webDashboard struct {
+	socketDir string
+	extra     []string
+	// token is generated per run and embedded in the page, which sends it
+	// back with every API request. Anyone who can load the page can read it,
+	// which is why the dashboard only listens on loopback.
+	token string
+}
+
+func (d *webDashboard) sockets() []string {
+	found, err := discoverSockets(d.socketDir)
+	if err != nil {
+		debugf("discovering sockets in %q: %v", d.socketDir, err)
+	}
+	seen := map[string]bool{}
+	var all []string
+	for _, path := range append(found, d.extra...) {
+		if !seen[path] {
+			seen[path] = true
+			all = append(all, path)
+		}
+	}
+	sort.Strings(all)
+	return all
+}
+
+// birds queries every socket concurrently. Sockets nobody listens on are
+// left over from birds that did not exit cleanly and are skipped.
+func (d *webDashboard) birds() []webBird {
+	sockets := d.sockets()
+	results := make([][]webBird, len(sockets))
+	var wg sync.WaitGroup
+	for i, path := range sockets {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			reply, err := controlCall(path, "status", webCallTimeout)
+			switch {
+			case errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, os.ErrNotExist):
+				return
+			case err != nil:
+				results[i] = []webBird{{Socket: path, Error: err.Error()}}
+			case !reply.OK:
+				results[i] = []webBird{{Socket: path, Error: reply.Error}}
+			default:
+				results[i] = splitFleetStatus(path, reply.Data)
+			}
+		}(i, path)
+	}
+	wg.Wait()
+	var birds []webBird
+	for _, found := range results {
+		birds = append(birds, found...)
+	}
+	return birds
+}
+
+// splitFleetStatus turns a fleet's status array into one entry per bird.
+func splitFleetStatus(socket string, data json.RawMessage) []webBird {
+	var fleet []json.RawMessage
+	if err := json.Unmarshal(data, &fleet); err != nil {
+		return []webBird{{Socket: socket, Status: data}}
+	}
+	birds := make([]webBird, 0, len(fleet))
+	for _, st := range fleet {
+		var named struct {
+			Name string `json:"name"`
+		}
+		_ = json.Unmarshal(st, &named)
+		birds = append(birds, webBird{Socket: socket, Name: named.Name, Status: st})
+	}
+	return birds
+}
+
+func (d *webDashboard) known(socket string) bool {
+	for _, path := range d.sockets() {
+		if path == socket {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *webDashboard) handler() http.Handler {
+	mux := http.NewServeMux()
+	page := bytes.ReplaceAll(webIndex, []byte(webTokenMarker), []byte(d.token))
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	})
+	mux.HandleFunc("GET /api/birds", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(d.birds())
+	})
+	// Commands are forwarded only to discovered sockets so the dashboard
+	// cannot be used to talk to arbitrary sockets.
+	mux.HandleFunc("POST /api/{command}", func(w http.ResponseWriter, r *http.Request) {
+		command := r.PathValue("command")
+		switch command {
+		case "pause", "resume", "reload", "send":
+		default:
+			writeAPIResponse(w, http.StatusNotFound, controlError(fmt.Errorf("unknown command %q", command)))
+			return
+		}
+		socket := r.URL.Query().Get("socket")
+		if !d.known(socket) {
+			writeAPIResponse(w, http.StatusNotFound, controlError(fmt.Errorf("unknown bird %q", socket)))
+			return
+		}
+		if name := strings.TrimSpace(r.URL.Query().Get("bird")); name != "" {
+			if strings.ContainsAny(name, " \t\n") {
+				writeAPIResponse(w, http.StatusBadRequest, controlError(fmt.Errorf("invalid bird name %q", name)))
+				return
+			}
+			command += " " + name
+		}
+		if r.PathValue("command") == "send" {
+			if message := strings.Join(strings.Fields(r.URL.Query().Get("message")), " "); message != "" {
+				command += " " + message
+			}
+		}
+		reply, err := controlCall(socket, command, webCallTimeout)
+		if err != nil {
+			writeAPIResponse(w, http.StatusBadGateway, controlError(err))
+			return
+		}
+		if !reply.OK {
+			writeAPIResponse(w, http.StatusBadGateway, controlError(errors.New(reply.Error)))
+			return
+		}
+		writeAPIResponse(w, http.StatusOK, controlOK(nil))
+	})
+	want := []byte(d.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Any name but a loopback one is a DNS rebinding attempt: a site
+		// whose name now resolves to this machine, reading the dashboard
+		// as its own.
+		if !loopbackHost(r.Host) {
+			writeAPIResponse(w, http.StatusMisdirectedRequest, controlError(fmt.Errorf("unknown host %q", r.Host)))
+			return
+		}
+		if crossSite(r) {
+			writeAPIResponse(w, http.StatusForbidden, controlError(errors.New("cross-site request refused")))
+			return
+		}
+		got := []byte(r.Header.Get(webTokenHeader))
+		if strings.HasPrefix(r.URL.Path, "/api/") && (len(want) == 0 || subtle.ConstantTimeCompare(got, want) != 1) {
+			writeAPIResponse(w, http.StatusForbidden, controlError(errors.New("missing or wrong dashboard token; reload the page")))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// newWebToken returns a random token for one run of the dashboard.
+func newWebToken() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// runWeb implements `typing-bird web`.
+func runWeb(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("web", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	listen := fs.String("listen", defaultWebListen, "address the dashboard listens on")
+	socketDir := fs.String("socket-dir", defaultSocketDir(), "directory where birds register control sockets")
+	verbose := fs.Bool("verbose", false, "enable debug logging")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: typing-bird web [--listen host:port] [--socket-dir dir] [control-socket ...]")
+		fmt.Fprintln(stderr, "")
+		fmt.Fprintln(stderr, "Serves a dashboard of every bird registered in the socket directory plus any")
+		fmt.Fprintln(stderr, "control sockets given as arguments. It only listens on loopback and only")
+		fmt.Fprintln(stderr, "accepts requests from the page it serves, for a loopback name.")
+		fmt.Fprintln(stderr, "")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	verboseLogging = *verbose
+	if !loopbackHost(*listen) {
+		fmt.Fprintf(stderr, "ERROR: %s is reachable from other machines, which could load the page and drive every bird; listen on 127.0.0.1 and forward the port (e.g. ssh -L) instead\n", *listen)
+		return 2
+	}
+	token, err := newWebToken()
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	d := &webDashboard{socketDir: *socketDir, token: token}
+	for _, path := range fs.Args() {
+		abs, err := absPath(path)
+		if err != nil {
+			fmt.Fprintf(stderr, "ERROR: %v\n", err)
+			return 2
+		}
+		d.extra = append(d.extra, abs)
+	}
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(stderr, "ERROR: failed listening on %q: %v\n", *listen, err)
+		return 1
+	}
+	srv := &http.Server{Handler: d.handler(), ReadHeaderTimeout: 10 * time.Second}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	go func() {
+		<-stop
+		_ = srv.Close()
+	}()
+	fmt.Fprintf(stdout, "typing-bird dashboard on http://%s/\n", ln.Addr())
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,117 @@
+//go:build unix
+
+package typingbird
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebDashboardAggregatesBirds(t *testing.T) {
+	dir := t.TempDir()
+	b, err := newBird(options{timeout: time.Second, order: orderRoundRobin, session: "s", messages: []string{"a"}}, "%1")
+	if err != nil {
+		t.Fatalf("newBird(...) error: %v", err)
+	}
+	live := filepath.Join(dir, "1.sock")
+	server, err := startControlServer(live, b.controlCommand)
+	if err != nil {
+		t.Fatalf("startControlServer(...) error: %v", err)
+	}
+	defer server.Close()
+
+	// A socket left behind by a bird that was killed.
+	stale := filepath.Join(dir, "2.sock")
+	ln, err := net.Listen("unix", stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+	if _, err := os.Stat(stale); err != nil {
+		t.Fatalf("stale socket missing: %v", err)
+	}
+
+	d := &webDashboard{socketDir: dir}
+	birds := d.birds()
+	if len(birds) != 1 || birds[0].Socket != live || birds[0].Error != "" {
+		t.Fatalf("birds() = %+v; want only the live bird", birds)
+	}
+	var st birdStatus
+	if err := json.Unmarshal(birds[0].Status, &st); err != nil || st.Session != "s" {
+		t.Fatalf("birds()[0].Status = %s (err %v); want session s", birds[0].Status, err)
+	}
+
+	d.token = "0123abcd"
+	h := d.handler()
+	// do sends a request as the dashboard page would, with headers
+	// overriding or, when empty, removing the page's own.
+	do := func(method, target string, headers ...string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, nil)
+		req.Host = "127.0.0.1:8788"
+		req.Header.Set(webTokenHeader, d.token)
+		for i := 0; i+1 < len(headers); i += 2 {
+			if headers[i] == "Host" {
+				req.Host = headers[i+1]
+			} else if headers[i+1] == "" {
+				req.Header.Del(headers[i])
+			} else {
+				req.Header.Set(headers[i], headers[i+1])
+			}
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := do("POST", "/api/pause?socket="+live); rec.Code != http.StatusOK || !b.status().Paused {
+		t.Fatalf("POST /api/pause = %d paused=%v; want 200 and paused", rec.Code, b.status().Paused)
+	}
+	if rec := do("POST", "/api/pause?socket=/tmp/elsewhere.sock"); rec.Code != http.StatusNotFound {
+		t.Fatalf("POST /api/pause for unknown socket = %d; want 404", rec.Code)
+	}
+	if rec := do("GET", "/"); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `content="0123abcd"`) {
+		t.Fatalf("GET / = %d with %d bytes; want the dashboard page with its token", rec.Code, rec.Body.Len())
+	}
+
+	refused := []struct {
+		name    string
+		method  string
+		target  string
+		headers []string
+		want    int
+	}{
+		{"no token", "POST", "/api/resume?socket=" + live, []string{webTokenHeader, ""}, http.StatusForbidden},
+		{"wrong token", "POST", "/api/resume?socket=" + live, []string{webTokenHeader, "guess"}, http.StatusForbidden},
+		{"cross-site form", "POST", "/api/resume?socket=" + live, []string{"Origin", "https://evil.example"}, http.StatusForbidden},
+		{"cross-site fetch", "POST", "/api/resume?socket=" + live, []string{"Sec-Fetch-Site", "cross-site"}, http.StatusForbidden},
+		{"rebound name", "GET", "/api/birds", []string{"Host", "evil.example:8788"}, http.StatusMisdirectedRequest},
+		{"rebound page", "GET", "/", []string{"Host", "evil.example:8788"}, http.StatusMisdirectedRequest},
+	}
+	for _, tt := range refused {
+		if rec := do(tt.method, tt.target, tt.headers...); rec.Code != tt.want {
+			t.Fatalf("%s: %s %s = %d; want %d", tt.name, tt.method, tt.target, rec.Code, tt.want)
+		}
+	}
+	if !b.status().Paused {
+		t.Fatalf("bird resumed by a refused request")
+	}
+}
+
+func TestRunWebRefusesNonLoopbackListen(t *testing.T) {
+	for _, listen := range []string{"0.0.0.0:0", ":0", "[::]:0", "192.168.1.4:0"} {
+		var stderr bytes.Buffer
+		if code := runWeb([]string{"--listen", listen}, io.Discard, &stderr); code != 2 || !strings.Contains(stderr.String(), "other machines") {
+			t.Fatalf("runWeb(--listen %s) = %d, %q; want 2 and a refusal", listen, code, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package typingbird
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// wslProxy is set on Windows, where tmux lives inside WSL: every tmux call
+// then runs through wsl.exe, in wslDistro when --wsl-distro names one and in
+// the default distribution otherwise.
+var (
+	wslProxy  = runtime.GOOS == "windows"
+	wslDistro string
+)
+
+// tmuxCommand returns the command that runs tmux with args on this platform.
+func tmuxCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command("tmux", args...)
+	if wslProxy {
+		cmd = exec.Command("wsl.exe", wslArgs(wslDistro, args)...)
+	}
+	ownProcessGroup(cmd)
+	return cmd
+}
+
+// wslArgs runs tmux with --exec, so args reach it without passing through a
+// shell inside WSL.
+func wslArgs(distro string, args []string) []string {
+	var argv []string
+	if distro != "" {
+		argv = append(argv, "--distribution", distro)
+	}
+	argv = append(argv, "--exec", "tmux")
+	return append(argv, args...)
+}
+
+// lookTmux checks that tmux can be run at all.
+func lookTmux() error {
+	if !wslProxy {
+		if _, err := exec.LookPath("tmux"); err != nil {
+			return fmt.Errorf("tmux not found in PATH: %w", err)
+		}
+		return nil
+	}
+	if _, err := exec.LookPath("wsl.exe"); err != nil {
+		return fmt.Errorf("wsl.exe not found in PATH: %w", err)
+	}
+	if out, err := tmuxCommand("-V").CombinedOutput(); err != nil {
+		return fmt.Errorf("tmux not available in WSL%s: %w: %s", wslDistroSuffix(), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func wslDistroSuffix() string {
+	if wslDistro == "" {
+		return ""
+	}
+	return fmt.Sprintf(" distribution %q", wslDistro)
+}
+
+// birdExecutable returns the path tmux should use to run this binary again,
+// for injected panes and hooks. Under WSL that is the Windows path as seen
+// from inside the distribution, which runs it through interop.
+func birdExecutable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil || !wslProxy {
+		return exe, err
+	}
+	return wslPath(exe), nil
+}
+
+// wslPath maps a Windows path to the one WSL sees: drive paths go under
+// /mnt and \\wsl$\distro\ (or \\wsl.localhost\distro\) paths to the
+// distribution's root. Other paths are returned unchanged.
+func wslPath(path string) string {
+	slashed := strings.ReplaceAll(path, `\`, "/")
+	if len(slashed) >= 3 && slashed[1] == ':' && slashed[2] == '/' {
+		drive := slashed[0] | 0x20
+		if drive >= 'a' && drive <= 'z' {
+			return "/mnt/" + string(drive) + slashed[2:]
+		}
+	}
+	for _, prefix := range []string{"//wsl$/", "//wsl.localhost/"} {
+		if len(slashed) < len(prefix) || !strings.EqualFold(slashed[:len(prefix)], prefix) {
+			continue
+		}
+		if _, rest, ok := strings.Cut(slashed[len(prefix):], "/"); ok {
+			return "/" + rest
+		}
+		return "/"
+	}
+	return path
+}
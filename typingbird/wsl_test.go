@@ -0,0 +1,45 @@
+package typingbird
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWSLArgs(t *testing.T) {
+	tests := []struct {
+		distro string
+		args   []string
+		want   []string
+	}{
+		{
+			args: []string{"send-keys", "-t", "%1", "-l", "--", "echo $HOME"},
+			want: []string{"--exec", "tmux", "send-keys", "-t", "%1", "-l", "--", "echo $HOME"},
+		},
+		{
+			distro: "Ubuntu-22.04",
+			args:   []string{"-V"},
+			want:   []string{"--distribution", "Ubuntu-22.04", "--exec", "tmux", "-V"},
+		},
+	}
+	for _, tt := range tests {
+		if got := wslArgs(tt.distro, tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("wslArgs(%q, %q) = %q; want %q", tt.distro, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestWSLPath(t *testing.T) {
+	tests := map[string]string{
+		`C:\Users\me\bin\typing-bird.exe`:                  "/mnt/c/Users/me/bin/typing-bird.exe",
+		`d:/tools/typing-bird.exe`:                         "/mnt/d/tools/typing-bird.exe",
+		`\\wsl$\Ubuntu\home\me\typing-bird`:                "/home/me/typing-bird",
+		`\\wsl.localhost\Debian\usr\local\bin\typing-bird`: "/usr/local/bin/typing-bird",
+		`\\server\share\typing-bird.exe`:                   `\\server\share\typing-bird.exe`,
+		"/usr/local/bin/typing-bird":                       "/usr/local/bin/typing-bird",
+	}
+	for in, want := range tests {
+		if got := wslPath(in); got != want {
+			t.Fatalf("wslPath(%q) = %q; want %q", in, got, want)
+		}
+	}
+}